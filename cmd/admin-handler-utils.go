@@ -98,6 +98,12 @@ func toAdminAPIErr(ctx context.Context, err error) APIError {
 		}
 	case SRError:
 		apiErr = errorCodes.ToAPIErrWithErr(e.Code, e.Cause)
+	case BucketErasureConfigNotFound:
+		apiErr = APIError{
+			Code:           "XMinioAdminNoSuchErasureConfiguration",
+			Description:    e.Error(),
+			HTTPStatusCode: http.StatusNotFound,
+		}
 	case decomError:
 		apiErr = APIError{
 			Code:           "XMinioDecommissionNotAllowed",
@@ -124,6 +130,60 @@ func toAdminAPIErr(ctx context.Context, err error) APIError {
 				Description:    err.Error(),
 				HTTPStatusCode: http.StatusBadRequest,
 			}
+		case errors.Is(err, errNodeNotCordoned):
+			apiErr = APIError{
+				Code:           "XMinioAdminNodeNotCordoned",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusNotFound,
+			}
+		case errors.Is(err, errNodeCordonQuorum):
+			apiErr = APIError{
+				Code:           "XMinioAdminNodeCordonQuorum",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusPreconditionFailed,
+			}
+		case errors.Is(err, errDriveNotInMaintenance):
+			apiErr = APIError{
+				Code:           "XMinioAdminDriveNotInMaintenance",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusNotFound,
+			}
+		case errors.Is(err, errDriveMaintenanceQuorum):
+			apiErr = APIError{
+				Code:           "XMinioAdminDriveMaintenanceQuorum",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusPreconditionFailed,
+			}
+		case errors.Is(err, errDriveNotReplaced), errors.Is(err, errDriveCordoned):
+			apiErr = APIError{
+				Code:           "XMinioAdminDriveInMaintenance",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusConflict,
+			}
+		case errors.Is(err, errUnrecoverableNotFound):
+			apiErr = APIError{
+				Code:           "XMinioAdminObjectNotUnrecoverable",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusNotFound,
+			}
+		case errors.Is(err, errUnrecoverableQuarantined):
+			apiErr = APIError{
+				Code:           "XMinioAdminObjectQuarantined",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusConflict,
+			}
+		case errors.Is(err, errInspectArchiveTooLarge):
+			apiErr = APIError{
+				Code:           "XMinioAdminInspectArchiveTooLarge",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusBadRequest,
+			}
+		case errors.Is(err, errUnrecoverableChanged):
+			apiErr = APIError{
+				Code:           "XMinioAdminObjectNotUnrecoverable",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusConflict,
+			}
 		case errors.Is(err, errConfigNotFound):
 			apiErr = APIError{
 				Code:           "XMinioConfigError",
@@ -32,6 +32,8 @@ import (
 	xldap "github.com/GuinsooLab/annastore/internal/config/identity/ldap"
 	"github.com/GuinsooLab/annastore/internal/config/identity/openid"
 	idplugin "github.com/GuinsooLab/annastore/internal/config/identity/plugin"
+	idsaml "github.com/GuinsooLab/annastore/internal/config/identity/saml"
+	idscim "github.com/GuinsooLab/annastore/internal/config/identity/scim"
 	polplugin "github.com/GuinsooLab/annastore/internal/config/policy/plugin"
 	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/logger"
@@ -454,6 +456,10 @@ func (a adminAPIHandlers) GetConfigHandler(w http.ResponseWriter, r *http.Reques
 				off = !globalSTSTLSConfig.Enabled
 			case config.IdentityPluginSubSys:
 				off = !idplugin.Enabled(item.Params)
+			case config.IdentitySAMLSubSys:
+				off = !idsaml.Enabled(item.Params)
+			case config.IdentitySCIMSubSys:
+				off = !idscim.Enabled(item.Params)
 			}
 			item.AddString(&s, off)
 		}
@@ -0,0 +1,284 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/gorilla/mux"
+	"github.com/minio/madmin-go"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// DriveMaintenanceStatus is the status of a drive under maintenance or
+// being healed.
+type DriveMaintenanceStatus struct {
+	Endpoint string `json:"endpoint"`
+	// State is one of cordoned, replacing, replaced when the fresh drive
+	// awaits healing or healing.
+	State string `json:"state"`
+	// DriveState is the state of the drive currently at the endpoint.
+	DriveState string              `json:"driveState"`
+	DiskID     string              `json:"diskID,omitempty"`
+	Updated    time.Time           `json:"updated,omitempty"`
+	Heal       *madmin.HealingDisk `json:"heal,omitempty"`
+}
+
+// getDriveEndpoint returns the endpoint named drive.
+func getDriveEndpoint(drive string) (Endpoint, bool) {
+	for _, pool := range globalEndpoints {
+		for _, ep := range pool.Endpoints {
+			if ep.String() == drive {
+				return ep, true
+			}
+		}
+	}
+	return Endpoint{}, false
+}
+
+// getDriveInfo returns the info of the drive at endpoint.
+func getDriveInfo(ctx context.Context, objAPI ObjectLayer, endpoint string) (madmin.Disk, bool) {
+	si, _ := objAPI.StorageInfo(ctx)
+	for _, disk := range si.Disks {
+		if disk.Endpoint == endpoint {
+			return disk, true
+		}
+	}
+	return madmin.Disk{}, false
+}
+
+// validateDriveMaintenanceReq validates a drive maintenance request and
+// returns the drive it targets.
+func validateDriveMaintenanceReq(ctx context.Context, w http.ResponseWriter, r *http.Request) (ObjectLayer, Endpoint) {
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.HealAdminAction)
+	if objectAPI == nil {
+		return nil, Endpoint{}
+	}
+
+	if _, ok := objectAPI.(*erasureServerPools); !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
+		return nil, Endpoint{}
+	}
+
+	drive := mux.Vars(r)["drive"]
+	ep, ok := getDriveEndpoint(drive)
+	if !ok {
+		apiErr := toAdminAPIErr(ctx, errInvalidArgument)
+		apiErr.Description = fmt.Sprintf("specified drive '%s' not found, please specify a valid drive endpoint", drive)
+		writeErrorResponseJSON(ctx, w, apiErr, r.URL)
+		return nil, Endpoint{}
+	}
+	return objectAPI, ep
+}
+
+// CordonDriveHandler - POST /minio/admin/v3/drive/cordon?drive={drive}
+// ----------
+// Refuses writes to the drive, reads are served from it as long as the
+// read quorum allows it.
+func (a adminAPIHandlers) CordonDriveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CordonDrive")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	a.setDriveMaintenance(ctx, w, r, driveCordoned)
+}
+
+// ReplaceDriveHandler - POST /minio/admin/v3/drive/replace?drive={drive}
+// ----------
+// Cordons the drive until it is swapped, the fresh drive is healed and
+// put back in service afterwards.
+func (a adminAPIHandlers) ReplaceDriveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ReplaceDrive")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	a.setDriveMaintenance(ctx, w, r, driveReplacing)
+}
+
+func (a adminAPIHandlers) setDriveMaintenance(ctx context.Context, w http.ResponseWriter, r *http.Request, state driveMaintenanceState) {
+	objectAPI, ep := validateDriveMaintenanceReq(ctx, w, r)
+	if objectAPI == nil {
+		return
+	}
+
+	disk, _ := getDriveInfo(ctx, objectAPI, ep.String())
+	if disk.UUID == "" && state == driveCordoned {
+		// Only a drive which was already pulled can be replaced
+		// without knowing its ID.
+		apiErr := toAdminAPIErr(ctx, errDiskNotFound)
+		apiErr.Description = fmt.Sprintf("drive '%s' is offline or unformatted and cannot be cordoned", ep)
+		writeErrorResponseJSON(ctx, w, apiErr, r.URL)
+		return
+	}
+
+	if err := globalDriveMaintenanceSys.Set(ctx, objectAPI, ep.String(), disk.UUID, state); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+}
+
+// UncordonDriveHandler - POST /minio/admin/v3/drive/uncordon?drive={drive}
+// ----------
+// Puts a cordoned drive or a drive marked for replacement back in service.
+func (a adminAPIHandlers) UncordonDriveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "UncordonDrive")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, ep := validateDriveMaintenanceReq(ctx, w, r)
+	if objectAPI == nil {
+		return
+	}
+
+	if err := globalDriveMaintenanceSys.Remove(ctx, objectAPI, ep.String()); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+}
+
+// HealDriveHandler - POST /minio/admin/v3/drive/heal?drive={drive}
+// ----------
+// Starts healing the erasure set of the drive onto it right away, progress
+// is reported by DriveMaintenanceStatusHandler.
+func (a adminAPIHandlers) HealDriveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "HealDrive")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, ep := validateDriveMaintenanceReq(ctx, w, r)
+	if objectAPI == nil {
+		return
+	}
+
+	// Only the node hosting the drive heals it.
+	if !ep.IsLocal {
+		for nodeIdx, proxyEp := range globalProxyEndpoints {
+			if proxyEp.Endpoint.Host == ep.Host {
+				if proxyRequestByNodeIndex(ctx, w, r, nodeIdx) {
+					return
+				}
+			}
+		}
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, errDiskNotFound), r.URL)
+		return
+	}
+
+	if m, ok := globalDriveMaintenanceSys.get(ep.String()); ok && m.DiskID != "" {
+		for _, disk := range globalLocalDrives {
+			if disk == nil || disk.Endpoint().String() != ep.String() {
+				continue
+			}
+			if id, _ := disk.GetDiskID(); id == m.DiskID {
+				// Writes to the drive are refused.
+				err := errDriveCordoned
+				if m.State == driveReplacing {
+					err = errDriveNotReplaced
+				}
+				writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+				return
+			}
+		}
+	}
+
+	if globalBackgroundHealState == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+	globalBackgroundHealState.pushHealLocalDisks(ep)
+}
+
+// DriveMaintenanceStatusHandler - GET /minio/admin/v3/drive/status?drive={drive}
+// ----------
+// Returns the drives under maintenance and the progress of the drives
+// being healed, optionally only for one drive.
+func (a adminAPIHandlers) DriveMaintenanceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "DriveMaintenanceStatus")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.ServerInfoAdminAction, iampolicy.HealAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	drive := r.Form.Get("drive")
+	si, _ := objectAPI.StorageInfo(ctx)
+	statuses := getDriveMaintenanceStatus(globalDriveMaintenanceSys.list(), si.Disks)
+	if drive != "" {
+		filtered := statuses[:0]
+		for _, st := range statuses {
+			if st.Endpoint == drive {
+				filtered = append(filtered, st)
+			}
+		}
+		statuses = filtered
+	}
+
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// getDriveMaintenanceStatus returns the status of the drives under
+// maintenance and of the drives being healed, sorted by endpoint.
+func getDriveMaintenanceStatus(maintenance map[string]driveMaintenance, disks []madmin.Disk) []DriveMaintenanceStatus {
+	statuses := make(map[string]DriveMaintenanceStatus)
+	for _, m := range maintenance {
+		statuses[m.Endpoint] = DriveMaintenanceStatus{
+			Endpoint:   m.Endpoint,
+			State:      string(m.State),
+			DriveState: madmin.DriveStateUnknown,
+			DiskID:     m.DiskID,
+			Updated:    m.Updated,
+		}
+	}
+	for _, disk := range disks {
+		st, ok := statuses[disk.Endpoint]
+		if !ok && disk.HealInfo == nil && !disk.Healing {
+			continue
+		}
+		st.Endpoint = disk.Endpoint
+		st.DriveState = disk.State
+		switch {
+		case disk.Healing || disk.HealInfo != nil:
+			st.State = "healing"
+			st.Heal = disk.HealInfo
+		case st.State == string(driveReplacing) && disk.UUID != st.DiskID:
+			st.State = "replaced"
+		}
+		statuses[disk.Endpoint] = st
+	}
+
+	res := make([]DriveMaintenanceStatus, 0, len(statuses))
+	for _, st := range statuses {
+		res = append(res, st)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Endpoint < res[j].Endpoint
+	})
+	return res
+}
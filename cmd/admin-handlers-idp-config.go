@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/GuinsooLab/annastore/internal/config"
+	xldap "github.com/GuinsooLab/annastore/internal/config/identity/ldap"
 	"github.com/GuinsooLab/annastore/internal/config/identity/openid"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/gorilla/mux"
@@ -35,11 +36,12 @@ import (
 )
 
 // List of implemented ID config types.
-var idCfgTypes = set.CreateStringSet("openid")
+var idCfgTypes = set.CreateStringSet("openid", "ldap")
 
 // SetIdentityProviderCfg:
 //
 // PUT <admin-prefix>/id-cfg?type=openid&name=dex1
+// PUT <admin-prefix>/id-cfg?type=ldap&name=corp
 func (a adminAPIHandlers) SetIdentityProviderCfg(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "SetIdentityCfg")
 
@@ -76,6 +78,8 @@ func (a adminAPIHandlers) SetIdentityProviderCfg(w http.ResponseWriter, r *http.
 	switch cfgType {
 	case "openid":
 		fmt.Fprintf(&cfgDataBuilder, "identity_openid")
+	case "ldap":
+		fmt.Fprintf(&cfgDataBuilder, "identity_ldap")
 	}
 
 	// Ensure body content type is opaque.
@@ -175,9 +179,9 @@ func (a adminAPIHandlers) GetIdentityProviderCfg(w http.ResponseWriter, r *http.
 
 	cfg := globalServerConfig.Clone()
 
-	cfgInfos, err := globalOpenIDConfig.GetConfigInfo(cfg, cfgName)
+	cfgInfos, err := getIdentityProviderCfgInfo(cfg, cfgType, cfgName)
 	if err != nil {
-		if err == openid.ErrProviderConfigNotFound {
+		if err == openid.ErrProviderConfigNotFound || err == xldap.ErrConfigNotFound {
 			writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminNoSuchConfigTarget), r.URL)
 			return
 		}
@@ -206,18 +210,30 @@ func (a adminAPIHandlers) GetIdentityProviderCfg(w http.ResponseWriter, r *http.
 	writeSuccessResponseJSON(w, econfigData)
 }
 
+// getIdentityProviderCfgInfo returns the configuration details of the IDP of
+// the given type and configuration name.
+func getIdentityProviderCfgInfo(cfg config.Config, cfgType, cfgName string) ([]madmin.IDPCfgInfo, error) {
+	if cfgType == "ldap" {
+		return globalLDAPConfig.GetConfigInfo(cfg, cfgName)
+	}
+	return globalOpenIDConfig.GetConfigInfo(cfg, cfgName)
+}
+
 func (a adminAPIHandlers) listIdentityProviders(ctx context.Context, w http.ResponseWriter, r *http.Request, cfgType, password string) {
-	// var subSys string
+	var (
+		cfgList interface{}
+		err     error
+	)
+	cfg := globalServerConfig.Clone()
 	switch cfgType {
 	case "openid":
-		// subSys = config.IdentityOpenIDSubSys
+		cfgList, err = globalOpenIDConfig.GetConfigList(cfg)
+	case "ldap":
+		cfgList, err = globalLDAPConfig.GetConfigList(cfg)
 	default:
 		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
 		return
 	}
-
-	cfg := globalServerConfig.Clone()
-	cfgList, err := globalOpenIDConfig.GetConfigList(cfg)
 	if err != nil {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 		return
@@ -262,9 +278,9 @@ func (a adminAPIHandlers) DeleteIdentityProviderCfg(w http.ResponseWriter, r *ht
 
 	cfg := globalServerConfig.Clone()
 
-	cfgInfos, err := globalOpenIDConfig.GetConfigInfo(cfg, cfgName)
+	cfgInfos, err := getIdentityProviderCfgInfo(cfg, cfgType, cfgName)
 	if err != nil {
-		if err == openid.ErrProviderConfigNotFound {
+		if err == openid.ErrProviderConfigNotFound || err == xldap.ErrConfigNotFound {
 			writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminNoSuchConfigTarget), r.URL)
 			return
 		}
@@ -290,6 +306,8 @@ func (a adminAPIHandlers) DeleteIdentityProviderCfg(w http.ResponseWriter, r *ht
 	switch cfgType {
 	case "openid":
 		subSys = config.IdentityOpenIDSubSys
+	case "ldap":
+		subSys = config.IdentityLDAPSubSys
 	default:
 		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
 		return
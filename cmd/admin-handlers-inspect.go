@@ -0,0 +1,121 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zip"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// InspectObjectHandler - GET /minio/admin/v3/inspect-object?bucket={bucket}&object={object}&versionId={versionId}&archive={bool}
+// ----------
+// Returns the state of the object version on every drive of its erasure
+// set, with the bitrot verification of its shards. With archive=true the
+// report is returned in an encrypted zip file along with the xl.meta and
+// part files of every drive, in the format of inspect-data. The shards found
+// missing, corrupted or outdated are repaired with the heal API.
+func (a adminAPIHandlers) InspectObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "InspectObject")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.InspectDataAction)
+	if objectAPI == nil {
+		return
+	}
+
+	z, ok := objectAPI.(*erasureServerPools)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, object, versionID := vars["bucket"], vars["object"], r.Form.Get("versionId")
+	if r.Form.Get("archive") != "true" {
+		report, err := z.inspectObject(ctx, bucket, object, versionID, nil)
+		if err != nil {
+			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+			return
+		}
+		data, err := json.Marshal(report)
+		if err != nil {
+			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+			return
+		}
+		writeSuccessResponseJSON(w, data)
+		return
+	}
+
+	// The files are archived while the object is locked against writes,
+	// so that they match the report. The archive size is bounded as a
+	// slow client keeps the object locked.
+	var started bool
+	_, err := z.inspectObject(ctx, bucket, object, versionID, func(ctx context.Context, report ObjectInspectReport) error {
+		if report.archiveSize() > maxInspectArchiveSize {
+			return errInspectArchiveTooLarge
+		}
+		data, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		encw, err := newInspectDataWriter(w)
+		if err != nil {
+			return err
+		}
+		started = true
+		defer encw.Close()
+
+		zipWriter := zip.NewWriter(encw)
+		defer zipWriter.Close()
+		if err = embedFileInZip(zipWriter, "report.json", data); err != nil {
+			return err
+		}
+		return z.archiveObject(ctx, report, func(r io.Reader, name string, si StatInfo) error {
+			header := &zip.FileHeader{
+				Name:     name,
+				Method:   zip.Deflate,
+				Modified: si.ModTime,
+			}
+			if header.Modified.IsZero() {
+				header.Modified = UTCNow()
+			}
+			zwriter, err := zipWriter.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(zwriter, r)
+			return err
+		})
+	})
+	if err != nil {
+		if started {
+			// The archive is partly written already.
+			logger.LogIf(ctx, err)
+			return
+		}
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/gorilla/mux"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// validateNodeCordonReq validates a node cordon request and returns the
+// node host:port it targets.
+func validateNodeCordonReq(ctx context.Context, w http.ResponseWriter, r *http.Request) (ObjectLayer, string) {
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.ServiceStopAdminAction)
+	if objectAPI == nil {
+		return nil, ""
+	}
+
+	node := mux.Vars(r)["node"]
+	peers, _ := globalEndpoints.peers()
+	if !contains(peers, node) {
+		apiErr := toAdminAPIErr(ctx, errInvalidArgument)
+		apiErr.Description = fmt.Sprintf("specified node '%s' not found, please specify a valid node host:port", node)
+		writeErrorResponseJSON(ctx, w, apiErr, r.URL)
+		return nil, ""
+	}
+	return objectAPI, node
+}
+
+// CordonNodeHandler - POST /minio/admin/v3/node/cordon?node={node}
+// ----------
+// Cordons a node before taking it down for maintenance. The request is
+// refused if an erasure set would lose its write quorum without the node.
+func (a adminAPIHandlers) CordonNodeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CordonNode")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, node := validateNodeCordonReq(ctx, w, r)
+	if objectAPI == nil {
+		return
+	}
+
+	if err := globalNodeCordonSys.Cordon(ctx, objectAPI, node); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+}
+
+// UncordonNodeHandler - POST /minio/admin/v3/node/uncordon?node={node}
+// ----------
+// Puts a cordoned node back in service and heals the writes it missed.
+func (a adminAPIHandlers) UncordonNodeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "UncordonNode")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, node := validateNodeCordonReq(ctx, w, r)
+	if objectAPI == nil {
+		return
+	}
+
+	if err := globalNodeCordonSys.Uncordon(ctx, objectAPI, node); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+}
+
+// ListCordonedNodesHandler - GET /minio/admin/v3/node/cordoned
+// ----------
+// Returns the nodes cordoned for maintenance.
+func (a adminAPIHandlers) ListCordonedNodesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListCordonedNodes")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.ServerInfoAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	data, err := json.Marshal(globalNodeCordonSys.list())
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/gorilla/mux"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// ListUnrecoverableHandler - GET /minio/admin/v3/unrecoverable?bucket={bucket}&prefix={prefix}
+// ----------
+// Returns the unrecoverable object versions with the state of their
+// shards, optionally only in bucket under prefix.
+func (a adminAPIHandlers) ListUnrecoverableHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListUnrecoverable")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.HealAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	data, err := json.Marshal(globalUnrecoverableSys.List(r.Form.Get("bucket"), r.Form.Get("prefix")))
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// QuarantineUnrecoverableHandler - POST /minio/admin/v3/unrecoverable/quarantine?bucket={bucket}&object={object}&versionId={versionId}&target={target}
+// ----------
+// Moves the shards left of an unrecoverable object version to the
+// target bucket and removes the object version.
+func (a adminAPIHandlers) QuarantineUnrecoverableHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "QuarantineUnrecoverable")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.HealAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	z, ok := objectAPI.(*erasureServerPools)
+	if !ok {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, object, target := vars["bucket"], vars["object"], vars["target"]
+	if target == bucket {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, errInvalidArgument), r.URL)
+		return
+	}
+	if _, err := objectAPI.GetBucketInfo(ctx, target, BucketOptions{}); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	o, err := z.quarantineObject(ctx, bucket, object, r.Form.Get("versionId"), target)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// ForgetUnrecoverableHandler - DELETE /minio/admin/v3/unrecoverable?bucket={bucket}&object={object}&versionId={versionId}
+// ----------
+// Removes an object version from the unrecoverable ones, once it was
+// quarantined or restored otherwise.
+func (a adminAPIHandlers) ForgetUnrecoverableHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ForgetUnrecoverable")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.HealAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := globalUnrecoverableSys.Forget(ctx, objectAPI, vars["bucket"], vars["object"], r.Form.Get("versionId")); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessNoContent(w)
+}
@@ -26,11 +26,13 @@ import (
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/GuinsooLab/annastore/internal/auth"
 	"github.com/GuinsooLab/annastore/internal/config/dns"
 	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/dustin/go-humanize"
 	"github.com/gorilla/mux"
 	"github.com/klauspost/compress/zip"
 	"github.com/minio/madmin-go"
@@ -100,7 +102,7 @@ func (a adminAPIHandlers) ListBucketUsers(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	data, err := json.Marshal(allCredentials)
+	data, err := json.Marshal(withAccessKeyUsage(allCredentials))
 	if err != nil {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 		return
@@ -146,7 +148,7 @@ func (a adminAPIHandlers) ListUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Marshal the response
-	data, err := json.Marshal(allCredentials)
+	data, err := json.Marshal(withAccessKeyUsage(allCredentials))
 	if err != nil {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 		return
@@ -376,6 +378,141 @@ func (a adminAPIHandlers) SetGroupStatus(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// maxIAMTagsRequestSize is the maximum size of a tags request body.
+const maxIAMTagsRequestSize = 64 * humanize.KiByte
+
+// readIAMTagsRequest reads and validates the JSON tags map of a request,
+// an empty map removes all tags.
+func readIAMTagsRequest(r *http.Request) (map[string]string, error) {
+	var tags map[string]string
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxIAMTagsRequestSize)).Decode(&tags); err != nil {
+		return nil, AdminError{
+			Code:       "XMinioAdminInvalidIAMTags",
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if err := validateIAMTags(tags); err != nil {
+		return nil, AdminError{
+			Code:       "XMinioAdminInvalidIAMTags",
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	return tags, nil
+}
+
+// SetUserTags - PUT /minio/admin/v3/set-user-tags?accessKey=<access_key>
+// Sets the tags of a user or a service account, available in policies as
+// aws:PrincipalTag/<key>.
+func (a adminAPIHandlers) SetUserTags(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetUserTags")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.CreateUserAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	tags, err := readIAMTagsRequest(r)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	if _, err = globalIAMSys.SetUserTags(ctx, mux.Vars(r)["accessKey"], tags); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+}
+
+// GetUserTags - GET /minio/admin/v3/user-tags?accessKey=<access_key>
+func (a adminAPIHandlers) GetUserTags(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetUserTags")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.GetUserAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	tags, err := globalIAMSys.GetUserTags(mux.Vars(r)["accessKey"])
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
+// SetGroupTags - PUT /minio/admin/v3/set-group-tags?group=<group>
+// Sets the tags of a group, inherited by its members as
+// aws:PrincipalTag/<key>.
+func (a adminAPIHandlers) SetGroupTags(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetGroupTags")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.AddUserToGroupAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	tags, err := readIAMTagsRequest(r)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	if _, err = globalIAMSys.SetGroupTags(ctx, mux.Vars(r)["group"], tags); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+}
+
+// GetGroupTags - GET /minio/admin/v3/group-tags?group=<group>
+func (a adminAPIHandlers) GetGroupTags(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetGroupTags")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.GetGroupAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	tags, err := globalIAMSys.GetGroupTags(mux.Vars(r)["group"])
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
 // SetUserStatus - PUT /minio/admin/v3/set-user-status?accessKey=<access_key>&status=[enabled|disabled]
 func (a adminAPIHandlers) SetUserStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "SetUserStatus")
@@ -636,7 +773,17 @@ func (a adminAPIHandlers) AddServiceAccount(w http.ResponseWriter, r *http.Reque
 		// In case of LDAP/OIDC we need to set `opts.claims` to ensure
 		// it is associated with the LDAP/OIDC user properly.
 		for k, v := range cred.Claims {
-			if k == expClaim {
+			switch k {
+			case expClaim, ldapPolicies:
+				// Service accounts do not inherit the LDAP attribute
+				// policies of the session.
+				continue
+			case sessionTagsClaim:
+				// Only transitive session tags persist to service
+				// accounts.
+				if tags := transitiveSessionTags(cred.Claims); len(tags) > 0 {
+					opts.claims[k] = tags
+				}
 				continue
 			}
 			opts.claims[k] = v
@@ -660,11 +807,14 @@ func (a adminAPIHandlers) AddServiceAccount(w http.ResponseWriter, r *http.Reque
 		// query their groups:
 		if globalLDAPConfig.Enabled {
 			opts.claims[ldapUserN] = targetUser // simple username
-			targetUser, targetGroups, err = globalLDAPConfig.LookupUserDN(targetUser)
+			user, err := globalLDAPConfig.LookupUserDN(targetUser)
 			if err != nil {
 				writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 				return
 			}
+			// Service accounts do not inherit the attribute policies of
+			// the LDAP user, only those mapped to their DN and groups.
+			targetUser, targetGroups = user.DN, user.Groups
 			opts.claims[ldapUser] = targetUser // username DN
 		}
 
@@ -676,7 +826,7 @@ func (a adminAPIHandlers) AddServiceAccount(w http.ResponseWriter, r *http.Reque
 
 	var sp *iampolicy.Policy
 	if len(createReq.Policy) > 0 {
-		sp, err = iampolicy.ParseConfig(bytes.NewReader(createReq.Policy))
+		sp, err = parseIAMPolicy(bytes.NewReader(createReq.Policy))
 		if err != nil {
 			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 			return
@@ -800,7 +950,7 @@ func (a adminAPIHandlers) UpdateServiceAccount(w http.ResponseWriter, r *http.Re
 
 	var sp *iampolicy.Policy
 	if len(updateReq.NewPolicy) > 0 {
-		sp, err = iampolicy.ParseConfig(bytes.NewReader(updateReq.NewPolicy))
+		sp, err = parseIAMPolicy(bytes.NewReader(updateReq.NewPolicy))
 		if err != nil {
 			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 			return
@@ -907,6 +1057,7 @@ func (a adminAPIHandlers) InfoServiceAccount(w http.ResponseWriter, r *http.Requ
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 		return
 	}
+	policyJSON = decodeTagConditionKeys(policyJSON)
 
 	infoResp := madmin.InfoServiceAccountResp{
 		ParentUser:    svcAccount.ParentUser,
@@ -985,8 +1136,16 @@ func (a adminAPIHandlers) ListServiceAccounts(w http.ResponseWriter, r *http.Req
 		serviceAccountsNames = append(serviceAccountsNames, svc.AccessKey)
 	}
 
-	listResp := madmin.ListServiceAccountsResp{
-		Accounts: serviceAccountsNames,
+	listResp := listServiceAccountsResp{
+		ListServiceAccountsResp: madmin.ListServiceAccountsResp{
+			Accounts: serviceAccountsNames,
+		},
+		LastUsed: make(map[string]AccessKeyUsage),
+	}
+	for _, accessKey := range serviceAccountsNames {
+		if u, ok := globalIAMSys.GetAccessKeyUsage(accessKey); ok {
+			listResp.LastUsed[accessKey] = u
+		}
 	}
 
 	data, err := json.Marshal(listResp)
@@ -1004,6 +1163,111 @@ func (a adminAPIHandlers) ListServiceAccounts(w http.ResponseWriter, r *http.Req
 	writeSuccessResponseJSON(w, encryptedData)
 }
 
+// StaleCredentials - GET /minio/admin/v3/stale-credentials?days={days}
+//
+// Lists the users and service accounts not used in the last days.
+func (a adminAPIHandlers) StaleCredentials(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "StaleCredentials")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.ListUsersAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	days := 90
+	if v := r.Form.Get("days"); v != "" {
+		var err error
+		days, err = strconv.Atoi(v)
+		if err != nil || days < 1 {
+			writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), r.URL)
+			return
+		}
+	}
+
+	report, err := globalIAMSys.GetStaleCredentials(ctx, days)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
+// RevokeSTSSessions - POST /minio/admin/v3/revoke-sts-sessions
+//
+// Revokes the temporary credentials selected by the accessKey, parentUser,
+// roleArn and issuedBefore fields of the request body until they expire.
+func (a adminAPIHandlers) RevokeSTSSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RevokeSTSSessions")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.DeleteUserAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	var filter stsRevocationFilter
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxEConfigJSONSize)).Decode(&filter); err != nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminConfigBadJSON), r.URL)
+		return
+	}
+	if filter.isEmpty() {
+		// Revoking all temporary credentials requires an explicit
+		// issuedBefore.
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), r.URL)
+		return
+	}
+
+	revoked, err := globalIAMSys.RevokeSTSSessions(ctx, filter)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(revoked)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
+// ListRevokedSTSSessions - GET /minio/admin/v3/revoked-sts-sessions
+func (a adminAPIHandlers) ListRevokedSTSSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListRevokedSTSSessions")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.ListUsersAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	revoked, err := globalIAMSys.ListRevokedSTSSessions(ctx)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(revoked)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
 // DeleteServiceAccount - DELETE /minio/admin/v3/delete-service-account
 func (a adminAPIHandlers) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "DeleteServiceAccount")
@@ -1215,6 +1479,7 @@ func (a adminAPIHandlers) AccountInfoHandler(w http.ResponseWriter, r *http.Requ
 			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 			return
 		}
+		buf = decodeTagConditionKeys(buf)
 	}
 
 	acctInfo := madmin.AccountInfo{
@@ -1242,7 +1507,11 @@ func (a adminAPIHandlers) AccountInfoHandler(w http.ResponseWriter, r *http.Requ
 			}
 
 			lcfg, _ := globalBucketObjectLockSys.Get(bucket.Name)
-			quota, _ := globalBucketQuotaSys.Get(ctx, bucket.Name)
+			// The account info only reports the bytes quota.
+			var quota *madmin.BucketQuota
+			if q, _ := globalBucketQuotaSys.Get(ctx, bucket.Name); q != nil {
+				quota = &madmin.BucketQuota{Quota: q.Quota, Type: q.Type}
+			}
 			rcfg, _, _ := globalBucketMetadataSys.GetReplicationConfig(ctx, bucket.Name)
 			tcfg, _, _ := globalBucketMetadataSys.GetTaggingConfig(bucket.Name)
 
@@ -1354,14 +1623,14 @@ func (a adminAPIHandlers) ListBucketPolicies(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	newPolicies := make(map[string]iampolicy.Policy)
+	newPolicies := make(map[string]json.RawMessage)
 	for name, p := range policies {
-		_, err = json.Marshal(p)
+		data, err := marshalIAMPolicy(p)
 		if err != nil {
 			logger.LogIf(ctx, err)
 			continue
 		}
-		newPolicies[name] = p
+		newPolicies[name] = data
 	}
 	if err = json.NewEncoder(w).Encode(newPolicies); err != nil {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
@@ -1386,14 +1655,14 @@ func (a adminAPIHandlers) ListCannedPolicies(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	newPolicies := make(map[string]iampolicy.Policy)
+	newPolicies := make(map[string]json.RawMessage)
 	for name, p := range policies {
-		_, err = json.Marshal(p)
+		data, err := marshalIAMPolicy(p)
 		if err != nil {
 			logger.LogIf(ctx, err)
 			continue
 		}
-		newPolicies[name] = p
+		newPolicies[name] = data
 	}
 	if err = json.NewEncoder(w).Encode(newPolicies); err != nil {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
@@ -1470,7 +1739,7 @@ func (a adminAPIHandlers) AddCannedPolicy(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	iamPolicy, err := iampolicy.ParseConfig(bytes.NewReader(iamPolicyBytes))
+	iamPolicy, err := parseIAMPolicy(bytes.NewReader(iamPolicyBytes))
 	if err != nil {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
 		return
@@ -1549,6 +1818,53 @@ func (a adminAPIHandlers) SetPolicyForUserOrGroup(w http.ResponseWriter, r *http
 	}
 }
 
+// maxPolicySimulationRequestSize is the maximum size of a policy simulation
+// request, which may carry the claims of a session.
+const maxPolicySimulationRequestSize = 1 * humanize.MiByte
+
+// SimulatePolicy - POST /minio/admin/v3/simulate-policy
+// Evaluates an S3 or admin request of a principal like the server does and
+// returns the decision with the matching statements and their sources.
+func (a adminAPIHandlers) SimulatePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SimulatePolicy")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.GetPolicyAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	var req policySimulationRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxPolicySimulationRequestSize)).Decode(&req); err != nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrAdminConfigBadJSON), r.URL)
+		return
+	}
+
+	res, err := simulatePolicy(ctx, req)
+	if err != nil {
+		switch err {
+		case errSimulationNoAction, errSimulationInvalidAction, errSimulationPrincipal,
+			errSimulationNoParent, errSimulationAnonymousBucket:
+			err = AdminError{
+				Code:       "XMinioAdminInvalidPolicySimulation",
+				Message:    err.Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
 const (
 	allPoliciesFile            = "policies.json"
 	allUsersFile               = "users.json"
@@ -1621,7 +1937,7 @@ func (a adminAPIHandlers) ExportIAM(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			policiesData, err := json.Marshal(allPolicies)
+			policiesData, err := marshalIAMPolicy(allPolicies)
 			if err != nil {
 				writeErrorResponse(ctx, w, exportError(ctx, err, iamFile, ""), r.URL)
 				return
@@ -1703,7 +2019,7 @@ func (a adminAPIHandlers) ExportIAM(w http.ResponseWriter, r *http.Request) {
 
 				var policyJSON []byte
 				if policy != nil {
-					policyJSON, err = json.Marshal(policy)
+					policyJSON, err = marshalIAMPolicy(policy)
 					if err != nil {
 						writeErrorResponse(ctx, w, exportError(ctx, err, iamFile, ""), r.URL)
 						return
@@ -1853,7 +2169,7 @@ func (a adminAPIHandlers) ImportIAM(w http.ResponseWriter, r *http.Request) {
 				writeErrorResponseJSON(ctx, w, importErrorWithAPIErr(ctx, ErrInvalidRequest, err, allPoliciesFile, ""), r.URL)
 				return
 			}
-			err = json.Unmarshal(data, &allPolicies)
+			err = json.Unmarshal(encodeTagConditionKeys(data), &allPolicies)
 			if err != nil {
 				writeErrorResponseJSON(ctx, w, importErrorWithAPIErr(ctx, ErrAdminConfigBadJSON, err, allPoliciesFile, ""), r.URL)
 				return
@@ -2011,7 +2327,7 @@ func (a adminAPIHandlers) ImportIAM(w http.ResponseWriter, r *http.Request) {
 				var sp *iampolicy.Policy
 				var err error
 				if len(svcAcctReq.SessionPolicy) > 0 {
-					sp, err = iampolicy.ParseConfig(bytes.NewReader(svcAcctReq.SessionPolicy))
+					sp, err = parseIAMPolicy(bytes.NewReader(svcAcctReq.SessionPolicy))
 					if err != nil {
 						writeErrorResponseJSON(ctx, w, importError(ctx, err, allSvcAcctsFile, user), r.URL)
 						return
@@ -2066,12 +2382,12 @@ func (a adminAPIHandlers) ImportIAM(w http.ResponseWriter, r *http.Request) {
 				// query their groups:
 				if globalLDAPConfig.Enabled {
 					opts.claims[ldapUserN] = svcAcctReq.AccessKey // simple username
-					targetUser, _, err := globalLDAPConfig.LookupUserDN(svcAcctReq.AccessKey)
+					targetUser, err := globalLDAPConfig.LookupUserDN(svcAcctReq.AccessKey)
 					if err != nil {
 						writeErrorResponseJSON(ctx, w, importError(ctx, err, allSvcAcctsFile, user), r.URL)
 						return
 					}
-					opts.claims[ldapUser] = targetUser // username DN
+					opts.claims[ldapUser] = targetUser.DN // username DN
 				}
 
 				if _, _, err = globalIAMSys.NewServiceAccount(ctx, svcAcctReq.Parent, svcAcctReq.Groups, opts); err != nil {
@@ -484,6 +484,68 @@ func (a adminAPIHandlers) DataUsageInfoHandler(w http.ResponseWriter, r *http.Re
 	writeSuccessResponseJSON(w, dataUsageInfoJSON)
 }
 
+// DataUsageHistoryHandler - GET /minio/admin/v3/datausageinfo/history?bucket=&from=&to=
+// ----------
+// Get the daily usage of a bucket, or of all buckets, and the daily
+// capacity of the pools, optionally between two dates formatted as
+// 2006-01-02.
+func (a adminAPIHandlers) DataUsageHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "DataUsageHistory")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.DataUsageInfoAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	parseDay := func(param string, day int64) (int64, error) {
+		v := r.Form.Get(param)
+		if v == "" {
+			return day, nil
+		}
+		t, err := time.Parse(usageDateFormat, v)
+		return usageDay(t), err
+	}
+	from, ferr := parseDay("from", 0)
+	to, terr := parseDay("to", usageDay(time.Now()))
+	if ferr != nil || terr != nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInvalidQueryParams), r.URL)
+		return
+	}
+
+	var buckets []string
+	if bucket := r.Form.Get("bucket"); bucket != "" {
+		if _, err := objectAPI.GetBucketInfo(ctx, bucket, BucketOptions{}); err != nil {
+			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+			return
+		}
+		buckets = append(buckets, bucket)
+	} else {
+		bucketsInfo, err := objectAPI.ListBuckets(ctx, BucketOptions{})
+		if err != nil {
+			writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+			return
+		}
+		for _, bi := range bucketsInfo {
+			buckets = append(buckets, bi.Name)
+		}
+	}
+
+	history, err := getDataUsageHistory(ctx, objectAPI, buckets, from, to)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
 func lriToLockEntry(l lockRequesterInfo, resource, server string) *madmin.LockEntry {
 	entry := &madmin.LockEntry{
 		Timestamp:  l.Timestamp,
@@ -1775,11 +1837,13 @@ func getServerInfo(ctx context.Context, r *http.Request) madmin.InfoMessage {
 	kmsStat := fetchKMSStatus()
 
 	ldap := madmin.LDAP{}
-	if globalLDAPConfig.Enabled {
-		ldapConn, err := globalLDAPConfig.Connect()
+	// LDAP is offline when any of its servers is unreachable.
+	for _, ldapCfg := range globalLDAPConfig.Cfgs {
+		ldapConn, err := ldapCfg.Connect()
 		//nolint:gocritic
 		if err != nil {
 			ldap.Status = string(madmin.ItemOffline)
+			break
 		} else if ldapConn == nil {
 			ldap.Status = "Not Configured"
 		} else {
@@ -2508,6 +2572,27 @@ func (a adminAPIHandlers) ServerInfoHandler(w http.ResponseWriter, r *http.Reque
 	writeSuccessResponseJSON(w, jsonBytes)
 }
 
+// BackgroundScheduleHandler - GET /minio/admin/v3/info/schedule
+// ----------
+// Get the scheduled windows in effect for the background tasks of the node.
+func (a adminAPIHandlers) BackgroundScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "BackgroundSchedule")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.ServerInfoAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	data, err := json.Marshal(globalBackgroundSchedule.status(time.Now()))
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
 func assignPoolNumbers(servers []madmin.ServerProperties) {
 	for i := range servers {
 		for idx, ge := range globalEndpoints {
@@ -2767,6 +2852,34 @@ type getRawDataer interface {
 	GetRawData(ctx context.Context, volume, file string, fn func(r io.Reader, host string, disk string, filename string, info StatInfo) error) error
 }
 
+// newInspectDataWriter writes the format version and a random key to w,
+// and returns a writer encrypting the data written to it with the key.
+func newInspectDataWriter(w io.Writer) (io.WriteCloser, error) {
+	var key [32]byte
+	// MUST use crypto/rand
+	if _, err := io.ReadFull(crand.Reader, key[:]); err != nil {
+		return nil, err
+	}
+	stream, err := sio.AES_256_GCM.Stream(key[:])
+	if err != nil {
+		return nil, err
+	}
+	// Zero nonce, we only use each key once, and 32 bytes is plenty.
+	nonce := make([]byte, stream.NonceSize())
+
+	// Write a version for making *incompatible* changes.
+	// The AdminClient will reject any version it does not know.
+	if _, err = w.Write([]byte{1}); err != nil {
+		return nil, err
+	}
+
+	// Write key first (without encryption)
+	if _, err = w.Write(key[:]); err != nil {
+		return nil, err
+	}
+	return stream.EncryptWriter(w, nonce, nil), nil
+}
+
 // InspectDataHandler - GET /minio/admin/v3/inspect-data
 // ----------
 // Download file from all nodes in a zip format
@@ -2806,37 +2919,14 @@ func (a adminAPIHandlers) InspectDataHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var key [32]byte
-	// MUST use crypto/rand
-	n, err := crand.Read(key[:])
-	if err != nil || n != len(key) {
-		logger.LogIf(ctx, err)
-		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInternalError), r.URL)
-		return
-	}
-	stream, err := sio.AES_256_GCM.Stream(key[:])
+	encw, err := newInspectDataWriter(w)
 	if err != nil {
 		logger.LogIf(ctx, err)
 		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInternalError), r.URL)
 		return
 	}
-	// Zero nonce, we only use each key once, and 32 bytes is plenty.
-	nonce := make([]byte, stream.NonceSize())
-	encw := stream.EncryptWriter(w, nonce, nil)
-
 	defer encw.Close()
 
-	// Write a version for making *incompatible* changes.
-	// The AdminClient will reject any version it does not know.
-	w.Write([]byte{1})
-
-	// Write key first (without encryption)
-	_, err = w.Write(key[:])
-	if err != nil {
-		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrInternalError), r.URL)
-		return
-	}
-
 	// Initialize a zip writer which will provide a zipped content
 	// of profiling data of all nodes
 	zipWriter := zip.NewWriter(encw)
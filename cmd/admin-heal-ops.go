@@ -97,6 +97,9 @@ type allHealState struct {
 	//    true: the disk is currently healing
 	healLocalDisks map[Endpoint]bool
 	healStatus     map[string]healingTracker // Indexed by disk ID
+
+	// serializes the saves of the manual heal sequences
+	persistMu sync.Mutex
 }
 
 // newHealState - initialize global heal state management
@@ -454,6 +457,23 @@ type healSequence struct {
 	// Holds the request-info for logging
 	ctx context.Context
 
+	// persisted state of a manual heal sequence, resumed after a
+	// restart, nil for the background heal sequence.
+	state *healSequenceState
+
+	// heal results not written to the heal report yet
+	report healReport
+
+	// time of the last checkpoint of the persisted state
+	lastCheckpoint time.Time
+
+	// serializes the checkpoints of the persisted state
+	checkpointMu sync.Mutex
+
+	// the heal sequence was resumed after a restart, its results
+	// are not consumed by any client.
+	resumed bool
+
 	// used to lock this structure as it is concurrently accessed
 	mutex sync.RWMutex
 }
@@ -468,13 +488,14 @@ func newHealSequence(ctx context.Context, bucket, objPrefix, clientAddr string,
 	ctx, cancel := context.WithCancel(logger.SetReqInfo(ctx, reqInfo))
 
 	clientToken := mustGetUUID()
+	startTime := UTCNow()
 
 	return &healSequence{
 		respCh:         make(chan healResult),
 		bucket:         bucket,
 		object:         objPrefix,
 		reportProgress: true,
-		startTime:      UTCNow(),
+		startTime:      startTime,
 		clientToken:    clientToken,
 		clientAddress:  clientAddr,
 		forceStarted:   forceStart,
@@ -489,6 +510,17 @@ func newHealSequence(ctx context.Context, bucket, objPrefix, clientAddr string,
 		scannedItemsMap:       make(map[madmin.HealItemType]int64),
 		healedItemsMap:        make(map[madmin.HealItemType]int64),
 		healFailedItemsMap:    make(map[string]int64),
+		state: &healSequenceState{
+			Bucket:        bucket,
+			Prefix:        objPrefix,
+			ClientToken:   clientToken,
+			ClientAddress: clientAddr,
+			Settings:      hs,
+			StartTime:     startTime,
+			Markers:       make(map[string]string),
+			Results:       make(map[string]int64),
+			ReportBucket:  globalHealConfig.GetReportBucket(),
+		},
 	}
 }
 
@@ -595,6 +627,13 @@ func (h *healSequence) pushHealResultItem(r madmin.HealResultItem) error {
 	for {
 		h.mutex.Lock()
 		itemsLen = len(h.currentStatus.Items)
+		if itemsLen == maxUnconsumedHealResultItems && h.resumed {
+			// no client consumes the results of a resumed heal
+			// sequence, drop the oldest one, the results are
+			// kept in the heal report.
+			h.currentStatus.Items = h.currentStatus.Items[1:]
+			itemsLen--
+		}
 		if itemsLen == maxUnconsumedHealResultItems {
 			// wait for a second, or quit if an external
 			// stop signal is received or the
@@ -649,6 +688,11 @@ func (h *healSequence) healSequenceStart(objAPI ObjectLayer) {
 	h.currentStatus.StartTime = UTCNow()
 	h.mutex.Unlock()
 
+	if h.state != nil {
+		h.checkpoint(true)
+		defer h.endPersisted(objAPI)
+	}
+
 	go h.traverseAndHeal(objAPI)
 
 	select {
@@ -749,6 +793,9 @@ func (h *healSequence) queueHealTask(source healSource, healType madmin.HealItem
 			return res.err
 		}
 		res.result.Type = healType
+		if healType == madmin.HealItemObject && h.state != nil {
+			h.recordResult(res)
+		}
 		if res.err != nil {
 			// Only report object error
 			if healType != madmin.HealItemObject {
@@ -833,9 +880,14 @@ func (h *healSequence) healBuckets(objAPI ObjectLayer, bucketsOnly bool) error {
 	})
 
 	for _, bucket := range buckets {
+		// Skip the buckets healed before a restart.
+		if h.bucketHealed(bucket.Name) {
+			continue
+		}
 		if err = h.healBucket(objAPI, bucket.Name, bucketsOnly); err != nil {
 			return err
 		}
+		h.endBucket(bucket.Name)
 	}
 
 	return nil
@@ -866,6 +918,22 @@ func (h *healSequence) healBucket(objAPI ObjectLayer, bucket string, bucketsOnly
 		return nil
 	}
 
+	// Resume the objects of the bucket from the last checkpoint.
+	if z, ok := objAPI.(*erasureServerPools); ok && h.state != nil {
+		markers := h.startBucket(bucket)
+		err := z.healObjectsFrom(h.ctx, bucket, h.object, markers, func(set, bucket, object, versionID string) error {
+			if err := h.healObject(bucket, object, versionID); err != nil {
+				return err
+			}
+			h.objectHealed(set, object)
+			return nil
+		})
+		if err != nil {
+			return errFnHealFromAPIErr(h.ctx, err)
+		}
+		return nil
+	}
+
 	if err := objAPI.HealObjects(h.ctx, bucket, h.object, h.settings, h.healObject); err != nil {
 		return errFnHealFromAPIErr(h.ctx, err)
 	}
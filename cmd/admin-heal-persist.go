@@ -0,0 +1,455 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/hash"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/madmin-go"
+)
+
+const (
+	// prefix under .minio.sys of the manual heal sequences saved by
+	// every node, to resume them after a restart.
+	healSequencesPrefix = "heal/sequences"
+
+	// interval between the checkpoints of a manual heal sequence.
+	healCheckpointInterval = 30 * time.Second
+
+	// prefix of the heal reports in the report bucket.
+	healReportsPrefix = "heal-reports"
+
+	// maximum number of objects in one part of a heal report.
+	healReportPartItems = 10000
+)
+
+// Status of an object in a heal report.
+const (
+	healReportOK              = "ok"
+	healReportHealed          = "healed"
+	healReportFailed          = "failed"
+	healReportDanglingRemoved = "dangling-removed"
+	healReportUnrecoverable   = "unrecoverable"
+)
+
+// healSequenceState - persisted state of a manual heal sequence,
+// saved at every checkpoint.
+type healSequenceState struct {
+	Bucket        string          `json:"bucket"`
+	Prefix        string          `json:"prefix"`
+	ClientToken   string          `json:"clientToken"`
+	ClientAddress string          `json:"clientAddress"`
+	Settings      madmin.HealOpts `json:"settings"`
+	StartTime     time.Time       `json:"startTime"`
+	Checkpoint    time.Time       `json:"checkpoint"`
+
+	// buckets completely healed.
+	HealedBuckets []string `json:"healedBuckets,omitempty"`
+	// bucket being healed and the last object healed in each of its
+	// erasure sets, indexed by "pool/set".
+	CurrentBucket string            `json:"currentBucket,omitempty"`
+	Markers       map[string]string `json:"markers,omitempty"`
+
+	// number of objects per heal report status.
+	Results map[string]int64 `json:"results"`
+
+	// bucket of the heal report and number of its parts written.
+	ReportBucket string `json:"reportBucket,omitempty"`
+	ReportParts  int    `json:"reportParts,omitempty"`
+
+	// set once the heal sequence has ended.
+	EndTime       time.Time         `json:"endTime,omitempty"`
+	Summary       healStatusSummary `json:"summary,omitempty"`
+	FailureDetail string            `json:"detail,omitempty"`
+}
+
+func (st healSequenceState) clone() healSequenceState {
+	c := st
+	c.HealedBuckets = append([]string(nil), st.HealedBuckets...)
+	c.Markers = make(map[string]string, len(st.Markers))
+	for k, v := range st.Markers {
+		c.Markers[k] = v
+	}
+	c.Results = make(map[string]int64, len(st.Results))
+	for k, v := range st.Results {
+		c.Results[k] = v
+	}
+	return c
+}
+
+// healReport - heal results not written to the heal report yet.
+type healReport struct {
+	buf  bytes.Buffer
+	w    *csv.Writer
+	rows int
+}
+
+// start - starts a report part with its header.
+func (r *healReport) start() {
+	r.w = csv.NewWriter(&r.buf)
+	r.w.Write([]string{"time", "bucket", "object", "versionId", "status", "detail"})
+}
+
+// add - records a heal result.
+func (r *healReport) add(record []string) {
+	if r.w == nil {
+		r.start()
+	}
+	r.w.Write(record)
+	r.rows++
+}
+
+// bytes - returns a copy of the report part.
+func (r *healReport) bytes() []byte {
+	r.w.Flush()
+	return append([]byte(nil), r.buf.Bytes()...)
+}
+
+// written - drops the first n bytes of the report part, holding rows
+// results, once they are written. The results recorded since are kept
+// for the next part.
+func (r *healReport) written(n, rows int) {
+	r.w.Flush()
+	rest := append([]byte(nil), r.buf.Bytes()[n:]...)
+	pending := r.rows - rows
+	*r = healReport{}
+	if pending == 0 {
+		return
+	}
+	r.start()
+	r.w.Flush()
+	r.buf.Write(rest)
+	r.rows = pending
+}
+
+// healSequencesFile - file of the heal sequences of this node.
+func healSequencesFile() string {
+	node := strings.NewReplacer(":", "-", "/", "-").Replace(globalLocalNodeName)
+	return pathJoin(healSequencesPrefix, node+".json")
+}
+
+// healReportStatus - classifies the heal result of an object, an
+// empty status is returned for the objects removed while healing.
+func healReportStatus(res healResult) string {
+	switch res.result.Detail {
+	case healDetailDanglingRemoved:
+		return healReportDanglingRemoved
+	case healDetailUnrecoverable:
+		return healReportUnrecoverable
+	}
+	if res.err != nil {
+		switch {
+		case isErrObjectNotFound(res.err), isErrVersionNotFound(res.err):
+			return ""
+		case errors.As(res.err, &InsufficientReadQuorum{}):
+			return healReportUnrecoverable
+		}
+		return healReportFailed
+	}
+	for i, d := range res.result.Before.Drives {
+		if d.State != madmin.DriveStateOk && i < len(res.result.After.Drives) &&
+			res.result.After.Drives[i].State == madmin.DriveStateOk {
+			return healReportHealed
+		}
+	}
+	return healReportOK
+}
+
+// newResumedHealSequence - creates the heal sequence resuming st.
+func newResumedHealSequence(st healSequenceState) *healSequence {
+	h := newHealSequence(GlobalContext, st.Bucket, st.Prefix, st.ClientAddress, st.Settings, false)
+	h.clientToken = st.ClientToken
+	h.startTime = st.StartTime
+	h.resumed = true
+	if st.Markers == nil {
+		st.Markers = make(map[string]string)
+	}
+	if st.Results == nil {
+		st.Results = make(map[string]int64)
+	}
+	h.state = &st
+	return h
+}
+
+// saveHealSequences - saves the state of the manual heal sequences
+// running on this node, the ended ones are removed.
+func (ahs *allHealState) saveHealSequences(ctx context.Context, objAPI ObjectLayer) error {
+	ahs.persistMu.Lock()
+	defer ahs.persistMu.Unlock()
+
+	ahs.RLock()
+	states := make([]healSequenceState, 0, len(ahs.healSeqMap))
+	for _, h := range ahs.healSeqMap {
+		if st, ok := h.persistedState(); ok {
+			states = append(states, st)
+		}
+	}
+	ahs.RUnlock()
+
+	if len(states) == 0 {
+		err := deleteConfig(ctx, objAPI, healSequencesFile())
+		if errors.Is(err, errConfigNotFound) {
+			err = nil
+		}
+		return err
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].StartTime.Before(states[j].StartTime)
+	})
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	return saveConfig(ctx, objAPI, healSequencesFile(), data)
+}
+
+// resumeHealSequences - relaunches the manual heal sequences of this
+// node interrupted by a restart, from their last checkpoint.
+func (ahs *allHealState) resumeHealSequences(ctx context.Context, objAPI ObjectLayer) {
+	data, err := readConfig(ctx, objAPI, healSequencesFile())
+	if err != nil {
+		if !errors.Is(err, errConfigNotFound) {
+			logger.LogIf(ctx, fmt.Errorf("Unable to read the heal sequences to resume: %w", err))
+		}
+		return
+	}
+
+	var states []healSequenceState
+	if err = json.Unmarshal(data, &states); err != nil {
+		logger.LogIf(ctx, fmt.Errorf("Unable to read the heal sequences to resume: %w", err))
+		return
+	}
+
+	for _, st := range states {
+		_, apiErr, errMsg := ahs.LaunchNewHealSequence(newResumedHealSequence(st), objAPI)
+		if apiErr.Code != "" {
+			logger.LogIf(ctx, fmt.Errorf("Unable to resume the heal sequence %s of %s: %s %s",
+				st.ClientToken, pathJoin(st.Bucket, st.Prefix), apiErr.Description, errMsg))
+		}
+	}
+}
+
+// persistedState - returns a copy of the state of a running manual
+// heal sequence.
+func (h *healSequence) persistedState() (healSequenceState, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if h.state == nil || !h.endTime.IsZero() {
+		return healSequenceState{}, false
+	}
+	return h.state.clone(), true
+}
+
+// bucketHealed - returns true if the bucket was completely healed
+// before the heal sequence was resumed.
+func (h *healSequence) bucketHealed(bucket string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if h.state == nil {
+		return false
+	}
+	for _, b := range h.state.HealedBuckets {
+		if b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// startBucket - records the bucket being healed and returns the last
+// object healed in each erasure set, to resume its objects healing.
+func (h *healSequence) startBucket(bucket string) map[string]string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.state.CurrentBucket != bucket {
+		h.state.CurrentBucket = bucket
+		h.state.Markers = make(map[string]string)
+	}
+	markers := make(map[string]string, len(h.state.Markers))
+	for k, v := range h.state.Markers {
+		markers[k] = v
+	}
+	return markers
+}
+
+// endBucket - records the bucket as completely healed.
+func (h *healSequence) endBucket(bucket string) {
+	if h.state == nil {
+		return
+	}
+	h.mutex.Lock()
+	h.state.HealedBuckets = append(h.state.HealedBuckets, bucket)
+	h.state.CurrentBucket = ""
+	h.state.Markers = make(map[string]string)
+	h.mutex.Unlock()
+
+	h.checkpoint(true)
+}
+
+// objectHealed - records the last object healed in the erasure set,
+// and saves a checkpoint when it is due.
+func (h *healSequence) objectHealed(set, object string) {
+	h.mutex.Lock()
+	h.state.Markers[set] = object
+	h.mutex.Unlock()
+
+	h.checkpoint(false)
+}
+
+// recordResult - counts the heal result of an object and adds it to
+// the heal report.
+func (h *healSequence) recordResult(res healResult) {
+	status := healReportStatus(res)
+	if status == "" {
+		return
+	}
+
+	detail := res.result.Detail
+	if res.err != nil && detail == "" {
+		detail = res.err.Error()
+	}
+
+	h.mutex.Lock()
+	h.state.Results[status]++
+	if h.state.ReportBucket != "" {
+		h.report.add([]string{
+			UTCNow().Format(time.RFC3339), res.result.Bucket, res.result.Object,
+			res.result.VersionID, status, detail,
+		})
+	}
+	h.mutex.Unlock()
+}
+
+// checkpoint - writes the pending part of the heal report and saves
+// the state of the heal sequence, at most once per
+// healCheckpointInterval unless forced or the report part is full.
+func (h *healSequence) checkpoint(force bool) {
+	h.mutex.Lock()
+	due := force || h.report.rows >= healReportPartItems ||
+		UTCNow().Sub(h.lastCheckpoint) >= healCheckpointInterval
+	if due {
+		h.lastCheckpoint = UTCNow()
+	}
+	h.mutex.Unlock()
+	if !due {
+		return
+	}
+
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return
+	}
+
+	h.checkpointMu.Lock()
+	defer h.checkpointMu.Unlock()
+
+	if err := h.writeReportPart(objAPI); err != nil {
+		logger.LogIf(h.ctx, fmt.Errorf("Unable to write the heal report to %s: %w", h.state.ReportBucket, err))
+	}
+
+	h.mutex.Lock()
+	h.state.Checkpoint = UTCNow()
+	h.mutex.Unlock()
+
+	logger.LogIf(h.ctx, globalAllHealState.saveHealSequences(GlobalContext, objAPI))
+}
+
+// healReportPath - path of the heal report objects of the sequence.
+func (h *healSequence) healReportPath(name string) string {
+	return pathJoin(healReportsPrefix, h.state.StartTime.Format("2006-01-02")+"-"+h.clientToken, name)
+}
+
+// writeReportPart - writes the results recorded since the last part
+// as a new part of the heal report.
+func (h *healSequence) writeReportPart(objAPI ObjectLayer) error {
+	h.mutex.Lock()
+	if h.report.rows == 0 {
+		h.mutex.Unlock()
+		return nil
+	}
+	data, rows := h.report.bytes(), h.report.rows
+	bucket, part := h.state.ReportBucket, h.state.ReportParts+1
+	h.mutex.Unlock()
+
+	// The results stay recorded until written, to be retried with the
+	// next part.
+	if err := putHealReportObject(objAPI, bucket, h.healReportPath(fmt.Sprintf("part-%05d.csv", part)), data); err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	h.report.written(len(data), rows)
+	h.state.ReportParts = part
+	h.mutex.Unlock()
+	return nil
+}
+
+// endPersisted - writes the rest of the heal report with its summary,
+// and removes the ended heal sequence from the saved ones. A heal
+// sequence interrupted by the server shutting down is kept to be
+// resumed.
+func (h *healSequence) endPersisted(objAPI ObjectLayer) {
+	if h.state == nil || GlobalContext.Err() != nil {
+		return
+	}
+
+	h.checkpointMu.Lock()
+	defer h.checkpointMu.Unlock()
+
+	if h.state.ReportBucket != "" {
+		err := h.writeReportPart(objAPI)
+		if err == nil {
+			h.mutex.Lock()
+			summary := h.state.clone()
+			summary.EndTime = h.endTime
+			summary.Summary = h.currentStatus.Summary
+			summary.FailureDetail = h.currentStatus.FailureDetail
+			h.mutex.Unlock()
+
+			var data []byte
+			if data, err = json.Marshal(summary); err == nil {
+				err = putHealReportObject(objAPI, summary.ReportBucket, h.healReportPath("summary.json"), data)
+			}
+		}
+		if err != nil {
+			logger.LogIf(h.ctx, fmt.Errorf("Unable to write the heal report to %s: %w", h.state.ReportBucket, err))
+		}
+	}
+
+	logger.LogIf(h.ctx, globalAllHealState.saveHealSequences(GlobalContext, objAPI))
+}
+
+func putHealReportObject(objAPI ObjectLayer, bucket, object string, data []byte) error {
+	hr, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", "", int64(len(data)))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(GlobalContext, bucket, object, NewPutObjReader(hr), ObjectOptions{})
+	return err
+}
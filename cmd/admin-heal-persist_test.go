@@ -0,0 +1,185 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/minio/madmin-go"
+)
+
+func TestHealReportStatus(t *testing.T) {
+	drives := func(states ...string) []madmin.HealDriveInfo {
+		d := make([]madmin.HealDriveInfo, len(states))
+		for i, s := range states {
+			d[i].State = s
+		}
+		return d
+	}
+	var healed madmin.HealResultItem
+	healed.Before.Drives = drives(madmin.DriveStateOk, madmin.DriveStateMissing)
+	healed.After.Drives = drives(madmin.DriveStateOk, madmin.DriveStateOk)
+
+	testCases := []struct {
+		res  healResult
+		want string
+	}{
+		{healResult{}, healReportOK},
+		{healResult{result: healed}, healReportHealed},
+		{healResult{result: madmin.HealResultItem{Detail: healDetailDanglingRemoved}, err: ObjectNotFound{}}, healReportDanglingRemoved},
+		{healResult{result: madmin.HealResultItem{Detail: healDetailUnrecoverable}, err: VersionNotFound{}}, healReportUnrecoverable},
+		{healResult{err: InsufficientReadQuorum{}}, healReportUnrecoverable},
+		{healResult{err: ObjectNotFound{}}, ""},
+		{healResult{err: errors.New("drive offline")}, healReportFailed},
+	}
+	for i, testCase := range testCases {
+		if got := healReportStatus(testCase.res); got != testCase.want {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.want, got)
+		}
+	}
+}
+
+func TestHealReportWritten(t *testing.T) {
+	const header = "time,bucket,object,versionId,status,detail\n"
+
+	var r healReport
+	r.add([]string{"t1", "bucket", "a", "", healReportHealed, ""})
+	r.add([]string{"t2", "bucket", "b", "", healReportHealed, ""})
+	data, rows := r.bytes(), r.rows
+
+	// Results recorded while the part is written are kept for the next one.
+	r.add([]string{"t3", "bucket", "c", "", healReportFailed, "drive offline"})
+	r.written(len(data), rows)
+	if r.rows != 1 {
+		t.Fatalf("expected 1 pending result, got %d", r.rows)
+	}
+	want := header + "t3,bucket,c,,failed,drive offline\n"
+	if got := string(r.bytes()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	r.written(len(want), 1)
+	if r.rows != 0 || r.w != nil || r.buf.Len() != 0 {
+		t.Fatalf("expected an empty report, got %d results", r.rows)
+	}
+}
+
+func TestHealObjectsFrom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	z := obj.(*erasureServerPools)
+	if err = obj.MakeBucketWithLocation(ctx, "bucket", MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"a", "b", "c", "d"} {
+		if _, err = obj.PutObject(ctx, "bucket", object, mustGetPutObjReader(t, bytes.NewReader([]byte("data")), 4, "", ""), ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	healed := func(markers map[string]string) []string {
+		var objects []string
+		err := z.healObjectsFrom(ctx, "bucket", "", markers, func(set, bucket, object, versionID string) error {
+			if set != "0/0" {
+				t.Errorf("unexpected set %s", set)
+			}
+			objects = append(objects, object)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(objects)
+		return objects
+	}
+
+	if got := healed(nil); len(got) != 4 {
+		t.Errorf("expected 4 objects, got %v", got)
+	}
+	// The marker itself is healed again when resuming.
+	if got := healed(map[string]string{"0/0": "c"}); len(got) != 2 || got[0] != "c" || got[1] != "d" {
+		t.Errorf("expected [c d], got %v", got)
+	}
+}
+
+func TestHealSequencesPersist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	defer func(node string) { globalLocalNodeName = node }(globalLocalNodeName)
+	globalLocalNodeName = "node1:9000"
+	ahs := newHealState(false)
+	h := newHealSequence(ctx, "bucket", "prefix", "127.0.0.1", madmin.HealOpts{Recursive: true}, false)
+	h.startBucket("bucket")
+	h.state.Markers["0/0"] = "prefix/object"
+	ahs.healSeqMap[pathJoin(h.bucket, h.object)] = h
+
+	if err = ahs.saveHealSequences(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := readConfig(ctx, obj, healSequencesFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []healSequenceState
+	if err = json.Unmarshal(data, &states); err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 {
+		t.Fatalf("expected 1 heal sequence, got %d", len(states))
+	}
+
+	r := newResumedHealSequence(states[0])
+	if r.clientToken != h.clientToken || r.bucket != "bucket" || r.object != "prefix" || !r.settings.Recursive || !r.resumed {
+		t.Errorf("unexpected resumed heal sequence %#v", r)
+	}
+	if markers := r.startBucket("bucket"); markers["0/0"] != "prefix/object" {
+		t.Errorf("expected the marker to be resumed, got %v", markers)
+	}
+	if markers := r.startBucket("other"); len(markers) != 0 {
+		t.Errorf("expected no markers for another bucket, got %v", markers)
+	}
+
+	// An ended heal sequence is removed.
+	h.endTime = UTCNow()
+	if err = ahs.saveHealSequences(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readConfig(ctx, obj, healSequencesFile()); !errors.Is(err, errConfigNotFound) {
+		t.Errorf("expected %v, got %v", errConfigNotFound, err)
+	}
+}
@@ -60,12 +60,14 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 
 		// Info operations
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/info").HandlerFunc(gz(httpTraceAll(adminAPI.ServerInfoHandler)))
+		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/info/schedule").HandlerFunc(gz(httpTraceAll(adminAPI.BackgroundScheduleHandler)))
 		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/inspect-data").HandlerFunc(httpTraceHdrs(adminAPI.InspectDataHandler)).Queries("volume", "{volume:.*}", "file", "{file:.*}")
 
 		// StorageInfo operations
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/storageinfo").HandlerFunc(gz(httpTraceAll(adminAPI.StorageInfoHandler)))
 		// DataUsageInfo operations
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/datausageinfo").HandlerFunc(gz(httpTraceAll(adminAPI.DataUsageInfoHandler)))
+		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/datausageinfo/history").HandlerFunc(gz(httpTraceAll(adminAPI.DataUsageHistoryHandler)))
 		// Metrics operation
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/metrics").HandlerFunc(gz(httpTraceAll(adminAPI.MetricsHandler)))
 
@@ -84,6 +86,28 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 
 			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/pools/decommission").HandlerFunc(gz(httpTraceAll(adminAPI.StartDecommission))).Queries("pool", "{pool:.*}")
 			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/pools/cancel").HandlerFunc(gz(httpTraceAll(adminAPI.CancelDecommission))).Queries("pool", "{pool:.*}")
+
+			// Drive maintenance operations
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/drive/cordon").HandlerFunc(gz(httpTraceAll(adminAPI.CordonDriveHandler))).Queries("drive", "{drive:.*}")
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/drive/uncordon").HandlerFunc(gz(httpTraceAll(adminAPI.UncordonDriveHandler))).Queries("drive", "{drive:.*}")
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/drive/replace").HandlerFunc(gz(httpTraceAll(adminAPI.ReplaceDriveHandler))).Queries("drive", "{drive:.*}")
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/drive/heal").HandlerFunc(gz(httpTraceAll(adminAPI.HealDriveHandler))).Queries("drive", "{drive:.*}")
+			adminRouter.Methods(http.MethodGet).Path(adminVersion + "/drive/status").HandlerFunc(gz(httpTraceAll(adminAPI.DriveMaintenanceStatusHandler)))
+
+			// Unrecoverable objects operations
+			adminRouter.Methods(http.MethodGet).Path(adminVersion + "/unrecoverable").HandlerFunc(gz(httpTraceAll(adminAPI.ListUnrecoverableHandler)))
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/unrecoverable/quarantine").HandlerFunc(gz(httpTraceAll(adminAPI.QuarantineUnrecoverableHandler))).Queries("bucket", "{bucket:.*}", "object", "{object:.*}", "target", "{target:.*}")
+			adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/unrecoverable").HandlerFunc(gz(httpTraceAll(adminAPI.ForgetUnrecoverableHandler))).Queries("bucket", "{bucket:.*}", "object", "{object:.*}")
+
+			// Object inspection operations
+			adminRouter.Methods(http.MethodGet).Path(adminVersion+"/inspect-object").HandlerFunc(httpTraceHdrs(adminAPI.InspectObjectHandler)).Queries("bucket", "{bucket:.*}", "object", "{object:.*}")
+		}
+
+		if globalIsDistErasure {
+			// Node maintenance operations
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/node/cordon").HandlerFunc(gz(httpTraceAll(adminAPI.CordonNodeHandler))).Queries("node", "{node:.*}")
+			adminRouter.Methods(http.MethodPost).Path(adminVersion+"/node/uncordon").HandlerFunc(gz(httpTraceAll(adminAPI.UncordonNodeHandler))).Queries("node", "{node:.*}")
+			adminRouter.Methods(http.MethodGet).Path(adminVersion + "/node/cordoned").HandlerFunc(gz(httpTraceAll(adminAPI.ListCordonedNodesHandler)))
 		}
 
 		// Profiling operations - deprecated API
@@ -151,6 +175,9 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 			HandlerFunc(gz(httpTraceHdrs(adminAPI.SetPolicyForUserOrGroup))).
 			Queries("policyName", "{policyName:.*}", "userOrGroup", "{userOrGroup:.*}", "isGroup", "{isGroup:true|false}")
 
+		// Simulate the policy evaluation of a request
+		adminRouter.Methods(http.MethodPost).Path(adminVersion + "/simulate-policy").HandlerFunc(gz(httpTraceHdrs(adminAPI.SimulatePolicy)))
+
 		// Remove user IAM
 		adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/remove-user").HandlerFunc(gz(httpTraceHdrs(adminAPI.RemoveUser))).Queries("accessKey", "{accessKey:.*}")
 
@@ -158,6 +185,13 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/list-users").HandlerFunc(gz(httpTraceHdrs(adminAPI.ListBucketUsers))).Queries("bucket", "{bucket:.*}")
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/list-users").HandlerFunc(gz(httpTraceHdrs(adminAPI.ListUsers)))
 
+		// Users and service accounts unused for a number of days
+		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/stale-credentials").HandlerFunc(gz(httpTraceHdrs(adminAPI.StaleCredentials)))
+
+		// STS session revocation
+		adminRouter.Methods(http.MethodPost).Path(adminVersion + "/revoke-sts-sessions").HandlerFunc(gz(httpTraceHdrs(adminAPI.RevokeSTSSessions)))
+		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/revoked-sts-sessions").HandlerFunc(gz(httpTraceHdrs(adminAPI.ListRevokedSTSSessions)))
+
 		// User info
 		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/user-info").HandlerFunc(gz(httpTraceHdrs(adminAPI.GetUserInfo))).Queries("accessKey", "{accessKey:.*}")
 		// Add/Remove members from group
@@ -172,6 +206,12 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 		// Set Group Status
 		adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-group-status").HandlerFunc(gz(httpTraceHdrs(adminAPI.SetGroupStatus))).Queries("group", "{group:.*}").Queries("status", "{status:.*}")
 
+		// User, service account and group tags
+		adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-user-tags").HandlerFunc(gz(httpTraceHdrs(adminAPI.SetUserTags))).Queries("accessKey", "{accessKey:.*}")
+		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/user-tags").HandlerFunc(gz(httpTraceHdrs(adminAPI.GetUserTags))).Queries("accessKey", "{accessKey:.*}")
+		adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-group-tags").HandlerFunc(gz(httpTraceHdrs(adminAPI.SetGroupTags))).Queries("group", "{group:.*}")
+		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/group-tags").HandlerFunc(gz(httpTraceHdrs(adminAPI.GetGroupTags))).Queries("group", "{group:.*}")
+
 		// Export IAM info to zipped file
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/export-iam").HandlerFunc(httpTraceHdrs(adminAPI.ExportIAM))
 
@@ -192,6 +232,13 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 		adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-bucket-quota").HandlerFunc(
 			gz(httpTraceHdrs(adminAPI.PutBucketQuotaConfigHandler))).Queries("bucket", "{bucket:.*}")
 
+		// GetBucketErasureConfig
+		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/get-bucket-erasure").HandlerFunc(
+			gz(httpTraceHdrs(adminAPI.GetBucketErasureConfigHandler))).Queries("bucket", "{bucket:.*}")
+		// PutBucketErasureConfig
+		adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-bucket-erasure").HandlerFunc(
+			gz(httpTraceHdrs(adminAPI.PutBucketErasureConfigHandler))).Queries("bucket", "{bucket:.*}")
+
 		// Bucket replication operations
 		// GetBucketTargetHandler
 		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/list-remote-targets").HandlerFunc(
@@ -220,9 +267,21 @@ func registerAdminRouter(router *mux.Router, enableConfigOps bool) {
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/tier").HandlerFunc(gz(httpTraceHdrs(adminAPI.ListTierHandler)))
 		adminRouter.Methods(http.MethodDelete).Path(adminVersion + "/tier/{tier}").HandlerFunc(gz(httpTraceHdrs(adminAPI.RemoveTierHandler)))
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/tier/{tier}").HandlerFunc(gz(httpTraceHdrs(adminAPI.VerifyTierHandler)))
+		adminRouter.Methods(http.MethodPost).Path(adminVersion + "/tier/{tier}/check").HandlerFunc(httpTraceHdrs(adminAPI.TierCheckHandler))
 		// Tier stats
 		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/tier-stats").HandlerFunc(gz(httpTraceHdrs(adminAPI.TierStatsHandler)))
 
+		// Batch job operations
+		adminRouter.Methods(http.MethodPut).Path(adminVersion + "/start-job").HandlerFunc(gz(httpTraceHdrs(adminAPI.StartBatchJob)))
+		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/list-jobs").HandlerFunc(gz(httpTraceHdrs(adminAPI.ListBatchJobs)))
+		adminRouter.Methods(http.MethodGet).Path(adminVersion+"/describe-job").HandlerFunc(gz(httpTraceHdrs(adminAPI.DescribeBatchJob))).Queries("jobId", "{jobId:.*}")
+		adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/cancel-job").HandlerFunc(gz(httpTraceHdrs(adminAPI.CancelBatchJob))).Queries("jobId", "{jobId:.*}")
+
+		// Object lambda access points
+		adminRouter.Methods(http.MethodPut).Path(adminVersion + "/object-lambda/set-access-point").HandlerFunc(gz(httpTraceHdrs(adminAPI.SetObjectLambdaAccessPoint)))
+		adminRouter.Methods(http.MethodGet).Path(adminVersion + "/object-lambda/list-access-points").HandlerFunc(gz(httpTraceHdrs(adminAPI.ListObjectLambdaAccessPoints)))
+		adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/object-lambda/remove-access-point").HandlerFunc(gz(httpTraceHdrs(adminAPI.RemoveObjectLambdaAccessPoint))).Queries("name", "{name:.*}")
+
 		// Cluster Replication APIs
 		adminRouter.Methods(http.MethodPut).Path(adminVersion + "/site-replication/add").HandlerFunc(gz(httpTraceHdrs(adminAPI.SiteReplicationAdd)))
 		adminRouter.Methods(http.MethodPut).Path(adminVersion + "/site-replication/remove").HandlerFunc(gz(httpTraceHdrs(adminAPI.SiteReplicationRemove)))
@@ -124,6 +124,11 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, rs *HTTPRangeSp
 		}
 	}
 
+	// Flag the object versions which cannot be read back.
+	if globalUnrecoverableSys.Contains(objInfo.Bucket, objInfo.Name, objInfo.VersionID, objInfo.ModTime) {
+		w.Header().Set(xhttp.MinIOUnrecoverable, "true")
+	}
+
 	// Set all other user defined metadata.
 	for k, v := range objInfo.UserDefined {
 		// Empty values for object lock and retention can be skipped.
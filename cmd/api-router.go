@@ -194,6 +194,7 @@ func registerAPIRouter(router *mux.Router) {
 
 	// API Router
 	apiRouter := router.PathPrefix(SlashSeparator).Subrouter()
+	apiRouter.Use(rateLimitMiddleware)
 
 	var routers []*mux.Router
 	for _, domainName := range globalDomainNames {
@@ -381,6 +381,9 @@ func healFreshDisk(ctx context.Context, z *erasureServerPools, endpoint Endpoint
 
 	logger.LogIf(ctx, tracker.delete(ctx))
 
+	// A drive replacing one under maintenance puts it back in service.
+	globalDriveMaintenanceSys.healed(ctx, z, endpoint.String(), tracker.ID)
+
 	return nil
 }
 
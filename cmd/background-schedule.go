@@ -0,0 +1,156 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config/schedule"
+)
+
+var (
+	// healSleeper throttles the background heal and MRF heal of objects,
+	// it only sleeps during scheduled windows.
+	healSleeper = newDynamicSleeper(0, 0, false)
+	// transitionSleeper throttles the ILM transition workers,
+	// it only sleeps during scheduled windows.
+	transitionSleeper = newDynamicSleeper(0, 0, false)
+	// replicationSleeper throttles the replication workers,
+	// it only sleeps during scheduled windows.
+	replicationSleeper = newDynamicSleeper(0, 0, false)
+)
+
+// backgroundSleeper returns the sleeper throttling task.
+func backgroundSleeper(task schedule.Task) *dynamicSleeper {
+	switch task {
+	case schedule.TaskScanner:
+		return scannerSleeper
+	case schedule.TaskHeal:
+		return healSleeper
+	case schedule.TaskTransition:
+		return transitionSleeper
+	case schedule.TaskReplication:
+		return replicationSleeper
+	}
+	return nil
+}
+
+// BackgroundTaskSchedule is the speed a background task runs at.
+type BackgroundTaskSchedule struct {
+	Task schedule.Task `json:"task"`
+	// Window is the name of the scheduled window in effect, if any.
+	Window string               `json:"window,omitempty"`
+	Speed  schedule.SpeedPreset `json:"speed"`
+}
+
+// BackgroundScheduleStatus is the status of the scheduled windows of
+// a node, evaluated in its local time.
+type BackgroundScheduleStatus struct {
+	Node    string                   `json:"node"`
+	Time    time.Time                `json:"time"`
+	Tasks   []BackgroundTaskSchedule `json:"tasks"`
+	Windows []schedule.Window        `json:"windows"`
+}
+
+// backgroundSchedule applies the speed of the scheduled windows in effect
+// to the sleepers of the background tasks.
+type backgroundSchedule struct {
+	mu  sync.Mutex
+	cfg schedule.Config
+	// active is the name of the window in effect per task.
+	active map[schedule.Task]string
+	// cfgCh wakes up the schedule when the config changes.
+	cfgCh chan struct{}
+}
+
+func newBackgroundSchedule() *backgroundSchedule {
+	return &backgroundSchedule{
+		active: make(map[schedule.Task]string),
+		cfgCh:  make(chan struct{}, 1),
+	}
+}
+
+func (s *backgroundSchedule) update(cfg schedule.Config) {
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+
+	select {
+	case s.cfgCh <- struct{}{}:
+	default:
+	}
+}
+
+// apply sets the speed of the windows in effect at t.
+func (s *backgroundSchedule) apply(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range schedule.AllTasks {
+		speed := schedule.SpeedDefault
+		w, ok := s.cfg.Active(task, t)
+		if ok {
+			speed = w.Speed
+			s.active[task] = w.Name
+		} else {
+			delete(s.active, task)
+		}
+		backgroundSleeper(task).SetSpeed(speed)
+	}
+}
+
+func (s *backgroundSchedule) status(t time.Time) BackgroundScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := BackgroundScheduleStatus{
+		Node:    globalLocalNodeName,
+		Time:    t,
+		Windows: s.cfg.Windows,
+	}
+	for _, task := range schedule.AllTasks {
+		status.Tasks = append(status.Tasks, BackgroundTaskSchedule{
+			Task:   task,
+			Window: s.active[task],
+			Speed:  backgroundSleeper(task).Speed(),
+		})
+	}
+	return status
+}
+
+// run applies the windows in effect every minute and on config changes.
+func (s *backgroundSchedule) run(ctx context.Context) {
+	s.apply(time.Now())
+
+	t := time.NewTicker(time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-s.cfgCh:
+		}
+		s.apply(time.Now())
+	}
+}
+
+// initBackgroundSchedule starts applying the scheduled windows.
+func initBackgroundSchedule(ctx context.Context) {
+	go globalBackgroundSchedule.run(ctx)
+}
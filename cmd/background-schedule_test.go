@@ -0,0 +1,156 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/GuinsooLab/annastore/internal/config/schedule"
+)
+
+func TestDynamicSleeperPaused(t *testing.T) {
+	d := newDynamicSleeper(0, 0, false)
+	d.SetSpeed(schedule.SpeedPaused)
+
+	done := make(chan struct{})
+	go func() {
+		d.Sleep(context.Background(), time.Second)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected sleep to wait while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.SetSpeed(schedule.SpeedDefault)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected sleep to return once resumed")
+	}
+
+	d.SetSpeed(schedule.SpeedPaused)
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := d.Timer(ctx)
+	cancel()
+	wait()
+}
+
+func TestDynamicSleeperSpeed(t *testing.T) {
+	d := newDynamicSleeper(0, 0, false)
+	testCases := []struct {
+		speed   schedule.SpeedPreset
+		factor  float64
+		maxWait time.Duration
+	}{
+		{schedule.SpeedDefault, 0, 0},
+		{schedule.SpeedSlowest, 100, 30 * time.Second},
+		{schedule.SpeedSlow, 20, 15 * time.Second},
+		{schedule.SpeedFast, 1, 100 * time.Millisecond},
+		{schedule.SpeedFastest, 0, 0},
+	}
+	for _, tc := range testCases {
+		d.SetSpeed(tc.speed)
+		factor, _, maxWait, paused, _ := d.current()
+		if factor != tc.factor || maxWait != tc.maxWait || paused {
+			t.Errorf("%s: expected factor %v and max wait %v, got %v and %v", tc.speed, tc.factor, tc.maxWait, factor, maxWait)
+		}
+	}
+}
+
+func TestBackgroundScheduleApply(t *testing.T) {
+	s := config.Config{
+		config.ScheduleSubSys: map[string]config.KVS{
+			"night": {
+				config.KV{Key: schedule.Tasks, Value: "scanner,heal"},
+				config.KV{Key: schedule.Days, Value: "*"},
+				config.KV{Key: schedule.Start, Value: "22:00"},
+				config.KV{Key: schedule.End, Value: "06:00"},
+				config.KV{Key: schedule.Speed, Value: "fastest"},
+			},
+			"office": {
+				config.KV{Key: schedule.Tasks, Value: "scanner,transition"},
+				config.KV{Key: schedule.Days, Value: "mon-fri"},
+				config.KV{Key: schedule.Start, Value: "09:00"},
+				config.KV{Key: schedule.End, Value: "18:00"},
+				config.KV{Key: schedule.Speed, Value: "paused"},
+			},
+		},
+	}
+	cfg, err := schedule.LookupConfig(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs := newBackgroundSchedule()
+	bs.update(cfg)
+	defer func() {
+		bs.update(schedule.Config{})
+		bs.apply(time.Now())
+	}()
+
+	// 2022-10-17 is a Monday.
+	testCases := []struct {
+		t      time.Time
+		speeds map[schedule.Task]schedule.SpeedPreset
+	}{
+		{
+			t: time.Date(2022, 10, 17, 23, 0, 0, 0, time.Local),
+			speeds: map[schedule.Task]schedule.SpeedPreset{
+				schedule.TaskScanner:     schedule.SpeedFastest,
+				schedule.TaskHeal:        schedule.SpeedFastest,
+				schedule.TaskTransition:  schedule.SpeedDefault,
+				schedule.TaskReplication: schedule.SpeedDefault,
+			},
+		},
+		{
+			t: time.Date(2022, 10, 17, 10, 0, 0, 0, time.Local),
+			speeds: map[schedule.Task]schedule.SpeedPreset{
+				schedule.TaskScanner:     schedule.SpeedPaused,
+				schedule.TaskHeal:        schedule.SpeedDefault,
+				schedule.TaskTransition:  schedule.SpeedPaused,
+				schedule.TaskReplication: schedule.SpeedDefault,
+			},
+		},
+		{
+			t: time.Date(2022, 10, 22, 10, 0, 0, 0, time.Local),
+			speeds: map[schedule.Task]schedule.SpeedPreset{
+				schedule.TaskScanner:     schedule.SpeedDefault,
+				schedule.TaskHeal:        schedule.SpeedDefault,
+				schedule.TaskTransition:  schedule.SpeedDefault,
+				schedule.TaskReplication: schedule.SpeedDefault,
+			},
+		},
+	}
+	for i, tc := range testCases {
+		bs.apply(tc.t)
+		status := bs.status(tc.t)
+		for _, task := range status.Tasks {
+			if task.Speed != tc.speeds[task.Task] {
+				t.Errorf("case %d: expected %s speed %s, got %s", i+1, task.Task, tc.speeds[task.Task], task.Speed)
+			}
+			if (task.Window != "") != (task.Speed != schedule.SpeedDefault) {
+				t.Errorf("case %d: unexpected %s window %q", i+1, task.Task, task.Window)
+			}
+		}
+	}
+}
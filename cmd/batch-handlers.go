@@ -0,0 +1,199 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// Admin actions guarding the batch job APIs, granted by "admin:*".
+const (
+	startBatchJobAction    iampolicy.AdminAction = "admin:StartBatchJob"
+	listBatchJobsAction    iampolicy.AdminAction = "admin:ListBatchJobs"
+	describeBatchJobAction iampolicy.AdminAction = "admin:DescribeBatchJob"
+	cancelBatchJobAction   iampolicy.AdminAction = "admin:CancelBatchJob"
+)
+
+// maxBatchJobRequestSize is the maximum size of a batch job definition.
+const maxBatchJobRequestSize = 1 << 20
+
+var errBatchJobNotFoundAdmin = AdminError{
+	Code:       "XMinioAdminBatchJobNotFound",
+	Message:    "Specified batch job was not found",
+	StatusCode: http.StatusNotFound,
+}
+
+func toBatchJobAPIErr(err error) error {
+	switch err {
+	case errBatchJobNotFound:
+		return errBatchJobNotFoundAdmin
+	case errBatchJobAccessDenied:
+		return AdminError{
+			Code:       "AccessDenied",
+			Message:    err.Error(),
+			StatusCode: http.StatusForbidden,
+		}
+	case errBatchJobInvalidOp, errBatchJobNoSource, errBatchJobNoTarget, errBatchJobNoTags,
+		errBatchJobNoStorageClass, errBatchJobStorageClass, errBatchJobNoRestoreDays, errBatchJobNoAsOf, errBatchJobAsOfSource:
+		return AdminError{
+			Code:       "XMinioAdminInvalidBatchJob",
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return err
+}
+
+// StartBatchJob - PUT /minio/admin/v3/start-job
+// ----------
+// Starts a new batch job, the JSON encoded BatchJobRequest is read from
+// the request body. Returns the initial status of the job.
+func (a adminAPIHandlers) StartBatchJob(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "StartBatchJob")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, startBatchJobAction)
+	if objectAPI == nil {
+		return
+	}
+	cred, claims, owner, s3Err := validateAdminSignature(ctx, r, "")
+	if s3Err != ErrNone {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(s3Err), r.URL)
+		return
+	}
+	if globalBatchJobPool == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	var req BatchJobRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBatchJobRequestSize)).Decode(&req); err != nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErrWithErr(ErrAdminConfigBadJSON, err), r.URL)
+		return
+	}
+	req.User = cred.AccessKey
+	if cred.ParentUser != "" {
+		req.User = cred.ParentUser
+	}
+	req.AccessKey, req.Groups, req.Claims, req.Owner = cred.AccessKey, cred.Groups, claims, owner
+
+	status, err := globalBatchJobPool.Start(ctx, req)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, toBatchJobAPIErr(err)), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// ListBatchJobs - GET /minio/admin/v3/list-jobs
+// ----------
+// Lists the status of all batch jobs.
+func (a adminAPIHandlers) ListBatchJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListBatchJobs")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, listBatchJobsAction)
+	if objectAPI == nil {
+		return
+	}
+	if globalBatchJobPool == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	jobs, err := globalBatchJobPool.List(ctx)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// DescribeBatchJob - GET /minio/admin/v3/describe-job?jobId=<id>
+// ----------
+// Returns the status, progress and failed objects of a batch job.
+func (a adminAPIHandlers) DescribeBatchJob(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "DescribeBatchJob")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, describeBatchJobAction)
+	if objectAPI == nil {
+		return
+	}
+	if globalBatchJobPool == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	status, err := globalBatchJobPool.Status(ctx, r.Form.Get("jobId"))
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, toBatchJobAPIErr(err)), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// CancelBatchJob - DELETE /minio/admin/v3/cancel-job?jobId=<id>
+// ----------
+// Cancels a running batch job.
+func (a adminAPIHandlers) CancelBatchJob(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CancelBatchJob")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, cancelBatchJobAction)
+	if objectAPI == nil {
+		return
+	}
+	if globalBatchJobPool == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	if err := globalBatchJobPool.Cancel(ctx, r.Form.Get("jobId")); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, toBatchJobAPIErr(err)), r.URL)
+		return
+	}
+	writeSuccessNoContent(w)
+}
@@ -0,0 +1,1195 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
+	"github.com/GuinsooLab/annastore/internal/crypto"
+	"github.com/GuinsooLab/annastore/internal/hash"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/minio/pkg/bucket/policy"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+const (
+	batchJobPrefix          = "batch-jobs"
+	batchJobRequestFile     = "job.json"
+	batchJobStatusFile      = "status.json"
+	batchJobListPageSize    = 1000
+	batchJobCheckpointItems = 100
+	batchJobCheckpointEvery = 10 * time.Second
+	batchJobResumeInterval  = time.Minute
+	batchJobMaxFailures     = 1000
+)
+
+// batchJobsConfigPrefix is the prefix under which batch jobs and their
+// checkpoints are persisted.
+var batchJobsConfigPrefix = path.Join(minioConfigPrefix, batchJobPrefix)
+
+// batchJobLockTimeout is short, failing to lock a job worker means another
+// node is running it.
+var batchJobLockTimeout = newDynamicTimeout(5*time.Second, time.Second)
+
+var (
+	errBatchJobNotFound       = errors.New("batch job not found")
+	errBatchJobInvalidOp      = errors.New("invalid batch job operation")
+	errBatchJobNoSource       = errors.New("batch job requires a source bucket or a manifest")
+	errBatchJobNoTarget       = errors.New("batch job copy requires a target bucket")
+	errBatchJobNoTags         = errors.New("batch job retag requires tags")
+	errBatchJobNoStorageClass = errors.New("batch job storage-class requires a storage class")
+	errBatchJobStorageClass   = errors.New("batch job storage class is not configured")
+	errBatchJobNoRestoreDays  = errors.New("batch job restore requires restoreDays")
+	errBatchJobEncrypted      = errors.New("copying encrypted objects to a different name is not supported")
+	errBatchJobLocked         = errors.New("object is locked by retention or legal hold")
+	errBatchJobNotTransition  = errors.New("object is not transitioned")
+	errBatchJobNoAsOf         = errors.New("batch job point-in-time requires asOf in the past")
+	errBatchJobAsOfSource     = errors.New("batch job point-in-time requires a source bucket, without manifest or target")
+	errBatchJobAccessDenied   = errors.New("batch job submitter is not allowed to access the object")
+)
+
+// batchJobOp is the operation a batch job applies to each selected object.
+type batchJobOp string
+
+const (
+	batchJobCopy         batchJobOp = "copy"
+	batchJobRetag        batchJobOp = "retag"
+	batchJobStorageClass batchJobOp = "storage-class"
+	batchJobRestore      batchJobOp = "restore"
+	batchJobDelete       batchJobOp = "delete"
+	batchJobPointInTime  batchJobOp = "point-in-time"
+)
+
+// batchJobState is the state of a batch job.
+type batchJobState string
+
+const (
+	batchJobRunning   batchJobState = "running"
+	batchJobCompleted batchJobState = "completed"
+	batchJobFailed    batchJobState = "failed"
+	batchJobCanceled  batchJobState = "canceled"
+)
+
+// BatchJobManifest is a CSV object listing the objects to operate on, one
+// per line as `bucket,object[,versionId]`.
+type BatchJobManifest struct {
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+}
+
+// BatchJobFilter selects a subset of the source objects.
+type BatchJobFilter struct {
+	CreatedAfter  time.Time         `json:"createdAfter,omitempty"`
+	CreatedBefore time.Time         `json:"createdBefore,omitempty"`
+	MinSize       int64             `json:"minSize,omitempty"`
+	MaxSize       int64             `json:"maxSize,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// BatchJobSource selects the objects a batch job operates on, either all
+// objects under a prefix or the objects listed in a manifest.
+type BatchJobSource struct {
+	Bucket      string            `json:"bucket,omitempty"`
+	Prefix      string            `json:"prefix,omitempty"`
+	AllVersions bool              `json:"allVersions,omitempty"`
+	Manifest    *BatchJobManifest `json:"manifest,omitempty"`
+	Filter      BatchJobFilter    `json:"filter"`
+}
+
+// BatchJobTarget is the destination of a copy batch job.
+type BatchJobTarget struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// BatchJobReport is the location the completion report is written to.
+type BatchJobReport struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// BatchJobRequest describes a batch job as submitted by the user.
+type BatchJobRequest struct {
+	ID           string            `json:"id"`
+	User         string            `json:"user"`
+	Started      time.Time         `json:"started"`
+	Workers      int               `json:"workers"`
+	Operation    batchJobOp        `json:"operation"`
+	Source       BatchJobSource    `json:"source"`
+	Target       *BatchJobTarget   `json:"target,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	StorageClass string            `json:"storageClass,omitempty"`
+	RestoreDays  int               `json:"restoreDays,omitempty"`
+	AsOf         time.Time         `json:"asOf,omitempty"`
+	Report       *BatchJobReport   `json:"report,omitempty"`
+
+	// Credentials of the submitter, the job only does what they are
+	// allowed to do.
+	AccessKey string                 `json:"accessKey"`
+	Groups    []string               `json:"groups,omitempty"`
+	Claims    map[string]interface{} `json:"claims,omitempty"`
+	Owner     bool                   `json:"owner,omitempty"`
+}
+
+// Validate checks if the batch job request is well formed.
+func (r BatchJobRequest) Validate() error {
+	if r.Source.Manifest == nil && r.Source.Bucket == "" {
+		return errBatchJobNoSource
+	}
+	switch r.Operation {
+	case batchJobCopy:
+		if r.Target == nil || r.Target.Bucket == "" {
+			return errBatchJobNoTarget
+		}
+	case batchJobRetag:
+		if len(r.Tags) == 0 {
+			return errBatchJobNoTags
+		}
+		if _, err := tags.NewTags(r.Tags, true); err != nil {
+			return err
+		}
+	case batchJobStorageClass:
+		if r.StorageClass == "" {
+			return errBatchJobNoStorageClass
+		}
+		if !globalStorageClass.IsValid(r.StorageClass) {
+			return errBatchJobStorageClass
+		}
+	case batchJobRestore:
+		if r.RestoreDays <= 0 {
+			return errBatchJobNoRestoreDays
+		}
+	case batchJobDelete:
+	case batchJobPointInTime:
+		if r.AsOf.IsZero() || r.AsOf.After(UTCNow()) {
+			return errBatchJobNoAsOf
+		}
+		if r.Source.Bucket == "" || r.Source.Manifest != nil || r.Target != nil {
+			return errBatchJobAsOfSource
+		}
+	default:
+		return errBatchJobInvalidOp
+	}
+	return nil
+}
+
+// isAllowed returns true if the submitter of the job may do action on
+// bucket/object.
+func (r BatchJobRequest) isAllowed(action iampolicy.Action, bucket, object string) bool {
+	// Jobs run without a request, only the identity of the submitter
+	// provides condition values.
+	req := &http.Request{Header: http.Header{}, Form: url.Values{}}
+	return globalIAMSys.IsAllowed(iampolicy.Args{
+		AccountName:     r.AccessKey,
+		Groups:          r.Groups,
+		Action:          action,
+		BucketName:      bucket,
+		ObjectName:      object,
+		ConditionValues: getConditionValues(req, "", r.AccessKey, r.Claims),
+		IsOwner:         r.Owner,
+		Claims:          r.Claims,
+	})
+}
+
+// checkAccess returns errBatchJobAccessDenied unless the submitter of the
+// job may do the job operation on its source, read its manifest and write
+// to its target and report location. The objects of a manifest are
+// checked as they are processed.
+func (r BatchJobRequest) checkAccess() error {
+	type access struct {
+		action         iampolicy.Action
+		bucket, object string
+	}
+	var checks []access
+	if m := r.Source.Manifest; m != nil {
+		checks = append(checks, access{iampolicy.GetObjectAction, m.Bucket, m.Object})
+	} else {
+		var list iampolicy.Action = iampolicy.ListBucketAction
+		if r.Source.AllVersions || r.Operation == batchJobPointInTime {
+			list = iampolicy.ListBucketVersionsAction
+		}
+		checks = append(checks, access{list, r.Source.Bucket, r.Source.Prefix})
+		for _, action := range r.objectActions(!r.Source.AllVersions) {
+			checks = append(checks, access{action, r.Source.Bucket, r.Source.Prefix})
+		}
+	}
+	if r.Operation == batchJobCopy {
+		checks = append(checks, access{iampolicy.PutObjectAction, r.Target.Bucket, r.Target.Prefix})
+	}
+	if r.Report != nil {
+		checks = append(checks, access{iampolicy.PutObjectAction, r.Report.Bucket, path.Join(r.Report.Prefix, r.ID+".report")})
+	}
+	for _, c := range checks {
+		if !r.isAllowed(c.action, c.bucket, c.object) {
+			return errBatchJobAccessDenied
+		}
+	}
+	return nil
+}
+
+// objectActions returns the actions the job operation does on a source
+// object, latest is set when the object is addressed without version.
+func (r BatchJobRequest) objectActions(latest bool) []iampolicy.Action {
+	var get, tagging, del iampolicy.Action = iampolicy.GetObjectVersionAction, iampolicy.PutObjectVersionTaggingAction, iampolicy.DeleteObjectVersionAction
+	if latest {
+		get, tagging, del = iampolicy.GetObjectAction, iampolicy.PutObjectTaggingAction, iampolicy.DeleteObjectAction
+	}
+	switch r.Operation {
+	case batchJobCopy:
+		return []iampolicy.Action{get}
+	case batchJobRetag:
+		return []iampolicy.Action{tagging}
+	case batchJobStorageClass:
+		return []iampolicy.Action{get, iampolicy.PutObjectAction}
+	case batchJobRestore:
+		return []iampolicy.Action{iampolicy.Action(policy.RestoreObjectAction)}
+	case batchJobDelete:
+		return []iampolicy.Action{del}
+	case batchJobPointInTime:
+		return []iampolicy.Action{iampolicy.GetObjectVersionAction, iampolicy.PutObjectAction, iampolicy.DeleteObjectAction}
+	}
+	return nil
+}
+
+// BatchJobFailure records an object the batch job failed to process.
+type BatchJobFailure struct {
+	Bucket    string `json:"bucket"`
+	Object    string `json:"object"`
+	VersionID string `json:"versionId,omitempty"`
+	Error     string `json:"error"`
+}
+
+// BatchJobWorker is the persisted progress of one of the workers a batch
+// job is split into, each worker processes the objects whose name hashes
+// to it. It doubles as the checkpoint from which an interrupted worker
+// resumes.
+type BatchJobWorker struct {
+	State         batchJobState     `json:"state"`
+	Node          string            `json:"node,omitempty"`
+	LastUpdate    time.Time         `json:"lastUpdate"`
+	Objects       uint64            `json:"objects"`
+	ObjectsFailed uint64            `json:"objectsFailed"`
+	Bytes         uint64            `json:"bytes"`
+	Error         string            `json:"error,omitempty"`
+	Failures      []BatchJobFailure `json:"failures,omitempty"`
+
+	// Checkpoint, the last object processed.
+	Marker          string `json:"marker,omitempty"`
+	VersionIDMarker string `json:"versionIdMarker,omitempty"`
+	ManifestLine    int64  `json:"manifestLine,omitempty"`
+	// Pass is the point-in-time pass in progress, see runPointInTime.
+	Pass int `json:"pass,omitempty"`
+}
+
+// BatchJobStatus is the persisted progress of a batch job, the totals are
+// the sums of its workers.
+type BatchJobStatus struct {
+	ID            string           `json:"id"`
+	Operation     batchJobOp       `json:"operation"`
+	User          string           `json:"user"`
+	State         batchJobState    `json:"state"`
+	Started       time.Time        `json:"started"`
+	LastUpdate    time.Time        `json:"lastUpdate"`
+	Finished      time.Time        `json:"finished,omitempty"`
+	Objects       uint64           `json:"objects"`
+	ObjectsFailed uint64           `json:"objectsFailed"`
+	Bytes         uint64           `json:"bytes"`
+	Error         string           `json:"error,omitempty"`
+	Report        string           `json:"report,omitempty"`
+	Workers       []BatchJobWorker `json:"workers"`
+}
+
+// update recomputes the totals of the job from its workers, and completes
+// a running job once all of its workers are done.
+func (s *BatchJobStatus) update() {
+	s.Objects, s.ObjectsFailed, s.Bytes = 0, 0, 0
+	done, failed := true, false
+	for _, w := range s.Workers {
+		s.Objects += w.Objects
+		s.ObjectsFailed += w.ObjectsFailed
+		s.Bytes += w.Bytes
+		switch w.State {
+		case batchJobRunning:
+			done = false
+		case batchJobFailed:
+			if !failed {
+				failed = true
+				s.Error = w.Error
+			}
+		}
+	}
+	s.LastUpdate = UTCNow()
+	if s.State != batchJobRunning || !done {
+		return
+	}
+	s.State = batchJobCompleted
+	if failed {
+		s.State = batchJobFailed
+	}
+	s.Finished = s.LastUpdate
+}
+
+func batchJobPath(id, file string) string {
+	return path.Join(batchJobsConfigPrefix, id, file)
+}
+
+func saveBatchJobJSON(ctx context.Context, objAPI ObjectLayer, configFile string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return saveConfig(ctx, objAPI, configFile, data)
+}
+
+func loadBatchJobRequest(ctx context.Context, objAPI ObjectLayer, id string) (req BatchJobRequest, err error) {
+	data, err := readConfig(ctx, objAPI, batchJobPath(id, batchJobRequestFile))
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			err = errBatchJobNotFound
+		}
+		return req, err
+	}
+	err = json.Unmarshal(data, &req)
+	return req, err
+}
+
+// updateBatchJobStatus applies fn to the persisted status of the job id
+// under the status lock, and returns the updated status.
+func updateBatchJobStatus(ctx context.Context, objAPI ObjectLayer, id string, fn func(*BatchJobStatus) error) (BatchJobStatus, error) {
+	// Lock distinct from the one taken to read and write the status.
+	locker := objAPI.NewNSLock(minioMetaBucket, batchJobPath(id, batchJobStatusFile)+".lock")
+	lkctx, err := locker.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return BatchJobStatus{}, err
+	}
+	ctx = lkctx.Context()
+	defer locker.Unlock(lkctx.Cancel)
+
+	status, err := loadBatchJobStatus(ctx, objAPI, id)
+	if err != nil {
+		return status, err
+	}
+	if err = fn(&status); err != nil {
+		return status, err
+	}
+	return status, saveBatchJobJSON(ctx, objAPI, batchJobPath(id, batchJobStatusFile), status)
+}
+
+func loadBatchJobStatus(ctx context.Context, objAPI ObjectLayer, id string) (status BatchJobStatus, err error) {
+	data, err := readConfig(ctx, objAPI, batchJobPath(id, batchJobStatusFile))
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			err = errBatchJobNotFound
+		}
+		return status, err
+	}
+	err = json.Unmarshal(data, &status)
+	return status, err
+}
+
+// batchJobPool runs batch jobs. Jobs are persisted under .minio.sys and
+// split into one worker per node, each worker processing the objects whose
+// name hashes to it. Any node may run any worker: a cluster wide lock per
+// worker guarantees a single runner, nodes periodically adopt the workers
+// not run by any node, new ones or those whose runner went away, and
+// resume them from their last checkpoint. A node runs at most one worker
+// of a job at a time.
+type batchJobPool struct {
+	objLayer ObjectLayer
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+}
+
+var globalBatchJobPool *batchJobPool
+
+// initBatchJobPool starts the batch job pool and resumes interrupted jobs.
+func initBatchJobPool(ctx context.Context, objAPI ObjectLayer) {
+	globalBatchJobPool = &batchJobPool{
+		objLayer: objAPI,
+		running:  make(map[string]context.CancelFunc),
+	}
+	go globalBatchJobPool.resume(ctx)
+}
+
+// resume periodically looks for running jobs with workers without a
+// runner.
+func (p *batchJobPool) resume(ctx context.Context) {
+	t := time.NewTimer(batchJobResumeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		ids, err := p.listJobIDs(ctx)
+		logger.LogIf(ctx, err)
+		for _, id := range ids {
+			status, err := loadBatchJobStatus(ctx, p.objLayer, id)
+			if err != nil || status.State != batchJobRunning || p.isRunning(id) || !status.adoptable() {
+				continue
+			}
+			req, err := loadBatchJobRequest(ctx, p.objLayer, id)
+			if err != nil {
+				logger.LogIf(ctx, err)
+				continue
+			}
+			go p.run(ctx, req)
+		}
+		t.Reset(batchJobResumeInterval)
+	}
+}
+
+// adoptable returns true if the worker is running but was never claimed
+// by a node or has not checkpointed for a while, its runner may be gone.
+func (w BatchJobWorker) adoptable() bool {
+	return w.State == batchJobRunning && (w.Node == "" || time.Since(w.LastUpdate) > 3*batchJobCheckpointEvery)
+}
+
+// adoptable returns true if some worker of the job is adoptable.
+func (s BatchJobStatus) adoptable() bool {
+	for _, w := range s.Workers {
+		if w.adoptable() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *batchJobPool) isRunning(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.running[id]
+	return ok
+}
+
+func (p *batchJobPool) listJobIDs(ctx context.Context) ([]string, error) {
+	seen := make(map[string]struct{})
+	for item := range listIAMConfigItems(ctx, p.objLayer, batchJobsConfigPrefix+SlashSeparator) {
+		if item.Err != nil {
+			return nil, item.Err
+		}
+		id := strings.SplitN(item.Item, SlashSeparator, 2)[0]
+		seen[id] = struct{}{}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// batchJobNodes returns the number of nodes of the deployment and the
+// index of the local node among them.
+func batchJobNodes() (nodes, local int) {
+	hosts := globalEndpoints.hostsSorted()
+	for i, host := range hosts {
+		if host == nil {
+			local = i
+		}
+	}
+	if len(hosts) == 0 {
+		return 1, 0
+	}
+	return len(hosts), local
+}
+
+// Start persists a new batch job and starts running it on this node, the
+// other nodes join it within batchJobResumeInterval.
+func (p *batchJobPool) Start(ctx context.Context, req BatchJobRequest) (BatchJobStatus, error) {
+	if err := req.Validate(); err != nil {
+		return BatchJobStatus{}, err
+	}
+	req.ID = mustGetUUID()
+	req.Started = UTCNow()
+	if err := req.checkAccess(); err != nil {
+		return BatchJobStatus{}, err
+	}
+	req.Workers, _ = batchJobNodes()
+	status := BatchJobStatus{
+		ID:         req.ID,
+		Operation:  req.Operation,
+		User:       req.User,
+		State:      batchJobRunning,
+		Started:    req.Started,
+		LastUpdate: req.Started,
+		Workers:    make([]BatchJobWorker, req.Workers),
+	}
+	for i := range status.Workers {
+		status.Workers[i] = BatchJobWorker{State: batchJobRunning, LastUpdate: req.Started}
+	}
+	if err := saveBatchJobJSON(ctx, p.objLayer, batchJobPath(req.ID, batchJobRequestFile), req); err != nil {
+		return status, err
+	}
+	if err := saveBatchJobJSON(ctx, p.objLayer, batchJobPath(req.ID, batchJobStatusFile), status); err != nil {
+		return status, err
+	}
+	go p.run(GlobalContext, req)
+	return status, nil
+}
+
+// List returns the status of all known batch jobs.
+func (p *batchJobPool) List(ctx context.Context) ([]BatchJobStatus, error) {
+	ids, err := p.listJobIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]BatchJobStatus, 0, len(ids))
+	for _, id := range ids {
+		status, err := loadBatchJobStatus(ctx, p.objLayer, id)
+		if err != nil {
+			continue
+		}
+		// Keep listings small, failures are available through Status.
+		for i := range status.Workers {
+			status.Workers[i].Failures = nil
+		}
+		jobs = append(jobs, status)
+	}
+	return jobs, nil
+}
+
+// Status returns the current status of the batch job id.
+func (p *batchJobPool) Status(ctx context.Context, id string) (BatchJobStatus, error) {
+	return loadBatchJobStatus(ctx, p.objLayer, id)
+}
+
+// Cancel marks the batch job id as canceled. A job running on this node
+// stops immediately, jobs running elsewhere stop at their next checkpoint.
+func (p *batchJobPool) Cancel(ctx context.Context, id string) error {
+	_, err := updateBatchJobStatus(ctx, p.objLayer, id, func(status *BatchJobStatus) error {
+		if status.State == batchJobRunning {
+			status.State = batchJobCanceled
+			status.Finished = UTCNow()
+			status.LastUpdate = status.Finished
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if cancel, ok := p.running[id]; ok {
+		cancel()
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+// batchJobRun is the state of a batch job worker running on this node.
+type batchJobRun struct {
+	objAPI ObjectLayer
+	req    BatchJobRequest
+	idx    int
+	worker BatchJobWorker
+	tags   string
+
+	items      int
+	checkpoint time.Time
+}
+
+// run runs a worker of req not run by any other node to completion,
+// starting with the worker of the local node.
+func (p *batchJobPool) run(ctx context.Context, req BatchJobRequest) {
+	nodes, local := batchJobNodes()
+	for k := 0; k < req.Workers; k++ {
+		idx := (local*req.Workers/nodes + k) % req.Workers
+		status, err := loadBatchJobStatus(ctx, p.objLayer, req.ID)
+		if err != nil {
+			logger.LogIf(ctx, err)
+			return
+		}
+		if status.State != batchJobRunning || len(status.Workers) != req.Workers {
+			return
+		}
+		if !status.Workers[idx].adoptable() {
+			continue
+		}
+		if p.runWorker(ctx, req, idx) {
+			return
+		}
+	}
+}
+
+// runWorker runs the worker idx of req to completion, it returns false if
+// another node already runs it.
+func (p *batchJobPool) runWorker(ctx context.Context, req BatchJobRequest, idx int) bool {
+	locker := p.objLayer.NewNSLock(minioMetaBucket, path.Join(batchJobsConfigPrefix, fmt.Sprintf("%s-%d.lock", req.ID, idx)))
+	lkctx, err := locker.GetLock(ctx, batchJobLockTimeout)
+	if err != nil {
+		// Worker is running elsewhere.
+		return false
+	}
+	ctx, cancel := context.WithCancel(lkctx.Context())
+	defer locker.Unlock(lkctx.Cancel)
+	defer cancel()
+
+	p.mu.Lock()
+	if _, ok := p.running[req.ID]; ok {
+		p.mu.Unlock()
+		return true
+	}
+	p.running[req.ID] = cancel
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.running, req.ID)
+		p.mu.Unlock()
+	}()
+
+	status, err := loadBatchJobStatus(ctx, p.objLayer, req.ID)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return true
+	}
+	if status.State != batchJobRunning || status.Workers[idx].State != batchJobRunning {
+		return true
+	}
+
+	job := &batchJobRun{
+		objAPI:     p.objLayer,
+		req:        req,
+		idx:        idx,
+		worker:     status.Workers[idx],
+		checkpoint: UTCNow(),
+	}
+	job.worker.Node = globalLocalNodeName
+	if req.Operation == batchJobRetag {
+		t, _ := tags.NewTags(req.Tags, true)
+		job.tags = t.String()
+	}
+
+	// The policies of the submitter may have changed since the job was
+	// submitted or last resumed.
+	err = req.checkAccess()
+	if err == nil {
+		if _, err = job.save(ctx); err != nil {
+			return true
+		}
+		switch {
+		case req.Operation == batchJobPointInTime:
+			err = job.runPointInTime(ctx)
+		case req.Source.Manifest != nil:
+			err = job.runManifest(ctx)
+		default:
+			err = job.runPrefix(ctx)
+		}
+	}
+	switch {
+	case errors.Is(err, errBatchJobCanceled):
+		return true
+	case err != nil && ctx.Err() != nil:
+		// Interrupted, by shutdown or a local cancel, the persisted
+		// status decides whether the job is resumed.
+		return true
+	case err != nil:
+		job.worker.State = batchJobFailed
+		job.worker.Error = err.Error()
+	default:
+		job.worker.State = batchJobCompleted
+	}
+	status, err = job.save(ctx)
+	if err != nil {
+		if !errors.Is(err, errBatchJobCanceled) {
+			logger.LogIf(ctx, err)
+		}
+		return true
+	}
+	// The last worker to finish writes the report of the job.
+	if status.State != batchJobRunning && req.Report != nil {
+		report, err := job.writeReport(ctx, status)
+		if err != nil {
+			logger.LogIf(ctx, err)
+			return true
+		}
+		_, err = updateBatchJobStatus(ctx, p.objLayer, req.ID, func(status *BatchJobStatus) error {
+			status.Report = report
+			return nil
+		})
+		logger.LogIf(ctx, err)
+	}
+	return true
+}
+
+var errBatchJobCanceled = errors.New("batch job canceled")
+
+// save persists the progress of the worker, unless the job was canceled
+// meanwhile, and returns the status of the job.
+func (j *batchJobRun) save(ctx context.Context) (BatchJobStatus, error) {
+	return updateBatchJobStatus(ctx, j.objAPI, j.req.ID, func(status *BatchJobStatus) error {
+		if status.State == batchJobCanceled {
+			return errBatchJobCanceled
+		}
+		j.worker.LastUpdate = UTCNow()
+		status.Workers[j.idx] = j.worker
+		status.update()
+		return nil
+	})
+}
+
+// maybeCheckpoint persists progress every batchJobCheckpointItems objects
+// or batchJobCheckpointEvery, whichever comes first.
+func (j *batchJobRun) maybeCheckpoint(ctx context.Context) error {
+	j.items++
+	if j.items < batchJobCheckpointItems && time.Since(j.checkpoint) < batchJobCheckpointEvery {
+		return nil
+	}
+	j.items = 0
+	j.checkpoint = UTCNow()
+	_, err := j.save(ctx)
+	return err
+}
+
+// owns returns true if the object is processed by this worker.
+func (j *batchJobRun) owns(bucket, object string) bool {
+	return j.req.Workers <= 1 || crcHashMod(pathJoin(bucket, object), j.req.Workers) == j.idx
+}
+
+func (j *batchJobRun) record(oi ObjectInfo, err error) {
+	if err == nil {
+		j.worker.Objects++
+		j.worker.Bytes += uint64(oi.Size)
+		return
+	}
+	j.worker.ObjectsFailed++
+	if len(j.worker.Failures) < batchJobMaxFailures {
+		j.worker.Failures = append(j.worker.Failures, BatchJobFailure{
+			Bucket:    oi.Bucket,
+			Object:    oi.Name,
+			VersionID: oi.VersionID,
+			Error:     err.Error(),
+		})
+	}
+}
+
+// runPrefix applies the job to all objects under the source prefix, in
+// listing order so that the marker can be used as a checkpoint.
+func (j *batchJobRun) runPrefix(ctx context.Context) error {
+	src := j.req.Source
+	marker, versionMarker := j.worker.Marker, j.worker.VersionIDMarker
+	for {
+		var (
+			objects   []ObjectInfo
+			truncated bool
+		)
+		if src.AllVersions {
+			res, err := j.objAPI.ListObjectVersions(ctx, src.Bucket, src.Prefix, marker, versionMarker, "", batchJobListPageSize)
+			if err != nil {
+				return err
+			}
+			objects, truncated = res.Objects, res.IsTruncated
+		} else {
+			res, err := j.objAPI.ListObjects(ctx, src.Bucket, src.Prefix, marker, "", batchJobListPageSize)
+			if err != nil {
+				return err
+			}
+			objects, truncated = res.Objects, res.IsTruncated
+		}
+		for _, oi := range objects {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if j.owns(oi.Bucket, oi.Name) && j.selected(oi) {
+				j.record(oi, j.apply(ctx, oi, !src.AllVersions))
+			}
+			marker, versionMarker = oi.Name, oi.VersionID
+			j.worker.Marker, j.worker.VersionIDMarker = marker, versionMarker
+			if err := j.maybeCheckpoint(ctx); err != nil {
+				return err
+			}
+		}
+		if !truncated || len(objects) == 0 {
+			return nil
+		}
+	}
+}
+
+// runPointInTime restores the source prefix to its state at asOf, in two
+// passes. The first copies forward every version which was current at asOf
+// and has been overwritten since, the second deletes objects which did not
+// exist at asOf. Versions are never removed, in versioned buckets the
+// restore can be undone the same way.
+func (j *batchJobRun) runPointInTime(ctx context.Context) error {
+	pit, ok := j.objAPI.(pointInTimeLister)
+	if !ok {
+		return NotImplemented{}
+	}
+	src := j.req.Source
+	for j.worker.Pass < 2 {
+		marker := j.worker.Marker
+		for {
+			var res ListObjectsInfo
+			var err error
+			if j.worker.Pass == 0 {
+				res, err = pit.ListObjectsAsOf(ctx, src.Bucket, src.Prefix, marker, "", batchJobListPageSize, j.req.AsOf)
+			} else {
+				res, err = j.objAPI.ListObjects(ctx, src.Bucket, src.Prefix, marker, "", batchJobListPageSize)
+			}
+			if err != nil {
+				return err
+			}
+			for _, oi := range res.Objects {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if j.owns(oi.Bucket, oi.Name) && j.selected(oi) {
+					if j.worker.Pass == 0 {
+						err = j.restoreVersion(ctx, oi)
+					} else {
+						err = j.removeIfNewer(ctx, pit, oi)
+					}
+					if err != errSkipPointInTime {
+						j.record(oi, err)
+					}
+				}
+				marker = oi.Name
+				j.worker.Marker = marker
+				if err = j.maybeCheckpoint(ctx); err != nil {
+					return err
+				}
+			}
+			if !res.IsTruncated || len(res.Objects) == 0 {
+				break
+			}
+		}
+		j.worker.Pass++
+		j.worker.Marker = ""
+		if _, err := j.save(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errSkipPointInTime is returned for objects a point-in-time restore does
+// not need to change, they are not counted.
+var errSkipPointInTime = errors.New("object is unchanged since asOf")
+
+// restoreVersion makes oi, the version current at asOf, the latest version
+// again by copying it forward.
+func (j *batchJobRun) restoreVersion(ctx context.Context, oi ObjectInfo) error {
+	cur, err := j.objAPI.GetObjectInfo(ctx, oi.Bucket, oi.Name, ObjectOptions{})
+	if err == nil && cur.VersionID == oi.VersionID && cur.ModTime.Equal(oi.ModTime) {
+		return errSkipPointInTime
+	}
+	if !j.req.isAllowed(iampolicy.GetObjectVersionAction, oi.Bucket, oi.Name) ||
+		!j.req.isAllowed(iampolicy.PutObjectAction, oi.Bucket, oi.Name) {
+		return errBatchJobAccessDenied
+	}
+	return batchJobRewrite(ctx, j.objAPI, oi, oi.Bucket, oi.Name, nil, false)
+}
+
+// removeIfNewer deletes the current object oi when it did not exist at asOf.
+func (j *batchJobRun) removeIfNewer(ctx context.Context, pit pointInTimeLister, oi ObjectInfo) error {
+	if !oi.ModTime.After(j.req.AsOf) {
+		return errSkipPointInTime
+	}
+	_, err := pit.GetObjectInfoAsOf(ctx, oi.Bucket, oi.Name, j.req.AsOf)
+	if err == nil {
+		return errSkipPointInTime
+	}
+	if !isErrObjectNotFound(err) && !isErrVersionNotFound(err) {
+		return err
+	}
+	if !j.req.isAllowed(iampolicy.DeleteObjectAction, oi.Bucket, oi.Name) {
+		return errBatchJobAccessDenied
+	}
+	_, err = j.objAPI.DeleteObject(ctx, oi.Bucket, oi.Name, ObjectOptions{
+		Versioned: globalBucketVersioningSys.PrefixEnabled(oi.Bucket, oi.Name),
+	})
+	return err
+}
+
+// runManifest applies the job to all objects listed in the manifest.
+func (j *batchJobRun) runManifest(ctx context.Context) error {
+	m := j.req.Source.Manifest
+	gr, err := j.objAPI.GetObjectNInfo(ctx, m.Bucket, m.Object, nil, http.Header{}, readLock, ObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	r := csv.NewReader(gr)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	for line := int64(1); ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("manifest line %d: %w", line, err)
+		}
+		if line <= j.worker.ManifestLine || len(record) < 2 || !j.owns(record[0], record[1]) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		opts := ObjectOptions{}
+		if len(record) > 2 {
+			opts.VersionID = record[2]
+		}
+		oi, err := j.objAPI.GetObjectInfo(ctx, record[0], record[1], opts)
+		if err != nil {
+			j.record(ObjectInfo{Bucket: record[0], Name: record[1], VersionID: opts.VersionID}, err)
+		} else if j.selected(oi) {
+			j.record(oi, j.apply(ctx, oi, opts.VersionID == ""))
+		}
+		j.worker.ManifestLine = line
+		if err = j.maybeCheckpoint(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// selected returns true if oi passes the job's source filter.
+func (j *batchJobRun) selected(oi ObjectInfo) bool {
+	f := j.req.Source.Filter
+	if oi.DeleteMarker && j.req.Operation != batchJobDelete {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !oi.ModTime.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !oi.ModTime.Before(f.CreatedBefore) {
+		return false
+	}
+	if f.MinSize > 0 && oi.Size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && oi.Size > f.MaxSize {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		t, err := tags.ParseObjectTags(oi.UserTags)
+		if err != nil {
+			return false
+		}
+		objTags := t.ToMap()
+		for k, v := range f.Tags {
+			if objTags[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// apply runs the job operation on a single object version, latest is set
+// when the object was selected without version, by a prefix listing of the
+// latest versions or a manifest line without version id.
+func (j *batchJobRun) apply(ctx context.Context, oi ObjectInfo, latest bool) error {
+	for _, action := range j.req.objectActions(latest) {
+		if !j.req.isAllowed(action, oi.Bucket, oi.Name) {
+			return errBatchJobAccessDenied
+		}
+	}
+	switch j.req.Operation {
+	case batchJobCopy:
+		dst := path.Join(j.req.Target.Prefix, oi.Name)
+		if !j.req.isAllowed(iampolicy.PutObjectAction, j.req.Target.Bucket, dst) {
+			return errBatchJobAccessDenied
+		}
+		if _, encrypted := crypto.IsEncrypted(oi.UserDefined); encrypted && (dst != oi.Name || j.req.Target.Bucket != oi.Bucket) {
+			return errBatchJobEncrypted
+		}
+		return batchJobRewrite(ctx, j.objAPI, oi, j.req.Target.Bucket, dst, nil, false)
+	case batchJobRetag:
+		_, err := j.objAPI.PutObjectTags(ctx, oi.Bucket, oi.Name, j.tags, ObjectOptions{VersionID: oi.VersionID})
+		return err
+	case batchJobStorageClass:
+		if oi.StorageClass == j.req.StorageClass {
+			return nil
+		}
+		return batchJobRewrite(ctx, j.objAPI, oi, oi.Bucket, oi.Name, map[string]string{
+			xhttp.AmzStorageClass: j.req.StorageClass,
+		}, true)
+	case batchJobRestore:
+		if oi.TransitionedObject.Status != lifecycle.TransitionComplete {
+			return errBatchJobNotTransition
+		}
+		return j.objAPI.RestoreTransitionedObject(ctx, oi.Bucket, oi.Name, ObjectOptions{
+			VersionID: oi.VersionID,
+			Transition: TransitionOptions{
+				RestoreRequest: &RestoreObjectRequest{Days: j.req.RestoreDays},
+				RestoreExpiry:  lifecycle.ExpectedExpiryTime(time.Now(), j.req.RestoreDays),
+			},
+		})
+	case batchJobDelete:
+		opts := ObjectOptions{
+			Versioned: globalBucketVersioningSys.PrefixEnabled(oi.Bucket, oi.Name),
+		}
+		// Deleting the latest version of a versioned object adds a delete
+		// marker, only versions named explicitly are removed.
+		if !latest {
+			opts.VersionID = oi.VersionID
+		}
+		if (opts.VersionID != "" || !opts.Versioned) && enforceRetentionForDeletion(ctx, oi) {
+			return errBatchJobLocked
+		}
+		_, err := j.objAPI.DeleteObject(ctx, oi.Bucket, oi.Name, opts)
+		return err
+	}
+	return errBatchJobInvalidOp
+}
+
+// batchJobRewrite writes the content of oi to dstBucket/dstObject with the
+// extra metadata applied. When inPlace is set the version id and modification
+// time are preserved, so rewriting an object does not add a new version,
+// similar to decommissioning. The data is copied as stored, still compressed
+// and encrypted, together with its ETag.
+func batchJobRewrite(ctx context.Context, objAPI ObjectLayer, oi ObjectInfo, dstBucket, dstObject string, extra map[string]string, inPlace bool) error {
+	versionID := oi.VersionID
+	if versionID == nullVersionID {
+		versionID = ""
+	}
+	gr, err := objAPI.GetObjectNInfo(ctx, oi.Bucket, oi.Name, nil, http.Header{}, noLock, ObjectOptions{
+		VersionID:    versionID,
+		NoDecryption: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	objInfo := gr.ObjInfo
+
+	userDefined := cloneMSS(objInfo.UserDefined)
+	for k, v := range extra {
+		userDefined[k] = v
+	}
+	if objInfo.UserTags != "" {
+		userDefined[xhttp.AmzObjectTagging] = objInfo.UserTags
+	}
+	opts := ObjectOptions{
+		UserDefined:  userDefined,
+		Versioned:    globalBucketVersioningSys.PrefixEnabled(dstBucket, dstObject),
+		PreserveETag: objInfo.ETag,
+	}
+	if inPlace {
+		opts.VersionID = versionID
+		opts.MTime = objInfo.ModTime
+	}
+
+	if objInfo.isMultipart() {
+		uploadID, err := objAPI.NewMultipartUpload(ctx, dstBucket, dstObject, opts)
+		if err != nil {
+			return err
+		}
+		defer objAPI.AbortMultipartUpload(ctx, dstBucket, dstObject, uploadID, ObjectOptions{})
+		parts := make([]CompletePart, len(objInfo.Parts))
+		for i, part := range objInfo.Parts {
+			part := part
+			hr, err := hash.NewReader(gr, part.Size, "", "", part.ActualSize)
+			if err != nil {
+				return err
+			}
+			pi, err := objAPI.PutObjectPart(ctx, dstBucket, dstObject, uploadID, part.Number, NewPutObjReader(hr), ObjectOptions{
+				PreserveETag: part.ETag,
+				IndexCB: func() []byte {
+					return part.Index
+				},
+			})
+			if err != nil {
+				return err
+			}
+			parts[i] = CompletePart{ETag: pi.ETag, PartNumber: pi.PartNumber}
+		}
+		_, err = objAPI.CompleteMultipartUpload(ctx, dstBucket, dstObject, uploadID, parts, ObjectOptions{MTime: opts.MTime})
+		return err
+	}
+
+	actualSize, err := objInfo.GetActualSize()
+	if err != nil {
+		return err
+	}
+	hr, err := hash.NewReader(gr, objInfo.Size, "", "", actualSize)
+	if err != nil {
+		return err
+	}
+	if len(objInfo.Parts) > 0 {
+		index := objInfo.Parts[0].Index
+		opts.IndexCB = func() []byte {
+			return index
+		}
+	}
+	_, err = objAPI.PutObject(ctx, dstBucket, dstObject, NewPutObjReader(hr), opts)
+	return err
+}
+
+// writeReport writes the completion report of the job, a JSON summary
+// followed by a CSV of failed objects, and returns its location.
+func (j *batchJobRun) writeReport(ctx context.Context, status BatchJobStatus) (string, error) {
+	if !j.req.isAllowed(iampolicy.PutObjectAction, j.req.Report.Bucket, path.Join(j.req.Report.Prefix, j.req.ID+".report")) {
+		return "", errBatchJobAccessDenied
+	}
+	var buf bytes.Buffer
+	summary := status
+	summary.Workers = make([]BatchJobWorker, len(status.Workers))
+	for i, w := range status.Workers {
+		w.Failures = nil
+		summary.Workers[i] = w
+	}
+	if err := json.NewEncoder(&buf).Encode(summary); err != nil {
+		return "", err
+	}
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"bucket", "object", "versionId", "error"})
+	for _, worker := range status.Workers {
+		for _, f := range worker.Failures {
+			w.Write([]string{f.Bucket, f.Object, f.VersionID, f.Error})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+
+	object := path.Join(j.req.Report.Prefix, j.req.ID+".report")
+	data := buf.Bytes()
+	hr, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", getSHA256Hash(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	_, err = j.objAPI.PutObject(ctx, j.req.Report.Bucket, object, NewPutObjReader(hr), ObjectOptions{
+		UserDefined: map[string]string{xhttp.ContentType: "text/plain"},
+	})
+	if err != nil {
+		return "", err
+	}
+	return path.Join(j.req.Report.Bucket, object), nil
+}
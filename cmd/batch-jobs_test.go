@@ -0,0 +1,254 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/crypto"
+	"github.com/GuinsooLab/annastore/internal/etag"
+	"github.com/GuinsooLab/annastore/internal/hash"
+	"github.com/GuinsooLab/annastore/internal/kms"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+func TestBatchJobRequestValidate(t *testing.T) {
+	src := BatchJobSource{Bucket: "bucket"}
+	testCases := []struct {
+		req BatchJobRequest
+		err error
+	}{
+		{BatchJobRequest{Operation: batchJobDelete}, errBatchJobNoSource},
+		{BatchJobRequest{Operation: "unknown", Source: src}, errBatchJobInvalidOp},
+		{BatchJobRequest{Operation: batchJobCopy, Source: src}, errBatchJobNoTarget},
+		{BatchJobRequest{Operation: batchJobCopy, Source: src, Target: &BatchJobTarget{Bucket: "dst"}}, nil},
+		{BatchJobRequest{Operation: batchJobRetag, Source: src}, errBatchJobNoTags},
+		{BatchJobRequest{Operation: batchJobRetag, Source: src, Tags: map[string]string{"team": "a"}}, nil},
+		{BatchJobRequest{Operation: batchJobStorageClass, Source: src}, errBatchJobNoStorageClass},
+		{BatchJobRequest{Operation: batchJobStorageClass, Source: src, StorageClass: "GLACIER"}, errBatchJobStorageClass},
+		{BatchJobRequest{Operation: batchJobStorageClass, Source: src, StorageClass: "REDUCED_REDUNDANCY"}, nil},
+		{BatchJobRequest{Operation: batchJobRestore, Source: src}, errBatchJobNoRestoreDays},
+		{BatchJobRequest{Operation: batchJobRestore, Source: src, RestoreDays: 3}, nil},
+		{BatchJobRequest{Operation: batchJobDelete, Source: BatchJobSource{Manifest: &BatchJobManifest{Bucket: "b", Object: "m.csv"}}}, nil},
+		{BatchJobRequest{Operation: batchJobPointInTime, Source: src}, errBatchJobNoAsOf},
+		{BatchJobRequest{Operation: batchJobPointInTime, Source: src, AsOf: time.Now().Add(time.Hour)}, errBatchJobNoAsOf},
+		{BatchJobRequest{Operation: batchJobPointInTime, Source: src, AsOf: time.Now(), Target: &BatchJobTarget{Bucket: "dst"}}, errBatchJobAsOfSource},
+		{BatchJobRequest{Operation: batchJobPointInTime, Source: src, AsOf: time.Now().Add(-time.Hour)}, nil},
+	}
+	for i, tc := range testCases {
+		if err := tc.req.Validate(); err != tc.err {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.err, err)
+		}
+	}
+}
+
+func TestBatchJobStatusUpdate(t *testing.T) {
+	status := BatchJobStatus{
+		State: batchJobRunning,
+		Workers: []BatchJobWorker{
+			{State: batchJobCompleted, Objects: 3, Bytes: 30},
+			{State: batchJobRunning, Objects: 2, ObjectsFailed: 1, Bytes: 20},
+		},
+	}
+	status.update()
+	if status.State != batchJobRunning || status.Objects != 5 || status.ObjectsFailed != 1 || status.Bytes != 50 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	status.Workers[1].State = batchJobFailed
+	status.Workers[1].Error = "drive full"
+	status.update()
+	if status.State != batchJobFailed || status.Error != "drive full" || status.Finished.IsZero() {
+		t.Fatalf("expected the job to fail once all workers are done, got %+v", status)
+	}
+}
+
+func TestBatchJobOwns(t *testing.T) {
+	owners := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		object := "object-" + strconv.Itoa(i)
+		owned := 0
+		for idx := range owners {
+			job := &batchJobRun{req: BatchJobRequest{Workers: len(owners)}, idx: idx}
+			if job.owns("bucket", object) {
+				owners[idx]++
+				owned++
+			}
+		}
+		if owned != 1 {
+			t.Fatalf("%s: expected a single worker, got %d", object, owned)
+		}
+	}
+	for idx, n := range owners {
+		if n == 0 {
+			t.Errorf("worker %d owns no object", idx)
+		}
+	}
+}
+
+func TestBatchJobObjectActions(t *testing.T) {
+	req := BatchJobRequest{Operation: batchJobDelete}
+	if actions := req.objectActions(true); len(actions) != 1 || actions[0] != iampolicy.DeleteObjectAction {
+		t.Errorf("expected %s for the latest version, got %v", iampolicy.DeleteObjectAction, actions)
+	}
+	if actions := req.objectActions(false); len(actions) != 1 || actions[0] != iampolicy.DeleteObjectVersionAction {
+		t.Errorf("expected %s for a version, got %v", iampolicy.DeleteObjectVersionAction, actions)
+	}
+}
+
+func TestBatchJobSelected(t *testing.T) {
+	now := time.Now()
+	job := &batchJobRun{req: BatchJobRequest{
+		Operation: batchJobRetag,
+		Source: BatchJobSource{
+			Bucket: "bucket",
+			Filter: BatchJobFilter{
+				CreatedBefore: now,
+				MinSize:       10,
+				Tags:          map[string]string{"team": "a"},
+			},
+		},
+	}}
+	testCases := []struct {
+		oi       ObjectInfo
+		selected bool
+	}{
+		{ObjectInfo{ModTime: now.Add(-time.Hour), Size: 20, UserTags: "team=a&env=prod"}, true},
+		{ObjectInfo{ModTime: now.Add(time.Hour), Size: 20, UserTags: "team=a"}, false},
+		{ObjectInfo{ModTime: now.Add(-time.Hour), Size: 5, UserTags: "team=a"}, false},
+		{ObjectInfo{ModTime: now.Add(-time.Hour), Size: 20, UserTags: "team=b"}, false},
+		{ObjectInfo{ModTime: now.Add(-time.Hour), Size: 20, UserTags: "team=a", DeleteMarker: true}, false},
+	}
+	for i, tc := range testCases {
+		if got := job.selected(tc.oi); got != tc.selected {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.selected, got)
+		}
+	}
+}
+
+// Wrapper for calling testBatchJobRewrite for both Erasure and FS.
+func TestBatchJobRewrite(t *testing.T) {
+	var err error
+	GlobalKMS, err = kms.Parse("my-minio-key:5lF+0pJM0OWwlQrvK2S/I7W9mO4a6rJJI7wzj7v09cw=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resetCompressEncryption()
+
+	ExecObjectLayerTest(t, testBatchJobRewrite)
+}
+
+// Tests that rewriting compressed and encrypted objects keeps their content.
+func testBatchJobRewrite(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	ctx := context.Background()
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(ctx, bucket, MakeBucketOptions{}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("batch job rewrite "), 64<<10)
+	size := int64(len(data))
+
+	// Compress the object the way PutObjectHandler does.
+	metadata := map[string]string{
+		ReservedMetadataPrefix + "compression": compressionAlgorithmV2,
+		ReservedMetadataPrefix + "actual-size": strconv.FormatInt(size, 10),
+	}
+	actualReader, err := hash.NewReader(bytes.NewReader(data), size, "", "", size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2c, idxCb := newS2CompressReader(actualReader, size, false)
+	defer s2c.Close()
+	hr, err := hash.NewReader(etag.Wrap(s2c, actualReader), -1, "", "", size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = obj.PutObject(ctx, bucket, "compressed", NewPutObjReader(hr), ObjectOptions{UserDefined: metadata, IndexCB: idxCb})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	// Encrypt the object with SSE-S3 the way PutObjectHandler does.
+	metadata = map[string]string{}
+	actualReader, err = hash.NewReader(bytes.NewReader(data), size, "", "", size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encReader, objectKey, err := newEncryptReader(ctx, actualReader, crypto.S3, "", nil, bucket, "encrypted", metadata, kms.Context{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := ObjectInfo{Size: size}
+	hr, err = hash.NewReader(etag.Wrap(encReader, actualReader), info.EncryptedSize(), "", "", size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pReader, err := NewPutObjReader(actualReader).WithEncryption(hr, &objectKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = obj.PutObject(ctx, bucket, "encrypted", pReader, ObjectOptions{UserDefined: metadata})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	for _, object := range []string{"compressed", "encrypted"} {
+		// An in place rewrite like the storage class operation and a
+		// rewrite to a new version like the restore operation.
+		for _, inPlace := range []bool{true, false} {
+			oi, err := obj.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+			if err != nil {
+				t.Fatalf("%s: %s: %v", instanceType, object, err)
+			}
+			if err = batchJobRewrite(ctx, obj, oi, bucket, object, nil, inPlace); err != nil {
+				t.Fatalf("%s: %s: rewrite failed: %v", instanceType, object, err)
+			}
+			gr, err := obj.GetObjectNInfo(ctx, bucket, object, nil, http.Header{}, readLock, ObjectOptions{})
+			if err != nil {
+				t.Fatalf("%s: %s: %v", instanceType, object, err)
+			}
+			got, err := io.ReadAll(gr)
+			gr.Close()
+			if err != nil {
+				t.Fatalf("%s: %s: %v", instanceType, object, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%s: %s: rewritten content does not match, got %d bytes, want %d", instanceType, object, len(got), len(data))
+			}
+			newInfo, err := obj.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+			if err != nil {
+				t.Fatalf("%s: %s: %v", instanceType, object, err)
+			}
+			if newInfo.ETag != oi.ETag {
+				t.Errorf("%s: %s: expected ETag %s, got %s", instanceType, object, oi.ETag, newInfo.ETag)
+			}
+			if object == "compressed" && !newInfo.IsCompressed() {
+				t.Errorf("%s: %s: object is no longer compressed", instanceType, object)
+			}
+			if _, encrypted := crypto.IsEncrypted(newInfo.UserDefined); object == "encrypted" && !encrypted {
+				t.Errorf("%s: %s: object is no longer encrypted", instanceType, object)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
+)
+
+const (
+	bucketErasureConfigFile = "erasure.json"
+
+	// maxBucketInlineThreshold is the largest inline threshold of a bucket,
+	// the same as the one of the storage classes.
+	maxBucketInlineThreshold = storageclass.MaxInlineThreshold
+)
+
+// BucketErasureConfig holds the erasure coding settings of a bucket, applied
+// to the objects written to it.
+type BucketErasureConfig struct {
+	// Parity is the parity of the objects of the STANDARD storage class,
+	// the deployment default when 0.
+	Parity int `json:"parity,omitempty"`
+	// InlineThreshold is the shard size below which object data is inlined
+	// in xl.meta, the server default when 0.
+	InlineThreshold int64 `json:"inlineThreshold,omitempty"`
+}
+
+// IsEmpty returns true if the configuration keeps the deployment defaults.
+func (c BucketErasureConfig) IsEmpty() bool {
+	return c.Parity == 0 && c.InlineThreshold == 0
+}
+
+// Validate returns an error if the configuration can not be applied to
+// erasure sets of the given drive counts.
+func (c BucketErasureConfig) Validate(setDriveCounts []int) error {
+	if c.Parity < 0 {
+		return fmt.Errorf("parity %d should be greater than or equal to 0", c.Parity)
+	}
+	// Objects of a bucket may be placed on any pool.
+	for _, setDriveCount := range setDriveCounts {
+		if c.Parity > setDriveCount/2 {
+			return fmt.Errorf("parity %d should be less than or equal to %d", c.Parity, setDriveCount/2)
+		}
+	}
+	if c.InlineThreshold < 0 || c.InlineThreshold > maxBucketInlineThreshold {
+		return fmt.Errorf("inline threshold %d should be between 0 and %d", c.InlineThreshold, maxBucketInlineThreshold)
+	}
+	return nil
+}
+
+// parseBucketErasureConfig parses BucketErasureConfig from json
+func parseBucketErasureConfig(data []byte) (*BucketErasureConfig, error) {
+	cfg := &BucketErasureConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Parity < 0 || cfg.InlineThreshold < 0 {
+		return cfg, fmt.Errorf("Invalid erasure config %#v", cfg)
+	}
+	return cfg, nil
+}
+
+// getBucketErasureConfig returns the erasure coding settings of bucket, the
+// zero value when none are set. Only the loaded bucket metadata is looked
+// up, as this is called for every object written.
+func getBucketErasureConfig(bucket string) BucketErasureConfig {
+	meta, err := globalBucketMetadataSys.Get(bucket)
+	if err != nil || meta.erasureConfig == nil {
+		return BucketErasureConfig{}
+	}
+	return *meta.erasureConfig
+}
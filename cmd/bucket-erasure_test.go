@@ -0,0 +1,134 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dustin/go-humanize"
+)
+
+func TestBucketErasureConfigValidate(t *testing.T) {
+	testCases := []struct {
+		data    string
+		success bool
+	}{
+		{data: `{}`, success: true},
+		{data: `{"parity":2}`, success: true},
+		{data: `{"parity":8,"inlineThreshold":1048576}`, success: true},
+		{data: `{"parity":9}`},
+		{data: `{"parity":-1}`},
+		{data: `{"inlineThreshold":1048577}`},
+		{data: `{"parity":"2"}`},
+	}
+	for i, tc := range testCases {
+		cfg, err := parseBucketErasureConfig([]byte(tc.data))
+		if err == nil {
+			err = cfg.Validate([]int{16, 20})
+		}
+		if tc.success && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+		if !tc.success && err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+}
+
+func TestBucketErasureConfigPutObject(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	const object = "object"
+	for _, bucket := range []string{"default", "archive"} {
+		if err = obj.MakeBucketWithLocation(ctx, bucket, MakeBucketOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta, err := globalBucketMetadataSys.Get("archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.erasureConfig = &BucketErasureConfig{Parity: 8, InlineThreshold: 512 * humanize.KiByte}
+	globalBucketMetadataSys.Set("archive", meta)
+
+	er := obj.(*erasureServerPools).serverPools[0].sets[0]
+	defaultParity := globalStorageClass.GetParityForSC("")
+	if defaultParity < 0 {
+		defaultParity = er.defaultParityCount
+	}
+	// Shards of 2MiB are inlined only with the bucket inline threshold.
+	data := bytes.Repeat([]byte("a"), 2<<20)
+	testCases := []struct {
+		bucket string
+		parity int
+		inline bool
+	}{
+		{bucket: "default", parity: defaultParity},
+		{bucket: "archive", parity: 8, inline: true},
+	}
+	for _, tc := range testCases {
+		if _, err = obj.PutObject(ctx, tc.bucket, object, mustGetPutObjReader(t, bytes.NewReader(data), int64(len(data)), "", ""), ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		fi, metaArr, _, err := er.getObjectFileInfo(ctx, tc.bucket, object, ObjectOptions{}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Erasure.ParityBlocks != tc.parity {
+			t.Errorf("%s: expected parity %d, got %d", tc.bucket, tc.parity, fi.Erasure.ParityBlocks)
+		}
+		// The write quorum follows the parity of the object.
+		wantWriteQuorum := fi.Erasure.DataBlocks
+		if fi.Erasure.DataBlocks == tc.parity {
+			wantWriteQuorum++
+		}
+		if _, writeQuorum, err := objectQuorumFromMeta(ctx, metaArr, make([]error, len(metaArr)), er.defaultParityCount); err != nil || writeQuorum != wantWriteQuorum {
+			t.Errorf("%s: expected write quorum %d, got %d (%v)", tc.bucket, wantWriteQuorum, writeQuorum, err)
+		}
+		if fi.InlineData() != tc.inline {
+			t.Errorf("%s: expected inline data %v, got %v", tc.bucket, tc.inline, fi.InlineData())
+		}
+
+		uploadID, err := obj.NewMultipartUpload(ctx, tc.bucket, object, ObjectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pi, err := obj.PutObjectPart(ctx, tc.bucket, object, uploadID, 1, mustGetPutObjReader(t, bytes.NewReader(data), int64(len(data)), "", ""), ObjectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = obj.CompleteMultipartUpload(ctx, tc.bucket, object, uploadID, []CompletePart{{PartNumber: 1, ETag: pi.ETag}}, ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if fi, _, _, err = er.getObjectFileInfo(ctx, tc.bucket, object, ObjectOptions{}, false); err != nil {
+			t.Fatal(err)
+		}
+		if fi.Erasure.ParityBlocks != tc.parity {
+			t.Errorf("%s: expected multipart parity %d, got %d", tc.bucket, tc.parity, fi.Erasure.ParityBlocks)
+		}
+	}
+}
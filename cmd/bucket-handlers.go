@@ -733,6 +733,12 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Object lambda access points share the bucket namespace.
+	if _, ok := globalObjectLambdaSys.Get(bucket); ok {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrBucketAlreadyExists), r.URL)
+		return
+	}
+
 	if objectLockEnabled {
 		// Creating a bucket with locking requires the user having more permissions
 		for _, action := range []iampolicy.Action{iampolicy.PutBucketObjectLockConfigurationAction, iampolicy.PutBucketVersioningAction} {
@@ -976,6 +982,8 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(errCode), r.URL)
 		return
 	}
+	globalIAMSys.recordAccessKeyUsage(r, cred)
+	rateLimitAuthenticated(r, cred)
 
 	// Once signature is validated, check if the user has
 	// explicit permissions for the user.
@@ -32,6 +32,7 @@ import (
 	sse "github.com/GuinsooLab/annastore/internal/bucket/encryption"
 	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
 	"github.com/GuinsooLab/annastore/internal/event"
+	"github.com/GuinsooLab/annastore/internal/hash"
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/GuinsooLab/annastore/internal/s3select"
@@ -217,6 +218,7 @@ func (t *transitionState) worker(ctx context.Context, objectAPI ObjectLayer) {
 				return
 			}
 			atomic.AddInt32(&t.activeTasks, 1)
+			wait := transitionSleeper.Timer(ctx)
 			var tier string
 			var err error
 			if tier, err = transitionObject(ctx, objectAPI, oi); err != nil {
@@ -231,6 +233,7 @@ func (t *transitionState) worker(ctx context.Context, objectAPI ObjectLayer) {
 				}
 				t.addLastDayStats(tier, ts)
 			}
+			wait()
 			atomic.AddInt32(&t.activeTasks, -1)
 
 		}
@@ -283,15 +286,24 @@ func initBackgroundTransition(ctx context.Context, objectAPI ObjectLayer) {
 
 var errInvalidStorageClass = errors.New("invalid storage class")
 
+// isTransitionTierValid returns true if tier is a remote tier or a custom
+// storage class objects can be transitioned to.
+func isTransitionTierValid(tier string) bool {
+	if _, ok := globalStorageClass.GetCustom(tier); ok {
+		return true
+	}
+	return globalTierConfigMgr.IsTierValid(tier)
+}
+
 func validateTransitionTier(lc *lifecycle.Lifecycle) error {
 	for _, rule := range lc.Rules {
 		if rule.Transition.StorageClass != "" {
-			if valid := globalTierConfigMgr.IsTierValid(rule.Transition.StorageClass); !valid {
+			if valid := isTransitionTierValid(rule.Transition.StorageClass); !valid {
 				return errInvalidStorageClass
 			}
 		}
 		if rule.NoncurrentVersionTransition.StorageClass != "" {
-			if valid := globalTierConfigMgr.IsTierValid(rule.NoncurrentVersionTransition.StorageClass); !valid {
+			if valid := isTransitionTierValid(rule.NoncurrentVersionTransition.StorageClass); !valid {
 				return errInvalidStorageClass
 			}
 		}
@@ -408,6 +420,9 @@ func transitionObject(ctx context.Context, objectAPI ObjectLayer, oi ObjectInfo)
 		return "", err
 	}
 	tier := lc.TransitionTier(oi.ToLifecycleOpts())
+	if _, ok := globalStorageClass.GetCustom(tier); ok {
+		return tier, rewriteObjectStorageClass(ctx, objectAPI, oi, tier)
+	}
 	opts := ObjectOptions{
 		Transition: TransitionOptions{
 			Status: lifecycle.TransitionPending,
@@ -422,6 +437,140 @@ func transitionObject(ctx context.Context, objectAPI ObjectLayer, oi ObjectInfo)
 	return tier, objectAPI.TransitionObject(ctx, oi.Bucket, oi.Name, opts)
 }
 
+// rewriteObjectStorageClass rewrites the version of an object described by
+// oi with the custom storage class sc, keeping its version id, modification
+// time and ETag.
+func rewriteObjectStorageClass(ctx context.Context, objectAPI ObjectLayer, oi ObjectInfo, sc string) error {
+	z, ok := objectAPI.(*erasureServerPools)
+	if !ok {
+		return NotImplemented{}
+	}
+	return z.rewriteObjectStorageClass(ctx, oi, sc)
+}
+
+// rewriteObjectStorageClass rewrites the version of an object described by
+// oi with the custom storage class sc. The version moves to a pool of sc
+// when it is the only version of the object, as all the versions of an
+// object are in the same pool. The object is write locked during the
+// rewrite, which is skipped if the version changed since oi was read.
+func (z *erasureServerPools) rewriteObjectStorageClass(ctx context.Context, oi ObjectInfo, sc string) error {
+	bucket, object := oi.Bucket, encodeDirObject(oi.Name)
+	versionID := oi.VersionID
+	if versionID == nullVersionID {
+		versionID = ""
+	}
+
+	lk := z.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	srcIdx, err := z.getPoolIdxExistingWithOpts(ctx, bucket, object, ObjectOptions{NoLock: true})
+	if err != nil {
+		return err
+	}
+	src := z.serverPools[srcIdx]
+	gr, err := src.GetObjectNInfo(ctx, bucket, object, nil, http.Header{}, noLock, ObjectOptions{
+		VersionID:    versionID,
+		NoDecryption: true,
+		NoLock:       true,
+	})
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	objInfo := gr.ObjInfo
+	if objInfo.ETag != oi.ETag || !objInfo.ModTime.Equal(oi.ModTime) || objInfo.StorageClass == sc {
+		return nil
+	}
+	actualSize, err := objInfo.GetActualSize()
+	if err != nil {
+		return err
+	}
+
+	dstIdx := srcIdx
+	if pools := globalStorageClass.GetPoolsForSC(sc); len(pools) > 0 && !contains(pools, srcIdx) && objInfo.NumVersions <= 1 {
+		if idx := z.getAvailablePoolIdx(ctx, bucket, object, sc, objInfo.Size); idx >= 0 && contains(pools, idx) {
+			dstIdx = idx
+		}
+	}
+	dst := z.serverPools[dstIdx]
+
+	userDefined := cloneMSS(objInfo.UserDefined)
+	userDefined[xhttp.AmzStorageClass] = sc
+
+	if objInfo.isMultipart() {
+		uploadID, err := dst.NewMultipartUpload(ctx, bucket, object, ObjectOptions{
+			VersionID:   versionID,
+			MTime:       objInfo.ModTime,
+			UserDefined: userDefined,
+		})
+		if err != nil {
+			return err
+		}
+		defer dst.AbortMultipartUpload(ctx, bucket, object, uploadID, ObjectOptions{})
+		parts := make([]CompletePart, len(objInfo.Parts))
+		for i, part := range objInfo.Parts {
+			hr, err := hash.NewReader(gr, part.Size, "", "", part.ActualSize)
+			if err != nil {
+				return err
+			}
+			index := part.Index
+			pi, err := dst.PutObjectPart(ctx, bucket, object, uploadID, part.Number, NewPutObjReader(hr), ObjectOptions{
+				PreserveETag: part.ETag,
+				IndexCB: func() []byte {
+					return index
+				},
+			})
+			if err != nil {
+				return err
+			}
+			parts[i] = CompletePart{
+				ETag:       pi.ETag,
+				PartNumber: pi.PartNumber,
+			}
+		}
+		_, err = dst.CompleteMultipartUpload(ctx, bucket, object, uploadID, parts, ObjectOptions{
+			MTime:  objInfo.ModTime,
+			NoLock: true,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		hr, err := hash.NewReader(gr, objInfo.Size, "", "", actualSize)
+		if err != nil {
+			return err
+		}
+		_, err = dst.PutObject(ctx, bucket, object, NewPutObjReader(hr), ObjectOptions{
+			VersionID:    versionID,
+			MTime:        objInfo.ModTime,
+			UserDefined:  userDefined,
+			PreserveETag: objInfo.ETag,
+			IndexCB: func() []byte {
+				return objInfo.Parts[0].Index
+			},
+			NoLock: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if dstIdx != srcIdx {
+		// Remove the version left on the previous pool.
+		_, err = src.DeleteObject(ctx, bucket, object, ObjectOptions{
+			VersionID: versionID,
+			NoLock:    true,
+		})
+	}
+	return err
+}
+
 // getTransitionedObjectReader returns a reader from the transitioned tier.
 func getTransitionedObjectReader(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, oi ObjectInfo, opts ObjectOptions) (gr *GetObjectReader, err error) {
 	tgtClient, err := globalTierConfigMgr.getDriver(oi.TransitionedObject.Tier)
@@ -790,5 +939,6 @@ func (oi ObjectInfo) ToLifecycleOpts() lifecycle.ObjectOpts {
 		RestoreOngoing:   oi.RestoreOngoing,
 		RestoreExpires:   oi.RestoreExpires,
 		TransitionStatus: oi.TransitionedObject.Status,
+		StorageClass:     oi.StorageClass,
 	}
 }
@@ -19,11 +19,13 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
 )
 
@@ -218,6 +220,10 @@ func TestObjectIsRemote(t *testing.T) {
 
 func TestValidateTransitionTier(t *testing.T) {
 	globalTierConfigMgr = NewTierConfigMgr()
+	globalStorageClass.UpdateCustom(map[string]storageclass.CustomClass{
+		"LOGS": {Name: "LOGS", Parity: 1, InlineThreshold: -1},
+	})
+	defer globalStorageClass.UpdateCustom(nil)
 	testCases := []struct {
 		xml         []byte
 		expectedErr error
@@ -227,6 +233,11 @@ func TestValidateTransitionTier(t *testing.T) {
 			xml:         []byte(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule><ID>rule</ID><Prefix /><Status>Enabled</Status><Transition><Days>1</Days><StorageClass>"NONEXISTENT"</StorageClass></Transition></Rule></LifecycleConfiguration>`),
 			expectedErr: errInvalidStorageClass,
 		},
+		{
+			// custom storage class
+			xml:         []byte(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule><ID>rule</ID><Prefix /><Status>Enabled</Status><Transition><Days>1</Days><StorageClass>LOGS</StorageClass></Transition></Rule></LifecycleConfiguration>`),
+			expectedErr: nil,
+		},
 		{
 			// no transition rule
 			xml:         []byte(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule><ID>rule</ID><Prefix /><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`),
@@ -245,3 +256,91 @@ func TestValidateTransitionTier(t *testing.T) {
 		}
 	}
 }
+
+// TestRewriteObjectStorageClass tests rewriteObjectStorageClass
+func TestRewriteObjectStorageClass(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	globalStorageClass.UpdateCustom(map[string]storageclass.CustomClass{
+		"LOGS": {Name: "LOGS", Parity: 1, InlineThreshold: -1},
+		// Objects of the class move to the second pool.
+		"ARCHIVE": {Name: "ARCHIVE", Parity: 2, Pools: []int{1}, InlineThreshold: -1},
+	})
+	defer globalStorageClass.UpdateCustom(nil)
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	testRewriteObjectStorageClass(ctx, t, obj, "LOGS", 0)
+
+	obj, fsDirs, err = prepareErasurePools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	testRewriteObjectStorageClass(ctx, t, obj, "ARCHIVE", 1)
+}
+
+func testRewriteObjectStorageClass(ctx context.Context, t *testing.T, obj ObjectLayer, sc string, wantPool int) {
+	setObjectLayer(obj)
+	z := obj.(*erasureServerPools)
+	bucket, object := "rewrite-sc", "object"
+	if err := obj.MakeBucketWithLocation(ctx, bucket, MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1<<20)
+	// Written to the first pool.
+	oi, err := z.serverPools[0].PutObject(ctx, bucket, object, mustGetPutObjReader(t, bytes.NewReader(data), int64(len(data)), "", ""), ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A version changed since it was read is not rewritten.
+	stale := oi
+	stale.ModTime = oi.ModTime.Add(-time.Minute)
+	if err = rewriteObjectStorageClass(ctx, obj, stale, sc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := obj.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.StorageClass == sc {
+		t.Fatalf("expected the object changed since it was read not to be rewritten")
+	}
+
+	if err = rewriteObjectStorageClass(ctx, obj, oi, sc); err != nil {
+		t.Fatal(err)
+	}
+	got, err = obj.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.StorageClass != sc {
+		t.Fatalf("expected storage class %s, got %s", sc, got.StorageClass)
+	}
+	if got.ETag != oi.ETag || !got.ModTime.Equal(oi.ModTime) {
+		t.Fatalf("expected ETag %s and mod time %s to be kept, got %s and %s", oi.ETag, oi.ModTime, got.ETag, got.ModTime)
+	}
+	for i, pool := range z.serverPools {
+		_, err = pool.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+		if i == wantPool && err != nil {
+			t.Fatalf("expected the object in pool %d, got %v", i, err)
+		}
+		if i != wantPool && !isErrObjectNotFound(err) {
+			t.Fatalf("expected the object not to be left in pool %d, got %v", i, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err = GetObject(ctx, obj, bucket, object, 0, got.Size, &buf, "", ObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("object data changed by the rewrite")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/gorilla/mux"
@@ -228,7 +229,20 @@ func (api objectAPIHandlers) ListObjectsV2Handler(w http.ResponseWriter, r *http
 		err               error
 	)
 
-	if r.Header.Get(xMinIOExtract) == "true" && strings.Contains(prefix, archivePattern) {
+	asOf, err := getAsOf(r, bucket)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
+	if !asOf.IsZero() {
+		// A point in time view exposes previous versions.
+		if s3Error := checkRequestAuthType(ctx, r, policy.ListBucketVersionsAction, bucket, ""); s3Error != ErrNone {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+			return
+		}
+		listObjectsV2Info, err = listObjectsV2AsOf(ctx, objectAPI, bucket, prefix, token, delimiter, maxKeys, startAfter, asOf)
+	} else if r.Header.Get(xMinIOExtract) == "true" && strings.Contains(prefix, archivePattern) {
 		// Inititate a list objects operation inside a zip file based in the input params
 		listObjectsV2Info, err = listObjectsV2InArchive(ctx, objectAPI, bucket, prefix, token, delimiter, maxKeys, fetchOwner, startAfter)
 	} else {
@@ -255,6 +269,29 @@ func (api objectAPIHandlers) ListObjectsV2Handler(w http.ResponseWriter, r *http
 	writeSuccessResponseXML(w, encodeResponse(response))
 }
 
+// listObjectsV2AsOf lists the objects of bucket as they were at asOf.
+func listObjectsV2AsOf(ctx context.Context, objectAPI ObjectLayer, bucket, prefix, token, delimiter string, maxKeys int, startAfter string, asOf time.Time) (ListObjectsV2Info, error) {
+	pit, ok := objectAPI.(pointInTimeLister)
+	if !ok {
+		return ListObjectsV2Info{}, NotImplemented{}
+	}
+	marker := token
+	if marker == "" {
+		marker = startAfter
+	}
+	loi, err := pit.ListObjectsAsOf(ctx, bucket, prefix, marker, delimiter, maxKeys, asOf)
+	if err != nil {
+		return ListObjectsV2Info{}, err
+	}
+	return ListObjectsV2Info{
+		IsTruncated:           loi.IsTruncated,
+		ContinuationToken:     token,
+		NextContinuationToken: loi.NextMarker,
+		Objects:               loi.Objects,
+		Prefixes:              loi.Prefixes,
+	}, nil
+}
+
 func parseRequestToken(token string) (subToken string, nodeIndex int) {
 	if token == "" {
 		return token, -1
@@ -126,6 +126,9 @@ func (sys *BucketMetadataSys) Update(ctx context.Context, bucket string, configF
 	case bucketQuotaConfigFile:
 		meta.QuotaConfigJSON = configData
 		meta.QuotaConfigUpdatedAt = updatedAt
+	case bucketErasureConfigFile:
+		meta.ErasureConfigJSON = configData
+		meta.ErasureConfigUpdatedAt = updatedAt
 	case objectLockConfig:
 		meta.ObjectLockConfigXML = configData
 		meta.ObjectLockConfigUpdatedAt = updatedAt
@@ -335,7 +338,7 @@ func (sys *BucketMetadataSys) GetPolicyConfig(bucket string) (*policy.Policy, ti
 
 // GetQuotaConfig returns configured bucket quota
 // The returned object may not be modified.
-func (sys *BucketMetadataSys) GetQuotaConfig(ctx context.Context, bucket string) (*madmin.BucketQuota, time.Time, error) {
+func (sys *BucketMetadataSys) GetQuotaConfig(ctx context.Context, bucket string) (*BucketQuota, time.Time, error) {
 	meta, err := sys.GetConfig(ctx, bucket)
 	if err != nil {
 		if errors.Is(err, errConfigNotFound) {
@@ -346,6 +349,39 @@ func (sys *BucketMetadataSys) GetQuotaConfig(ctx context.Context, bucket string)
 	return meta.quotaConfig, meta.QuotaConfigUpdatedAt, nil
 }
 
+// GetErasureConfig returns configured bucket erasure coding settings
+// The returned object may not be modified.
+func (sys *BucketMetadataSys) GetErasureConfig(ctx context.Context, bucket string) (*BucketErasureConfig, time.Time, error) {
+	meta, err := sys.GetConfig(ctx, bucket)
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return nil, time.Time{}, BucketErasureConfigNotFound{Bucket: bucket}
+		}
+		return nil, time.Time{}, err
+	}
+	if meta.erasureConfig == nil {
+		return nil, time.Time{}, BucketErasureConfigNotFound{Bucket: bucket}
+	}
+	return meta.erasureConfig, meta.ErasureConfigUpdatedAt, nil
+}
+
+// MinErasureParity returns the smallest parity set on a bucket, 0 if no
+// bucket has its own parity.
+func (sys *BucketMetadataSys) MinErasureParity() (parity int) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	for _, meta := range sys.metadataMap {
+		if meta.erasureConfig == nil || meta.erasureConfig.Parity == 0 {
+			continue
+		}
+		if parity == 0 || meta.erasureConfig.Parity < parity {
+			parity = meta.erasureConfig.Parity
+		}
+	}
+	return parity
+}
+
 // GetReplicationConfig returns configured bucket replication config
 // The returned object may not be modified.
 func (sys *BucketMetadataSys) GetReplicationConfig(ctx context.Context, bucket string) (*replication.Config, time.Time, error) {
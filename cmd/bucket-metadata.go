@@ -88,6 +88,8 @@ type BucketMetadata struct {
 	QuotaConfigUpdatedAt        time.Time
 	ReplicationConfigUpdatedAt  time.Time
 	VersioningConfigUpdatedAt   time.Time
+	ErasureConfigJSON           []byte
+	ErasureConfigUpdatedAt      time.Time
 
 	// Unexported fields. Must be updated atomically.
 	policyConfig           *policy.Policy
@@ -97,10 +99,11 @@ type BucketMetadata struct {
 	versioningConfig       *versioning.Versioning
 	sseConfig              *bucketsse.BucketSSEConfig
 	taggingConfig          *tags.Tags
-	quotaConfig            *madmin.BucketQuota
+	quotaConfig            *BucketQuota
 	replicationConfig      *replication.Config
 	bucketTargetConfig     *madmin.BucketTargets
 	bucketTargetConfigMeta map[string]string
+	erasureConfig          *BucketErasureConfig
 }
 
 // newBucketMetadata creates BucketMetadata with the supplied name and Created to Now.
@@ -110,7 +113,7 @@ func newBucketMetadata(name string) BucketMetadata {
 		notificationConfig: &event.Config{
 			XMLNS: "http://s3.amazonaws.com/doc/2006-03-01/",
 		},
-		quotaConfig: &madmin.BucketQuota{},
+		quotaConfig: &BucketQuota{},
 		versioningConfig: &versioning.Versioning{
 			XMLNS: "http://s3.amazonaws.com/doc/2006-03-01/",
 		},
@@ -257,6 +260,15 @@ func (b *BucketMetadata) parseAllConfigs(ctx context.Context, objectAPI ObjectLa
 		}
 	}
 
+	if len(b.ErasureConfigJSON) != 0 {
+		b.erasureConfig, err = parseBucketErasureConfig(b.ErasureConfigJSON)
+		if err != nil {
+			return err
+		}
+	} else {
+		b.erasureConfig = nil
+	}
+
 	if len(b.ReplicationConfigXML) != 0 {
 		b.replicationConfig, err = replication.ParseConfig(bytes.NewReader(b.ReplicationConfigXML))
 		if err != nil {
@@ -401,6 +413,10 @@ func (b *BucketMetadata) defaultTimestamps() {
 	if b.VersioningConfigUpdatedAt.IsZero() {
 		b.VersioningConfigUpdatedAt = b.Created
 	}
+
+	if b.ErasureConfigUpdatedAt.IsZero() {
+		b.ErasureConfigUpdatedAt = b.Created
+	}
 }
 
 // Save config to supplied ObjectLayer api.
@@ -430,6 +446,7 @@ func (b *BucketMetadata) Save(ctx context.Context, api ObjectLayer) error {
 func deleteBucketMetadata(ctx context.Context, obj objectDeleter, bucket string) error {
 	metadataFiles := []string{
 		dataUsageCacheName,
+		dataUsageHistoryName,
 		bucketMetadataFile,
 		path.Join(replicationDir, resyncFileName),
 	}
@@ -184,6 +184,9 @@ func getConditionValues(r *http.Request, lc string, username string, claims map[
 		}
 	}
 
+	// Tag condition keys must not be set by the request parameters.
+	setRequestTagConditionValues(args, r.Header.Get(xhttp.AmzObjectTagging))
+
 	return args
 }
 
@@ -18,29 +18,58 @@
 package cmd
 
 import (
+	"container/heap"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/GuinsooLab/annastore/internal/event"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/minio/madmin-go"
 )
 
+// RollingQuota specifies a quota of usage for a bucket enforced by deleting
+// its oldest objects instead of rejecting writes. It does not reuse the
+// removed "fifo" type, the configurations of older releases stay ignored.
+const RollingQuota madmin.QuotaType = "rolling"
+
+// BucketQuota holds the quota of a bucket, it extends madmin.BucketQuota
+// with a maximum number of objects.
+type BucketQuota struct {
+	Quota uint64           `json:"quota"`
+	Type  madmin.QuotaType `json:"quotatype,omitempty"`
+	// Objects is the maximum number of objects, 0 for no limit.
+	Objects uint64 `json:"objects,omitempty"`
+}
+
+// IsEmpty returns true if the quota has no limits.
+func (q BucketQuota) IsEmpty() bool {
+	return q.Quota == 0 && q.Objects == 0
+}
+
+// IsValid returns false if the quota is invalid, an empty quota is valid.
+func (q BucketQuota) IsValid() bool {
+	if q.IsEmpty() {
+		return true
+	}
+	return q.Type == madmin.HardQuota || q.Type == RollingQuota
+}
+
 // BucketQuotaSys - map of bucket and quota configuration.
 type BucketQuotaSys struct {
 	bucketStorageCache timedValue
 }
 
 // Get - Get quota configuration.
-func (sys *BucketQuotaSys) Get(ctx context.Context, bucketName string) (*madmin.BucketQuota, error) {
+func (sys *BucketQuotaSys) Get(ctx context.Context, bucketName string) (*BucketQuota, error) {
 	if globalIsGateway {
 		objAPI := newObjectLayerFn()
 		if objAPI == nil {
 			return nil, errServerNotInitialized
 		}
-		return &madmin.BucketQuota{}, nil
+		return &BucketQuota{}, nil
 	}
 	qCfg, _, err := globalBucketMetadataSys.GetQuotaConfig(ctx, bucketName)
 	return qCfg, err
@@ -81,14 +110,14 @@ func (sys *BucketQuotaSys) GetBucketUsageInfo(bucket string) (BucketUsageInfo, e
 }
 
 // parseBucketQuota parses BucketQuota from json
-func parseBucketQuota(bucket string, data []byte) (quotaCfg *madmin.BucketQuota, err error) {
-	quotaCfg = &madmin.BucketQuota{}
+func parseBucketQuota(bucket string, data []byte) (quotaCfg *BucketQuota, err error) {
+	quotaCfg = &BucketQuota{}
 	if err = json.Unmarshal(data, quotaCfg); err != nil {
 		return quotaCfg, err
 	}
 	if !quotaCfg.IsValid() {
 		if quotaCfg.Type == "fifo" {
-			logger.LogIf(GlobalContext, errors.New("Detected older 'fifo' quota config, 'fifo' feature is removed and not supported anymore. Please clear your quota configs using 'mc admin bucket quota alias/bucket --clear' and use 'mc ilm add' for expiration of objects"))
+			logger.LogIf(GlobalContext, errors.New("Detected older 'fifo' quota config, 'fifo' feature is removed and not supported anymore. Please clear your quota configs using 'mc admin bucket quota alias/bucket --clear' and use a 'rolling' quota or 'mc ilm add' for expiration of objects"))
 			return quotaCfg, nil
 		}
 		return quotaCfg, fmt.Errorf("Invalid quota config %#v", quotaCfg)
@@ -106,13 +135,16 @@ func (sys *BucketQuotaSys) enforceQuotaHard(ctx context.Context, bucket string,
 		return err
 	}
 
-	if q != nil && q.Type == madmin.HardQuota && q.Quota > 0 {
+	if q != nil && q.Type == madmin.HardQuota && !q.IsEmpty() {
 		bui, err := sys.GetBucketUsageInfo(bucket)
 		if err != nil {
 			return err
 		}
 
-		if bui.Size > 0 && ((bui.Size + uint64(size)) >= q.Quota) {
+		if q.Quota > 0 && bui.Size > 0 && ((bui.Size + uint64(size)) >= q.Quota) {
+			return BucketQuotaExceeded{Bucket: bucket}
+		}
+		if q.Objects > 0 && bui.ObjectsCount >= q.Objects {
 			return BucketQuotaExceeded{Bucket: bucket}
 		}
 	}
@@ -126,3 +158,211 @@ func enforceBucketQuotaHard(ctx context.Context, bucket string, size int64) erro
 	}
 	return globalBucketQuotaSys.enforceQuotaHard(ctx, bucket, size)
 }
+
+// fifoQuotaObject - an object which may be deleted to enforce a FIFO quota,
+// with all its versions.
+type fifoQuotaObject struct {
+	modTime  time.Time
+	size     uint64
+	versions []ObjectInfo
+}
+
+// fifoQuotaSelection selects the oldest objects to delete to free at least
+// a number of bytes and objects. It is a heap of the selected objects with
+// the newest one first, so that an object older than the newest selected
+// one replaces it when the others are enough.
+type fifoQuotaSelection struct {
+	freeBytes   uint64
+	freeObjects uint64
+
+	objects []fifoQuotaObject
+	size    uint64
+}
+
+func (s fifoQuotaSelection) Len() int { return len(s.objects) }
+func (s fifoQuotaSelection) Less(i, j int) bool {
+	return s.objects[i].modTime.After(s.objects[j].modTime)
+}
+func (s fifoQuotaSelection) Swap(i, j int) { s.objects[i], s.objects[j] = s.objects[j], s.objects[i] }
+
+func (s *fifoQuotaSelection) Push(x interface{}) {
+	o := x.(fifoQuotaObject)
+	s.objects = append(s.objects, o)
+	s.size += o.size
+}
+
+func (s *fifoQuotaSelection) Pop() interface{} {
+	o := s.objects[len(s.objects)-1]
+	s.objects = s.objects[:len(s.objects)-1]
+	s.size -= o.size
+	return o
+}
+
+// add adds an object to the selection if it is older than the newest
+// selected object, or if not enough objects are selected yet.
+func (s *fifoQuotaSelection) add(o fifoQuotaObject) {
+	heap.Push(s, o)
+	s.trim()
+}
+
+// trim drops the newest selected objects while the others free enough.
+func (s *fifoQuotaSelection) trim() {
+	for len(s.objects) > 0 {
+		newest := s.objects[0]
+		if uint64(len(s.objects)-1) < s.freeObjects || s.size-newest.size < s.freeBytes {
+			break
+		}
+		heap.Pop(s)
+	}
+}
+
+// enforceFIFOQuotas deletes the oldest objects of the buckets with a FIFO
+// quota exceeding their quota, according to the last data usage.
+func enforceFIFOQuotas(ctx context.Context, objAPI ObjectLayer) {
+	if globalBucketQuotaSys == nil {
+		return
+	}
+	dui, err := loadDataUsageFromBackend(ctx, objAPI)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+	for bucket, bui := range dui.BucketsUsage {
+		q, err := globalBucketQuotaSys.Get(ctx, bucket)
+		if err != nil || q == nil || q.Type != RollingQuota {
+			continue
+		}
+		if (q.Quota == 0 || bui.Size <= q.Quota) && (q.Objects == 0 || bui.ObjectsCount <= q.Objects) {
+			continue
+		}
+		if err = enforceFIFOQuotaBucket(ctx, objAPI, bucket, q, bui); err != nil {
+			logger.LogIf(ctx, fmt.Errorf("unable to enforce the FIFO quota of bucket %s: %w", bucket, err))
+		}
+	}
+}
+
+// enforceFIFOQuotaBucket deletes the oldest objects of a bucket, with all
+// their versions, until it is under its quota. An object is as old as its
+// latest version, and the objects with a version under retention or legal
+// hold are not deleted. The objects to delete are selected while walking
+// the bucket from its last data usage bui, and trimmed once the walk
+// measured the actual usage.
+func enforceFIFOQuotaBucket(ctx context.Context, objAPI ObjectLayer, bucket string, q *BucketQuota, bui BucketUsageInfo) error {
+	versioned := globalBucketVersioningSys.Enabled(bucket)
+	suspended := globalBucketVersioningSys.Suspended(bucket)
+	lockEnabled := false
+	if rcfg, err := globalBucketObjectLockSys.Get(bucket); err == nil {
+		lockEnabled = rcfg.LockEnabled
+	}
+
+	objInfoCh := make(chan ObjectInfo)
+	if err := objAPI.Walk(ctx, bucket, "", objInfoCh, ObjectOptions{}); err != nil {
+		return err
+	}
+
+	sel := &fifoQuotaSelection{}
+	quotaExcess := func(size, count uint64) {
+		sel.freeBytes, sel.freeObjects = 0, 0
+		if q.Quota > 0 && size > q.Quota {
+			sel.freeBytes = size - q.Quota
+		}
+		if q.Objects > 0 && count > q.Objects {
+			sel.freeObjects = count - q.Objects
+		}
+	}
+	quotaExcess(bui.Size, bui.ObjectsCount)
+
+	// Walk returns all the versions of an object together, the latest first.
+	var (
+		size      uint64
+		count     uint64
+		cur       fifoQuotaObject
+		curLocked bool
+	)
+	flush := func() {
+		if len(cur.versions) == 0 {
+			return
+		}
+		size += cur.size
+		// Objects whose latest version is a delete marker are not counted.
+		if !cur.versions[0].DeleteMarker {
+			count++
+			if !curLocked {
+				sel.add(cur)
+			}
+		}
+		cur, curLocked = fifoQuotaObject{}, false
+	}
+	for obj := range objInfoCh {
+		if len(cur.versions) > 0 && cur.versions[0].Name != obj.Name {
+			flush()
+		}
+		if len(cur.versions) == 0 {
+			cur.modTime = obj.ModTime
+		}
+		cur.versions = append(cur.versions, obj)
+		if !obj.DeleteMarker {
+			cur.size += uint64(obj.Size)
+		}
+		if lockEnabled && enforceRetentionForDeletion(ctx, obj) {
+			curLocked = true
+		}
+	}
+	flush()
+
+	quotaExcess(size, count)
+	if sel.freeBytes == 0 && sel.freeObjects == 0 {
+		// Under quota, the data usage was outdated.
+		return nil
+	}
+	// Less may be needed than estimated from the data usage, when more
+	// is needed the remainder is deleted after the next scanner cycle.
+	sel.trim()
+
+	var versions []ObjectInfo
+	for _, o := range sel.objects {
+		versions = append(versions, o.versions...)
+	}
+	for len(versions) > 0 {
+		n := len(versions)
+		if n > maxDeleteList {
+			n = maxDeleteList
+		}
+		batch := versions[:n]
+		versions = versions[n:]
+
+		objects := make([]ObjectToDelete, 0, len(batch))
+		for _, obj := range batch {
+			versionID := obj.VersionID
+			if versionID == "" && (versioned || suspended) {
+				versionID = nullVersionID
+			}
+			objects = append(objects, ObjectToDelete{
+				ObjectV: ObjectV{
+					ObjectName: obj.Name,
+					VersionID:  versionID,
+				},
+			})
+		}
+		_, errs := objAPI.DeleteObjects(ctx, bucket, objects, ObjectOptions{
+			Versioned:        versioned,
+			VersionSuspended: suspended,
+		})
+		for i, err := range errs {
+			if err != nil {
+				if !isErrObjectNotFound(err) && !isErrVersionNotFound(err) {
+					logger.LogIf(ctx, err)
+				}
+				continue
+			}
+			// Notify object deleted event.
+			sendEvent(eventArgs{
+				EventName:  event.ObjectRemovedDelete,
+				BucketName: bucket,
+				Object:     batch[i],
+				Host:       "Internal: [ROLLING-QUOTA-EXPIRY]",
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+)
+
+func TestParseBucketQuota(t *testing.T) {
+	testCases := []struct {
+		data    string
+		quota   BucketQuota
+		success bool
+	}{
+		{data: `{}`, success: true},
+		{data: `{"quota":1024,"quotatype":"hard"}`, quota: BucketQuota{Quota: 1024, Type: madmin.HardQuota}, success: true},
+		{data: `{"objects":10,"quotatype":"hard"}`, quota: BucketQuota{Objects: 10, Type: madmin.HardQuota}, success: true},
+		{data: `{"quota":1024,"objects":10,"quotatype":"rolling"}`, quota: BucketQuota{Quota: 1024, Objects: 10, Type: RollingQuota}, success: true},
+		// The removed fifo quota of older releases is ignored.
+		{data: `{"quota":1024,"quotatype":"fifo"}`, quota: BucketQuota{Quota: 1024, Type: "fifo"}, success: true},
+		{data: `{"objects":10}`},
+		{data: `{"quota":1024,"quotatype":"soft"}`},
+		{data: `not json`},
+	}
+	for i, tc := range testCases {
+		q, err := parseBucketQuota("bucket", []byte(tc.data))
+		if tc.success != (err == nil) {
+			t.Errorf("case %d: expected success %t, got error %v", i+1, tc.success, err)
+			continue
+		}
+		if tc.success && *q != tc.quota {
+			t.Errorf("case %d: expected %+v, got %+v", i+1, tc.quota, *q)
+		}
+	}
+}
+
+func TestFIFOQuotaSelection(t *testing.T) {
+	now := time.Now()
+	object := func(age, size int) fifoQuotaObject {
+		return fifoQuotaObject{
+			modTime:  now.Add(-time.Duration(age) * time.Hour),
+			size:     uint64(size),
+			versions: []ObjectInfo{{Name: fmt.Sprintf("age-%d", age)}},
+		}
+	}
+	objects := []fifoQuotaObject{object(3, 10), object(1, 10), object(5, 30), object(2, 10), object(4, 20)}
+
+	testCases := []struct {
+		freeBytes, freeObjects uint64
+		selected               []string
+	}{
+		{freeObjects: 2, selected: []string{"age-4", "age-5"}},
+		{freeBytes: 40, selected: []string{"age-4", "age-5"}},
+		{freeBytes: 51, selected: []string{"age-3", "age-4", "age-5"}},
+		{freeBytes: 10, freeObjects: 3, selected: []string{"age-3", "age-4", "age-5"}},
+		{freeBytes: 1000, selected: []string{"age-1", "age-2", "age-3", "age-4", "age-5"}},
+	}
+	for i, tc := range testCases {
+		sel := &fifoQuotaSelection{freeBytes: tc.freeBytes, freeObjects: tc.freeObjects}
+		for _, o := range objects {
+			sel.add(o)
+		}
+		var selected []string
+		for _, o := range sel.objects {
+			selected = append(selected, o.versions[0].Name)
+		}
+		sort.Strings(selected)
+		if fmt.Sprint(selected) != fmt.Sprint(tc.selected) {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.selected, selected)
+		}
+	}
+}
+
+func TestEnforceFIFOQuotaBucket(t *testing.T) {
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+	ExecObjectLayerTest(t, testEnforceFIFOQuotaBucket)
+}
+
+func testEnforceFIFOQuotaBucket(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	ctx := context.Background()
+	bucket := "fifo-quota"
+	if err := obj.MakeBucketWithLocation(ctx, bucket, MakeBucketOptions{}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for i := 0; i < 5; i++ {
+		data := bytes.Repeat([]byte("a"), 100)
+		_, err := obj.PutObject(ctx, bucket, fmt.Sprintf("object-%d", i), mustGetPutObjReader(t, bytes.NewReader(data), int64(len(data)), "", ""), ObjectOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	list := func() (names []string) {
+		res, err := obj.ListObjects(ctx, bucket, "", "", "", 100)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		for _, o := range res.Objects {
+			names = append(names, o.Name)
+		}
+		return names
+	}
+
+	// Keep at most 3 objects.
+	if err := enforceFIFOQuotaBucket(ctx, obj, bucket, &BucketQuota{Objects: 3, Type: RollingQuota}, BucketUsageInfo{Size: 500, ObjectsCount: 5}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if names := fmt.Sprint(list()); names != "[object-2 object-3 object-4]" {
+		t.Fatalf("%s: expected the 3 newest objects, got %s", instanceType, names)
+	}
+
+	// Keep at most 150 bytes, from an outdated data usage.
+	if err := enforceFIFOQuotaBucket(ctx, obj, bucket, &BucketQuota{Quota: 150, Type: RollingQuota}, BucketUsageInfo{Size: 500, ObjectsCount: 5}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if names := fmt.Sprint(list()); names != "[object-4]" {
+		t.Fatalf("%s: expected the newest object, got %s", instanceType, names)
+	}
+}
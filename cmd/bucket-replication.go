@@ -1389,7 +1389,9 @@ func (p *ReplicationPool) AddMRFWorker() {
 			if !ok {
 				return
 			}
+			wait := replicationSleeper.Timer(p.ctx)
 			replicateObject(p.ctx, oi, p.objLayer)
+			wait()
 		case <-p.mrfWorkerKillCh:
 			return
 		}
@@ -1407,12 +1409,16 @@ func (p *ReplicationPool) AddWorker() {
 			if !ok {
 				return
 			}
+			wait := replicationSleeper.Timer(p.ctx)
 			replicateObject(p.ctx, oi, p.objLayer)
+			wait()
 		case doi, ok := <-p.replicaDeleteCh:
 			if !ok {
 				return
 			}
+			wait := replicationSleeper.Timer(p.ctx)
 			replicateDelete(p.ctx, doi, p.objLayer)
+			wait()
 		case <-p.workerKillCh:
 			return
 		}
@@ -1429,12 +1435,16 @@ func (p *ReplicationPool) AddExistingObjectReplicateWorker() {
 			if !ok {
 				return
 			}
+			wait := replicationSleeper.Timer(p.ctx)
 			replicateObject(p.ctx, oi, p.objLayer)
+			wait()
 		case doi, ok := <-p.existingReplicaDeleteCh:
 			if !ok {
 				return
 			}
+			wait := replicationSleeper.Timer(p.ctx)
 			replicateDelete(p.ctx, doi, p.objLayer)
+			wait()
 		}
 	}
 }
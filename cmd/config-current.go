@@ -30,16 +30,21 @@ import (
 	"github.com/GuinsooLab/annastore/internal/config/callhome"
 	"github.com/GuinsooLab/annastore/internal/config/compress"
 	"github.com/GuinsooLab/annastore/internal/config/dns"
+	"github.com/GuinsooLab/annastore/internal/config/drivehealth"
 	"github.com/GuinsooLab/annastore/internal/config/etcd"
 	"github.com/GuinsooLab/annastore/internal/config/heal"
 	xldap "github.com/GuinsooLab/annastore/internal/config/identity/ldap"
 	"github.com/GuinsooLab/annastore/internal/config/identity/openid"
 	idplugin "github.com/GuinsooLab/annastore/internal/config/identity/plugin"
+	idsaml "github.com/GuinsooLab/annastore/internal/config/identity/saml"
+	idscim "github.com/GuinsooLab/annastore/internal/config/identity/scim"
 	xtls "github.com/GuinsooLab/annastore/internal/config/identity/tls"
 	"github.com/GuinsooLab/annastore/internal/config/notify"
 	"github.com/GuinsooLab/annastore/internal/config/policy/opa"
 	polplugin "github.com/GuinsooLab/annastore/internal/config/policy/plugin"
+	"github.com/GuinsooLab/annastore/internal/config/ratelimit"
 	"github.com/GuinsooLab/annastore/internal/config/scanner"
+	"github.com/GuinsooLab/annastore/internal/config/schedule"
 	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/config/subnet"
 	"github.com/GuinsooLab/annastore/internal/crypto"
@@ -59,6 +64,8 @@ func initHelp() {
 		config.IdentityOpenIDSubSys: openid.DefaultKVS,
 		config.IdentityTLSSubSys:    xtls.DefaultKVS,
 		config.IdentityPluginSubSys: idplugin.DefaultKVS,
+		config.IdentitySAMLSubSys:   idsaml.DefaultKVS,
+		config.IdentitySCIMSubSys:   idscim.DefaultKVS,
 		config.PolicyOPASubSys:      opa.DefaultKVS,
 		config.PolicyPluginSubSys:   polplugin.DefaultKVS,
 		config.SiteSubSys:           config.DefaultSiteKVS,
@@ -72,12 +79,16 @@ func initHelp() {
 		config.ScannerSubSys:        scanner.DefaultKVS,
 		config.SubnetSubSys:         subnet.DefaultKVS,
 		config.CallhomeSubSys:       callhome.DefaultKVS,
+		config.RateLimitSubSys:      ratelimit.DefaultKVS,
+		config.ScheduleSubSys:       schedule.DefaultKVS,
 	}
 	for k, v := range notify.DefaultNotificationKVS {
 		kvs[k] = v
 	}
 	if globalIsErasure {
 		kvs[config.StorageClassSubSys] = storageclass.DefaultKVS
+		kvs[config.StorageClassCustomSubSys] = storageclass.CustomDefaultKVS
+		kvs[config.DriveHealthSubSys] = drivehealth.DefaultKVS
 	}
 	config.RegisterDefaultKVS(kvs)
 
@@ -116,6 +127,14 @@ func initHelp() {
 			Key:         config.IdentityPluginSubSys,
 			Description: "enable Identity Plugin via external hook",
 		},
+		config.HelpKV{
+			Key:         config.IdentitySAMLSubSys,
+			Description: "enable SAML 2.0 SSO support",
+		},
+		config.HelpKV{
+			Key:         config.IdentitySCIMSubSys,
+			Description: "enable SCIM 2.0 provisioning of users and groups",
+		},
 		config.HelpKV{
 			Key:         config.PolicyPluginSubSys,
 			Description: "enable Access Management Plugin for policy enforcement",
@@ -209,49 +228,75 @@ func initHelp() {
 			Description: "enable callhome for the cluster",
 			Optional:    true,
 		},
+		config.HelpKV{
+			Key:             config.RateLimitSubSys,
+			Description:     "limit the rate of the requests of access keys, groups or on buckets",
+			MultipleTargets: true,
+		},
+		config.HelpKV{
+			Key:             config.ScheduleSubSys,
+			Description:     "schedule the speed of the scanner, healing, transitions and replication in time windows",
+			MultipleTargets: true,
+		},
 	}
 
 	if globalIsErasure {
-		helpSubSys = append(helpSubSys, config.HelpKV{})
-		copy(helpSubSys[2:], helpSubSys[1:])
+		helpSubSys = append(helpSubSys, config.HelpKV{}, config.HelpKV{})
+		copy(helpSubSys[3:], helpSubSys[1:])
 		helpSubSys[1] = config.HelpKV{
 			Key:         config.StorageClassSubSys,
 			Description: "define object level redundancy",
 		}
+		helpSubSys[2] = config.HelpKV{
+			Key:             config.StorageClassCustomSubSys,
+			Description:     "define named storage classes with their own redundancy and placement",
+			MultipleTargets: true,
+		}
+		helpSubSys = append(helpSubSys, config.HelpKV{
+			Key:         config.DriveHealthSubSys,
+			Description: "monitor the SMART/NVMe health of the local drives",
+			Optional:    true,
+		})
 	}
 
 	helpMap := map[string]config.HelpKVS{
-		"":                          helpSubSys, // Help for all sub-systems.
-		config.SiteSubSys:           config.SiteHelp,
-		config.RegionSubSys:         config.RegionHelp,
-		config.APISubSys:            api.Help,
-		config.StorageClassSubSys:   storageclass.Help,
-		config.EtcdSubSys:           etcd.Help,
-		config.CacheSubSys:          cache.Help,
-		config.CompressionSubSys:    compress.Help,
-		config.HealSubSys:           heal.Help,
-		config.ScannerSubSys:        scanner.Help,
-		config.IdentityOpenIDSubSys: openid.Help,
-		config.IdentityLDAPSubSys:   xldap.Help,
-		config.IdentityTLSSubSys:    xtls.Help,
-		config.IdentityPluginSubSys: idplugin.Help,
-		config.PolicyOPASubSys:      opa.Help,
-		config.PolicyPluginSubSys:   polplugin.Help,
-		config.LoggerWebhookSubSys:  logger.Help,
-		config.AuditWebhookSubSys:   logger.HelpWebhook,
-		config.AuditKafkaSubSys:     logger.HelpKafka,
-		config.NotifyAMQPSubSys:     notify.HelpAMQP,
-		config.NotifyKafkaSubSys:    notify.HelpKafka,
-		config.NotifyMQTTSubSys:     notify.HelpMQTT,
-		config.NotifyNATSSubSys:     notify.HelpNATS,
-		config.NotifyNSQSubSys:      notify.HelpNSQ,
-		config.NotifyMySQLSubSys:    notify.HelpMySQL,
-		config.NotifyPostgresSubSys: notify.HelpPostgres,
-		config.NotifyRedisSubSys:    notify.HelpRedis,
-		config.NotifyWebhookSubSys:  notify.HelpWebhook,
-		config.NotifyESSubSys:       notify.HelpES,
-		config.SubnetSubSys:         subnet.HelpSubnet,
-		config.CallhomeSubSys:       callhome.HelpCallhome,
+		"":                              helpSubSys, // Help for all sub-systems.
+		config.SiteSubSys:               config.SiteHelp,
+		config.RegionSubSys:             config.RegionHelp,
+		config.APISubSys:                api.Help,
+		config.StorageClassSubSys:       storageclass.Help,
+		config.StorageClassCustomSubSys: storageclass.CustomHelp,
+		config.EtcdSubSys:               etcd.Help,
+		config.CacheSubSys:              cache.Help,
+		config.CompressionSubSys:        compress.Help,
+		config.HealSubSys:               heal.Help,
+		config.ScannerSubSys:            scanner.Help,
+		config.DriveHealthSubSys:        drivehealth.Help,
+		config.IdentityOpenIDSubSys:     openid.Help,
+		config.IdentityLDAPSubSys:       xldap.Help,
+		config.IdentityTLSSubSys:        xtls.Help,
+		config.IdentityPluginSubSys:     idplugin.Help,
+		config.IdentitySAMLSubSys:       idsaml.Help,
+		config.IdentitySCIMSubSys:       idscim.Help,
+		config.PolicyOPASubSys:          opa.Help,
+		config.PolicyPluginSubSys:       polplugin.Help,
+		config.LoggerWebhookSubSys:      logger.Help,
+		config.AuditWebhookSubSys:       logger.HelpWebhook,
+		config.AuditKafkaSubSys:         logger.HelpKafka,
+		config.NotifyAMQPSubSys:         notify.HelpAMQP,
+		config.NotifyKafkaSubSys:        notify.HelpKafka,
+		config.NotifyMQTTSubSys:         notify.HelpMQTT,
+		config.NotifyNATSSubSys:         notify.HelpNATS,
+		config.NotifyNSQSubSys:          notify.HelpNSQ,
+		config.NotifyMySQLSubSys:        notify.HelpMySQL,
+		config.NotifyPostgresSubSys:     notify.HelpPostgres,
+		config.NotifyRedisSubSys:        notify.HelpRedis,
+		config.NotifyWebhookSubSys:      notify.HelpWebhook,
+		config.NotifyESSubSys:           notify.HelpES,
+		config.SubnetSubSys:             subnet.HelpSubnet,
+		config.CallhomeSubSys:           callhome.HelpCallhome,
+		config.RateLimitSubSys:          ratelimit.Help,
+		config.ScheduleSubSys:           schedule.Help,
 	}
 
 	config.RegisterHelpSubSys(helpMap)
@@ -302,6 +347,21 @@ func validateSubSysConfig(s config.Config, subSys string, objAPI ObjectLayer) er
 				}
 			}
 		}
+	case config.StorageClassCustomSubSys:
+		if globalIsErasure {
+			if objAPI == nil {
+				return errServerNotInitialized
+			}
+			classes, err := storageclass.LookupCustomConfig(s, objAPI.SetDriveCounts())
+			if err != nil {
+				return err
+			}
+			for name := range classes {
+				if globalTierConfigMgr != nil && globalTierConfigMgr.IsTierValid(name) {
+					return config.Errorf("storage class %s conflicts with the remote tier of the same name", name)
+				}
+			}
+		}
 	case config.CacheSubSys:
 		if _, err := cache.LookupConfig(s[config.CacheSubSys][config.Default]); err != nil {
 			return err
@@ -325,6 +385,10 @@ func validateSubSysConfig(s config.Config, subSys string, objAPI ObjectLayer) er
 		if _, err := scanner.LookupConfig(s[config.ScannerSubSys][config.Default]); err != nil {
 			return err
 		}
+	case config.DriveHealthSubSys:
+		if _, err := drivehealth.LookupConfig(s[config.DriveHealthSubSys][config.Default]); err != nil {
+			return err
+		}
 	case config.EtcdSubSys:
 		etcdCfg, err := etcd.LookupConfig(s[config.EtcdSubSys][config.Default], globalRootCAs)
 		if err != nil {
@@ -343,14 +407,14 @@ func validateSubSysConfig(s config.Config, subSys string, objAPI ObjectLayer) er
 			return err
 		}
 	case config.IdentityLDAPSubSys:
-		cfg, err := xldap.Lookup(s[config.IdentityLDAPSubSys][config.Default], globalRootCAs)
+		cfgs, err := xldap.LookupConfig(s, globalRootCAs)
 		if err != nil {
 			return err
 		}
-		if cfg.Enabled {
+		for _, cfg := range cfgs.Cfgs {
 			conn, cerr := cfg.Connect()
 			if cerr != nil {
-				return cerr
+				return fmt.Errorf("%s: %w", cfg.Name, cerr)
 			}
 			conn.Close()
 		}
@@ -363,6 +427,15 @@ func validateSubSysConfig(s config.Config, subSys string, objAPI ObjectLayer) er
 			NewGatewayHTTPTransport(), xhttp.DrainBody, globalSite.Region); err != nil {
 			return err
 		}
+	case config.IdentitySAMLSubSys:
+		if _, err := idsaml.LookupConfig(s[config.IdentitySAMLSubSys][config.Default],
+			NewGatewayHTTPTransport(), globalSite.Region); err != nil {
+			return err
+		}
+	case config.IdentitySCIMSubSys:
+		if _, err := idscim.LookupConfig(s[config.IdentitySCIMSubSys][config.Default]); err != nil {
+			return err
+		}
 	case config.SubnetSubSys:
 		if _, err := subnet.LookupConfig(s[config.SubnetSubSys][config.Default], nil); err != nil {
 			return err
@@ -371,6 +444,14 @@ func validateSubSysConfig(s config.Config, subSys string, objAPI ObjectLayer) er
 		if _, err := callhome.LookupConfig(s[config.CallhomeSubSys][config.Default]); err != nil {
 			return err
 		}
+	case config.RateLimitSubSys:
+		if _, err := ratelimit.LookupConfig(s); err != nil {
+			return err
+		}
+	case config.ScheduleSubSys:
+		if _, err := schedule.LookupConfig(s); err != nil {
+			return err
+		}
 	case config.PolicyOPASubSys:
 		// In case legacy OPA config is being set, we treat it as if the
 		// AuthZPlugin is being set.
@@ -619,7 +700,17 @@ func applyDynamicConfigForSubSys(ctx context.Context, objAPI ObjectLayer, s conf
 		}
 		// update dynamic scanner values.
 		scannerCycle.Store(scannerCfg.Cycle)
+		scannerExcessObjectVersions.Store(scannerCfg.ExcessVersions)
+		scannerExcessObjectVersionsTotalSize.Store(scannerCfg.ExcessVersionsSize)
 		logger.LogIf(ctx, scannerSleeper.Update(scannerCfg.Delay, scannerCfg.MaxWait))
+	case config.DriveHealthSubSys:
+		if globalIsErasure {
+			driveHealthCfg, err := drivehealth.LookupConfig(s[config.DriveHealthSubSys][config.Default])
+			if err != nil {
+				return fmt.Errorf("Unable to apply drive health config: %w", err)
+			}
+			globalDriveHealthMonitor.update(driveHealthCfg)
+		}
 	case config.LoggerWebhookSubSys:
 		loggerCfg, err := logger.LookupConfigForSubSys(s, config.LoggerWebhookSubSys)
 		if err != nil {
@@ -685,6 +776,15 @@ func applyDynamicConfigForSubSys(ctx context.Context, objAPI ObjectLayer, s conf
 				}
 			}
 		}
+	case config.StorageClassCustomSubSys:
+		if globalIsErasure && objAPI != nil {
+			classes, err := storageclass.LookupCustomConfig(s, objAPI.SetDriveCounts())
+			if err != nil {
+				logger.LogIf(ctx, fmt.Errorf("Unable to initialize custom storage class config: %w", err))
+			} else {
+				globalStorageClass.UpdateCustom(classes)
+			}
+		}
 	case config.CallhomeSubSys:
 		callhomeCfg, err := callhome.LookupConfig(s[config.CallhomeSubSys][config.Default])
 		if err != nil {
@@ -693,6 +793,20 @@ func applyDynamicConfigForSubSys(ctx context.Context, objAPI ObjectLayer, s conf
 			globalCallhomeConfig = callhomeCfg
 			updateCallhomeParams(ctx, objAPI)
 		}
+	case config.RateLimitSubSys:
+		rateLimitCfg, err := ratelimit.LookupConfig(s)
+		if err != nil {
+			logger.LogIf(ctx, fmt.Errorf("Unable to load rate limit config: %w", err))
+		} else {
+			globalRateLimiter.update(rateLimitCfg)
+		}
+	case config.ScheduleSubSys:
+		scheduleCfg, err := schedule.LookupConfig(s)
+		if err != nil {
+			logger.LogIf(ctx, fmt.Errorf("Unable to load schedule config: %w", err))
+		} else {
+			globalBackgroundSchedule.update(scheduleCfg)
+		}
 	}
 	globalServerConfigMu.Lock()
 	defer globalServerConfigMu.Unlock()
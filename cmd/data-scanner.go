@@ -29,6 +29,7 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -38,6 +39,7 @@ import (
 	"github.com/GuinsooLab/annastore/internal/bucket/replication"
 	"github.com/GuinsooLab/annastore/internal/color"
 	"github.com/GuinsooLab/annastore/internal/config/heal"
+	"github.com/GuinsooLab/annastore/internal/config/schedule"
 	"github.com/GuinsooLab/annastore/internal/event"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/bits-and-blooms/bloom/v3"
@@ -45,6 +47,7 @@ import (
 	"github.com/minio/madmin-go"
 	"github.com/minio/pkg/console"
 	uatomic "go.uber.org/atomic"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -68,6 +71,14 @@ var (
 	// Sleeper values are updated when config is loaded.
 	scannerSleeper = newDynamicSleeper(10, 10*time.Second, true)
 	scannerCycle   = uatomic.NewDuration(dataScannerStartDelay)
+
+	// Alert thresholds of the versions of an object, updated when
+	// config is loaded.
+	scannerExcessObjectVersions          = uatomic.NewUint64(100)
+	scannerExcessObjectVersionsTotalSize = uatomic.NewUint64(humanize.TiByte)
+	// scannerExcessVersionsEvents limits the excess versions events, sent
+	// again for the same objects every cycle.
+	scannerExcessVersionsEvents = rate.NewLimiter(rate.Every(time.Second), 10)
 )
 
 // initDataScanner will start the scanner in the background.
@@ -228,6 +239,10 @@ func runDataScanner(pctx context.Context, objAPI ObjectLayer) {
 				tmp, _ = cycleInfo.MarshalMsg(tmp)
 				err = saveConfig(ctx, objAPI, dataUsageBloomNamePath, tmp)
 				logger.LogIf(ctx, err)
+
+				// Delete the oldest objects of the buckets exceeding
+				// their FIFO quota.
+				enforceFIFOQuotas(ctx, objAPI)
 			}
 		}
 	}
@@ -879,6 +894,7 @@ type scannerItem struct {
 type sizeSummary struct {
 	totalSize       int64
 	versions        uint64
+	deleteMarkers   uint64
 	replicatedSize  int64
 	pendingSize     int64
 	failedSize      int64
@@ -1076,6 +1092,38 @@ func (i *scannerItem) applyVersionActions(ctx context.Context, o ObjectLayer, fi
 	return i.applyNewerNoncurrentVersionLimit(ctx, o, fivs)
 }
 
+// alertExcessVersions logs and sends an event naming the object when its
+// versions, as counted in the versions histogram, are more or total a
+// larger size than the configured thresholds. The object is logged once
+// in a while and the events are rate limited, as it is found again every
+// cycle.
+func (i *scannerItem) alertExcessVersions(ctx context.Context, versions uint64, size int64) {
+	maxVersions := scannerExcessObjectVersions.Load()
+	maxSize := scannerExcessObjectVersionsTotalSize.Load()
+	if (maxVersions == 0 || versions < maxVersions) &&
+		(maxSize == 0 || size < 0 || uint64(size) < maxSize) {
+		return
+	}
+
+	object := pathJoin(i.bucket, i.objectPath())
+	logger.LogOnceIf(ctx, fmt.Errorf("Object %s reached the excess versions alert thresholds (alert_excess_versions=%d, alert_excess_versions_size=%s)",
+		object, maxVersions, humanize.IBytes(maxSize)), "excess-versions-"+object)
+	if !scannerExcessVersionsEvents.Allow() {
+		return
+	}
+	sendEvent(eventArgs{
+		EventName:  event.ObjectManyVersions,
+		BucketName: i.bucket,
+		Object: ObjectInfo{
+			Bucket: i.bucket,
+			Name:   i.objectPath(),
+			Size:   size,
+		},
+		RespElements: map[string]string{"x-minio-versions": strconv.FormatUint(versions, 10)},
+		Host:         "Internal: [Scanner]",
+	})
+}
+
 // applyActions will apply lifecycle checks on to a scanned item.
 // The resulting size on disk will always be returned.
 // The metadata will be compared to consensus on the object layer before any changes are applied.
@@ -1289,6 +1337,10 @@ type dynamicSleeper struct {
 	// isScanner should be set when this is used by the scanner
 	// to record metrics.
 	isScanner bool
+
+	// speed of the scheduled window in effect, overriding
+	// the factor and maximum sleep unless default.
+	speed schedule.SpeedPreset
 }
 
 // newDynamicSleeper
@@ -1310,11 +1362,13 @@ func (d *dynamicSleeper) Timer(ctx context.Context) func() {
 		doneAt := time.Now()
 		for {
 			// Grab current values
-			d.mu.RLock()
-			minWait, maxWait := d.minSleep, d.maxSleep
-			factor := d.factor
-			cycle := d.cycle
-			d.mu.RUnlock()
+			factor, minWait, maxWait, paused, cycle := d.current()
+			if paused {
+				if !d.waitCycle(ctx, cycle) {
+					return
+				}
+				continue
+			}
 			elapsed := doneAt.Sub(t)
 			// Don't sleep for really small amount of time
 			wantSleep := time.Duration(float64(elapsed) * factor)
@@ -1358,11 +1412,13 @@ func (d *dynamicSleeper) Timer(ctx context.Context) func() {
 func (d *dynamicSleeper) Sleep(ctx context.Context, base time.Duration) {
 	for {
 		// Grab current values
-		d.mu.RLock()
-		minWait, maxWait := d.minSleep, d.maxSleep
-		factor := d.factor
-		cycle := d.cycle
-		d.mu.RUnlock()
+		factor, minWait, maxWait, paused, cycle := d.current()
+		if paused {
+			if !d.waitCycle(ctx, cycle) {
+				return
+			}
+			continue
+		}
 		// Don't sleep for really small amount of time
 		wantSleep := time.Duration(float64(base) * factor)
 		if wantSleep <= minWait {
@@ -1415,6 +1471,64 @@ func (d *dynamicSleeper) Update(factor float64, maxWait time.Duration) error {
 	return nil
 }
 
+// SetSpeed sets the speed of the scheduled window in effect and cycles
+// all waiting.
+func (d *dynamicSleeper) SetSpeed(speed schedule.SpeedPreset) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.speed == speed {
+		return
+	}
+	close(d.cycle)
+	d.speed = speed
+	d.cycle = make(chan struct{})
+}
+
+// Speed returns the speed of the scheduled window in effect.
+func (d *dynamicSleeper) Speed() schedule.SpeedPreset {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.speed
+}
+
+// current returns the current sleep settings with the speed of the
+// scheduled window applied.
+func (d *dynamicSleeper) current() (factor float64, minWait, maxWait time.Duration, paused bool, cycle chan struct{}) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	factor, minWait, maxWait, cycle = d.factor, d.minSleep, d.maxSleep, d.cycle
+	switch d.speed {
+	case schedule.SpeedPaused:
+		paused = true
+	case schedule.SpeedSlowest:
+		factor, maxWait = 100, 30*time.Second
+	case schedule.SpeedSlow:
+		factor, maxWait = 20, 15*time.Second
+	case schedule.SpeedFast:
+		factor, maxWait = 1, 100*time.Millisecond
+	case schedule.SpeedFastest:
+		factor = 0
+	}
+	return factor, minWait, maxWait, paused, cycle
+}
+
+// waitCycle waits for the settings to be updated while paused,
+// it returns false if ctx is canceled first.
+func (d *dynamicSleeper) waitCycle(ctx context.Context, cycle chan struct{}) bool {
+	t := time.Now()
+	defer func() {
+		if d.isScanner {
+			globalScannerMetrics.incTime(scannerMetricYield, time.Since(t))
+		}
+	}()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-cycle:
+		return true
+	}
+}
+
 const (
 	// ILMExpiry - audit trail for ILM expiry
 	ILMExpiry = "ilm:expiry"
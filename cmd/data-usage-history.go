@@ -0,0 +1,342 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+)
+
+//go:generate msgp -file $GOFILE -unexported
+//msgp:tuple bucketUsageSnapshot poolUsageSnapshot
+//msgp:ignore BucketUsageSnapshot PoolUsageSnapshot DataUsageHistory
+
+const (
+	dataUsageHistoryName      = ".usage-history.bin"
+	dataUsagePoolsHistoryPath = bucketMetaPrefix + SlashSeparator + ".usage-pools-history.bin"
+
+	// dataUsageHistoryDays is the number of daily snapshots kept.
+	dataUsageHistoryDays = 400
+	// dataUsageGrowthDays is the number of days growth rates are
+	// averaged over.
+	dataUsageGrowthDays = 30
+
+	// usageDateFormat is the format of the dates of the snapshots.
+	usageDateFormat = "2006-01-02"
+)
+
+// usageDay returns the number of days between the Unix epoch and t in UTC.
+func usageDay(t time.Time) int64 {
+	return t.UTC().Unix() / int64(24*time.Hour/time.Second)
+}
+
+// usageDate returns the date of a day since the Unix epoch.
+func usageDate(day int64) string {
+	return time.Unix(day*int64(24*time.Hour/time.Second), 0).UTC().Format(usageDateFormat)
+}
+
+// bucketUsageSnapshot is the usage of a bucket on a day.
+type bucketUsageSnapshot struct {
+	Day                     int64
+	Size                    uint64
+	Objects                 uint64
+	Versions                uint64
+	DeleteMarkers           uint64
+	ReplicationPendingSize  uint64
+	ReplicationPendingCount uint64
+}
+
+// bucketUsageHistory is the daily usage of a bucket, oldest first.
+type bucketUsageHistory struct {
+	Snapshots []bucketUsageSnapshot `msg:"s"`
+}
+
+// add replaces the snapshot of the same day or appends s, dropping
+// the snapshots older than dataUsageHistoryDays.
+func (h *bucketUsageHistory) add(s bucketUsageSnapshot) {
+	if n := len(h.Snapshots); n > 0 && h.Snapshots[n-1].Day == s.Day {
+		h.Snapshots[n-1] = s
+	} else {
+		h.Snapshots = append(h.Snapshots, s)
+	}
+	for len(h.Snapshots) > 0 && h.Snapshots[0].Day <= s.Day-dataUsageHistoryDays {
+		h.Snapshots = h.Snapshots[1:]
+	}
+}
+
+// growth returns the average daily growth of the size and of the number
+// of objects over the last dataUsageGrowthDays days.
+func (h bucketUsageHistory) growth() (size, objects float64, ok bool) {
+	first, last, ok := growthRange(len(h.Snapshots), func(i int) int64 { return h.Snapshots[i].Day })
+	if !ok {
+		return 0, 0, false
+	}
+	from, to := h.Snapshots[first], h.Snapshots[last]
+	days := float64(to.Day - from.Day)
+	return (float64(to.Size) - float64(from.Size)) / days,
+		(float64(to.Objects) - float64(from.Objects)) / days, true
+}
+
+// poolUsageSnapshot is the raw capacity of a pool on a day.
+type poolUsageSnapshot struct {
+	Day   int64
+	Used  uint64
+	Total uint64
+}
+
+// poolUsageHistory is the daily capacity of a pool, oldest first.
+type poolUsageHistory struct {
+	Snapshots []poolUsageSnapshot `msg:"s"`
+}
+
+// add replaces the snapshot of the same day or appends s, dropping
+// the snapshots older than dataUsageHistoryDays.
+func (h *poolUsageHistory) add(s poolUsageSnapshot) {
+	if n := len(h.Snapshots); n > 0 && h.Snapshots[n-1].Day == s.Day {
+		h.Snapshots[n-1] = s
+	} else {
+		h.Snapshots = append(h.Snapshots, s)
+	}
+	for len(h.Snapshots) > 0 && h.Snapshots[0].Day <= s.Day-dataUsageHistoryDays {
+		h.Snapshots = h.Snapshots[1:]
+	}
+}
+
+// growth returns the average daily growth of the used capacity over the
+// last dataUsageGrowthDays days, and the number of days left until the
+// pool is full at that rate, negative if the pool is not growing.
+func (h poolUsageHistory) growth() (used, daysToFull float64, ok bool) {
+	first, last, ok := growthRange(len(h.Snapshots), func(i int) int64 { return h.Snapshots[i].Day })
+	if !ok {
+		return 0, 0, false
+	}
+	from, to := h.Snapshots[first], h.Snapshots[last]
+	used = (float64(to.Used) - float64(from.Used)) / float64(to.Day-from.Day)
+	daysToFull = -1
+	if used > 0 && to.Total > to.Used {
+		daysToFull = float64(to.Total-to.Used) / used
+	}
+	return used, daysToFull, true
+}
+
+// growthRange returns the indexes of the oldest snapshot within the last
+// dataUsageGrowthDays days of the newest one and of the newest one, ok is
+// false if they are not at least a day apart.
+func growthRange(n int, day func(i int) int64) (first, last int, ok bool) {
+	if n < 2 {
+		return 0, 0, false
+	}
+	last = n - 1
+	first = last
+	for first > 0 && day(first-1) >= day(last)-dataUsageGrowthDays {
+		first--
+	}
+	return first, last, day(last) > day(first)
+}
+
+// poolsUsageHistory is the daily capacity of all pools, by pool index.
+type poolsUsageHistory struct {
+	Pools []poolUsageHistory `msg:"p"`
+}
+
+func bucketUsageHistoryPath(bucket string) string {
+	return path.Join(bucketMetaPrefix, bucket, dataUsageHistoryName)
+}
+
+func loadBucketUsageHistory(ctx context.Context, objAPI ObjectLayer, bucket string) (h bucketUsageHistory, err error) {
+	buf, err := readConfig(ctx, objAPI, bucketUsageHistoryPath(bucket))
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return h, nil
+		}
+		return h, err
+	}
+	_, err = h.UnmarshalMsg(buf)
+	return h, err
+}
+
+func loadPoolsUsageHistory(ctx context.Context, objAPI ObjectLayer) (h poolsUsageHistory, err error) {
+	buf, err := readConfig(ctx, objAPI, dataUsagePoolsHistoryPath)
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return h, nil
+		}
+		return h, err
+	}
+	_, err = h.UnmarshalMsg(buf)
+	return h, err
+}
+
+// dataUsageHistoryDay is the last day the usage history was recorded by
+// this server.
+var dataUsageHistoryDay int64
+
+// recordDataUsageHistory records the usage of the buckets and the capacity
+// of the pools at the end of a scanner cycle, once a day.
+func recordDataUsageHistory(ctx context.Context, objAPI ObjectLayer, dui DataUsageInfo) {
+	now := time.Now()
+	day := usageDay(now)
+	if dui.LastUpdate.IsZero() || ctx.Err() != nil || atomic.LoadInt64(&dataUsageHistoryDay) == day {
+		return
+	}
+
+	for bucket, bui := range dui.BucketsUsage {
+		h, err := loadBucketUsageHistory(ctx, objAPI, bucket)
+		if err != nil {
+			logger.LogIf(ctx, err)
+			continue
+		}
+		s := bucketUsageSnapshot{
+			Day:           day,
+			Size:          bui.Size,
+			Objects:       bui.ObjectsCount,
+			Versions:      bui.VersionsCount,
+			DeleteMarkers: bui.DeleteMarkersCount,
+		}
+		for _, tgt := range bui.ReplicationInfo {
+			s.ReplicationPendingSize += tgt.ReplicationPendingSize
+			s.ReplicationPendingCount += tgt.ReplicationPendingCount
+		}
+		h.add(s)
+		buf, err := h.MarshalMsg(nil)
+		if err != nil {
+			logger.LogIf(ctx, err)
+			continue
+		}
+		logger.LogIf(ctx, saveConfig(ctx, objAPI, bucketUsageHistoryPath(bucket), buf))
+	}
+
+	storageInfo, _ := objAPI.StorageInfo(ctx)
+	if len(storageInfo.Disks) > 0 {
+		ph, err := loadPoolsUsageHistory(ctx, objAPI)
+		if err != nil {
+			logger.LogIf(ctx, err)
+		} else {
+			_, singleDrive := objAPI.(*erasureSingle)
+			pools := make(map[int]poolUsageSnapshot)
+			for _, disk := range storageInfo.Disks {
+				idx := disk.PoolIndex
+				if singleDrive {
+					idx = 0
+				}
+				if idx < 0 {
+					continue
+				}
+				s := pools[idx]
+				s.Day = day
+				s.Used += disk.UsedSpace
+				s.Total += disk.TotalSpace
+				pools[idx] = s
+			}
+			for idx, s := range pools {
+				for len(ph.Pools) <= idx {
+					ph.Pools = append(ph.Pools, poolUsageHistory{})
+				}
+				ph.Pools[idx].add(s)
+			}
+			buf, err := ph.MarshalMsg(nil)
+			if err == nil {
+				err = saveConfig(ctx, objAPI, dataUsagePoolsHistoryPath, buf)
+			}
+			logger.LogIf(ctx, err)
+		}
+	}
+
+	atomic.StoreInt64(&dataUsageHistoryDay, day)
+}
+
+// BucketUsageSnapshot is the usage of a bucket on a day.
+type BucketUsageSnapshot struct {
+	Date                    string `json:"date"`
+	Size                    uint64 `json:"size"`
+	ObjectsCount            uint64 `json:"objectsCount"`
+	VersionsCount           uint64 `json:"versionsCount"`
+	DeleteMarkersCount      uint64 `json:"deleteMarkersCount"`
+	ReplicationPendingSize  uint64 `json:"replicationPendingSize"`
+	ReplicationPendingCount uint64 `json:"replicationPendingCount"`
+}
+
+// PoolUsageSnapshot is the raw capacity of a pool on a day.
+type PoolUsageSnapshot struct {
+	Date  string `json:"date"`
+	Used  uint64 `json:"used"`
+	Total uint64 `json:"total"`
+}
+
+// DataUsageHistory is the daily usage of buckets and capacity of pools
+// within a range of days.
+type DataUsageHistory struct {
+	Buckets map[string][]BucketUsageSnapshot `json:"buckets"`
+	Pools   map[int][]PoolUsageSnapshot      `json:"pools"`
+}
+
+// getDataUsageHistory returns the usage history of buckets and pools from
+// day to day included.
+func getDataUsageHistory(ctx context.Context, objAPI ObjectLayer, buckets []string, from, to int64) (DataUsageHistory, error) {
+	history := DataUsageHistory{
+		Buckets: make(map[string][]BucketUsageSnapshot, len(buckets)),
+		Pools:   make(map[int][]PoolUsageSnapshot),
+	}
+	for _, bucket := range buckets {
+		h, err := loadBucketUsageHistory(ctx, objAPI, bucket)
+		if err != nil {
+			return history, err
+		}
+		snapshots := []BucketUsageSnapshot{}
+		for _, s := range h.Snapshots {
+			if s.Day < from || s.Day > to {
+				continue
+			}
+			snapshots = append(snapshots, BucketUsageSnapshot{
+				Date:                    usageDate(s.Day),
+				Size:                    s.Size,
+				ObjectsCount:            s.Objects,
+				VersionsCount:           s.Versions,
+				DeleteMarkersCount:      s.DeleteMarkers,
+				ReplicationPendingSize:  s.ReplicationPendingSize,
+				ReplicationPendingCount: s.ReplicationPendingCount,
+			})
+		}
+		history.Buckets[bucket] = snapshots
+	}
+
+	ph, err := loadPoolsUsageHistory(ctx, objAPI)
+	if err != nil {
+		return history, err
+	}
+	for idx, h := range ph.Pools {
+		snapshots := []PoolUsageSnapshot{}
+		for _, s := range h.Snapshots {
+			if s.Day < from || s.Day > to {
+				continue
+			}
+			snapshots = append(snapshots, PoolUsageSnapshot{
+				Date:  usageDate(s.Day),
+				Used:  s.Used,
+				Total: s.Total,
+			})
+		}
+		history.Pools[idx] = snapshots
+	}
+	return history, nil
+}
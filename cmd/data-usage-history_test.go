@@ -0,0 +1,153 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBucketUsageHistoryAdd(t *testing.T) {
+	var h bucketUsageHistory
+	for day := int64(1000); day < 1000+dataUsageHistoryDays+10; day++ {
+		h.add(bucketUsageSnapshot{Day: day, Size: uint64(day)})
+	}
+	if len(h.Snapshots) != dataUsageHistoryDays {
+		t.Fatalf("expected %d snapshots, got %d", dataUsageHistoryDays, len(h.Snapshots))
+	}
+	if h.Snapshots[0].Day != 1010 {
+		t.Errorf("expected oldest snapshot on day 1010, got %d", h.Snapshots[0].Day)
+	}
+
+	// A snapshot of the same day replaces the last one.
+	last := h.Snapshots[len(h.Snapshots)-1]
+	h.add(bucketUsageSnapshot{Day: last.Day, Size: 1})
+	if len(h.Snapshots) != dataUsageHistoryDays || h.Snapshots[len(h.Snapshots)-1].Size != 1 {
+		t.Errorf("expected the snapshot of day %d to be replaced", last.Day)
+	}
+}
+
+func TestUsageHistoryGrowth(t *testing.T) {
+	var h bucketUsageHistory
+	if _, _, ok := h.growth(); ok {
+		t.Fatal("expected no growth without snapshots")
+	}
+	h.add(bucketUsageSnapshot{Day: 100, Size: 1000, Objects: 10})
+	if _, _, ok := h.growth(); ok {
+		t.Fatal("expected no growth with a single snapshot")
+	}
+	// Only the last 30 days are accounted.
+	h.add(bucketUsageSnapshot{Day: 150, Size: 5000, Objects: 50})
+	h.add(bucketUsageSnapshot{Day: 160, Size: 6000, Objects: 40})
+	h.add(bucketUsageSnapshot{Day: 170, Size: 8000, Objects: 30})
+	size, objects, ok := h.growth()
+	if !ok || size != 150 || objects != -1 {
+		t.Errorf("expected growth of 150 bytes and -1 object per day, got %v and %v", size, objects)
+	}
+
+	var p poolUsageHistory
+	p.add(poolUsageSnapshot{Day: 10, Used: 100, Total: 1000})
+	p.add(poolUsageSnapshot{Day: 20, Used: 200, Total: 1000})
+	used, daysToFull, ok := p.growth()
+	if !ok || used != 10 || daysToFull != 80 {
+		t.Errorf("expected growth of 10 bytes per day and 80 days to full, got %v and %v", used, daysToFull)
+	}
+	p.add(poolUsageSnapshot{Day: 30, Used: 50, Total: 1000})
+	if used, daysToFull, _ = p.growth(); used >= 0 || daysToFull != -1 {
+		t.Errorf("expected a shrinking pool never to be full, got %v and %v", used, daysToFull)
+	}
+}
+
+func TestUsageDay(t *testing.T) {
+	day := usageDay(time.Date(2022, 10, 18, 23, 59, 0, 0, time.UTC))
+	if date := usageDate(day); date != "2022-10-18" {
+		t.Errorf("expected 2022-10-18, got %s", date)
+	}
+	if day != int64(math.Floor(float64(time.Date(2022, 10, 18, 0, 0, 0, 0, time.UTC).Unix())/86400)) {
+		t.Errorf("unexpected day %d", day)
+	}
+}
+
+func TestRecordDataUsageHistory(t *testing.T) {
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+	ExecObjectLayerTest(t, testRecordDataUsageHistory)
+}
+
+func testRecordDataUsageHistory(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	ctx := context.Background()
+	atomic.StoreInt64(&dataUsageHistoryDay, 0)
+
+	dui := DataUsageInfo{
+		LastUpdate: time.Now(),
+		BucketsUsage: map[string]BucketUsageInfo{
+			"usage-history": {
+				Size:               1024,
+				ObjectsCount:       4,
+				VersionsCount:      6,
+				DeleteMarkersCount: 2,
+				ReplicationInfo: map[string]BucketTargetUsageInfo{
+					"arn1": {ReplicationPendingSize: 10, ReplicationPendingCount: 1},
+					"arn2": {ReplicationPendingSize: 20, ReplicationPendingCount: 2},
+				},
+			},
+		},
+	}
+	recordDataUsageHistory(ctx, obj, dui)
+
+	// Recorded once a day.
+	dui.BucketsUsage["usage-history"] = BucketUsageInfo{Size: 1}
+	recordDataUsageHistory(ctx, obj, dui)
+
+	today := usageDay(time.Now())
+	history, err := getDataUsageHistory(ctx, obj, []string{"usage-history"}, 0, today)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	snapshots := history.Buckets["usage-history"]
+	if len(snapshots) != 1 {
+		t.Fatalf("%s: expected 1 snapshot, got %d", instanceType, len(snapshots))
+	}
+	expected := BucketUsageSnapshot{
+		Date:                    usageDate(today),
+		Size:                    1024,
+		ObjectsCount:            4,
+		VersionsCount:           6,
+		DeleteMarkersCount:      2,
+		ReplicationPendingSize:  30,
+		ReplicationPendingCount: 3,
+	}
+	if snapshots[0] != expected {
+		t.Errorf("%s: expected %+v, got %+v", instanceType, expected, snapshots[0])
+	}
+	if len(history.Pools[0]) != 1 || history.Pools[0][0].Total == 0 {
+		t.Errorf("%s: expected the capacity of the pool, got %+v", instanceType, history.Pools)
+	}
+
+	history, err = getDataUsageHistory(ctx, obj, []string{"usage-history"}, today+1, today+2)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(history.Buckets["usage-history"]) != 0 {
+		t.Errorf("%s: expected no snapshot out of range, got %+v", instanceType, history.Buckets)
+	}
+	atomic.StoreInt64(&dataUsageHistoryDay, 0)
+}
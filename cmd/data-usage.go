@@ -41,8 +41,15 @@ const (
 )
 
 // storeDataUsageInBackend will store all objects sent on the gui channel until closed.
+// The last usage sent, at the end of the scanner cycle, is recorded in the
+// daily usage history.
 func storeDataUsageInBackend(ctx context.Context, objAPI ObjectLayer, dui <-chan DataUsageInfo) {
+	var last DataUsageInfo
+	defer func() {
+		recordDataUsageHistory(ctx, objAPI, last)
+	}()
 	for dataUsageInfo := range dui {
+		last = dataUsageInfo
 		json := jsoniter.ConfigCompatibleWithStandardLibrary
 		dataUsageJSON, err := json.Marshal(dataUsageInfo)
 		if err != nil {
@@ -26,6 +26,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -133,6 +134,9 @@ func TestDataUsageUpdate(t *testing.T) {
 			if e.ObjSizes != w.oSizes {
 				t.Error("got histogram", e.ObjSizes, "want", w.oSizes)
 			}
+			if want := (versionsHistogram{1: uint64(w.objs)}); e.ObjVersions != want {
+				t.Error("got versions histogram", e.ObjVersions, "want", want)
+			}
 		})
 	}
 
@@ -621,3 +625,29 @@ func equalAsJSON(a, b interface{}) bool {
 	}
 	return bytes.Equal(aj, bj)
 }
+
+func TestVersionsHistogram(t *testing.T) {
+	var h versionsHistogram
+	for _, versions := range []uint64{0, 1, 1, 2, 9, 10, 99, 100, 1000, 10000, 1 << 40} {
+		h.add(versions)
+	}
+	want := map[string]uint64{
+		"UNVERSIONED":            1,
+		"SINGLE_VERSION":         2,
+		"BETWEEN_2_AND_10":       2,
+		"BETWEEN_10_AND_100":     2,
+		"BETWEEN_100_AND_1000":   1,
+		"BETWEEN_1000_AND_10000": 1,
+		"GREATER_THAN_10000":     2,
+	}
+	if got := h.toMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	var e dataUsageEntry
+	e.addSizes(sizeSummary{totalSize: 10, versions: 3})
+	e.merge(dataUsageEntry{ObjVersions: versionsHistogram{1: 4}})
+	if want := (versionsHistogram{1: 4, 2: 1}); e.ObjVersions != want {
+		t.Errorf("got %v, want %v", e.ObjVersions, want)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config/drivehealth"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/GuinsooLab/annastore/internal/smart"
+)
+
+// driveHealth is the last SMART/NVMe health read from a local drive.
+type driveHealth struct {
+	Endpoint string `json:"endpoint"`
+	smart.Health
+	// Failing is set when the drive is predicted to fail, by itself or
+	// by the configured wear and reallocated sectors limits.
+	Failing bool      `json:"failing"`
+	Error   string    `json:"error,omitempty"`
+	Updated time.Time `json:"updated"`
+
+	// diskID identifies the drive, it changes when the drive is replaced.
+	diskID string
+}
+
+// driveHealthMonitor periodically polls the SMART/NVMe health of the local
+// drives, raises alerts on degradation and applies the configured action
+// to drives predicted to fail.
+type driveHealthMonitor struct {
+	mu     sync.RWMutex
+	cfg    drivehealth.Config
+	drives map[string]driveHealth
+	// cfgCh wakes up the monitor when the config changes.
+	cfgCh chan struct{}
+
+	// readHealth reads the health of the drive mounted at path.
+	readHealth func(path string) (smart.Health, error)
+}
+
+func newDriveHealthMonitor() *driveHealthMonitor {
+	return &driveHealthMonitor{
+		drives: make(map[string]driveHealth),
+		cfgCh:  make(chan struct{}, 1),
+		readHealth: func(path string) (smart.Health, error) {
+			device, err := smart.DeviceOf(path)
+			if err != nil {
+				return smart.Health{}, err
+			}
+			return smart.GetHealth(device)
+		},
+	}
+}
+
+func (m *driveHealthMonitor) update(cfg drivehealth.Config) {
+	m.mu.Lock()
+	m.cfg = cfg
+	m.mu.Unlock()
+
+	select {
+	case m.cfgCh <- struct{}{}:
+	default:
+	}
+}
+
+func (m *driveHealthMonitor) config() drivehealth.Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cfg
+}
+
+// getDrives returns the last health of the local drives sorted by endpoint.
+func (m *driveHealthMonitor) getDrives() []driveHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	drives := make([]driveHealth, 0, len(m.drives))
+	for _, d := range m.drives {
+		drives = append(drives, d)
+	}
+	sort.Slice(drives, func(i, j int) bool {
+		return drives[i].Endpoint < drives[j].Endpoint
+	})
+	return drives
+}
+
+// run polls the local drives until ctx is canceled.
+func (m *driveHealthMonitor) run(ctx context.Context) {
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.cfgCh:
+		case <-timer.C:
+			if cfg := m.config(); cfg.Enabled {
+				m.poll(ctx, cfg, globalLocalDrives)
+			}
+		}
+		interval := m.config().Interval
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		timer.Reset(interval)
+	}
+}
+
+// poll reads the health of the drives and compares it to the previous one.
+func (m *driveHealthMonitor) poll(ctx context.Context, cfg drivehealth.Config, drives []StorageAPI) {
+	for _, disk := range drives {
+		if disk == nil {
+			continue
+		}
+		endpoint := disk.Endpoint().String()
+		diskID, _ := disk.GetDiskID()
+		cur := driveHealth{
+			Endpoint: endpoint,
+			Updated:  UTCNow(),
+			diskID:   diskID,
+		}
+		h, err := m.readHealth(disk.Endpoint().Path)
+		if err != nil {
+			// Virtual drives, devices behind RAID controllers and
+			// missing privileges do not allow reading the health.
+			logger.LogOnceIf(ctx, fmt.Errorf("Unable to read the health of drive %s: %w", endpoint, err), "drive-health-"+endpoint)
+			cur.Error = err.Error()
+		} else {
+			cur.Health = h
+			cur.Failing = h.Failing ||
+				(h.WearLevel >= 0 && h.WearLevel >= cfg.MaxWear) ||
+				h.ReallocatedSectors >= cfg.MaxReallocatedSectors
+		}
+
+		m.mu.Lock()
+		prev, ok := m.drives[endpoint]
+		if ok && prev.diskID != "" && diskID != "" && prev.diskID != diskID {
+			// The drive was replaced, the previous health was the one of
+			// the old drive.
+			prev, ok = driveHealth{}, false
+		}
+		m.drives[endpoint] = cur
+		m.mu.Unlock()
+
+		if !ok || (cur.Error == "" && !cur.Failing) || cfg.Action != drivehealth.ActionReadOnly {
+			clearDriveReadOnly(disk)
+		}
+		if cur.Error != "" {
+			continue
+		}
+		for _, alert := range driveHealthAlerts(cfg, prev, ok && prev.Error == "", cur) {
+			logger.LogAlwaysIf(ctx, alert)
+		}
+		if cur.Failing && (!ok || !prev.Failing) {
+			applyDriveFailureAction(ctx, cfg.Action, disk)
+		}
+	}
+}
+
+// driveHealthAlerts returns the alerts raised by the health cur of a drive
+// given its previous health prev, if known.
+func driveHealthAlerts(cfg drivehealth.Config, prev driveHealth, known bool, cur driveHealth) (alerts []error) {
+	if cur.Failing && (!known || !prev.Failing) {
+		alerts = append(alerts, fmt.Errorf("Drive %s (%s) is predicted to fail: wear level %d%%, %d reallocated sectors, %d media errors",
+			cur.Endpoint, cur.Device, cur.WearLevel, cur.ReallocatedSectors, cur.MediaErrors))
+	}
+	if cur.Temperature > cfg.MaxTemperature && (!known || prev.Temperature <= cfg.MaxTemperature) {
+		alerts = append(alerts, fmt.Errorf("Drive %s (%s) temperature %d°C is above %d°C",
+			cur.Endpoint, cur.Device, cur.Temperature, cfg.MaxTemperature))
+	}
+	if known && cur.ReallocatedSectors > prev.ReallocatedSectors {
+		alerts = append(alerts, fmt.Errorf("Drive %s (%s) reallocated %d more sectors, %d in total",
+			cur.Endpoint, cur.Device, cur.ReallocatedSectors-prev.ReallocatedSectors, cur.ReallocatedSectors))
+	}
+	if known && cur.MediaErrors > prev.MediaErrors {
+		alerts = append(alerts, fmt.Errorf("Drive %s (%s) reported %d more media errors, %d in total",
+			cur.Endpoint, cur.Device, cur.MediaErrors-prev.MediaErrors, cur.MediaErrors))
+	}
+	return alerts
+}
+
+// applyDriveFailureAction applies action to a local drive which started
+// failing.
+func applyDriveFailureAction(ctx context.Context, action drivehealth.FailureAction, disk StorageAPI) {
+	switch action {
+	case drivehealth.ActionReadOnly:
+		if d, ok := disk.(*xlStorageDiskIDCheck); ok {
+			d.setReadOnly(true)
+			logger.Info("Drive %s is now read-only as it is predicted to fail", disk.Endpoint())
+		}
+	case drivehealth.ActionHeal:
+		if disk.Healing() != nil || globalBackgroundHealState == nil {
+			return
+		}
+		// Healing the drive like a fresh drive rewrites the shards lost
+		// or corrupted on it, which repairs the damage done so far. The
+		// shards stay on the failing drive, only replacing it restores
+		// the redundancy of its erasure set.
+		if err := newHealingTracker(disk).save(ctx); err != nil {
+			logger.LogIf(ctx, fmt.Errorf("Unable to start healing the erasure set of failing drive %s: %w", disk.Endpoint(), err))
+			return
+		}
+		globalBackgroundHealState.pushHealLocalDisks(disk.Endpoint())
+		logger.Info("Healing the erasure set of drive %s as it is predicted to fail", disk.Endpoint())
+	}
+}
+
+// clearDriveReadOnly resumes writing to a local drive made read-only when
+// it started failing.
+func clearDriveReadOnly(disk StorageAPI) {
+	if d, ok := disk.(*xlStorageDiskIDCheck); ok && d.isReadOnly() {
+		d.setReadOnly(false)
+		logger.Info("Drive %s is writable again as it is no longer predicted to fail", disk.Endpoint())
+	}
+}
+
+func initDriveHealthMonitor(ctx context.Context) {
+	go globalDriveHealthMonitor.run(ctx)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config/drivehealth"
+	"github.com/GuinsooLab/annastore/internal/smart"
+)
+
+func TestDriveHealthAlerts(t *testing.T) {
+	cfg := drivehealth.Config{
+		MaxTemperature:        65,
+		MaxWear:               90,
+		MaxReallocatedSectors: 100,
+	}
+	healthy := driveHealth{Health: smart.Health{Temperature: 40, WearLevel: 10}}
+
+	testCases := []struct {
+		prev   driveHealth
+		known  bool
+		cur    driveHealth
+		alerts int
+	}{
+		// Healthy drive.
+		{healthy, true, healthy, 0},
+		// First poll of a healthy drive.
+		{driveHealth{}, false, healthy, 0},
+		// Drive starts failing.
+		{healthy, true, driveHealth{Health: smart.Health{Temperature: 40, WearLevel: 95}, Failing: true}, 1},
+		// Drive still failing, already alerted.
+		{
+			driveHealth{Health: smart.Health{Temperature: 40, WearLevel: 95}, Failing: true}, true,
+			driveHealth{Health: smart.Health{Temperature: 40, WearLevel: 96}, Failing: true}, 0,
+		},
+		// Drive too hot.
+		{healthy, true, driveHealth{Health: smart.Health{Temperature: 70, WearLevel: 10}}, 1},
+		// Drive still too hot, already alerted.
+		{
+			driveHealth{Health: smart.Health{Temperature: 70}}, true,
+			driveHealth{Health: smart.Health{Temperature: 71}}, 0,
+		},
+		// New reallocated sectors and media errors.
+		{healthy, true, driveHealth{Health: smart.Health{Temperature: 40, ReallocatedSectors: 2, MediaErrors: 1}}, 2},
+		// Reallocated sectors of an unknown drive are not new.
+		{driveHealth{}, false, driveHealth{Health: smart.Health{Temperature: 40, ReallocatedSectors: 2}}, 0},
+	}
+
+	for i, testCase := range testCases {
+		alerts := driveHealthAlerts(cfg, testCase.prev, testCase.known, testCase.cur)
+		if len(alerts) != testCase.alerts {
+			t.Errorf("Test %d: expected %d alerts, got %v", i+1, testCase.alerts, alerts)
+		}
+	}
+}
+
+func TestDriveHealthMonitorReadOnly(t *testing.T) {
+	disk, _, err := newXLStorageTestSetup(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err = disk.MakeVol(ctx, "before"); err != nil {
+		t.Fatal(err)
+	}
+
+	health := smart.Health{Device: "/dev/sda", Temperature: 35, WearLevel: -1}
+	m := newDriveHealthMonitor()
+	m.readHealth = func(string) (smart.Health, error) {
+		return health, nil
+	}
+	cfg := drivehealth.Config{
+		Enabled:               true,
+		MaxTemperature:        65,
+		MaxWear:               90,
+		MaxReallocatedSectors: 100,
+		Action:                drivehealth.ActionReadOnly,
+	}
+
+	m.poll(ctx, cfg, []StorageAPI{disk})
+	if drives := m.getDrives(); len(drives) != 1 || drives[0].Failing {
+		t.Fatalf("expected a healthy drive, got %v", drives)
+	}
+	if disk.isReadOnly() {
+		t.Fatal("healthy drive must not be read-only")
+	}
+
+	health.ReallocatedSectors = 150
+	m.poll(ctx, cfg, []StorageAPI{disk})
+	if drives := m.getDrives(); len(drives) != 1 || !drives[0].Failing {
+		t.Fatalf("expected a failing drive, got %v", drives)
+	}
+	if !disk.isReadOnly() {
+		t.Fatal("failing drive must be read-only")
+	}
+	if err = disk.MakeVol(ctx, "after"); !errors.Is(err, errDiskReadOnly) {
+		t.Fatalf("expected %v writing to a read-only drive, got %v", errDiskReadOnly, err)
+	}
+	if _, err = disk.StatVol(ctx, "before"); err != nil {
+		t.Fatalf("expected reads from a read-only drive to succeed, got %v", err)
+	}
+
+	// Drives which health cannot be read are reported without metrics.
+	m.readHealth = func(string) (smart.Health, error) {
+		return smart.Health{}, errors.New("not supported")
+	}
+	m.poll(ctx, cfg, []StorageAPI{disk})
+	if drives := m.getDrives(); len(drives) != 1 || drives[0].Error == "" {
+		t.Fatalf("expected a drive health error, got %v", drives)
+	}
+	if !disk.isReadOnly() {
+		t.Fatal("failing drive must stay read-only while its health cannot be read")
+	}
+
+	// Writes resume once the drive is healthy again.
+	m.readHealth = func(string) (smart.Health, error) {
+		return health, nil
+	}
+	health.ReallocatedSectors = 0
+	m.poll(ctx, cfg, []StorageAPI{disk})
+	if disk.isReadOnly() {
+		t.Fatal("healthy drive must not be read-only")
+	}
+
+	// and once the failing drive is replaced.
+	health.ReallocatedSectors = 150
+	m.poll(ctx, cfg, []StorageAPI{disk})
+	if !disk.isReadOnly() {
+		t.Fatal("failing drive must be read-only")
+	}
+	health.ReallocatedSectors = 0
+	m.readHealth = func(string) (smart.Health, error) {
+		return smart.Health{}, errors.New("not supported")
+	}
+	xl := disk.storage
+	format := `{"version":"1","format":"xl","id":"592a41c2-b7cc-4130-b883-c4b5cb15965b","xl":{"version":"3","this":"e07285a6-8c73-4962-89c6-047fb939f803","sets":[["e07285a6-8c73-4962-89c6-047fb939f803"]],"distributionAlgo":"CRCMOD"}}`
+	if err = xl.WriteAll(ctx, minioMetaBucket, formatConfigFile, []byte(format)); err != nil {
+		t.Fatal(err)
+	}
+	xl.Lock()
+	xl.formatLastCheck = time.Time{}
+	xl.Unlock()
+	m.poll(ctx, cfg, []StorageAPI{disk})
+	if disk.isReadOnly() {
+		t.Fatal("replaced drive must not be read-only")
+	}
+}
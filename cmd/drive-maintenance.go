@@ -0,0 +1,330 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/madmin-go"
+)
+
+const driveMaintenanceConfigFile = "drive-maintenance.json"
+
+var driveMaintenanceConfigPath = path.Join(minioConfigPrefix, driveMaintenanceConfigFile)
+
+// driveMaintenanceState is the maintenance state of a drive.
+type driveMaintenanceState string
+
+const (
+	// driveCordoned drives refuse writes and keep serving reads.
+	driveCordoned driveMaintenanceState = "cordoned"
+	// driveReplacing drives are cordoned until they are swapped, the
+	// fresh drive is then healed and the drive back in service.
+	driveReplacing driveMaintenanceState = "replacing"
+)
+
+// driveMaintenance is a drive put under maintenance by an administrator.
+type driveMaintenance struct {
+	Endpoint string                `json:"endpoint"`
+	State    driveMaintenanceState `json:"state"`
+	// DiskID is the ID of the drive put under maintenance, a drive
+	// swapped in its place is not affected.
+	DiskID  string    `json:"diskID"`
+	Updated time.Time `json:"updated"`
+}
+
+type driveMaintenanceConfig struct {
+	Drives map[string]driveMaintenance `json:"drives"`
+}
+
+// driveMaintenanceSys holds the drives under maintenance in the cluster.
+// Changes are persisted by the node receiving them and reloaded by the
+// other nodes.
+type driveMaintenanceSys struct {
+	// mu serializes the changes of the config.
+	mu sync.Mutex
+	// drives holds the driveMaintenanceConfig which is never modified
+	// once stored, it is read for every write to a local drive.
+	drives atomic.Value
+	// cordoned holds the set of IDs of the cordoned drives.
+	cordoned atomic.Value
+}
+
+var (
+	errDriveNotInMaintenance  = errors.New("drive is not under maintenance")
+	errDriveNotReplaced       = errors.New("drive marked for replacement was not replaced yet")
+	errDriveCordoned          = errors.New("drive is cordoned, uncordon it first")
+	errDriveMaintenanceQuorum = errors.New("putting the drive under maintenance would lose the write quorum of its erasure set")
+)
+
+func newDriveMaintenanceSys() *driveMaintenanceSys {
+	sys := &driveMaintenanceSys{}
+	sys.store(driveMaintenanceConfig{})
+	return sys
+}
+
+func (sys *driveMaintenanceSys) store(cfg driveMaintenanceConfig) {
+	cordoned := make(map[string]struct{}, len(cfg.Drives))
+	for _, d := range cfg.Drives {
+		if d.DiskID != "" {
+			cordoned[d.DiskID] = struct{}{}
+		}
+	}
+	sys.drives.Store(cfg)
+	sys.cordoned.Store(cordoned)
+}
+
+// isCordoned returns true if writes to the drive with diskID are refused.
+func (sys *driveMaintenanceSys) isCordoned(diskID string) bool {
+	if sys == nil || diskID == "" {
+		return false
+	}
+	_, ok := sys.cordoned.Load().(map[string]struct{})[diskID]
+	return ok
+}
+
+// get returns the maintenance of the drive at endpoint, if any.
+func (sys *driveMaintenanceSys) get(endpoint string) (driveMaintenance, bool) {
+	d, ok := sys.drives.Load().(driveMaintenanceConfig).Drives[endpoint]
+	return d, ok
+}
+
+// list returns all drives under maintenance.
+func (sys *driveMaintenanceSys) list() map[string]driveMaintenance {
+	return sys.drives.Load().(driveMaintenanceConfig).Drives
+}
+
+func readDriveMaintenanceConfig(ctx context.Context, objAPI ObjectLayer) (cfg driveMaintenanceConfig, err error) {
+	data, err := readConfig(ctx, objAPI, driveMaintenanceConfigPath)
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Load reloads the drives under maintenance from the config store.
+func (sys *driveMaintenanceSys) Load(ctx context.Context, objAPI ObjectLayer) error {
+	sys.mu.Lock()
+	defer sys.mu.Unlock()
+
+	cfg, err := readDriveMaintenanceConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	sys.store(cfg)
+	return nil
+}
+
+// update applies fn to the drives under maintenance, saves them and asks
+// the other nodes to reload them.
+func (sys *driveMaintenanceSys) update(ctx context.Context, objAPI ObjectLayer, fn func(drives map[string]driveMaintenance) error) error {
+	sys.mu.Lock()
+	defer sys.mu.Unlock()
+
+	// Serialize the changes of the drives under maintenance of all nodes,
+	// with a lock distinct from the one taken to write the config.
+	lk := objAPI.NewNSLock(minioMetaBucket, driveMaintenanceConfigPath+".lock")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	// Changes may have been made on other nodes.
+	cfg, err := readDriveMaintenanceConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	drives := make(map[string]driveMaintenance, len(cfg.Drives)+1)
+	for k, v := range cfg.Drives {
+		drives[k] = v
+	}
+	if err = fn(drives); err != nil {
+		return err
+	}
+
+	cfg = driveMaintenanceConfig{Drives: drives}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err = saveConfig(ctx, objAPI, driveMaintenanceConfigPath, data); err != nil {
+		return err
+	}
+	sys.store(cfg)
+
+	if globalNotificationSys != nil {
+		for _, nerr := range globalNotificationSys.LoadDriveMaintenance() {
+			if nerr.Err != nil {
+				reqInfo := (&logger.ReqInfo{}).AppendTags("peerAddress", nerr.Host.String())
+				logger.LogIf(logger.SetReqInfo(ctx, reqInfo), nerr.Err)
+			}
+		}
+	}
+	return nil
+}
+
+// Set puts the drive with diskID at endpoint in state, as long as its
+// erasure set keeps its write quorum without the drives under maintenance.
+func (sys *driveMaintenanceSys) Set(ctx context.Context, objAPI ObjectLayer, endpoint, diskID string, state driveMaintenanceState) error {
+	z, ok := objAPI.(*erasureServerPools)
+	if !ok {
+		return NotImplemented{}
+	}
+	si, _ := objAPI.StorageInfo(ctx)
+	writeQuorums := erasureWriteQuorums(z)
+
+	return sys.update(ctx, objAPI, func(drives map[string]driveMaintenance) error {
+		if _, ok := drives[endpoint]; !ok {
+			maintenance := make(map[string]struct{}, len(drives)+1)
+			for ep := range drives {
+				maintenance[ep] = struct{}{}
+			}
+			maintenance[endpoint] = struct{}{}
+			if err := checkDriveMaintenanceQuorum(si.Disks, writeQuorums, maintenance); err != nil {
+				return err
+			}
+		}
+		drives[endpoint] = driveMaintenance{
+			Endpoint: endpoint,
+			State:    state,
+			DiskID:   diskID,
+			Updated:  UTCNow(),
+		}
+		return nil
+	})
+}
+
+// Remove puts the drive at endpoint back in service.
+func (sys *driveMaintenanceSys) Remove(ctx context.Context, objAPI ObjectLayer, endpoint string) error {
+	return sys.update(ctx, objAPI, func(drives map[string]driveMaintenance) error {
+		if _, ok := drives[endpoint]; !ok {
+			return errDriveNotInMaintenance
+		}
+		delete(drives, endpoint)
+		return nil
+	})
+}
+
+// healed is called once the drive with diskID at endpoint was healed, the
+// maintenance of a drive it replaced is over.
+func (sys *driveMaintenanceSys) healed(ctx context.Context, objAPI ObjectLayer, endpoint, diskID string) {
+	d, ok := sys.get(endpoint)
+	if !ok || d.DiskID == diskID {
+		return
+	}
+	err := sys.update(ctx, objAPI, func(drives map[string]driveMaintenance) error {
+		if d, ok := drives[endpoint]; ok && d.DiskID != diskID {
+			delete(drives, endpoint)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+	logger.Info("Drive '%s' replaced and healed, back in service", endpoint)
+}
+
+// erasureWriteQuorums returns the write quorum of the erasure sets of each
+// pool, for the new objects written with the smallest parity.
+func erasureWriteQuorums(z *erasureServerPools) []int {
+	minParity := minObjectParity()
+	writeQuorums := make([]int, len(z.serverPools))
+	for i, pool := range z.serverPools {
+		parity := pool.defaultParityCount
+		if minParity >= 0 && minParity < parity {
+			parity = minParity
+		}
+		writeQuorums[i] = pool.setDriveCount - parity
+		if writeQuorums[i] == parity {
+			writeQuorums[i]++
+		}
+	}
+	return writeQuorums
+}
+
+// minObjectParity returns the smallest parity new objects are written
+// with: of the STANDARD, RRS and custom storage classes and of the buckets
+// with their own parity, -1 if none is configured.
+func minObjectParity() int {
+	minParity := -1
+	setMin := func(parity int) {
+		if parity >= 0 && (minParity < 0 || parity < minParity) {
+			minParity = parity
+		}
+	}
+	setMin(globalStorageClass.GetParityForSC(storageclass.STANDARD))
+	setMin(globalStorageClass.GetParityForSC(storageclass.RRS))
+	for _, c := range globalStorageClass.GetCustomClasses() {
+		setMin(c.Parity)
+	}
+	if globalBucketMetadataSys != nil {
+		if parity := globalBucketMetadataSys.MinErasureParity(); parity > 0 {
+			setMin(parity)
+		}
+	}
+	return minParity
+}
+
+// checkDriveMaintenanceQuorum returns an error if an erasure set loses its
+// write quorum once the drives under maintenance refuse writes, drives
+// already offline are not counted.
+func checkDriveMaintenanceQuorum(disks []madmin.Disk, writeQuorums []int, maintenance map[string]struct{}) error {
+	type setIdx struct{ pool, set int }
+	online := make(map[setIdx]int)
+	for _, disk := range disks {
+		if disk.PoolIndex < 0 || disk.PoolIndex >= len(writeQuorums) {
+			continue
+		}
+		set := setIdx{disk.PoolIndex, disk.SetIndex}
+		if _, ok := online[set]; !ok {
+			online[set] = 0
+		}
+		if disk.State != madmin.DriveStateOk {
+			continue
+		}
+		if _, ok := maintenance[disk.Endpoint]; ok {
+			continue
+		}
+		online[set]++
+	}
+	for set, n := range online {
+		if n < writeQuorums[set.pool] {
+			return fmt.Errorf("%w: pool %d, set %d would have %d drives accepting writes, write quorum is %d",
+				errDriveMaintenanceQuorum, set.pool+1, set.set+1, n, writeQuorums[set.pool])
+		}
+	}
+	return nil
+}
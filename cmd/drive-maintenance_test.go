@@ -0,0 +1,171 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
+	"github.com/minio/madmin-go"
+)
+
+func TestDriveMaintenanceCordon(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Shutdown(context.Background())
+	defer removeRoots(fsDirs)
+	defer globalDriveMaintenanceSys.store(driveMaintenanceConfig{})
+	defer func(sc storageclass.Config) {
+		globalStorageClass = sc
+	}(globalStorageClass)
+	// Objects without parity need all the drives of a set.
+	globalStorageClass = storageclass.Config{
+		Standard: storageclass.StorageClass{Parity: 4},
+		RRS:      storageclass.StorageClass{Parity: 2},
+	}
+
+	z := obj.(*erasureServerPools)
+	disk := z.serverPools[0].sets[0].getDisks()[0]
+	diskID, err := disk.GetDiskID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := disk.Endpoint().String()
+
+	if err = obj.MakeBucketWithLocation(ctx, "bucket", MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = globalDriveMaintenanceSys.Set(ctx, obj, endpoint, diskID, driveCordoned); err != nil {
+		t.Fatal(err)
+	}
+	if !globalDriveMaintenanceSys.isCordoned(diskID) {
+		t.Fatal("expected the drive to be cordoned")
+	}
+	if err = disk.MakeVol(ctx, "cordoned"); !errors.Is(err, errDiskReadOnly) {
+		t.Fatalf("expected %v writing to a cordoned drive, got %v", errDiskReadOnly, err)
+	}
+	if _, err = disk.StatVol(ctx, "bucket"); err != nil {
+		t.Fatalf("expected reads from a cordoned drive to succeed, got %v", err)
+	}
+
+	// The maintenance survives a reload.
+	globalDriveMaintenanceSys.store(driveMaintenanceConfig{})
+	if err = globalDriveMaintenanceSys.Load(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := globalDriveMaintenanceSys.get(endpoint); !ok || m.State != driveCordoned || m.DiskID != diskID {
+		t.Fatalf("unexpected maintenance after reload %v", m)
+	}
+
+	if err = globalDriveMaintenanceSys.Remove(ctx, obj, endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol(ctx, "uncordoned"); err != nil {
+		t.Fatalf("expected writes to an uncordoned drive to succeed, got %v", err)
+	}
+	if err = globalDriveMaintenanceSys.Remove(ctx, obj, endpoint); !errors.Is(err, errDriveNotInMaintenance) {
+		t.Fatalf("expected %v, got %v", errDriveNotInMaintenance, err)
+	}
+
+	// Healing the same drive does not end its replacement, healing the
+	// drive swapped in its place does.
+	if err = globalDriveMaintenanceSys.Set(ctx, obj, endpoint, diskID, driveReplacing); err != nil {
+		t.Fatal(err)
+	}
+	globalDriveMaintenanceSys.healed(ctx, obj, endpoint, diskID)
+	if _, ok := globalDriveMaintenanceSys.get(endpoint); !ok {
+		t.Fatal("expected the drive to still be marked for replacement")
+	}
+	globalDriveMaintenanceSys.healed(ctx, obj, endpoint, mustGetUUID())
+	if _, ok := globalDriveMaintenanceSys.get(endpoint); ok {
+		t.Fatal("expected the replaced drive to be back in service")
+	}
+}
+
+func TestCheckDriveMaintenanceQuorum(t *testing.T) {
+	// One set of 4 drives with a write quorum of 3.
+	disks := []madmin.Disk{
+		{Endpoint: "http://node1:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node2:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node3:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node4:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+	}
+	writeQuorums := []int{3}
+
+	testCases := []struct {
+		maintenance []string
+		disks       []madmin.Disk
+		ok          bool
+	}{
+		{[]string{"http://node1:9000/d1"}, disks, true},
+		{[]string{"http://node1:9000/d1", "http://node2:9000/d1"}, disks, false},
+		// A drive of the set is already offline.
+		{[]string{"http://node2:9000/d1"}, append([]madmin.Disk{{Endpoint: "http://node1:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOffline}}, disks[1:]...), false},
+		// Marking the offline drive for replacement changes nothing.
+		{[]string{"http://node1:9000/d1"}, append([]madmin.Disk{{Endpoint: "http://node1:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOffline}}, disks[1:]...), true},
+	}
+
+	for i, testCase := range testCases {
+		maintenance := make(map[string]struct{})
+		for _, ep := range testCase.maintenance {
+			maintenance[ep] = struct{}{}
+		}
+		err := checkDriveMaintenanceQuorum(testCase.disks, writeQuorums, maintenance)
+		if testCase.ok && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+		if !testCase.ok && !errors.Is(err, errDriveMaintenanceQuorum) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, errDriveMaintenanceQuorum, err)
+		}
+	}
+}
+
+func TestGetDriveMaintenanceStatus(t *testing.T) {
+	heal := &madmin.HealingDisk{Endpoint: "http://node2/d1", ItemsHealed: 10}
+	maintenance := map[string]driveMaintenance{
+		"http://node1/d1": {Endpoint: "http://node1/d1", State: driveCordoned, DiskID: "id-1"},
+		"http://node1/d2": {Endpoint: "http://node1/d2", State: driveReplacing, DiskID: "id-2"},
+		"http://node1/d3": {Endpoint: "http://node1/d3", State: driveReplacing, DiskID: "id-3"},
+	}
+	disks := []madmin.Disk{
+		{Endpoint: "http://node1/d1", UUID: "id-1", State: madmin.DriveStateOk},
+		{Endpoint: "http://node1/d2", UUID: "id-2", State: madmin.DriveStateOk},
+		{Endpoint: "http://node1/d3", UUID: "new", State: madmin.DriveStateOk},
+		{Endpoint: "http://node1/d4", UUID: "id-4", State: madmin.DriveStateOk},
+		{Endpoint: "http://node2/d1", UUID: "id-5", State: madmin.DriveStateOk, Healing: true, HealInfo: heal},
+	}
+
+	expected := []DriveMaintenanceStatus{
+		{Endpoint: "http://node1/d1", State: "cordoned", DriveState: madmin.DriveStateOk, DiskID: "id-1"},
+		{Endpoint: "http://node1/d2", State: "replacing", DriveState: madmin.DriveStateOk, DiskID: "id-2"},
+		{Endpoint: "http://node1/d3", State: "replaced", DriveState: madmin.DriveStateOk, DiskID: "id-3"},
+		{Endpoint: "http://node2/d1", State: "healing", DriveState: madmin.DriveStateOk, Heal: heal},
+	}
+	if got := getDriveMaintenanceStatus(maintenance, disks); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
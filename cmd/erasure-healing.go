@@ -33,6 +33,12 @@ import (
 
 const reservedMetadataPrefixLowerDataShardFix = ReservedMetadataPrefixLower + "data-shard-fix"
 
+// Details of the heal result of an object which could not be read.
+const (
+	healDetailDanglingRemoved = "dangling object removed"
+	healDetailUnrecoverable   = "object unrecoverable, not enough drives to read it"
+)
+
 // AcceptableDelta returns 'true' if the fi.DiskMTime is under
 // acceptable delta of "delta" duration with maxTime.
 //
@@ -865,21 +871,31 @@ func (er erasureObjects) purgeObjectDangling(ctx context.Context, bucket, object
 	m, err := er.deleteIfDangling(ctx, bucket, object, metaArr, errs, dataErrs, ObjectOptions{
 		VersionID: versionID,
 	})
+	metaErrs := errs
 	errs = make([]error, len(errs))
 	for i := range errs {
 		errs[i] = err
 	}
 	if err == nil {
+		er.recordUnrecoverable(ctx, bucket, object, versionID, m, metaErrs, dataErrs)
 		// Dangling object successfully purged, size is '0'
 		m.Size = 0
 	}
+	// deleteIfDangling returns not found once the object is purged,
+	// an object which is not dangling cannot be read nor removed.
+	detail := healDetailUnrecoverable
+	if err != nil {
+		detail = healDetailDanglingRemoved
+	}
 	// Generate file/version not found with default heal result
 	err = errFileNotFound
 	if versionID != "" {
 		err = errFileVersionNotFound
 	}
-	return er.defaultHealResult(m, storageDisks, storageEndpoints,
-		errs, bucket, object, versionID), err
+	result := er.defaultHealResult(m, storageDisks, storageEndpoints,
+		errs, bucket, object, versionID)
+	result.Detail = detail
+	return result, err
 }
 
 // Object is considered dangling/corrupted if any only
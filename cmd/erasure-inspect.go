@@ -0,0 +1,361 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/madmin-go"
+)
+
+const (
+	// shardStateOutdated is the state of a shard whose xl.meta does not
+	// hold the latest state of the object version.
+	shardStateOutdated = "outdated"
+
+	// maxInspectArchiveSize is the largest total size of the part files
+	// of an inspection archive, as the object stays locked against writes
+	// while the archive is sent.
+	maxInspectArchiveSize = humanize.GiByte
+)
+
+var errInspectArchiveTooLarge = fmt.Errorf("object shards exceed %s, too large to be archived", humanize.IBytes(maxInspectArchiveSize))
+
+// ObjectInspectPart is the state of a part of an object version on a drive.
+type ObjectInspectPart struct {
+	Number int `json:"number"`
+	// Size is the expected size of the shard file of the part.
+	Size int64 `json:"size"`
+	// FileSize is the size of the shard file found on the drive, -1 if
+	// it is missing.
+	FileSize  int64     `json:"fileSize"`
+	ModTime   time.Time `json:"modTime,omitempty"`
+	Algorithm string    `json:"algorithm"`
+	// Checksum of the whole shard file, empty for streaming bitrot
+	// algorithms as their checksums are stored along with the data.
+	Checksum string `json:"checksum,omitempty"`
+	// State is one of the madmin.DriveState* values.
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// ObjectInspectShard is the state of an object version on a drive of its
+// erasure set.
+type ObjectInspectShard struct {
+	Endpoint string `json:"endpoint"`
+	// Drive is the index of the drive in the erasure set.
+	Drive int `json:"drive"`
+	// Shard is the erasure index of the data held by the drive, 0 if unknown.
+	Shard int `json:"shard,omitempty"`
+	// State is one of the madmin.DriveState* values or "outdated".
+	State   string              `json:"state"`
+	Error   string              `json:"error,omitempty"`
+	ModTime time.Time           `json:"modTime,omitempty"`
+	DataDir string              `json:"dataDir,omitempty"`
+	Inline  bool                `json:"inline,omitempty"`
+	Parts   []ObjectInspectPart `json:"parts,omitempty"`
+}
+
+// ObjectInspectReport is the result of the inspection of an object version
+// on every drive of its erasure set.
+type ObjectInspectReport struct {
+	Bucket       string    `json:"bucket"`
+	Object       string    `json:"object"`
+	VersionID    string    `json:"versionId,omitempty"`
+	Pool         int       `json:"pool"`
+	Set          int       `json:"set"`
+	ModTime      time.Time `json:"modTime,omitempty"`
+	Size         int64     `json:"size"`
+	DeleteMarker bool      `json:"deleteMarker,omitempty"`
+	DataBlocks   int       `json:"dataBlocks"`
+	ParityBlocks int       `json:"parityBlocks"`
+	ReadQuorum   int       `json:"readQuorum"`
+	WriteQuorum  int       `json:"writeQuorum"`
+	// Metadata is the number of drives holding the latest xl.meta.
+	Metadata int `json:"metadata"`
+	// HealthyShards is the number of drives whose shard passed the bitrot
+	// verification.
+	HealthyShards int                  `json:"healthyShards"`
+	Readable      bool                 `json:"readable"`
+	Writable      bool                 `json:"writable"`
+	Shards        []ObjectInspectShard `json:"shards"`
+}
+
+// shardErrState returns the state of a shard from the error reading it.
+func shardErrState(err error) string {
+	switch {
+	case err == nil:
+		return madmin.DriveStateOk
+	case errors.Is(err, errDiskNotFound):
+		return madmin.DriveStateOffline
+	case errors.Is(err, errFileNotFound), errors.Is(err, errFileVersionNotFound),
+		errors.Is(err, errPathNotFound), errors.Is(err, errVolumeNotFound):
+		return madmin.DriveStateMissing
+	case errors.Is(err, errDiskAccessDenied), errors.Is(err, errFileAccessDenied), errors.Is(err, errVolumeAccessDenied):
+		return madmin.DriveStatePermission
+	case errors.Is(err, errFaultyDisk):
+		return madmin.DriveStateFaulty
+	}
+	return madmin.DriveStateCorrupt
+}
+
+// inspectObject reads xl.meta of the object version from every drive of the
+// set and verifies the bitrot checksums of its shards. fn, if not nil, is
+// called with the report while the object version is still locked against
+// writes.
+func (er erasureObjects) inspectObject(ctx context.Context, bucket, object, versionID string, fn func(ctx context.Context, report ObjectInspectReport) error) (ObjectInspectReport, error) {
+	report := ObjectInspectReport{
+		Bucket:    bucket,
+		Object:    object,
+		VersionID: versionID,
+		Pool:      er.poolIndex,
+		Set:       er.setIndex,
+	}
+	object = encodeDirObject(object)
+
+	lk := er.NewNSLock(bucket, object)
+	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return report, err
+	}
+	ctx = lkctx.Context()
+	defer lk.RUnlock(lkctx.Cancel)
+
+	disks := er.getDisks()
+	metaArr, errs := readAllFileInfo(ctx, disks, bucket, object, versionID, true)
+
+	// The latest metadata, even without quorum, tells the expected layout.
+	onlineDisks, modTime := listOnlineDisks(disks, metaArr, errs)
+	var fi FileInfo
+	for i, disk := range onlineDisks {
+		if disk != nil {
+			fi = metaArr[i]
+			report.Metadata++
+		}
+	}
+	if !fi.IsValid() {
+		if countErrs(errs, errFileNotFound)+countErrs(errs, errFileVersionNotFound) == len(errs) {
+			if versionID != "" {
+				return report, VersionNotFound{Bucket: bucket, Object: report.Object, VersionID: versionID}
+			}
+			return report, ObjectNotFound{Bucket: bucket, Object: report.Object}
+		}
+		fi = FileInfo{Erasure: ErasureInfo{
+			DataBlocks:   len(disks) - er.defaultParityCount,
+			ParityBlocks: er.defaultParityCount,
+		}}
+	}
+	if report.VersionID == "" && fi.VersionID != "" {
+		report.VersionID = fi.VersionID
+	}
+	report.ModTime = modTime
+	report.Size = fi.Size
+	report.DeleteMarker = fi.Deleted
+	report.DataBlocks = fi.Erasure.DataBlocks
+	report.ParityBlocks = fi.Erasure.ParityBlocks
+	if report.ReadQuorum, report.WriteQuorum, err = objectQuorumFromMeta(ctx, metaArr, errs, er.defaultParityCount); err != nil {
+		report.ReadQuorum = report.DataBlocks
+		report.WriteQuorum = report.DataBlocks
+		if report.DataBlocks == report.ParityBlocks {
+			report.WriteQuorum++
+		}
+	}
+
+	endpoints := er.getEndpoints()
+	report.Shards = make([]ObjectInspectShard, len(disks))
+	var wg sync.WaitGroup
+	for i := range disks {
+		report.Shards[i] = ObjectInspectShard{
+			Endpoint: endpoints[i].String(),
+			Drive:    i,
+		}
+		if errs[i] == nil && disks[i] == nil {
+			errs[i] = errDiskNotFound
+		}
+		if errs[i] != nil {
+			report.Shards[i].State = shardErrState(errs[i])
+			report.Shards[i].Error = errs[i].Error()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			report.Shards[i] = inspectShard(ctx, disks[i], bucket, object, report.Shards[i], metaArr[i], fi, onlineDisks[i] != nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, s := range report.Shards {
+		if s.State == madmin.DriveStateOk {
+			report.HealthyShards++
+		}
+	}
+	report.Readable = report.Metadata >= report.ReadQuorum && report.HealthyShards >= report.ReadQuorum
+	report.Writable = report.Metadata >= report.WriteQuorum
+	if fi.Deleted || fi.IsRemote() {
+		// No data to read, only the metadata counts.
+		report.Readable = report.Metadata >= report.ReadQuorum
+	}
+	if fn != nil {
+		return report, fn(ctx, report)
+	}
+	return report, nil
+}
+
+// inspectShard verifies the parts of the object version held by disk
+// described by meta, against the latest metadata fi of the version.
+func inspectShard(ctx context.Context, disk StorageAPI, bucket, object string, s ObjectInspectShard, meta, fi FileInfo, latest bool) ObjectInspectShard {
+	s.Shard = meta.Erasure.Index
+	s.ModTime = meta.ModTime
+	s.DataDir = meta.DataDir
+	s.Inline = meta.InlineData()
+	s.State = madmin.DriveStateOk
+	if !latest || meta.DataDir != fi.DataDir {
+		s.State = shardStateOutdated
+		return s
+	}
+	if meta.Deleted || meta.IsRemote() {
+		return s
+	}
+
+	for _, part := range meta.Parts {
+		checksumInfo := meta.Erasure.GetChecksumInfo(part.Number)
+		shardSize := meta.Erasure.ShardFileSize(part.Size)
+		p := ObjectInspectPart{
+			Number:    part.Number,
+			Size:      bitrotShardFileSize(shardSize, meta.Erasure.ShardSize(), checksumInfo.Algorithm),
+			FileSize:  -1,
+			Algorithm: checksumInfo.Algorithm.String(),
+			Checksum:  hex.EncodeToString(checksumInfo.Hash),
+		}
+
+		var err error
+		if s.Inline {
+			p.FileSize = int64(len(meta.Data))
+			p.ModTime = meta.ModTime
+			err = bitrotVerify(bytes.NewReader(meta.Data), int64(len(meta.Data)), shardSize,
+				checksumInfo.Algorithm, checksumInfo.Hash, meta.Erasure.ShardSize())
+		} else {
+			partPath := pathJoin(object, meta.DataDir, fmt.Sprintf("part.%d", part.Number))
+			var st []StatInfo
+			if st, err = disk.StatInfoFile(ctx, bucket, partPath, false); err == nil && len(st) > 0 {
+				p.FileSize = st[0].Size
+				p.ModTime = st[0].ModTime
+				// Verify the part alone to report the state of each part.
+				partFi := meta
+				partFi.Parts = []ObjectPartInfo{part}
+				err = disk.VerifyFile(ctx, bucket, object, partFi)
+			}
+		}
+		p.State = shardErrState(err)
+		if err != nil {
+			p.Error = err.Error()
+			// A corrupted part makes the shard corrupted, missing
+			// parts only matter if no other part is corrupted.
+			if s.State == madmin.DriveStateOk || p.State == madmin.DriveStateCorrupt {
+				s.State = p.State
+			}
+		}
+		s.Parts = append(s.Parts, p)
+	}
+	return s
+}
+
+// inspectObject inspects the object version in the pool holding it, fn is
+// called as for erasureObjects.inspectObject.
+func (z *erasureServerPools) inspectObject(ctx context.Context, bucket, object, versionID string, fn func(ctx context.Context, report ObjectInspectReport) error) (report ObjectInspectReport, err error) {
+	for _, pool := range z.serverPools {
+		report, err = pool.getHashedSet(object).inspectObject(ctx, bucket, object, versionID, fn)
+		if isErrObjectNotFound(err) || isErrVersionNotFound(err) {
+			continue
+		}
+		return report, err
+	}
+	return report, err
+}
+
+// archiveSize returns the total size of the part files archived by
+// archiveObject.
+func (r ObjectInspectReport) archiveSize() (size int64) {
+	for _, s := range r.Shards {
+		if s.Inline || s.DataDir == "" {
+			continue
+		}
+		for _, p := range s.Parts {
+			if p.FileSize > 0 {
+				size += p.FileSize
+			}
+		}
+	}
+	return size
+}
+
+// archiveObject calls fn with the xl.meta and the part files of an inspected
+// object version found on every drive of its erasure set, named after the
+// index of the drive in the set. It is called while inspectObject holds the
+// lock of the object, so that the files match the report.
+func (z *erasureServerPools) archiveObject(ctx context.Context, report ObjectInspectReport, fn func(r io.Reader, name string, si StatInfo) error) error {
+	if report.Pool >= len(z.serverPools) || report.Set >= len(z.serverPools[report.Pool].sets) {
+		return errInvalidArgument
+	}
+	er := z.serverPools[report.Pool].sets[report.Set]
+	object := encodeDirObject(report.Object)
+	for i, disk := range er.getDisks() {
+		if disk == nil || !disk.IsOnline() || i >= len(report.Shards) {
+			continue
+		}
+		drive := strconv.Itoa(i)
+		raw, err := disk.ReadAll(ctx, report.Bucket, pathJoin(object, xlStorageFormatFile))
+		if err != nil {
+			continue
+		}
+		if err = fn(bytes.NewReader(raw), pathJoin(drive, xlStorageFormatFile), StatInfo{Size: int64(len(raw))}); err != nil {
+			return err
+		}
+
+		s := report.Shards[i]
+		if s.Inline || s.DataDir == "" {
+			continue
+		}
+		for _, p := range s.Parts {
+			if p.FileSize < 0 {
+				continue
+			}
+			partPath := pathJoin(s.DataDir, fmt.Sprintf("part.%d", p.Number))
+			rc, err := disk.ReadFileStream(ctx, report.Bucket, pathJoin(object, partPath), 0, p.FileSize)
+			if err != nil {
+				continue
+			}
+			err = fn(rc, pathJoin(drive, partPath), StatInfo{Size: p.FileSize, ModTime: p.ModTime})
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minio/madmin-go"
+)
+
+func TestInspectObject(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	setObjectLayer(obj)
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+
+	const bucket, object = "bucket", "object"
+	if err = obj.MakeBucketWithLocation(ctx, bucket, MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Enough parity to read and repair the object with three shards lost.
+	meta, err := globalBucketMetadataSys.Get(bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.erasureConfig = &BucketErasureConfig{Parity: 4}
+	globalBucketMetadataSys.Set(bucket, meta)
+
+	// Large enough for the shards not to be inlined.
+	data := bytes.Repeat([]byte("a"), 4<<20)
+	if _, err = obj.PutObject(ctx, bucket, object, mustGetPutObjReader(t, bytes.NewReader(data), int64(len(data)), "", ""), ObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	z := obj.(*erasureServerPools)
+	if _, err = z.inspectObject(ctx, bucket, "missing", "", nil); !isErrObjectNotFound(err) {
+		t.Fatalf("expected object not found, got %v", err)
+	}
+
+	er := z.serverPools[0].sets[0]
+	fi, _, _, err := er.getObjectFileInfo(ctx, bucket, object, ObjectOptions{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disks := er.getDisks()
+	objectDir := func(i int) string {
+		return filepath.Join(disks[i].Endpoint().Path, bucket, object)
+	}
+	partPath := func(i int) string {
+		return filepath.Join(objectDir(i), fi.DataDir, "part.1")
+	}
+	// Corrupt the shard of the first drive, remove the part of the second
+	// and the whole object from the third.
+	f, err := os.OpenFile(partPath(0), os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.WriteAt(bytes.Repeat([]byte("b"), 1024), 1024); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err = os.Remove(partPath(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.RemoveAll(objectDir(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every xl.meta and part file left is archived, while the object is
+	// still locked.
+	files := make(map[string]int64)
+	report, err := z.inspectObject(ctx, bucket, object, "", func(ctx context.Context, report ObjectInspectReport) error {
+		return z.archiveObject(ctx, report, func(r io.Reader, name string, si StatInfo) error {
+			n, err := io.Copy(io.Discard, r)
+			files[name] = n
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStates := map[int]string{0: madmin.DriveStateCorrupt, 1: madmin.DriveStateMissing, 2: madmin.DriveStateMissing}
+	for i, s := range report.Shards {
+		want, ok := wantStates[i]
+		if !ok {
+			want = madmin.DriveStateOk
+		}
+		if s.State != want {
+			t.Errorf("drive %d: expected state %s, got %s (%s)", i, want, s.State, s.Error)
+		}
+		if i != 2 && (len(s.Parts) != 1 || s.Parts[0].State != want || s.Shard != fi.Erasure.Distribution[i]) {
+			t.Errorf("drive %d: unexpected shard %#v", i, s)
+		}
+	}
+	if report.Metadata != len(disks)-1 || report.HealthyShards != len(disks)-3 || !report.Readable || !report.Writable {
+		t.Errorf("unexpected report %#v", report)
+	}
+
+	var metas, parts int
+	for name, size := range files {
+		switch {
+		case strings.HasSuffix(name, xlStorageFormatFile):
+			metas++
+		case strings.HasSuffix(name, "part.1"):
+			parts++
+			if size != report.Shards[0].Parts[0].Size {
+				t.Errorf("%s: expected size %d, got %d", name, report.Shards[0].Parts[0].Size, size)
+			}
+		}
+	}
+	if metas != len(disks)-1 || parts != len(disks)-2 {
+		t.Errorf("unexpected archived files %v", files)
+	}
+	if size := report.archiveSize(); size != int64(parts)*report.Shards[0].Parts[0].Size {
+		t.Errorf("unexpected archive size %d", size)
+	}
+
+	// A deep heal repairs all the shards.
+	if _, err = z.HealObject(ctx, bucket, object, "", madmin.HealOpts{ScanMode: madmin.HealDeepScan}); err != nil {
+		t.Fatal(err)
+	}
+	if report, err = z.inspectObject(ctx, bucket, object, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if report.HealthyShards != len(disks) {
+		t.Errorf("expected all shards healthy after repair, got %#v", report.Shards)
+	}
+}
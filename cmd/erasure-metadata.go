@@ -423,9 +423,11 @@ func objectQuorumFromMeta(ctx context.Context, partsMetaData []FileInfo, errs []
 		parityBlocks = defaultParityCount
 	}
 
-	// For erasure code upgraded objects choose the parity
-	// blocks saved internally, instead of 'defaultParityCount'
-	if _, ok := latestFileInfo.Metadata[minIOErasureUpgraded]; ok {
+	// For erasure code upgraded objects and objects written with
+	// the parity of their bucket choose the parity blocks saved
+	// internally, instead of 'defaultParityCount'
+	_, upgraded := latestFileInfo.Metadata[minIOErasureUpgraded]
+	if upgraded || latestFileInfo.Erasure.DataBlocks+parityBlocks != len(partsMetaData) {
 		if latestFileInfo.Erasure.ParityBlocks != 0 {
 			parityBlocks = latestFileInfo.Erasure.ParityBlocks
 		}
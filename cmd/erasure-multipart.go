@@ -329,10 +329,7 @@ func (er erasureObjects) newMultipartUpload(ctx context.Context, bucket string,
 	userDefined := cloneMSS(opts.UserDefined)
 
 	onlineDisks := er.getDisks()
-	parityDrives := globalStorageClass.GetParityForSC(userDefined[xhttp.AmzStorageClass])
-	if parityDrives < 0 {
-		parityDrives = er.defaultParityCount
-	}
+	parityDrives := er.objectParity(bucket, userDefined[xhttp.AmzStorageClass])
 
 	parityOrig := parityDrives
 	for _, disk := range onlineDisks {
@@ -1121,13 +1118,15 @@ func (er erasureObjects) CompleteMultipartUpload(ctx context.Context, bucket str
 	}
 
 	// Hold namespace to complete the transaction
-	lk := er.NewNSLock(bucket, object)
-	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
-	if err != nil {
-		return oi, err
+	if !opts.NoLock {
+		lk := er.NewNSLock(bucket, object)
+		lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+		if err != nil {
+			return oi, err
+		}
+		ctx = lkctx.Context()
+		defer lk.Unlock(lkctx.Cancel)
 	}
-	ctx = lkctx.Context()
-	defer lk.Unlock(lkctx.Cancel)
 
 	// Write final `xl.meta` at uploadID location
 	onlineDisks, err = writeUniqueFileInfo(ctx, onlineDisks, minioMetaMultipartBucket, uploadIDPath, partsMetadata, writeQuorum)
@@ -1182,6 +1181,8 @@ func (er erasureObjects) CompleteMultipartUpload(ctx context.Context, bucket str
 	// we are adding a new version to this object under the namespace lock, so this is the latest version.
 	fi.IsLatest = true
 
+	globalStorageClassStats.written(bucket, fi.Metadata[xhttp.AmzStorageClass], fi.Size)
+
 	// Success, return object info.
 	return fi.ToObjectInfo(bucket, object, opts.Versioned || opts.VersionSuspended), nil
 }
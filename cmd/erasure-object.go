@@ -32,6 +32,7 @@ import (
 	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
 	"github.com/GuinsooLab/annastore/internal/bucket/object/lock"
 	"github.com/GuinsooLab/annastore/internal/bucket/replication"
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/event"
 	"github.com/GuinsooLab/annastore/internal/hash"
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
@@ -561,6 +562,38 @@ func readAllXL(ctx context.Context, disks []StorageAPI, bucket, object string, r
 	return metaFileInfos, errs
 }
 
+// readAllXLVersions reads xl.meta of object from all disks and returns the
+// versions found on at least half of them, newest first.
+func readAllXLVersions(ctx context.Context, disks []StorageAPI, bucket, object string) ([]xlMetaV2ShallowVersion, error) {
+	metadataShallowVersions := make([][]xlMetaV2ShallowVersion, len(disks))
+
+	g := errgroup.WithNErrs(len(disks))
+	for index := range disks {
+		index := index
+		g.Go(func() error {
+			if disks[index] == nil {
+				return errDiskNotFound
+			}
+			rf, err := disks[index].ReadXL(ctx, bucket, object, false)
+			if err != nil {
+				return err
+			}
+			var xl xlMetaV2
+			if err = xl.LoadOrConvert(rf.Buf); err != nil {
+				return err
+			}
+			metadataShallowVersions[index] = xl.versions
+			return nil
+		}, index)
+	}
+
+	readQuorum := (len(disks) + 1) / 2
+	if err := reduceReadQuorumErrs(ctx, g.Wait(), objectOpIgnoredErrs, readQuorum); err != nil {
+		return nil, err
+	}
+	return mergeXLV2Versions(readQuorum, false, 0, metadataShallowVersions...), nil
+}
+
 func (er erasureObjects) getObjectFileInfo(ctx context.Context, bucket, object string, opts ObjectOptions, readData bool) (fi FileInfo, metaArr []FileInfo, onlineDisks []StorageAPI, err error) {
 	disks := er.getDisks()
 
@@ -872,6 +905,34 @@ func (er erasureObjects) PutObject(ctx context.Context, bucket string, object st
 	return er.putObject(ctx, bucket, object, data, opts)
 }
 
+// objectParity returns the parity of a new object of storage class sc in
+// bucket, the bucket parity if any replaces the STANDARD storage class one.
+func (er erasureObjects) objectParity(bucket, sc string) int {
+	if sc = strings.TrimSpace(sc); sc == "" || sc == storageclass.STANDARD {
+		if parity := getBucketErasureConfig(bucket).Parity; parity > 0 {
+			return parity
+		}
+	}
+	parity := globalStorageClass.GetParityForSC(sc)
+	if parity < 0 {
+		parity = er.defaultParityCount
+	}
+	return parity
+}
+
+// objectInlineThreshold returns the shard size below which the data of a new
+// object of storage class sc in bucket is inlined. Custom storage classes
+// come first, then the bucket inline threshold.
+func objectInlineThreshold(bucket, sc string) int64 {
+	if threshold := globalStorageClass.GetInlineThresholdForSC(sc); threshold >= 0 {
+		return threshold
+	}
+	if threshold := getBucketErasureConfig(bucket).InlineThreshold; threshold > 0 {
+		return threshold
+	}
+	return smallFileThreshold
+}
+
 // putObject wrapper for erasureObjects PutObject
 func (er erasureObjects) putObject(ctx context.Context, bucket string, object string, r *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, err error) {
 	auditObjectErasureSet(ctx, object, &er)
@@ -885,10 +946,8 @@ func (er erasureObjects) putObject(ctx context.Context, bucket string, object st
 	parityDrives := len(storageDisks) / 2
 	if !opts.MaxParity {
 		// Get parity and data drive count based on storage class metadata
-		parityDrives = globalStorageClass.GetParityForSC(userDefined[xhttp.AmzStorageClass])
-		if parityDrives < 0 {
-			parityDrives = er.defaultParityCount
-		}
+		// and bucket erasure settings
+		parityDrives = er.objectParity(bucket, userDefined[xhttp.AmzStorageClass])
 
 		// If we have offline disks upgrade the number of erasure codes for this object.
 		parityOrig := parityDrives
@@ -998,21 +1057,23 @@ func (er erasureObjects) putObject(ctx context.Context, bucket string, object st
 		}
 	}()
 
+	inlineThreshold := objectInlineThreshold(bucket, userDefined[xhttp.AmzStorageClass])
+
 	shardFileSize := erasure.ShardFileSize(data.Size())
 	writers := make([]io.Writer, len(onlineDisks))
 	var inlineBuffers []*bytes.Buffer
 	if shardFileSize >= 0 {
-		if !opts.Versioned && shardFileSize < smallFileThreshold {
+		if !opts.Versioned && shardFileSize < inlineThreshold {
 			inlineBuffers = make([]*bytes.Buffer, len(onlineDisks))
-		} else if shardFileSize < smallFileThreshold/8 {
+		} else if shardFileSize < inlineThreshold/8 {
 			inlineBuffers = make([]*bytes.Buffer, len(onlineDisks))
 		}
 	} else {
 		// If compressed, use actual size to determine.
 		if sz := erasure.ShardFileSize(data.ActualSize()); sz > 0 {
-			if !opts.Versioned && sz < smallFileThreshold {
+			if !opts.Versioned && sz < inlineThreshold {
 				inlineBuffers = make([]*bytes.Buffer, len(onlineDisks))
-			} else if sz < smallFileThreshold/8 {
+			} else if sz < inlineThreshold/8 {
 				inlineBuffers = make([]*bytes.Buffer, len(onlineDisks))
 			}
 		}
@@ -1169,6 +1230,8 @@ func (er erasureObjects) putObject(ctx context.Context, bucket string, object st
 	// we are adding a new version to this object under the namespace lock, so this is the latest version.
 	fi.IsLatest = true
 
+	globalStorageClassStats.written(bucket, fi.Metadata[xhttp.AmzStorageClass], fi.Size)
+
 	return fi.ToObjectInfo(bucket, object, opts.Versioned || opts.VersionSuspended), nil
 }
 
@@ -1424,13 +1487,15 @@ func (er erasureObjects) DeleteObject(ctx context.Context, bucket, object string
 	}
 
 	// Acquire a write lock before deleting the object.
-	lk := er.NewNSLock(bucket, object)
-	lkctx, err := lk.GetLock(ctx, globalDeleteOperationTimeout)
-	if err != nil {
-		return ObjectInfo{}, err
+	if !opts.NoLock {
+		lk := er.NewNSLock(bucket, object)
+		lkctx, err := lk.GetLock(ctx, globalDeleteOperationTimeout)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		ctx = lkctx.Context()
+		defer lk.Unlock(lkctx.Cancel)
 	}
-	ctx = lkctx.Context()
-	defer lk.Unlock(lkctx.Cancel)
 
 	versionFound := true
 	objInfo = ObjectInfo{VersionID: opts.VersionID} // version id needed in Delete API response.
@@ -30,6 +30,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	humanize "github.com/dustin/go-humanize"
@@ -1215,3 +1216,75 @@ func TestGetObjectWithOutdatedDisks(t *testing.T) {
 		}
 	}
 }
+
+func TestGetObjectInfoAsOf(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	setObjectLayer(obj)
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+
+	bucket, object := "bucket", "object"
+	if err = obj.MakeBucketWithLocation(ctx, bucket, MakeBucketOptions{VersioningEnabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two versions, a delete marker and a third version, an hour apart.
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var versionIDs []string
+	for i, content := range []string{"one", "two", "", "four"} {
+		opts := ObjectOptions{Versioned: true, MTime: base.Add(time.Duration(i) * time.Hour)}
+		if content == "" {
+			if _, err = obj.DeleteObject(ctx, bucket, object, opts); err != nil {
+				t.Fatal(err)
+			}
+			versionIDs = append(versionIDs, "")
+			continue
+		}
+		oi, err := obj.PutObject(ctx, bucket, object, mustGetPutObjReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versionIDs = append(versionIDs, oi.VersionID)
+	}
+
+	z := obj.(*erasureServerPools)
+	testCases := []struct {
+		asOf      time.Time
+		versionID string
+	}{
+		{base.Add(-time.Minute), ""},
+		{base, versionIDs[0]},
+		{base.Add(90 * time.Minute), versionIDs[1]},
+		{base.Add(2 * time.Hour), ""},
+		{base.Add(150 * time.Minute), ""},
+		{base.Add(3 * time.Hour), versionIDs[3]},
+		{time.Now(), versionIDs[3]},
+	}
+	for i, tc := range testCases {
+		oi, err := z.GetObjectInfoAsOf(ctx, bucket, object, tc.asOf)
+		if tc.versionID == "" {
+			if !isErrObjectNotFound(err) {
+				t.Errorf("case %d: expected ObjectNotFound, got %v", i+1, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: %v", i+1, err)
+		}
+		if oi.VersionID != tc.versionID {
+			t.Errorf("case %d: expected version %s, got %s", i+1, tc.versionID, oi.VersionID)
+		}
+	}
+
+	if _, err = z.GetObjectInfoAsOf(ctx, bucket, "missing", time.Now()); !isErrObjectNotFound(err) {
+		t.Errorf("expected ObjectNotFound for a missing object, got %v", err)
+	}
+}
@@ -33,6 +33,7 @@ import (
 
 	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
 	"github.com/GuinsooLab/annastore/internal/config/storageclass"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/GuinsooLab/annastore/internal/sync/errgroup"
 	"github.com/minio/madmin-go"
@@ -294,11 +295,42 @@ func (p serverPoolsAvailableSpace) FilterMaxUsed(max int) {
 	}
 }
 
-// getAvailablePoolIdx will return an index that can hold size bytes.
+// FilterPools will filter out any pools that are not in pools, unless none
+// of them has available space, in which case all are preserved.
+func (p serverPoolsAvailableSpace) FilterPools(pools []int) {
+	if len(pools) == 0 {
+		// Nothing to do.
+		return
+	}
+	var ok bool
+	for _, z := range p {
+		if z.Available > 0 && contains(pools, z.Index) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		// Preferred pools are full or suspended.
+		// Do not modify
+		return
+	}
+
+	// Remove entries that are not preferred.
+	for i, z := range p {
+		if contains(pools, z.Index) {
+			continue
+		}
+		p[i].Available = 0
+	}
+}
+
+// getAvailablePoolIdx will return an index that can hold size bytes,
+// preferably one of the pools of the storage class sc.
 // -1 is returned if no serverPools have available space for the size given.
-func (z *erasureServerPools) getAvailablePoolIdx(ctx context.Context, bucket, object string, size int64) int {
+func (z *erasureServerPools) getAvailablePoolIdx(ctx context.Context, bucket, object, sc string, size int64) int {
 	serverPools := z.getServerPoolsAvailableSpace(ctx, bucket, object, size)
 	serverPools.FilterMaxUsed(100 - (100 * diskReserveFraction))
+	serverPools.FilterPools(globalStorageClass.GetPoolsForSC(sc))
 	total := serverPools.TotalAvailable()
 	if total == 0 {
 		return -1
@@ -467,14 +499,14 @@ func (z *erasureServerPools) getPoolIdxExistingNoLock(ctx context.Context, bucke
 	})
 }
 
-func (z *erasureServerPools) getPoolIdxNoLock(ctx context.Context, bucket, object string, size int64) (idx int, err error) {
+func (z *erasureServerPools) getPoolIdxNoLock(ctx context.Context, bucket, object, sc string, size int64) (idx int, err error) {
 	idx, err = z.getPoolIdxExistingNoLock(ctx, bucket, object)
 	if err != nil && !isErrObjectNotFound(err) {
 		return idx, err
 	}
 
 	if isErrObjectNotFound(err) {
-		idx = z.getAvailablePoolIdx(ctx, bucket, object, size)
+		idx = z.getAvailablePoolIdx(ctx, bucket, object, sc, size)
 		if idx < 0 {
 			return -1, toObjectErr(errDiskFull)
 		}
@@ -486,14 +518,14 @@ func (z *erasureServerPools) getPoolIdxNoLock(ctx context.Context, bucket, objec
 // getPoolIdx returns the found previous object and its corresponding pool idx,
 // if none are found falls back to most available space pool, this function is
 // designed to be only used by PutObject, CopyObject (newObject creation) and NewMultipartUpload.
-func (z *erasureServerPools) getPoolIdx(ctx context.Context, bucket, object string, size int64) (idx int, err error) {
+func (z *erasureServerPools) getPoolIdx(ctx context.Context, bucket, object, sc string, size int64) (idx int, err error) {
 	idx, err = z.getPoolIdxExistingWithOpts(ctx, bucket, object, ObjectOptions{SkipDecommissioned: true})
 	if err != nil && !isErrObjectNotFound(err) {
 		return idx, err
 	}
 
 	if isErrObjectNotFound(err) {
-		idx = z.getAvailablePoolIdx(ctx, bucket, object, size)
+		idx = z.getAvailablePoolIdx(ctx, bucket, object, sc, size)
 		if idx < 0 {
 			return -1, toObjectErr(errDiskFull)
 		}
@@ -970,7 +1002,7 @@ func (z *erasureServerPools) PutObject(ctx context.Context, bucket string, objec
 		opts.NoLock = true
 	}
 
-	idx, err := z.getPoolIdxNoLock(ctx, bucket, object, data.Size())
+	idx, err := z.getPoolIdxNoLock(ctx, bucket, object, opts.UserDefined[xhttp.AmzStorageClass], data.Size())
 	if err != nil {
 		return ObjectInfo{}, err
 	}
@@ -1113,7 +1145,7 @@ func (z *erasureServerPools) CopyObject(ctx context.Context, srcBucket, srcObjec
 		dstOpts.NoLock = true
 	}
 
-	poolIdx, err := z.getPoolIdxNoLock(ctx, dstBucket, dstObject, srcInfo.Size)
+	poolIdx, err := z.getPoolIdxNoLock(ctx, dstBucket, dstObject, dstOpts.UserDefined[xhttp.AmzStorageClass], srcInfo.Size)
 	if err != nil {
 		return objInfo, err
 	}
@@ -1303,6 +1335,99 @@ func (z *erasureServerPools) ListObjects(ctx context.Context, bucket, prefix, ma
 	return loi, nil
 }
 
+// ListObjectsAsOf lists the objects of bucket as they were at asOf. Objects
+// created after asOf, or deleted at asOf, are skipped, all other objects
+// are returned at their version current at asOf. Common prefixes are
+// computed from the current namespace.
+func (z *erasureServerPools) ListObjectsAsOf(ctx context.Context, bucket, prefix, marker, delimiter string, maxKeys int, asOf time.Time) (ListObjectsInfo, error) {
+	var (
+		loi  ListObjectsInfo
+		last string
+	)
+	if maxKeys <= 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	for {
+		opts := listPathOptions{
+			Bucket:      bucket,
+			Prefix:      prefix,
+			Separator:   delimiter,
+			Limit:       maxKeysPlusOne(maxKeys-len(loi.Objects)-len(loi.Prefixes), marker != ""),
+			Marker:      marker,
+			InclDeleted: true,
+			AskDisks:    globalAPIConfig.getListQuorum(),
+		}
+
+		merged, err := z.listPath(ctx, &opts)
+		if err != nil && err != io.EOF {
+			if !isErrBucketNotFound(err) {
+				logger.LogIf(ctx, err)
+			}
+			return loi, err
+		}
+		merged.forwardPast(opts.Marker)
+		if merged.len() == 0 {
+			return loi, nil
+		}
+		lastEntry := merged.o[len(merged.o)-1].name
+		objects := merged.fileInfosAsOf(bucket, prefix, delimiter, asOf)
+		merged.truncate(0)
+
+		for _, obj := range objects {
+			if len(loi.Objects)+len(loi.Prefixes) == maxKeys {
+				loi.IsTruncated = true
+				loi.NextMarker = opts.encodeMarker(last)
+				return loi, nil
+			}
+			if obj.IsDir && obj.ModTime.IsZero() && delimiter != "" {
+				loi.Prefixes = append(loi.Prefixes, obj.Name)
+			} else {
+				loi.Objects = append(loi.Objects, obj)
+			}
+			last = obj.Name
+		}
+		if err == io.EOF {
+			return loi, nil
+		}
+		// Entries not present at asOf were skipped, keep listing.
+		marker = opts.encodeMarker(lastEntry)
+	}
+}
+
+// GetObjectInfoAsOf returns the version of object which was current at
+// asOf. ObjectNotFound is returned if the object did not exist, or was
+// deleted, at asOf. The versions are read from the object's set in every
+// pool, as decommissioning may leave versions of an object in two pools.
+func (z *erasureServerPools) GetObjectInfoAsOf(ctx context.Context, bucket, object string, asOf time.Time) (ObjectInfo, error) {
+	if err := checkGetObjArgs(ctx, bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+	objectName := encodeDirObject(object)
+
+	poolVersions := make([][]xlMetaV2ShallowVersion, 0, len(z.serverPools))
+	for _, pool := range z.serverPools {
+		versions, err := readAllXLVersions(ctx, pool.getHashedSet(objectName).getDisks(), bucket, objectName)
+		if err != nil {
+			if errors.Is(err, errFileNotFound) || errors.Is(err, errVolumeNotFound) {
+				continue
+			}
+			return ObjectInfo{}, toObjectErr(err, bucket, object)
+		}
+		poolVersions = append(poolVersions, versions)
+	}
+
+	xl := xlMetaV2{versions: mergeXLV2Versions(1, false, 0, poolVersions...)}
+	versions, err := xl.ListVersions(bucket, objectName)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+	fi, err := fileInfoVersionAsOf(versions, asOf)
+	if err != nil {
+		return ObjectInfo{}, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	return fi.ToObjectInfo(bucket, object, globalBucketVersioningSys.PrefixEnabled(bucket, object)), nil
+}
+
 func (z *erasureServerPools) ListMultipartUploads(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
 	if err := checkListMultipartArgs(ctx, bucket, prefix, keyMarker, uploadIDMarker, delimiter, z); err != nil {
 		return ListMultipartsInfo{}, err
@@ -1362,7 +1487,7 @@ func (z *erasureServerPools) NewMultipartUpload(ctx context.Context, bucket, obj
 
 	// any parallel writes on the object will block for this poolIdx
 	// to return since this holds a read lock on the namespace.
-	idx, err := z.getPoolIdx(ctx, bucket, object, -1)
+	idx, err := z.getPoolIdx(ctx, bucket, object, opts.UserDefined[xhttp.AmzStorageClass], -1)
 	if err != nil {
 		return "", err
 	}
@@ -1894,7 +2019,11 @@ func (z *erasureServerPools) Walk(ctx context.Context, bucket, prefix string, re
 // HealObjectFn closure function heals the object.
 type HealObjectFn func(bucket, object, versionID string) error
 
-func listAndHeal(ctx context.Context, bucket, prefix string, set *erasureObjects, healEntry func(metaCacheEntry) error) error {
+// healSetObjectFn closure function heals the object listed in the
+// erasure set identified by set, formatted as "pool/set".
+type healSetObjectFn func(set, bucket, object, versionID string) error
+
+func listAndHeal(ctx context.Context, bucket, prefix, forwardTo string, set *erasureObjects, healEntry func(metaCacheEntry) error) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -1923,7 +2052,7 @@ func listAndHeal(ctx context.Context, bucket, prefix string, set *erasureObjects
 		path:           path,
 		filterPrefix:   filterPrefix,
 		recursive:      true,
-		forwardTo:      "",
+		forwardTo:      forwardTo,
 		minDisks:       1,
 		reportNotFound: false,
 		agreed: func(entry metaCacheEntry) {
@@ -1957,7 +2086,17 @@ func listAndHeal(ctx context.Context, bucket, prefix string, set *erasureObjects
 }
 
 func (z *erasureServerPools) HealObjects(ctx context.Context, bucket, prefix string, opts madmin.HealOpts, healObjectFn HealObjectFn) error {
-	healEntry := func(entry metaCacheEntry) error {
+	return z.healObjectsFrom(ctx, bucket, prefix, nil, func(_, bucket, object, versionID string) error {
+		return healObjectFn(bucket, object, versionID)
+	})
+}
+
+// healObjectsFrom heals the objects under prefix like HealObjects, the
+// erasure sets being listed from the object name in markers for their
+// "pool/set" key onwards. Since every set is listed in lexical order, the
+// last object healed in each set is enough to resume an interrupted heal.
+func (z *erasureServerPools) healObjectsFrom(ctx context.Context, bucket, prefix string, markers map[string]string, healObjectFn healSetObjectFn) error {
+	healEntry := func(set string, entry metaCacheEntry) error {
 		if entry.isDir() {
 			return nil
 		}
@@ -1980,11 +2119,11 @@ func (z *erasureServerPools) HealObjects(ctx context.Context, bucket, prefix str
 		}
 		fivs, err := entry.fileInfoVersions(bucket)
 		if err != nil {
-			return healObjectFn(bucket, entry.name, "")
+			return healObjectFn(set, bucket, entry.name, "")
 		}
 
 		for _, version := range fivs.Versions {
-			err := healObjectFn(bucket, version.Name, version.VersionID)
+			err := healObjectFn(set, bucket, version.Name, version.VersionID)
 			if err != nil && !isErrObjectNotFound(err) && !isErrVersionNotFound(err) {
 				return err
 			}
@@ -1997,8 +2136,8 @@ func (z *erasureServerPools) HealObjects(ctx context.Context, bucket, prefix str
 	defer cancel()
 
 	var poolErrs [][]error
-	for idx, erasureSet := range z.serverPools {
-		if z.IsSuspended(idx) {
+	for poolIdx, erasureSet := range z.serverPools {
+		if z.IsSuspended(poolIdx) {
 			continue
 		}
 		errs := make([]error, len(erasureSet.sets))
@@ -2008,7 +2147,10 @@ func (z *erasureServerPools) HealObjects(ctx context.Context, bucket, prefix str
 			go func(idx int, set *erasureObjects) {
 				defer wg.Done()
 
-				errs[idx] = listAndHeal(ctx, bucket, prefix, set, healEntry)
+				setKey := fmt.Sprintf("%d/%d", poolIdx, idx)
+				errs[idx] = listAndHeal(ctx, bucket, prefix, markers[setKey], set, func(entry metaCacheEntry) error {
+					return healEntry(setKey, entry)
+				})
 			}(idx, set)
 		}
 		wg.Wait()
@@ -2049,6 +2191,7 @@ func (z *erasureServerPools) HealObject(ctx context.Context, bucket, object, ver
 	// Return the first nil error
 	for idx, err := range errs {
 		if err == nil {
+			globalUnrecoverableSys.healed(ctx, bucket, decodeDirObject(object), versionID)
 			return results[idx], nil
 		}
 	}
@@ -2060,7 +2203,16 @@ func (z *erasureServerPools) HealObject(ctx context.Context, bucket, object, ver
 		}
 	}
 
-	// At this stage, all errors are 'not found'
+	// At this stage, all errors are 'not found', keep the result of the
+	// pool which found a dangling or an unrecoverable object.
+	for _, result := range results {
+		if result.Detail != "" {
+			if versionID != "" {
+				return result, VersionNotFound{Bucket: bucket, Object: object, VersionID: versionID}
+			}
+			return result, ObjectNotFound{Bucket: bucket, Object: object}
+		}
+	}
 	if versionID != "" {
 		return madmin.HealResultItem{}, VersionNotFound{
 			Bucket:    bucket,
@@ -2159,6 +2311,13 @@ func (z *erasureServerPools) Health(ctx context.Context, opts HealthOptions) Hea
 	diskIDs := globalNotificationSys.GetLocalDiskIDs(ctx)
 	if !opts.Maintenance {
 		diskIDs = append(diskIDs, getLocalDiskIDs(z))
+	} else {
+		// Cordoned nodes are about to be taken down as well.
+		for idx, client := range globalNotificationSys.peerClients {
+			if client != nil && globalNodeCordonSys.isCordoned(client.host.String()) {
+				diskIDs[idx] = nil
+			}
+		}
 	}
 
 	for _, localDiskIDs := range diskIDs {
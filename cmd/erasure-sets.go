@@ -315,6 +315,13 @@ func (s *erasureSets) GetLockers(setIndex int) func() ([]dsync.NetLocker, string
 	return func() ([]dsync.NetLocker, string) {
 		lockers := make([]dsync.NetLocker, len(s.erasureLockers[setIndex]))
 		copy(lockers, s.erasureLockers[setIndex])
+		for i, locker := range lockers {
+			// Cordoned nodes are about to be taken down, they
+			// are left out like offline lockers.
+			if l, ok := locker.(*lockRESTClient); ok && globalNodeCordonSys.isCordoned(l.u.Host) {
+				lockers[i] = nil
+			}
+		}
 		return lockers, s.erasureLockOwner
 	}
 }
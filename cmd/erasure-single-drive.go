@@ -1172,6 +1172,9 @@ func (es *erasureSingle) putObject(ctx context.Context, bucket string, object st
 	}
 	if opts.UserDefined["etag"] == "" {
 		opts.UserDefined["etag"] = r.MD5CurrentHexString()
+		if opts.PreserveETag != "" {
+			opts.UserDefined["etag"] = opts.PreserveETag
+		}
 	}
 
 	// Guess content-type from the extension if possible.
@@ -2436,6 +2439,9 @@ func (es *erasureSingle) PutObjectPart(ctx context.Context, bucket, object, uplo
 	fi.ModTime = UTCNow()
 
 	md5hex := r.MD5CurrentHexString()
+	if opts.PreserveETag != "" {
+		md5hex = opts.PreserveETag
+	}
 
 	var index []byte
 	if opts.IndexCB != nil {
@@ -252,6 +252,10 @@ func (er *erasureObjects) healErasureSet(ctx context.Context, buckets []string,
 				}
 			}
 
+			// Slow down or pause during scheduled windows.
+			wait := healSleeper.Timer(ctx)
+			defer wait()
+
 			fivs, err := entry.fileInfoVersions(bucket)
 			if err != nil {
 				err := bgSeq.queueHealTask(healSource{
@@ -43,6 +43,8 @@ import (
 	xldap "github.com/GuinsooLab/annastore/internal/config/identity/ldap"
 	"github.com/GuinsooLab/annastore/internal/config/identity/openid"
 	idplugin "github.com/GuinsooLab/annastore/internal/config/identity/plugin"
+	idsaml "github.com/GuinsooLab/annastore/internal/config/identity/saml"
+	idscim "github.com/GuinsooLab/annastore/internal/config/identity/scim"
 	xtls "github.com/GuinsooLab/annastore/internal/config/identity/tls"
 	polplugin "github.com/GuinsooLab/annastore/internal/config/policy/plugin"
 	"github.com/GuinsooLab/annastore/internal/config/storageclass"
@@ -205,12 +207,44 @@ var (
 	// healthcheck readiness deadlines and cors settings.
 	globalAPIConfig = apiConfig{listQuorum: "strict"}
 
+	// globalRateLimiter enforces the per access key, group and bucket
+	// request rate limits.
+	globalRateLimiter = newRateLimiter()
+
+	// globalStorageClassStats counts the objects written per storage class.
+	globalStorageClassStats = newStorageClassStats()
+
+	// globalDriveHealthMonitor polls the SMART/NVMe health of the local drives.
+	globalDriveHealthMonitor = newDriveHealthMonitor()
+
+	// globalBackgroundSchedule applies the scheduled windows of the
+	// background tasks.
+	globalBackgroundSchedule = newBackgroundSchedule()
+
+	// globalDriveMaintenanceSys holds the drives under maintenance.
+	globalDriveMaintenanceSys = newDriveMaintenanceSys()
+
+	// globalNodeCordonSys holds the nodes cordoned for maintenance.
+	globalNodeCordonSys = newNodeCordonSys()
+
+	// globalUnrecoverableSys holds the unrecoverable object versions.
+	globalUnrecoverableSys = newUnrecoverableSys()
+
 	globalStorageClass storageclass.Config
 
-	globalLDAPConfig   xldap.Config
+	globalLDAPConfig   xldap.Configs
 	globalOpenIDConfig openid.Config
 	globalSTSTLSConfig xtls.Config
 
+	// SAML identity provider configuration.
+	globalSAMLConfig idsaml.Config
+
+	// Accepted SAML assertions, refused when exchanged again.
+	globalSAMLReplays = &samlReplayCache{}
+
+	// SCIM provisioning configuration.
+	globalSCIMConfig idscim.Config
+
 	globalAuthNPlugin *idplugin.AuthNPlugin
 
 	// CA root certificates, a nil value means system certs pool will be used
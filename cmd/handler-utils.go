@@ -29,12 +29,14 @@ import (
 	"net/textproto"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/GuinsooLab/annastore/internal/auth"
 	"github.com/GuinsooLab/annastore/internal/handlers"
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/minio/madmin-go"
+	"github.com/minio/pkg/bucket/policy"
 	xnet "github.com/minio/pkg/net"
 )
 
@@ -353,7 +355,7 @@ func collectAPIStats(api string, f http.HandlerFunc) http.HandlerFunc {
 
 		statsWriter := logger.NewResponseWriter(w)
 
-		f.ServeHTTP(statsWriter, r)
+		f.ServeHTTP(statsWriter, r.WithContext(context.WithValue(r.Context(), s3APIKey{}, api)))
 
 		globalHTTPStats.updateStats(api, r, statsWriter)
 	}
@@ -492,3 +494,52 @@ func proxyRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, e
 	f.ServeHTTP(w, r)
 	return
 }
+
+var errInvalidAsOf = errors.New("invalid as-of time, expected RFC3339 or HTTP date format")
+
+// getAsOf returns the point in time requested with the as-of query
+// parameter or the X-Minio-As-Of header, in RFC3339 or HTTP date format.
+// A zero time is returned if neither is present.
+func getAsOf(r *http.Request, bucket string) (time.Time, error) {
+	v := r.Form.Get(xhttp.AsOf)
+	if v == "" {
+		v = r.Header.Get(xhttp.MinIOAsOf)
+	}
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Time{}, InvalidArgument{Bucket: bucket, Err: errInvalidAsOf}
+}
+
+// resolveAsOfVersion sets opts.VersionID to the version of object which was
+// current at the time requested with getAsOf, unless a version id has been
+// requested explicitly.
+func resolveAsOfVersion(ctx context.Context, objectAPI ObjectLayer, r *http.Request, bucket, object string, opts *ObjectOptions) error {
+	asOf, err := getAsOf(r, bucket)
+	if err != nil || asOf.IsZero() || opts.VersionID != "" {
+		return err
+	}
+	// A point in time read may return a previous version.
+	if s3Err := checkRequestAuthType(ctx, r, policy.GetObjectVersionAction, bucket, object); s3Err != ErrNone {
+		return PrefixAccessDenied{Bucket: bucket, Object: object}
+	}
+	pit, ok := objectAPI.(pointInTimeLister)
+	if !ok {
+		return NotImplemented{}
+	}
+	oi, err := pit.GetObjectInfoAsOf(ctx, bucket, object, asOf)
+	if err != nil {
+		return err
+	}
+	opts.VersionID = oi.VersionID
+	if opts.VersionID == "" {
+		opts.VersionID = nullVersionID
+	}
+	return nil
+}
@@ -26,7 +26,15 @@ import (
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
 )
 
-const unavailable = "offline"
+const (
+	unavailable = "offline"
+	maintenance = "maintenance"
+)
+
+// isNodeCordoned returns true if this node is cordoned for maintenance.
+func isNodeCordoned() bool {
+	return globalIsDistErasure && globalNodeCordonSys.isCordoned(globalLocalNodeName)
+}
 
 func shouldProxy() bool {
 	return newObjectLayerFn() == nil
@@ -47,12 +55,18 @@ func ClusterCheckHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts := HealthOptions{Maintenance: r.Form.Get("maintenance") == "true"}
+	if !opts.Maintenance && isNodeCordoned() {
+		w.Header().Set(xhttp.MinIOServerStatus, maintenance)
+		writeResponse(w, http.StatusServiceUnavailable, nil, mimeNone)
+		return
+	}
+
 	objLayer := newObjectLayerFn()
 
 	ctx, cancel := context.WithTimeout(ctx, globalAPIConfig.getClusterDeadline())
 	defer cancel()
 
-	opts := HealthOptions{Maintenance: r.Form.Get("maintenance") == "true"}
 	result := objLayer.Health(ctx, opts)
 	if result.WriteQuorum > 0 {
 		w.Header().Set(xhttp.MinIOWriteQuorum, strconv.Itoa(result.WriteQuorum))
@@ -90,6 +104,12 @@ func ClusterReadCheckHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isNodeCordoned() {
+		w.Header().Set(xhttp.MinIOServerStatus, maintenance)
+		writeResponse(w, http.StatusServiceUnavailable, nil, mimeNone)
+		return
+	}
+
 	objLayer := newObjectLayerFn()
 
 	ctx, cancel := context.WithTimeout(ctx, globalAPIConfig.getClusterDeadline())
@@ -104,8 +124,15 @@ func ClusterReadCheckHandler(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, http.StatusOK, nil, mimeNone)
 }
 
-// ReadinessCheckHandler Checks if the process is up. Always returns success.
+// ReadinessCheckHandler Checks if the process is up and ready for requests.
+// A node cordoned for maintenance is not ready, so that load balancers stop
+// sending it new requests.
 func ReadinessCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if isNodeCordoned() {
+		w.Header().Set(xhttp.MinIOServerStatus, maintenance)
+		writeResponse(w, http.StatusServiceUnavailable, nil, mimeNone)
+		return
+	}
 	LivenessCheckHandler(w, r)
 }
 
@@ -114,6 +141,9 @@ func LivenessCheckHandler(w http.ResponseWriter, r *http.Request) {
 	if shouldProxy() {
 		// Service not initialized yet
 		w.Header().Set(xhttp.MinIOServerStatus, unavailable)
+	} else if isNodeCordoned() {
+		// The process is alive, only report it is being drained.
+		w.Header().Set(xhttp.MinIOServerStatus, maintenance)
 	}
 
 	if globalIsGateway {
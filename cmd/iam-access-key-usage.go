@@ -0,0 +1,366 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/auth"
+	"github.com/GuinsooLab/annastore/internal/handlers"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/madmin-go"
+)
+
+const (
+	// IAM file persisting the last use of the access keys of all nodes.
+	iamAccessKeyUsageFile = iamConfigPrefix + "/access-key-usage.json"
+
+	// Interval at which each node persists the access key usage it
+	// recorded.
+	accessKeyUsagePersistInterval = 5 * time.Minute
+
+	// A use of an access key from the same source IP and API within this
+	// duration of the last one is not recorded.
+	accessKeyUsageResolution = time.Minute
+)
+
+// AccessKeyUsage - last successful authentication of an access key.
+type AccessKeyUsage struct {
+	LastUsed time.Time `json:"lastUsed"`
+	SourceIP string    `json:"sourceIP,omitempty"`
+	API      string    `json:"api,omitempty"`
+}
+
+// accessKeyUsageFile is the persisted access key usage of all nodes.
+type accessKeyUsageFile struct {
+	Version int `json:"version"`
+	// Since is the time the usage of access keys started being recorded.
+	Since time.Time                 `json:"since"`
+	Usage map[string]AccessKeyUsage `json:"usage"`
+}
+
+// accessKeyUsageTracker records the last use of access keys in memory and
+// periodically merges them into the persisted usage of all nodes.
+type accessKeyUsageTracker struct {
+	mu    sync.RWMutex
+	since time.Time
+	// usage is the persisted usage merged with the local one.
+	usage map[string]AccessKeyUsage
+	// pending is the local usage recorded since the last persist.
+	pending map[string]AccessKeyUsage
+}
+
+func newAccessKeyUsageTracker() *accessKeyUsageTracker {
+	return &accessKeyUsageTracker{
+		usage:   make(map[string]AccessKeyUsage),
+		pending: make(map[string]AccessKeyUsage),
+	}
+}
+
+// record records a use of an access key.
+func (t *accessKeyUsageTracker) record(accessKey string, u AccessKeyUsage) {
+	t.mu.RLock()
+	last, ok := t.usage[accessKey]
+	t.mu.RUnlock()
+	if ok && last.SourceIP == u.SourceIP && last.API == u.API &&
+		u.LastUsed.Sub(last.LastUsed) < accessKeyUsageResolution {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.usage[accessKey]; ok && last.LastUsed.After(u.LastUsed) {
+		return
+	}
+	t.usage[accessKey] = u
+	t.pending[accessKey] = u
+}
+
+// get returns the last use of an access key.
+func (t *accessKeyUsageTracker) get(accessKey string) (AccessKeyUsage, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	u, ok := t.usage[accessKey]
+	return u, ok
+}
+
+// trackedSince returns the time since when the usage of access keys is
+// recorded, zero until it was loaded.
+func (t *accessKeyUsageTracker) trackedSince() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.since
+}
+
+// mergeAccessKeyUsage merges usage into into, keeping the latest use of each
+// access key.
+func mergeAccessKeyUsage(into, usage map[string]AccessKeyUsage) {
+	for accessKey, u := range usage {
+		if last, ok := into[accessKey]; !ok || u.LastUsed.After(last.LastUsed) {
+			into[accessKey] = u
+		}
+	}
+}
+
+// persist merges the usage recorded since the last persist into the
+// persisted usage of all nodes and saves it. The usage of access keys which
+// no longer exist is dropped.
+func (t *accessKeyUsageTracker) persist(ctx context.Context, store *IAMStoreSys, exists func(accessKey string) bool) error {
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[string]AccessKeyUsage)
+	t.mu.Unlock()
+
+	// Serialize the read-modify-write of the usage of all nodes, with a
+	// lock distinct from the one taken to write the usage file.
+	lk := objAPI.NewNSLock(minioMetaBucket, iamAccessKeyUsageFile+".lock")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		t.restorePending(pending)
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	var f accessKeyUsageFile
+	err = store.loadIAMConfig(ctx, &f, iamAccessKeyUsageFile)
+	notFound := errors.Is(err, errConfigNotFound)
+	if err != nil && !notFound {
+		t.restorePending(pending)
+		return err
+	}
+	if f.Usage == nil {
+		f = accessKeyUsageFile{
+			Version: 1,
+			Since:   UTCNow(),
+			Usage:   make(map[string]AccessKeyUsage),
+		}
+	}
+	mergeAccessKeyUsage(f.Usage, pending)
+	for accessKey := range f.Usage {
+		if !exists(accessKey) {
+			delete(f.Usage, accessKey)
+		}
+	}
+
+	if len(pending) > 0 || notFound {
+		if err = store.saveIAMConfig(ctx, &f, iamAccessKeyUsageFile); err != nil {
+			t.restorePending(pending)
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Keep the usage recorded while persisting.
+	mergeAccessKeyUsage(f.Usage, t.pending)
+	t.usage = f.Usage
+	t.since = f.Since
+	return nil
+}
+
+// restorePending restores the pending usage of a failed persist.
+func (t *accessKeyUsageTracker) restorePending(pending map[string]AccessKeyUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	mergeAccessKeyUsage(t.pending, pending)
+}
+
+// s3APIKey is the context key of the S3 API name of a request.
+type s3APIKey struct{}
+
+// accessKeyUsageAPI returns the API of a request recorded as the last use of
+// an access key.
+func accessKeyUsageAPI(r *http.Request) string {
+	if api, ok := r.Context().Value(s3APIKey{}).(string); ok {
+		return "s3:" + api
+	}
+	if strings.HasPrefix(r.URL.Path, adminPathPrefix+SlashSeparator) {
+		api := strings.TrimPrefix(r.URL.Path, adminPathPrefix+SlashSeparator)
+		if i := strings.Index(api, SlashSeparator); i >= 0 {
+			// Skip the API version.
+			api = api[i+1:]
+		}
+		return "admin:" + api
+	}
+	if r.Method == http.MethodPost && r.URL.Path == SlashSeparator {
+		// Only AssumeRole is signed with the access key of a user.
+		return "sts:" + assumeRole
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// recordAccessKeyUsage - records a successful authentication of a user,
+// service account or the root user with their access key. Temporary
+// credentials are not recorded.
+func (sys *IAMSys) recordAccessKeyUsage(r *http.Request, cred auth.Credentials) {
+	if sys == nil || sys.accessKeyUsage == nil || cred.AccessKey == "" || cred.IsTemp() {
+		return
+	}
+	sys.accessKeyUsage.record(cred.AccessKey, AccessKeyUsage{
+		LastUsed: UTCNow(),
+		SourceIP: handlers.GetSourceIP(r),
+		API:      accessKeyUsageAPI(r),
+	})
+}
+
+// GetAccessKeyUsage - returns the last use of an access key.
+func (sys *IAMSys) GetAccessKeyUsage(accessKey string) (AccessKeyUsage, bool) {
+	if sys.accessKeyUsage == nil {
+		return AccessKeyUsage{}, false
+	}
+	return sys.accessKeyUsage.get(accessKey)
+}
+
+// persistAccessKeyUsage - periodically persists the recorded access key usage
+// until ctx is done.
+func (sys *IAMSys) persistAccessKeyUsage(ctx context.Context) {
+	exists := func(accessKey string) bool {
+		if accessKey == globalActiveCred.AccessKey {
+			return true
+		}
+		_, ok := sys.store.GetUser(accessKey)
+		return ok
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	// Load the persisted usage right away, and then spread the persists of
+	// the nodes over the interval.
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if err := sys.accessKeyUsage.persist(ctx, sys.store, exists); err != nil {
+				logger.LogIf(ctx, err)
+			}
+			timer.Reset(accessKeyUsagePersistInterval/2 + time.Duration(r.Int63n(int64(accessKeyUsagePersistInterval))))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// userInfoWithUsage - the info of a user listed by list-users, with the last
+// use of their access key.
+type userInfoWithUsage struct {
+	madmin.UserInfo
+	LastUsed *AccessKeyUsage `json:"lastUsed,omitempty"`
+}
+
+// withAccessKeyUsage - adds the last use of the access keys to users.
+func withAccessKeyUsage(users map[string]madmin.UserInfo) map[string]userInfoWithUsage {
+	res := make(map[string]userInfoWithUsage, len(users))
+	for accessKey, info := range users {
+		u := userInfoWithUsage{UserInfo: info}
+		if usage, ok := globalIAMSys.GetAccessKeyUsage(accessKey); ok {
+			u.LastUsed = &usage
+		}
+		res[accessKey] = u
+	}
+	return res
+}
+
+// listServiceAccountsResp - the service accounts listed by
+// list-service-accounts, with the last use of their access keys.
+type listServiceAccountsResp struct {
+	madmin.ListServiceAccountsResp
+	LastUsed map[string]AccessKeyUsage `json:"lastUsed,omitempty"`
+}
+
+// staleCredential - a user or service account unused for a number of days.
+type staleCredential struct {
+	AccessKey  string          `json:"accessKey"`
+	Type       string          `json:"type"`
+	ParentUser string          `json:"parentUser,omitempty"`
+	Status     string          `json:"status"`
+	UpdatedAt  time.Time       `json:"updatedAt,omitempty"`
+	LastUsed   *AccessKeyUsage `json:"lastUsed,omitempty"`
+}
+
+// staleCredentialsReport - the users and service accounts unused for a
+// number of days.
+type staleCredentialsReport struct {
+	Days int       `json:"days"`
+	Now  time.Time `json:"now"`
+	// TrackedSince is the time since when access key usage is recorded,
+	// credentials never used may have been used before.
+	TrackedSince time.Time         `json:"trackedSince"`
+	Credentials  []staleCredential `json:"credentials"`
+}
+
+// GetStaleCredentials - returns the users and service accounts which were not
+// used in the last days and not updated since, the latter ones being never
+// used.
+func (sys *IAMSys) GetStaleCredentials(ctx context.Context, days int) (staleCredentialsReport, error) {
+	if !sys.Initialized() {
+		return staleCredentialsReport{}, errServerNotInitialized
+	}
+
+	select {
+	case <-sys.configLoaded:
+	case <-ctx.Done():
+		return staleCredentialsReport{}, ctx.Err()
+	}
+
+	now := UTCNow()
+	cutoff := now.Add(-time.Duration(days) * 24 * time.Hour)
+	report := staleCredentialsReport{
+		Days:         days,
+		Now:          now,
+		TrackedSince: sys.accessKeyUsage.trackedSince(),
+		Credentials:  []staleCredential{},
+	}
+	for _, u := range sys.store.GetUsersAndServiceAccounts() {
+		c := staleCredential{
+			AccessKey:  u.Credentials.AccessKey,
+			Type:       "user",
+			ParentUser: u.Credentials.ParentUser,
+			Status:     u.Credentials.Status,
+			UpdatedAt:  u.UpdatedAt,
+		}
+		if u.Credentials.IsServiceAccount() {
+			c.Type = "serviceAccount"
+		}
+		if usage, ok := sys.GetAccessKeyUsage(c.AccessKey); ok {
+			if usage.LastUsed.After(cutoff) {
+				continue
+			}
+			c.LastUsed = &usage
+		} else if u.UpdatedAt.After(cutoff) {
+			continue
+		}
+		report.Credentials = append(report.Credentials, c)
+	}
+	sort.Slice(report.Credentials, func(i, j int) bool {
+		return report.Credentials[i].AccessKey < report.Credentials[j].AccessKey
+	})
+	return report, nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAccessKeyUsageTrackerRecord(t *testing.T) {
+	tracker := newAccessKeyUsageTracker()
+	now := time.Now().UTC()
+
+	tracker.record("alice", AccessKeyUsage{LastUsed: now, SourceIP: "10.0.0.1", API: "s3:getobject"})
+	// Same source and API within the resolution is not recorded again.
+	tracker.record("alice", AccessKeyUsage{LastUsed: now.Add(time.Second), SourceIP: "10.0.0.1", API: "s3:getobject"})
+	if u, _ := tracker.get("alice"); !u.LastUsed.Equal(now) {
+		t.Fatalf("expected last use at %v, got %v", now, u.LastUsed)
+	}
+
+	// A different API is recorded.
+	tracker.record("alice", AccessKeyUsage{LastUsed: now.Add(2 * time.Second), SourceIP: "10.0.0.1", API: "s3:putobject"})
+	if u, _ := tracker.get("alice"); u.API != "s3:putobject" {
+		t.Fatalf("expected s3:putobject, got %s", u.API)
+	}
+
+	// An older use is ignored.
+	tracker.record("alice", AccessKeyUsage{LastUsed: now.Add(-time.Hour), SourceIP: "10.0.0.2", API: "s3:listobjectsv2"})
+	if u, _ := tracker.get("alice"); u.SourceIP != "10.0.0.1" {
+		t.Fatalf("expected 10.0.0.1, got %s", u.SourceIP)
+	}
+
+	if len(tracker.pending) != 1 {
+		t.Fatalf("expected one pending access key, got %d", len(tracker.pending))
+	}
+}
+
+func TestMergeAccessKeyUsage(t *testing.T) {
+	now := time.Now().UTC()
+	into := map[string]AccessKeyUsage{
+		"alice": {LastUsed: now, SourceIP: "10.0.0.1"},
+		"bob":   {LastUsed: now, SourceIP: "10.0.0.1"},
+	}
+	mergeAccessKeyUsage(into, map[string]AccessKeyUsage{
+		"alice": {LastUsed: now.Add(-time.Minute), SourceIP: "10.0.0.2"},
+		"bob":   {LastUsed: now.Add(time.Minute), SourceIP: "10.0.0.2"},
+		"carol": {LastUsed: now, SourceIP: "10.0.0.2"},
+	})
+	for accessKey, sourceIP := range map[string]string{"alice": "10.0.0.1", "bob": "10.0.0.2", "carol": "10.0.0.2"} {
+		if into[accessKey].SourceIP != sourceIP {
+			t.Errorf("%s: expected %s, got %s", accessKey, sourceIP, into[accessKey].SourceIP)
+		}
+	}
+}
+
+func TestAccessKeyUsageAPI(t *testing.T) {
+	testCases := []struct {
+		method, target string
+		s3API          string
+		expected       string
+	}{
+		{method: http.MethodGet, target: "/bucket/object", s3API: "getobject", expected: "s3:getobject"},
+		{method: http.MethodGet, target: adminPathPrefix + adminAPIVersionPrefix + "/list-users", expected: "admin:list-users"},
+		{method: http.MethodPost, target: "/", expected: "sts:AssumeRole"},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(tc.method, tc.target, nil)
+		if tc.s3API != "" {
+			r = r.WithContext(context.WithValue(r.Context(), s3APIKey{}, tc.s3API))
+		}
+		if api := accessKeyUsageAPI(r); api != tc.expected {
+			t.Errorf("case %d: expected %s, got %s", i+1, tc.expected, api)
+		}
+	}
+}
@@ -330,7 +330,8 @@ func (iamOS *IAMObjectStore) listAllIAMConfigItems(ctx context.Context) (map[str
 			}
 		}
 
-		if !found && (item.Item != "format.json") {
+		if !found && item.Item != "format.json" && item.Item != "access-key-usage.json" &&
+			item.Item != "sts-revocations.json" {
 			logger.LogIf(ctx, fmt.Errorf("unknown type of IAM file listed: %v", item.Item))
 		}
 	}
@@ -20,7 +20,6 @@ package cmd
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -153,9 +152,10 @@ func getMappedPolicyPath(name string, userType IAMUserType, isGroup bool) string
 
 // UserIdentity represents a user's secret key and their status
 type UserIdentity struct {
-	Version     int              `json:"version"`
-	Credentials auth.Credentials `json:"credentials"`
-	UpdatedAt   time.Time        `json:"updatedAt,omitempty"`
+	Version     int               `json:"version"`
+	Credentials auth.Credentials  `json:"credentials"`
+	UpdatedAt   time.Time         `json:"updatedAt,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
 }
 
 func newUserIdentity(cred auth.Credentials) UserIdentity {
@@ -164,10 +164,11 @@ func newUserIdentity(cred auth.Credentials) UserIdentity {
 
 // GroupInfo contains info about a group
 type GroupInfo struct {
-	Version   int       `json:"version"`
-	Status    string    `json:"status"`
-	Members   []string  `json:"members"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	Version   int               `json:"version"`
+	Status    string            `json:"status"`
+	Members   []string          `json:"members"`
+	UpdatedAt time.Time         `json:"updatedAt,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
 }
 
 func newGroupInfo(members []string) GroupInfo {
@@ -207,26 +208,41 @@ type PolicyDoc struct {
 	Policy     iampolicy.Policy
 	CreateDate time.Time `json:",omitempty"`
 	UpdateDate time.Time `json:",omitempty"`
+
+	// principalTags substitutes the principal tag variables of Policy,
+	// nil if it has none.
+	principalTags *principalTagPolicy
 }
 
 func newPolicyDoc(p iampolicy.Policy) PolicyDoc {
 	now := UTCNow().Round(time.Millisecond)
 	return PolicyDoc{
-		Version:    1,
-		Policy:     p,
-		CreateDate: now,
-		UpdateDate: now,
+		Version:       1,
+		Policy:        p,
+		CreateDate:    now,
+		UpdateDate:    now,
+		principalTags: newPrincipalTagPolicy(p),
 	}
 }
 
 // defaultPolicyDoc - used to wrap a default policy as PolicyDoc.
 func defaultPolicyDoc(p iampolicy.Policy) PolicyDoc {
 	return PolicyDoc{
-		Version: 1,
-		Policy:  p,
+		Version:       1,
+		Policy:        p,
+		principalTags: newPrincipalTagPolicy(p),
 	}
 }
 
+// principalPolicy returns the policy with the principal tag variables
+// substituted from the condition values of a request.
+func (d PolicyDoc) principalPolicy(values map[string][]string) iampolicy.Policy {
+	if d.principalTags == nil || values == nil {
+		return d.Policy
+	}
+	return d.principalTags.substitute(d.Policy, values)
+}
+
 func (d *PolicyDoc) update(p iampolicy.Policy) {
 	now := UTCNow().Round(time.Millisecond)
 	d.UpdateDate = now
@@ -234,6 +250,14 @@ func (d *PolicyDoc) update(p iampolicy.Policy) {
 		d.CreateDate = now
 	}
 	d.Policy = p
+	d.principalTags = newPrincipalTagPolicy(p)
+}
+
+// MarshalJSON encodes the policy document with the tag condition keys of
+// its policy as written by users, parseJSON encodes them again.
+func (d PolicyDoc) MarshalJSON() ([]byte, error) {
+	type policyDoc PolicyDoc
+	return marshalIAMPolicy(policyDoc(d))
 }
 
 // parseJSON parses both the old and the new format for storing policy
@@ -244,6 +268,7 @@ func (d *PolicyDoc) update(p iampolicy.Policy) {
 // both the old and the new formats.
 func (d *PolicyDoc) parseJSON(data []byte) error {
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	data = encodeTagConditionKeys(data)
 	var doc PolicyDoc
 	err := json.Unmarshal(data, &doc)
 	if err != nil {
@@ -253,9 +278,11 @@ func (d *PolicyDoc) parseJSON(data []byte) error {
 			return err
 		}
 		d.Policy = doc.Policy
+		d.principalTags = newPrincipalTagPolicy(d.Policy)
 		return nil
 	}
 	*d = doc
+	d.principalTags = newPrincipalTagPolicy(d.Policy)
 	return nil
 }
 
@@ -792,6 +819,72 @@ func (store *IAMStoreSys) SetGroupStatus(ctx context.Context, group string, enab
 	return gi.UpdatedAt, nil
 }
 
+// SetGroupTags - sets the tags of a group, whose members inherit them.
+func (store *IAMStoreSys) SetGroupTags(ctx context.Context, group string, tags map[string]string) (updatedAt time.Time, err error) {
+	if group == "" {
+		return updatedAt, errInvalidArgument
+	}
+
+	cache := store.lock()
+	defer store.unlock()
+
+	gi, ok := cache.iamGroupsMap[group]
+	if !ok {
+		return updatedAt, errNoSuchGroup
+	}
+
+	gi.Tags = tags
+	gi.UpdatedAt = UTCNow()
+	if err := store.saveGroupInfo(ctx, group, gi); err != nil {
+		return gi.UpdatedAt, err
+	}
+
+	cache.iamGroupsMap[group] = gi
+	cache.updatedAt = time.Now()
+
+	return gi.UpdatedAt, nil
+}
+
+// GetGroupTags - returns the tags of a group.
+func (store *IAMStoreSys) GetGroupTags(group string) (map[string]string, error) {
+	cache := store.rlock()
+	defer store.runlock()
+
+	gi, ok := cache.iamGroupsMap[group]
+	if !ok {
+		return nil, errNoSuchGroup
+	}
+	return gi.Tags, nil
+}
+
+// GetPrincipalTags - returns the tags of the groups of a user, overridden
+// by the tags of the user.
+func (store *IAMStoreSys) GetPrincipalTags(user string, groups []string) map[string]string {
+	cache := store.rlock()
+	defer store.runlock()
+
+	names := set.CreateStringSet(groups...)
+	if memberships, ok := cache.iamUserGroupMemberships[user]; ok {
+		names = names.Union(memberships)
+	}
+
+	tags := make(map[string]string)
+	// Groups are applied in a stable order for conflicting tags.
+	for _, group := range names.ToSlice() {
+		if gi, ok := cache.iamGroupsMap[group]; ok && gi.Status != statusDisabled {
+			for k, v := range gi.Tags {
+				tags[k] = v
+			}
+		}
+	}
+	if ui, ok := cache.iamUsersMap[user]; ok {
+		for k, v := range ui.Tags {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
 // GetGroupDescription - builds up group description
 func (store *IAMStoreSys) GetGroupDescription(group string) (gd madmin.GroupDesc, err error) {
 	cache := store.rlock()
@@ -1031,6 +1124,12 @@ func (store *IAMStoreSys) DeletePolicy(ctx context.Context, policy string) error
 // GetPolicy - gets the policy definition. Allows specifying multiple comma
 // separated policies - returns a combined policy.
 func (store *IAMStoreSys) GetPolicy(name string) (iampolicy.Policy, error) {
+	return store.GetPrincipalPolicy(name, nil)
+}
+
+// GetPrincipalPolicy is like GetPolicy, with the principal tag variables of
+// the policies substituted from the condition values of a request.
+func (store *IAMStoreSys) GetPrincipalPolicy(name string, values map[string][]string) (iampolicy.Policy, error) {
 	if name == "" {
 		return iampolicy.Policy{}, errInvalidArgument
 	}
@@ -1048,7 +1147,7 @@ func (store *IAMStoreSys) GetPolicy(name string) (iampolicy.Policy, error) {
 		if !ok {
 			return v.Policy, errNoSuchPolicy
 		}
-		combinedPolicy = combinedPolicy.Merge(v.Policy)
+		combinedPolicy = combinedPolicy.Merge(v.principalPolicy(values))
 	}
 	return combinedPolicy, nil
 }
@@ -1157,7 +1256,7 @@ func (store *IAMStoreSys) ListPolicyDocs(ctx context.Context, bucketName string)
 }
 
 // helper function - does not take locks.
-func filterPolicies(cache *iamCache, policyName string, bucketName string) (string, iampolicy.Policy) {
+func filterPolicies(cache *iamCache, policyName string, bucketName string, values map[string][]string) (string, iampolicy.Policy) {
 	var policies []string
 	mp := newMappedPolicy(policyName)
 	combinedPolicy := iampolicy.Policy{}
@@ -1171,7 +1270,7 @@ func filterPolicies(cache *iamCache, policyName string, bucketName string) (stri
 		}
 		if bucketName == "" || p.Policy.MatchResource(bucketName) {
 			policies = append(policies, policy)
-			combinedPolicy = combinedPolicy.Merge(p.Policy)
+			combinedPolicy = combinedPolicy.Merge(p.principalPolicy(values))
 		}
 	}
 	return strings.Join(policies, ","), combinedPolicy
@@ -1186,7 +1285,17 @@ func (store *IAMStoreSys) FilterPolicies(policyName string, bucketName string) (
 	cache := store.rlock()
 	defer store.runlock()
 
-	return filterPolicies(cache, policyName, bucketName)
+	return filterPolicies(cache, policyName, bucketName, nil)
+}
+
+// FilterPrincipalPolicies is like FilterPolicies without a bucket, with the
+// principal tag variables of the policies substituted from the condition
+// values of a request.
+func (store *IAMStoreSys) FilterPrincipalPolicies(policyName string, values map[string][]string) (string, iampolicy.Policy) {
+	cache := store.rlock()
+	defer store.runlock()
+
+	return filterPolicies(cache, policyName, "", values)
 }
 
 // GetBucketUsers - returns users (not STS or service accounts) that have access
@@ -1216,7 +1325,7 @@ func (store *IAMStoreSys) GetBucketUsers(bucket string) (map[string]madmin.UserI
 				}
 			}
 		}
-		matchedPolicies, _ := filterPolicies(cache, strings.Join(policies, ","), bucket)
+		matchedPolicies, _ := filterPolicies(cache, strings.Join(policies, ","), bucket, nil)
 		if len(matchedPolicies) > 0 {
 			result[k] = madmin.UserInfo{
 				PolicyName: matchedPolicies,
@@ -1504,7 +1613,7 @@ func (store *IAMStoreSys) SetTempUser(ctx context.Context, accessKey string, cre
 
 	if policyName != "" {
 		mp := newMappedPolicy(policyName)
-		_, combinedPolicyStmt := filterPolicies(cache, mp.Policies, "")
+		_, combinedPolicyStmt := filterPolicies(cache, mp.Policies, "", nil)
 
 		if combinedPolicyStmt.IsEmpty() {
 			return time.Time{}, fmt.Errorf("specified policy %s, not found %w", policyName, errNoSuchPolicy)
@@ -1674,6 +1783,7 @@ func (store *IAMStoreSys) SetUserStatus(ctx context.Context, accessKey string, s
 			return auth.AccountOff
 		}(),
 	})
+	uinfo.Tags = ui.Tags
 
 	if err := store.saveUserIdentity(ctx, accessKey, regUser, uinfo); err != nil {
 		return updatedAt, err
@@ -1685,6 +1795,36 @@ func (store *IAMStoreSys) SetUserStatus(ctx context.Context, accessKey string, s
 	return uinfo.UpdatedAt, nil
 }
 
+// SetUserTags - sets the tags of a regular user or a service account.
+func (store *IAMStoreSys) SetUserTags(ctx context.Context, accessKey string, tags map[string]string) (updatedAt time.Time, err error) {
+	cache := store.lock()
+	defer store.unlock()
+
+	ui, ok := cache.iamUsersMap[accessKey]
+	if !ok {
+		return updatedAt, errNoSuchUser
+	}
+
+	userType := regUser
+	switch {
+	case ui.Credentials.IsTemp():
+		return updatedAt, errIAMActionNotAllowed
+	case ui.Credentials.IsServiceAccount():
+		userType = svcUser
+	}
+
+	ui.Tags = tags
+	ui.UpdatedAt = UTCNow()
+	if err := store.saveUserIdentity(ctx, accessKey, userType, ui); err != nil {
+		return updatedAt, err
+	}
+
+	cache.iamUsersMap[accessKey] = ui
+	cache.updatedAt = time.Now()
+
+	return ui.UpdatedAt, nil
+}
+
 // AddServiceAccount - add a new service account
 func (store *IAMStoreSys) AddServiceAccount(ctx context.Context, cred auth.Credentials) (updatedAt time.Time, err error) {
 	cache := store.lock()
@@ -1770,11 +1910,11 @@ func (store *IAMStoreSys) UpdateServiceAccount(ctx context.Context, accessKey st
 	}
 
 	if opts.sessionPolicy != nil {
-		if err := opts.sessionPolicy.Validate(); err != nil {
+		if err := validateIAMPolicy(*opts.sessionPolicy); err != nil {
 			return updatedAt, err
 		}
 
-		policyBuf, err := json.Marshal(opts.sessionPolicy)
+		policyBuf, err := marshalIAMPolicy(opts.sessionPolicy)
 		if err != nil {
 			return updatedAt, err
 		}
@@ -1794,6 +1934,7 @@ func (store *IAMStoreSys) UpdateServiceAccount(ctx context.Context, accessKey st
 	}
 
 	u := newUserIdentity(cr)
+	u.Tags = ui.Tags
 	if err := store.saveUserIdentity(ctx, u.Credentials.AccessKey, svcUser, u); err != nil {
 		return updatedAt, err
 	}
@@ -1896,6 +2037,9 @@ func (store *IAMStoreSys) AddUser(ctx context.Context, accessKey string, ureq ma
 			return auth.AccountOff
 		}(),
 	})
+	if ok {
+		u.Tags = ui.Tags
+	}
 
 	if err := store.saveUserIdentity(ctx, accessKey, regUser, u); err != nil {
 		return updatedAt, err
@@ -1920,6 +2064,7 @@ func (store *IAMStoreSys) UpdateUserSecretKey(ctx context.Context, accessKey, se
 	cred := ui.Credentials
 	cred.SecretKey = secretKey
 	u := newUserIdentity(cred)
+	u.Tags = ui.Tags
 	if err := store.saveUserIdentity(ctx, accessKey, regUser, u); err != nil {
 		return err
 	}
@@ -1943,6 +2088,43 @@ func (store *IAMStoreSys) GetSTSAndServiceAccounts() []auth.Credentials {
 	return res
 }
 
+// GetUsersAndServiceAccounts - returns the identities of all regular users and
+// service accounts.
+func (store *IAMStoreSys) GetUsersAndServiceAccounts() []UserIdentity {
+	cache := store.rlock()
+	defer store.runlock()
+
+	var res []UserIdentity
+	for _, u := range cache.iamUsersMap {
+		if !u.Credentials.IsTemp() {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// GetTempUsers - returns the identities of all temporary credentials.
+func (store *IAMStoreSys) GetTempUsers() []UserIdentity {
+	cache := store.rlock()
+	defer store.runlock()
+
+	var res []UserIdentity
+	for _, u := range cache.iamUsersMap {
+		if u.Credentials.IsTemp() {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// GetUserGroups - returns the groups a user is a member of.
+func (store *IAMStoreSys) GetUserGroups(name string) []string {
+	cache := store.rlock()
+	defer store.runlock()
+
+	return cache.iamUserGroupMemberships[name].ToSlice()
+}
+
 // UpdateUserIdentity - updates a user credential.
 func (store *IAMStoreSys) UpdateUserIdentity(ctx context.Context, cred auth.Credentials) error {
 	cache := store.lock()
@@ -1957,6 +2139,9 @@ func (store *IAMStoreSys) UpdateUserIdentity(ctx context.Context, cred auth.Cred
 		userType = stsUser
 	}
 	ui := newUserIdentity(cred)
+	if u, ok := cache.iamUsersMap[cred.AccessKey]; ok {
+		ui.Tags = u.Tags
+	}
 	// Overwrite the user identity here. As store should be
 	// atomic, it shouldn't cause any corruption.
 	if err := store.saveUserIdentity(ctx, cred.AccessKey, userType, ui); err != nil {
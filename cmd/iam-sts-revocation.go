@@ -0,0 +1,281 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+)
+
+// IAM file persisting the revoked temporary credentials.
+const iamSTSRevocationsFile = iamConfigPrefix + "/sts-revocations.json"
+
+// revokedSTSSession - a revoked temporary credential, kept until it
+// expires.
+type revokedSTSSession struct {
+	AccessKey  string    `json:"accessKey"`
+	ParentUser string    `json:"parentUser,omitempty"`
+	RoleARN    string    `json:"roleArn,omitempty"`
+	IssuedAt   time.Time `json:"issuedAt,omitempty"`
+	Expiration time.Time `json:"expiration"`
+	RevokedAt  time.Time `json:"revokedAt,omitempty"`
+}
+
+// stsRevocationsFile is the persisted revoked temporary credentials.
+type stsRevocationsFile struct {
+	Version  int                          `json:"version"`
+	Sessions map[string]revokedSTSSession `json:"sessions"`
+}
+
+// stsRevocationFilter - selects the temporary credentials to revoke, all
+// of the non-empty fields must match.
+type stsRevocationFilter struct {
+	AccessKey    string    `json:"accessKey,omitempty"`
+	ParentUser   string    `json:"parentUser,omitempty"`
+	RoleARN      string    `json:"roleArn,omitempty"`
+	IssuedBefore time.Time `json:"issuedBefore,omitempty"`
+}
+
+// isEmpty returns whether the filter selects all temporary credentials.
+func (f stsRevocationFilter) isEmpty() bool {
+	return f.AccessKey == "" && f.ParentUser == "" && f.RoleARN == "" && f.IssuedBefore.IsZero()
+}
+
+// matches returns whether the filter selects a session.
+func (f stsRevocationFilter) matches(s revokedSTSSession) bool {
+	return (f.AccessKey == "" || f.AccessKey == s.AccessKey) &&
+		(f.ParentUser == "" || f.ParentUser == s.ParentUser) &&
+		(f.RoleARN == "" || f.RoleARN == s.RoleARN) &&
+		(f.IssuedBefore.IsZero() || s.IssuedAt.Before(f.IssuedBefore))
+}
+
+// stsSessionOf returns the session of temporary credentials.
+func stsSessionOf(u UserIdentity) revokedSTSSession {
+	s := revokedSTSSession{
+		AccessKey:  u.Credentials.AccessKey,
+		ParentUser: u.Credentials.ParentUser,
+		IssuedAt:   u.UpdatedAt,
+		Expiration: u.Credentials.Expiration,
+	}
+	if claims, err := getClaimsFromTokenWithSecret(u.Credentials.SessionToken, globalActiveCred.SecretKey); err == nil {
+		if roleArn, ok := claims[roleArnClaim].(string); ok {
+			s.RoleARN = roleArn
+		}
+	}
+	return s
+}
+
+// purgeExpiredSTSRevocations removes the sessions expired at now, which
+// cannot be used anymore.
+func purgeExpiredSTSRevocations(sessions map[string]revokedSTSSession, now time.Time) {
+	for accessKey, s := range sessions {
+		if !s.Expiration.After(now) {
+			delete(sessions, accessKey)
+		}
+	}
+}
+
+// stsRevocations - the revoked temporary credentials loaded from the IAM
+// store.
+type stsRevocations struct {
+	mu       sync.RWMutex
+	sessions map[string]revokedSTSSession
+}
+
+func newSTSRevocations() *stsRevocations {
+	return &stsRevocations{
+		sessions: make(map[string]revokedSTSSession),
+	}
+}
+
+// set replaces the revoked sessions.
+func (r *stsRevocations) set(sessions map[string]revokedSTSSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = sessions
+}
+
+// isRevoked returns whether the temporary credentials of an access key are
+// revoked.
+func (r *stsRevocations) isRevoked(accessKey string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.sessions[accessKey]
+	return ok
+}
+
+// list returns the revoked sessions not yet expired, sorted by access key.
+func (r *stsRevocations) list() []revokedSTSSession {
+	now := UTCNow()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]revokedSTSSession, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		if s.Expiration.After(now) {
+			res = append(res, s)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].AccessKey < res[j].AccessKey
+	})
+	return res
+}
+
+// loadSTSRevocationsFile loads the revoked sessions from the IAM store,
+// without the expired ones.
+func loadSTSRevocationsFile(ctx context.Context, store *IAMStoreSys) (map[string]revokedSTSSession, error) {
+	var f stsRevocationsFile
+	err := store.loadIAMConfig(ctx, &f, iamSTSRevocationsFile)
+	if err != nil && !errors.Is(err, errConfigNotFound) {
+		return nil, err
+	}
+	if f.Sessions == nil {
+		f.Sessions = make(map[string]revokedSTSSession)
+	}
+	purgeExpiredSTSRevocations(f.Sessions, UTCNow())
+	return f.Sessions, nil
+}
+
+// loadSTSRevocations - loads the revoked temporary credentials.
+func (sys *IAMSys) loadSTSRevocations(ctx context.Context) error {
+	sessions, err := loadSTSRevocationsFile(ctx, sys.store)
+	if err != nil {
+		return err
+	}
+	sys.stsRevocations.set(sessions)
+	return nil
+}
+
+// LoadSTSRevocations - reloads the revoked temporary credentials, on a
+// notification from the peer which revoked them.
+func (sys *IAMSys) LoadSTSRevocations(ctx context.Context) error {
+	if !sys.Initialized() {
+		return errServerNotInitialized
+	}
+	return sys.loadSTSRevocations(ctx)
+}
+
+// IsSTSSessionRevoked - returns whether temporary credentials are revoked.
+func (sys *IAMSys) IsSTSSessionRevoked(accessKey string) bool {
+	if sys == nil || sys.stsRevocations == nil {
+		return false
+	}
+	return sys.stsRevocations.isRevoked(accessKey)
+}
+
+// ListRevokedSTSSessions - returns the revoked temporary credentials which
+// have not expired yet.
+func (sys *IAMSys) ListRevokedSTSSessions(ctx context.Context) ([]revokedSTSSession, error) {
+	if !sys.Initialized() {
+		return nil, errServerNotInitialized
+	}
+
+	select {
+	case <-sys.configLoaded:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return sys.stsRevocations.list(), nil
+}
+
+// RevokeSTSSessions - revokes the temporary credentials selected by a
+// filter until they expire, and notifies the peers. The revoked sessions
+// are removed from the revocations once expired.
+func (sys *IAMSys) RevokeSTSSessions(ctx context.Context, f stsRevocationFilter) ([]revokedSTSSession, error) {
+	if !sys.Initialized() {
+		return nil, errServerNotInitialized
+	}
+
+	select {
+	case <-sys.configLoaded:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if f.isEmpty() {
+		return nil, errInvalidArgument
+	}
+
+	now := UTCNow()
+	revoked := []revokedSTSSession{}
+	for _, u := range sys.store.GetTempUsers() {
+		if !u.Credentials.Expiration.After(now) {
+			continue
+		}
+		s := stsSessionOf(u)
+		if !f.matches(s) {
+			continue
+		}
+		s.RevokedAt = now
+		revoked = append(revoked, s)
+	}
+	if len(revoked) == 0 {
+		return revoked, nil
+	}
+
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return nil, errServerNotInitialized
+	}
+	// Serialize the updates of the revocations of all nodes, with a lock
+	// distinct from the one taken to write the revocations file.
+	lk := objAPI.NewNSLock(minioMetaBucket, iamSTSRevocationsFile+".lock")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return nil, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	sessions, err := loadSTSRevocationsFile(ctx, sys.store)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range revoked {
+		if _, ok := sessions[s.AccessKey]; !ok {
+			sessions[s.AccessKey] = s
+		}
+	}
+	if err = sys.store.saveIAMConfig(ctx, &stsRevocationsFile{
+		Version:  1,
+		Sessions: sessions,
+	}, iamSTSRevocationsFile); err != nil {
+		return nil, err
+	}
+	sys.stsRevocations.set(sessions)
+
+	// Notify all other MinIO peers to reload the revocations.
+	if !sys.HasWatcher() {
+		for _, nerr := range globalNotificationSys.LoadSTSRevocations() {
+			if nerr.Err != nil {
+				logger.GetReqInfo(ctx).SetTags("peerAddress", nerr.Host.String())
+				logger.LogIf(ctx, nerr.Err)
+			}
+		}
+	}
+
+	sort.Slice(revoked, func(i, j int) bool {
+		return revoked[i].AccessKey < revoked[j].AccessKey
+	})
+	return revoked, nil
+}
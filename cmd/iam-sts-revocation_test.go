@@ -0,0 +1,86 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSTSRevocationFilter(t *testing.T) {
+	now := time.Now().UTC()
+	session := revokedSTSSession{
+		AccessKey:  "TEMPACCESSKEY",
+		ParentUser: "alice",
+		RoleARN:    "arn:minio:iam:::role/idp-role",
+		IssuedAt:   now.Add(-time.Hour),
+		Expiration: now.Add(time.Hour),
+	}
+
+	testCases := []struct {
+		filter stsRevocationFilter
+		match  bool
+	}{
+		{filter: stsRevocationFilter{AccessKey: "TEMPACCESSKEY"}, match: true},
+		{filter: stsRevocationFilter{AccessKey: "OTHERACCESSKEY"}, match: false},
+		{filter: stsRevocationFilter{ParentUser: "alice"}, match: true},
+		{filter: stsRevocationFilter{ParentUser: "bob"}, match: false},
+		{filter: stsRevocationFilter{RoleARN: "arn:minio:iam:::role/idp-role"}, match: true},
+		{filter: stsRevocationFilter{RoleARN: "arn:minio:iam:::role/other"}, match: false},
+		{filter: stsRevocationFilter{IssuedBefore: now}, match: true},
+		{filter: stsRevocationFilter{IssuedBefore: now.Add(-2 * time.Hour)}, match: false},
+		{filter: stsRevocationFilter{ParentUser: "alice", IssuedBefore: now}, match: true},
+		{filter: stsRevocationFilter{ParentUser: "alice", IssuedBefore: now.Add(-2 * time.Hour)}, match: false},
+	}
+	for i, testCase := range testCases {
+		if testCase.filter.isEmpty() {
+			t.Fatalf("Test %d: unexpected empty filter", i+1)
+		}
+		if match := testCase.filter.matches(session); match != testCase.match {
+			t.Errorf("Test %d: expected match %v, got %v", i+1, testCase.match, match)
+		}
+	}
+
+	if !(stsRevocationFilter{}).isEmpty() {
+		t.Error("Expected the zero filter to be empty")
+	}
+}
+
+func TestPurgeExpiredSTSRevocations(t *testing.T) {
+	now := time.Now().UTC()
+	sessions := map[string]revokedSTSSession{
+		"expired": {AccessKey: "expired", Expiration: now.Add(-time.Minute)},
+		"valid":   {AccessKey: "valid", Expiration: now.Add(time.Minute)},
+	}
+	purgeExpiredSTSRevocations(sessions, now)
+	if _, ok := sessions["expired"]; ok {
+		t.Error("Expected the expired session to be purged")
+	}
+	if _, ok := sessions["valid"]; !ok {
+		t.Error("Expected the valid session to be kept")
+	}
+
+	r := newSTSRevocations()
+	r.set(sessions)
+	if !r.isRevoked("valid") || r.isRevoked("expired") {
+		t.Error("Unexpected revoked sessions")
+	}
+	if list := r.list(); len(list) != 1 || list[0].AccessKey != "valid" {
+		t.Errorf("Unexpected list of revoked sessions: %v", list)
+	}
+}
@@ -0,0 +1,547 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7/pkg/set"
+	"github.com/minio/pkg/bucket/policy/condition"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// Tag based condition keys, e.g. "aws:PrincipalTag/team". The policy
+// package only supports statically named condition keys, so policies carry
+// them as variables of the aws:principaltype key, e.g.
+// "aws:principaltype/PrincipalTag/team", see encodeTagConditionKeys. They
+// are looked up in the condition values by the name of that key, e.g.
+// "principaltype/PrincipalTag/team".
+const (
+	tagConditionCarrier = condition.AWSPrincipalType
+	tagConditionPrefix  = "principaltype/"
+
+	principalTagPrefix = tagConditionPrefix + "PrincipalTag/"
+	requestTagPrefix   = tagConditionPrefix + "RequestTag/"
+	resourceTagPrefix  = tagConditionPrefix + "ResourceTag/"
+	tagKeysName        = tagConditionPrefix + "TagKeys"
+
+	principalTagVarPrefix = "${aws:PrincipalTag/"
+)
+
+// Session tag claims of STS credentials and service accounts derived from
+// them.
+const (
+	sessionTagsClaim       = "sessionTags"
+	transitiveTagKeysClaim = "transitiveTagKeys"
+
+	// OpenID providers pass session tags in this claim, like for AWS.
+	openIDTagsClaim = "https://aws.amazon.com/tags"
+)
+
+// STS form parameters for session tags.
+const (
+	stsTagsPrefix              = "Tags.member."
+	stsTransitiveTagKeysPrefix = "TransitiveTagKeys.member."
+)
+
+// tagSessionAction is required to pass session tags to AssumeRole, like
+// sts:TagSession for AWS. Tagging a session is tagging its principal, so it
+// is the action required to set the tags of users.
+const tagSessionAction = iampolicy.CreateUserAdminAction
+
+const (
+	maxIAMTags        = 50
+	maxIAMTagKeyLen   = 128
+	maxIAMTagValueLen = 256
+)
+
+var (
+	validIAMTagRegex = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+	principalTagVarRegex = regexp.MustCompile(`\$\{aws:PrincipalTag/([^}]+)\}`)
+
+	// Tag condition keys of policy documents, as written by users and as
+	// carried by the policy package. Only object keys are matched, i.e.
+	// strings following "{" or "," and followed by ":".
+	tagConditionKeyRegex        = regexp.MustCompile(`([{,]\s*)"aws:((?:PrincipalTag|RequestTag|ResourceTag)/[^"\\]*|TagKeys)"(\s*:)`)
+	carriedTagConditionKeyRegex = regexp.MustCompile(`([{,]\s*)"` + string(tagConditionCarrier) + `/((?:PrincipalTag|RequestTag|ResourceTag)/[^"\\]*|TagKeys)"(\s*:)`)
+
+	errIAMTagsTooMany  = fmt.Errorf("at most %d tags are allowed", maxIAMTags)
+	errIAMTagInvalid   = fmt.Errorf("tag keys must have 1 to %d and values at most %d letters, digits, spaces or _.:/=+-@ characters", maxIAMTagKeyLen, maxIAMTagValueLen)
+	errIAMTagReserved  = fmt.Errorf("tag keys must not start with aws:")
+	errIAMTagDuplicate = fmt.Errorf("tag keys must be unique")
+)
+
+// isTagConditionName returns whether name is the condition value name of a
+// tag condition key, with or without the carrier key prefix.
+func isTagConditionName(name string) bool {
+	if len(name) >= len(tagConditionPrefix) && strings.EqualFold(name[:len(tagConditionPrefix)], tagConditionPrefix) {
+		name = name[len(tagConditionPrefix):]
+	}
+	for _, prefix := range []string{"PrincipalTag/", "RequestTag/", "ResourceTag/"} {
+		if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return strings.EqualFold(name, "TagKeys")
+}
+
+// encodeTagConditionKeys rewrites the tag condition keys of a policy
+// document to keys the policy package can parse, e.g. "aws:TagKeys" to
+// "aws:principaltype/TagKeys".
+func encodeTagConditionKeys(data []byte) []byte {
+	return tagConditionKeyRegex.ReplaceAll(data, []byte(`${1}"`+string(tagConditionCarrier)+`/${2}"${3}`))
+}
+
+// decodeTagConditionKeys reverts encodeTagConditionKeys, for policy
+// documents returned to users.
+func decodeTagConditionKeys(data []byte) []byte {
+	return carriedTagConditionKeyRegex.ReplaceAll(data, []byte(`${1}"aws:${2}"${3}`))
+}
+
+// marshalIAMPolicy encodes an IAM policy like json.Marshal, with the tag
+// condition keys as written by users.
+func marshalIAMPolicy(p interface{}) ([]byte, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTagConditionKeys(data), nil
+}
+
+// validateIAMTags validates tags set on users, groups, service accounts
+// and sessions.
+func validateIAMTags(tags map[string]string) error {
+	if len(tags) > maxIAMTags {
+		return errIAMTagsTooMany
+	}
+	for k, v := range tags {
+		if k == "" || len(k) > maxIAMTagKeyLen || len(v) > maxIAMTagValueLen ||
+			!validIAMTagRegex.MatchString(k) || !validIAMTagRegex.MatchString(v) {
+			return errIAMTagInvalid
+		}
+		if strings.HasPrefix(strings.ToLower(k), "aws:") {
+			return errIAMTagReserved
+		}
+	}
+	return nil
+}
+
+// parseSessionTags parses the session tags of an AssumeRole request, passed as
+// Tags.member.N.Key, Tags.member.N.Value and TransitiveTagKeys.member.N
+// parameters.
+func parseSessionTags(form url.Values) (tags map[string]string, transitive []string, err error) {
+	for i := 1; ; i++ {
+		prefix := stsTagsPrefix + strconv.Itoa(i)
+		key, ok := form[prefix+".Key"]
+		if !ok {
+			break
+		}
+		if i > maxIAMTags {
+			return nil, nil, errIAMTagsTooMany
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		// Tag keys are case insensitive, like for AWS.
+		for k := range tags {
+			if strings.EqualFold(k, key[0]) {
+				return nil, nil, errIAMTagDuplicate
+			}
+		}
+		tags[key[0]] = form.Get(prefix + ".Value")
+	}
+	if err = validateIAMTags(tags); err != nil {
+		return nil, nil, err
+	}
+
+	for i := 1; ; i++ {
+		key, ok := form[stsTransitiveTagKeysPrefix+strconv.Itoa(i)]
+		if !ok {
+			break
+		}
+		if _, ok := tags[key[0]]; !ok {
+			return nil, nil, fmt.Errorf("transitive tag key %s is not a session tag", key[0])
+		}
+		transitive = append(transitive, key[0])
+	}
+	return tags, transitive, nil
+}
+
+// openIDSessionTags returns the session tags passed by an OpenID provider
+// in the AWS tags claim.
+func openIDSessionTags(claims map[string]interface{}) (tags map[string]string, transitive []string, err error) {
+	v, ok := claims[openIDTagsClaim]
+	if !ok {
+		return nil, nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid %s claim", openIDTagsClaim)
+	}
+	if pt, ok := m["principal_tags"].(map[string]interface{}); ok {
+		tags = make(map[string]string, len(pt))
+		for k, v := range pt {
+			switch v := v.(type) {
+			case string:
+				tags[k] = v
+			case []interface{}:
+				if len(v) > 0 {
+					tags[k], _ = v[0].(string)
+				}
+			}
+		}
+	}
+	if err = validateIAMTags(tags); err != nil {
+		return nil, nil, err
+	}
+	if tk, ok := m["transitive_tag_keys"].([]interface{}); ok {
+		for _, k := range tk {
+			if s, ok := k.(string); ok {
+				if _, ok := tags[s]; ok {
+					transitive = append(transitive, s)
+				}
+			}
+		}
+	}
+	return tags, transitive, nil
+}
+
+// setSessionTagClaims adds session tags to the claims of new credentials.
+func setSessionTagClaims(claims map[string]interface{}, tags map[string]string, transitive []string) {
+	if len(tags) == 0 {
+		return
+	}
+	claims[sessionTagsClaim] = tags
+	if len(transitive) > 0 {
+		claims[transitiveTagKeysClaim] = transitive
+	}
+}
+
+// sessionTagsFromClaims returns the session tags carried by claims.
+func sessionTagsFromClaims(claims map[string]interface{}) map[string]string {
+	tags := make(map[string]string)
+	switch v := claims[sessionTagsClaim].(type) {
+	case map[string]string:
+		for k, v := range v {
+			tags[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range v {
+			if s, ok := v.(string); ok {
+				tags[k] = s
+			}
+		}
+	}
+	return tags
+}
+
+// transitiveTagKeysFromClaims returns the transitive session tag keys
+// carried by claims.
+func transitiveTagKeysFromClaims(claims map[string]interface{}) []string {
+	switch v := claims[transitiveTagKeysClaim].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		keys := make([]string, 0, len(v))
+		for _, k := range v {
+			if s, ok := k.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+// transitiveSessionTags returns the session tags of claims which persist
+// to credentials derived from the session, i.e. service accounts.
+func transitiveSessionTags(claims map[string]interface{}) map[string]string {
+	tags := sessionTagsFromClaims(claims)
+	res := make(map[string]string)
+	for _, k := range transitiveTagKeysFromClaims(claims) {
+		if v, ok := tags[k]; ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// setRequestTagConditionValues replaces any tag condition values taken from
+// the request parameters with the object tags of the request.
+func setRequestTagConditionValues(values map[string][]string, objectTags string) {
+	for k := range values {
+		if isTagConditionName(k) {
+			delete(values, k)
+		}
+	}
+	if objectTags == "" {
+		return
+	}
+	q, err := url.ParseQuery(objectTags)
+	if err != nil {
+		return
+	}
+	keys := make([]string, 0, len(q))
+	for k, v := range q {
+		values[requestTagPrefix+k] = v
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values[tagKeysName] = keys
+}
+
+// setTagConditionValues sets the principal and resource tag condition
+// values. Principal tags are the session tags, overridden by the tags of the
+// groups and of the principal, overridden by the tags of a service account.
+func (sys *IAMSys) setTagConditionValues(args iampolicy.Args, principal string) {
+	for k := range args.ConditionValues {
+		if strings.HasPrefix(k, principalTagPrefix) || strings.HasPrefix(k, resourceTagPrefix) {
+			delete(args.ConditionValues, k)
+		}
+	}
+
+	tags := sessionTagsFromClaims(args.Claims)
+	for k, v := range sys.store.GetPrincipalTags(principal, args.Groups) {
+		tags[k] = v
+	}
+	if principal != args.AccountName {
+		if u, ok := sys.store.GetUser(args.AccountName); ok {
+			for k, v := range u.Tags {
+				tags[k] = v
+			}
+		}
+	}
+	for k, v := range tags {
+		args.ConditionValues[principalTagPrefix+k] = []string{v}
+	}
+
+	if args.BucketName != "" && globalBucketMetadataSys != nil {
+		if t, _, err := globalBucketMetadataSys.GetTaggingConfig(args.BucketName); err == nil {
+			for k, v := range t.ToMap() {
+				args.ConditionValues[resourceTagPrefix+k] = []string{v}
+			}
+		}
+	}
+}
+
+// maxPrincipalTagPolicies is the maximum number of substituted policies
+// cached per policy, i.e. of distinct values of the tags it uses.
+const maxPrincipalTagPolicies = 1000
+
+// principalTagPolicy substitutes the ${aws:PrincipalTag/key} variables of a
+// policy, which the policy package does not support, through its JSON
+// encoding. The substituted policies are cached by the values of the tags
+// of the variables, so that a policy is substituted once per tag values
+// rather than on every evaluation.
+type principalTagPolicy struct {
+	data []byte
+	keys []string
+
+	mu       sync.Mutex
+	policies map[string]iampolicy.Policy
+}
+
+// newPrincipalTagPolicy returns nil if p has no principal tag variables.
+func newPrincipalTagPolicy(p iampolicy.Policy) *principalTagPolicy {
+	data, err := json.Marshal(p)
+	if err != nil || !bytes.Contains(data, []byte(principalTagVarPrefix)) {
+		return nil
+	}
+	keys := set.NewStringSet()
+	for _, m := range principalTagVarRegex.FindAllSubmatch(data, -1) {
+		keys.Add(string(m[1]))
+	}
+	return &principalTagPolicy{
+		data:     data,
+		keys:     keys.ToSlice(),
+		policies: make(map[string]iampolicy.Policy),
+	}
+}
+
+// substitute returns p, the policy of tp, with the principal tag variables
+// substituted from the condition values of a request. p is returned if the
+// substituted policy cannot be parsed.
+func (tp *principalTagPolicy) substitute(p iampolicy.Policy, values map[string][]string) iampolicy.Policy {
+	var key strings.Builder
+	for _, k := range tp.keys {
+		if v := values[principalTagPrefix+k]; len(v) > 0 {
+			key.WriteString(v[0])
+		}
+		key.WriteByte(0)
+	}
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if sp, ok := tp.policies[key.String()]; ok {
+		return sp
+	}
+	var sp iampolicy.Policy
+	if err := json.Unmarshal(substitutePrincipalTags(tp.data, values), &sp); err != nil {
+		return p
+	}
+	if len(tp.policies) >= maxPrincipalTagPolicies {
+		tp.policies = make(map[string]iampolicy.Policy)
+	}
+	tp.policies[key.String()] = sp
+	return sp
+}
+
+// substitutePrincipalTags replaces ${aws:PrincipalTag/key} variables in a
+// policy document with the principal tag values, variables of absent tags
+// are kept and so never match.
+func substitutePrincipalTags(data []byte, values map[string][]string) []byte {
+	return principalTagVarRegex.ReplaceAllFunc(data, func(m []byte) []byte {
+		key := string(m[len(principalTagVarPrefix) : len(m)-1])
+		v, ok := values[principalTagPrefix+key]
+		if !ok || len(v) == 0 || v[0] == "" {
+			return m
+		}
+		b, err := json.Marshal(v[0])
+		if err != nil {
+			return m
+		}
+		return b[1 : len(b)-1]
+	})
+}
+
+// parseSessionPolicy parses the session policy of a request, with the
+// principal tag variables substituted from its condition values. Session
+// policies are parsed on every request, so they are substituted in their
+// text.
+func parseSessionPolicy(s string, values map[string][]string) (*iampolicy.Policy, error) {
+	p, err := parseIAMPolicy(strings.NewReader(s))
+	if err != nil || !strings.Contains(s, principalTagVarPrefix) {
+		return p, err
+	}
+	var sp iampolicy.Policy
+	if err = json.Unmarshal(encodeTagConditionKeys(substitutePrincipalTags([]byte(s), values)), &sp); err != nil {
+		return nil, err
+	}
+	return &sp, nil
+}
+
+// parseIAMPolicy parses and validates an IAM policy like
+// iampolicy.ParseConfig, also accepting the tag condition keys.
+func parseIAMPolicy(r io.Reader) (*iampolicy.Policy, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = encodeTagConditionKeys(data)
+	p, err := iampolicy.ParseConfig(bytes.NewReader(data))
+	if err == nil || p == nil {
+		return p, err
+	}
+
+	// Validation of the policy package rejects condition keys with a
+	// variable, validate the policy without the tag conditions instead.
+	stripped, ok := stripTagConditions(data)
+	if !ok {
+		return nil, err
+	}
+	if _, err = iampolicy.ParseConfig(bytes.NewReader(stripped)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// validateIAMPolicy validates a parsed IAM policy like Policy.Validate,
+// also accepting the tag condition keys.
+func validateIAMPolicy(p iampolicy.Policy) error {
+	err := p.Validate()
+	if err == nil {
+		return nil
+	}
+	data, merr := json.Marshal(p)
+	if merr != nil {
+		return err
+	}
+	_, err = parseIAMPolicy(bytes.NewReader(data))
+	return err
+}
+
+// stripTagConditions removes the conditions on tag keys from a policy
+// document, returns false if there are none.
+func stripTagConditions(data []byte) ([]byte, bool) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false
+	}
+	found := false
+	for k, v := range doc {
+		if !strings.EqualFold(k, "Statement") {
+			continue
+		}
+		statements, _ := v.([]interface{})
+		for _, st := range statements {
+			st, ok := st.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for ck, cv := range st {
+				if !strings.EqualFold(ck, "Condition") {
+					continue
+				}
+				conditions, ok := cv.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for fn, args := range conditions {
+					args, ok := args.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					for key := range args {
+						name := key
+						if i := strings.Index(key, ":"); i >= 0 {
+							name = key[i+1:]
+						}
+						if isTagConditionName(name) {
+							delete(args, key)
+							found = true
+						}
+					}
+					if len(args) == 0 {
+						delete(conditions, fn)
+					}
+				}
+				if len(conditions) == 0 {
+					delete(st, ck)
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	stripped, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return stripped, true
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/minio/pkg/bucket/policy/condition"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+func TestParseSessionTags(t *testing.T) {
+	testCases := []struct {
+		form           url.Values
+		wantTags       map[string]string
+		wantTransitive []string
+		wantErr        bool
+	}{
+		{
+			form: url.Values{},
+		},
+		{
+			form: url.Values{
+				"Tags.member.1.Key":          {"team"},
+				"Tags.member.1.Value":        {"red"},
+				"Tags.member.2.Key":          {"project"},
+				"Tags.member.2.Value":        {"apollo"},
+				"TransitiveTagKeys.member.1": {"team"},
+			},
+			wantTags:       map[string]string{"team": "red", "project": "apollo"},
+			wantTransitive: []string{"team"},
+		},
+		{
+			// Transitive keys must be session tags.
+			form: url.Values{
+				"Tags.member.1.Key":          {"team"},
+				"Tags.member.1.Value":        {"red"},
+				"TransitiveTagKeys.member.1": {"project"},
+			},
+			wantErr: true,
+		},
+		{
+			// Tag keys are case insensitive.
+			form: url.Values{
+				"Tags.member.1.Key": {"team"},
+				"Tags.member.2.Key": {"Team"},
+			},
+			wantErr: true,
+		},
+		{
+			form: url.Values{
+				"Tags.member.1.Key": {"aws:team"},
+			},
+			wantErr: true,
+		},
+		{
+			form: url.Values{
+				"Tags.member.1.Key":   {"team"},
+				"Tags.member.1.Value": {"red;drop"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		tags, transitive, err := parseSessionTags(tc.form)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("case %d: expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %v", i+1, err)
+		}
+		if !reflect.DeepEqual(tags, tc.wantTags) || !reflect.DeepEqual(transitive, tc.wantTransitive) {
+			t.Errorf("case %d: expected %v %v, got %v %v", i+1, tc.wantTags, tc.wantTransitive, tags, transitive)
+		}
+	}
+}
+
+func TestTransitiveSessionTags(t *testing.T) {
+	// Claims as decoded from a session token.
+	claims := map[string]interface{}{
+		sessionTagsClaim:       map[string]interface{}{"team": "red", "project": "apollo"},
+		transitiveTagKeysClaim: []interface{}{"team"},
+	}
+	if got := transitiveSessionTags(claims); !reflect.DeepEqual(got, map[string]string{"team": "red"}) {
+		t.Errorf("unexpected transitive tags %v", got)
+	}
+}
+
+const testABACPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": ["s3:GetObject"],
+      "Resource": ["arn:aws:s3:::*"],
+      "Condition": {
+        "StringEquals": {"aws:ResourceTag/team": "${aws:PrincipalTag/team}"}
+      }
+    },
+    {
+      "Effect": "Allow",
+      "Action": ["s3:PutObject"],
+      "Resource": ["arn:aws:s3:::home/${aws:PrincipalTag/team}/*"],
+      "Condition": {
+        "ForAllValues:StringEquals": {"aws:TagKeys": ["team"]}
+      }
+    }
+  ]
+}`
+
+func TestParseIAMPolicyTags(t *testing.T) {
+	if _, err := parseIAMPolicy(strings.NewReader(testABACPolicy)); err != nil {
+		t.Fatalf("expected the tag condition keys to be accepted, got %v", err)
+	}
+
+	// Other validation errors are still reported.
+	invalid := strings.Replace(testABACPolicy, `"s3:GetObject"`, `"s3:NoSuchAction"`, 1)
+	if _, err := parseIAMPolicy(strings.NewReader(invalid)); err == nil {
+		t.Fatal("expected an invalid action to be rejected")
+	}
+	invalid = strings.Replace(testABACPolicy, "aws:ResourceTag/team", "aws:NoSuchTag/team", 1)
+	if _, err := parseIAMPolicy(strings.NewReader(invalid)); err == nil {
+		t.Fatal("expected an unknown condition key to be rejected")
+	}
+}
+
+func TestPrincipalPolicyTags(t *testing.T) {
+	p, err := parseIAMPolicy(strings.NewReader(testABACPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := newPolicyDoc(*p)
+
+	testCases := []struct {
+		action iampolicy.Action
+		bucket string
+		object string
+		values map[string][]string
+		want   bool
+	}{
+		{
+			action: iampolicy.GetObjectAction,
+			bucket: "red-data",
+			object: "a.txt",
+			values: map[string][]string{principalTagPrefix + "team": {"red"}, resourceTagPrefix + "team": {"red"}},
+			want:   true,
+		},
+		{
+			action: iampolicy.GetObjectAction,
+			bucket: "blue-data",
+			object: "a.txt",
+			values: map[string][]string{principalTagPrefix + "team": {"red"}, resourceTagPrefix + "team": {"blue"}},
+			want:   false,
+		},
+		{
+			// Untagged principals never match a tag variable.
+			action: iampolicy.GetObjectAction,
+			bucket: "blue-data",
+			object: "a.txt",
+			values: map[string][]string{resourceTagPrefix + "team": {"blue"}},
+			want:   false,
+		},
+		{
+			action: iampolicy.PutObjectAction,
+			bucket: "home",
+			object: "red/a.txt",
+			values: map[string][]string{principalTagPrefix + "team": {"red"}, tagKeysName: {"team"}},
+			want:   true,
+		},
+		{
+			action: iampolicy.PutObjectAction,
+			bucket: "home",
+			object: "blue/a.txt",
+			values: map[string][]string{principalTagPrefix + "team": {"red"}},
+			want:   false,
+		},
+		{
+			action: iampolicy.PutObjectAction,
+			bucket: "home",
+			object: "red/a.txt",
+			values: map[string][]string{principalTagPrefix + "team": {"red"}, tagKeysName: {"team", "owner"}},
+			want:   false,
+		},
+	}
+
+	for i, tc := range testCases {
+		args := iampolicy.Args{
+			AccountName:     "user",
+			Action:          tc.action,
+			BucketName:      tc.bucket,
+			ObjectName:      tc.object,
+			ConditionValues: tc.values,
+		}
+		if got := doc.principalPolicy(args.ConditionValues).IsAllowed(args); got != tc.want {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.want, got)
+		}
+	}
+
+	// The policy is substituted once per value of the team tag.
+	if n := len(doc.principalTags.policies); n != 2 {
+		t.Errorf("expected 2 substituted policies, got %d", n)
+	}
+	if newPrincipalTagPolicy(iampolicy.DefaultPolicies[0].Definition) != nil {
+		t.Error("expected no substitution for a policy without principal tag variables")
+	}
+
+	// Session policies are substituted in their text.
+	sp, err := parseSessionPolicy(testABACPolicy, map[string][]string{principalTagPrefix + "team": {"red"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := iampolicy.Args{
+		AccountName: "user",
+		Action:      iampolicy.PutObjectAction,
+		BucketName:  "home",
+		ObjectName:  "red/a.txt",
+	}
+	if !sp.IsAllowed(args) {
+		t.Error("expected the substituted session policy to allow the request")
+	}
+}
+
+func TestSetRequestTagConditionValues(t *testing.T) {
+	values := map[string][]string{
+		// Condition values copied from request parameters.
+		principalTagPrefix + "team":       {"red"},
+		"PrincipalType/ResourceTag/team":  {"red"},
+		tagKeysName:                       {"team"},
+		"PrincipalTag/team":               {"red"},
+		condition.AWSPrincipalType.Name(): {"User"},
+		"username":                        {"user"},
+	}
+	setRequestTagConditionValues(values, "project=apollo&owner=alice")
+
+	want := map[string][]string{
+		requestTagPrefix + "project":      {"apollo"},
+		requestTagPrefix + "owner":        {"alice"},
+		tagKeysName:                       {"owner", "project"},
+		condition.AWSPrincipalType.Name(): {"User"},
+		"username":                        {"user"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("expected %v, got %v", want, values)
+	}
+}
+
+func TestTagConditionKeys(t *testing.T) {
+	data := []byte(`{"Condition": {"StringEquals": {"aws:PrincipalTag/team": "red", "aws:username": "aws:TagKeys"},` +
+		` "ForAllValues:StringEquals": { "aws:TagKeys" : ["team"]}}}`)
+	want := []byte(`{"Condition": {"StringEquals": {"aws:principaltype/PrincipalTag/team": "red", "aws:username": "aws:TagKeys"},` +
+		` "ForAllValues:StringEquals": { "aws:principaltype/TagKeys" : ["team"]}}}`)
+	encoded := encodeTagConditionKeys(data)
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("expected %s, got %s", want, encoded)
+	}
+	if encoded := encodeTagConditionKeys(encoded); !bytes.Equal(encoded, want) {
+		t.Fatalf("expected encoding to be idempotent, got %s", encoded)
+	}
+	if decoded := decodeTagConditionKeys(encoded); !bytes.Equal(decoded, data) {
+		t.Fatalf("expected %s, got %s", data, decoded)
+	}
+
+	// Policies round trip through the policy package with the keys as
+	// written by users.
+	p, err := parseIAMPolicy(strings.NewReader(testABACPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := marshalIAMPolicy(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte(`"aws:ResourceTag/team"`)) || !bytes.Contains(out, []byte(`"aws:TagKeys"`)) ||
+		bytes.Contains(out, []byte(tagConditionCarrier+"/")) {
+		t.Errorf("unexpected policy %s", out)
+	}
+	var doc PolicyDoc
+	if err = doc.parseJSON(out); err != nil || !doc.Policy.Equals(*p) {
+		t.Errorf("expected the policy to be parsed again, got %v", err)
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -39,6 +38,8 @@ import (
 	xldap "github.com/GuinsooLab/annastore/internal/config/identity/ldap"
 	"github.com/GuinsooLab/annastore/internal/config/identity/openid"
 	idplugin "github.com/GuinsooLab/annastore/internal/config/identity/plugin"
+	idsaml "github.com/GuinsooLab/annastore/internal/config/identity/saml"
+	idscim "github.com/GuinsooLab/annastore/internal/config/identity/scim"
 	"github.com/GuinsooLab/annastore/internal/config/policy/opa"
 	polplugin "github.com/GuinsooLab/annastore/internal/config/policy/plugin"
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
@@ -87,7 +88,7 @@ type IAMSys struct {
 	sync.Mutex
 
 	iamRefreshInterval time.Duration
-	ldapConfig         xldap.Config  // only valid if usersSysType is LDAPUsers
+	ldapConfig         xldap.Configs // only valid if usersSysType is LDAPUsers
 	openIDConfig       openid.Config // only valid if OpenID is configured
 
 	usersSysType UsersSysType
@@ -99,6 +100,12 @@ type IAMSys struct {
 
 	// configLoaded will be closed and remain so after first load.
 	configLoaded chan struct{}
+
+	// Last use of the access keys.
+	accessKeyUsage *accessKeyUsageTracker
+
+	// Revoked temporary credentials.
+	stsRevocations *stsRevocations
 }
 
 // IAMUserType represents a user type inside MinIO server
@@ -207,6 +214,10 @@ func (sys *IAMSys) Load(ctx context.Context) error {
 		atomic.AddUint64(&sys.TotalRefreshFailures, 1)
 		return err
 	}
+	if err = sys.loadSTSRevocations(ctx); err != nil {
+		// The previously loaded revocations remain in effect.
+		logger.LogIf(ctx, fmt.Errorf("Unable to load STS revocations: %w", err))
+	}
 	loadDuration := time.Since(loadStartTime)
 
 	atomic.StoreUint64(&sys.LastRefreshDurationMilliseconds, uint64(loadDuration.Milliseconds()))
@@ -227,8 +238,6 @@ func (sys *IAMSys) Init(ctx context.Context, objAPI ObjectLayer, etcdClient *etc
 	s := globalServerConfig
 	globalServerConfigMu.RUnlock()
 
-	ldapCfg := s[config.IdentityLDAPSubSys][config.Default]
-
 	var err error
 	globalOpenIDConfig, err = openid.LookupConfig(s,
 		NewGatewayHTTPTransport(), xhttp.DrainBody, globalSite.Region)
@@ -237,7 +246,7 @@ func (sys *IAMSys) Init(ctx context.Context, objAPI ObjectLayer, etcdClient *etc
 	}
 
 	// Initialize if LDAP is enabled
-	globalLDAPConfig, err = xldap.Lookup(ldapCfg, globalRootCAs)
+	globalLDAPConfig, err = xldap.LookupConfig(s, globalRootCAs)
 	if err != nil {
 		logger.LogIf(ctx, fmt.Errorf("Unable to parse LDAP configuration: %w", err))
 	}
@@ -250,6 +259,18 @@ func (sys *IAMSys) Init(ctx context.Context, objAPI ObjectLayer, etcdClient *etc
 
 	setGlobalAuthNPlugin(idplugin.New(authNPluginCfg))
 
+	globalSAMLConfig, err = idsaml.LookupConfig(s[config.IdentitySAMLSubSys][config.Default],
+		NewGatewayHTTPTransport(), globalSite.Region)
+	if err != nil {
+		logger.LogIf(ctx, fmt.Errorf("Unable to initialize SAML: %w", err))
+	}
+	globalSAMLConfig.Replays = globalSAMLReplays
+
+	globalSCIMConfig, err = idscim.LookupConfig(s[config.IdentitySCIMSubSys][config.Default])
+	if err != nil {
+		logger.LogIf(ctx, fmt.Errorf("Unable to initialize SCIM: %w", err))
+	}
+
 	authZPluginCfg, err := polplugin.LookupConfig(s[config.PolicyPluginSubSys][config.Default],
 		NewGatewayHTTPTransport(), xhttp.DrainBody)
 	if err != nil {
@@ -374,6 +395,9 @@ func (sys *IAMSys) Init(ctx context.Context, objAPI ObjectLayer, etcdClient *etc
 	// Start watching changes to storage.
 	go sys.watch(ctx)
 
+	// Persist the last use of access keys.
+	go sys.persistAccessKeyUsage(ctx)
+
 	// Load RoleARNs
 	sys.rolesMap = make(map[arn.ARN]string)
 
@@ -388,6 +412,11 @@ func (sys *IAMSys) Init(ctx context.Context, objAPI ObjectLayer, etcdClient *etc
 		sys.validateAndAddRolePolicyMappings(ctx, riMap)
 	}
 
+	// From SAML if a role policy is configured.
+	if riMap := globalSAMLConfig.GetRoleInfo(); riMap != nil {
+		sys.validateAndAddRolePolicyMappings(ctx, riMap)
+	}
+
 	sys.printIAMRoles()
 }
 
@@ -522,6 +551,8 @@ func (sys *IAMSys) loadWatchedEvent(ctx context.Context, event iamWatchEvent) (e
 		policyMapFile := strings.TrimPrefix(event.keyPath, iamConfigPolicyDBGroupsPrefix)
 		user := strings.TrimSuffix(policyMapFile, ".json")
 		err = sys.store.PolicyMappingNotificationHandler(ctx, user, true, regUser)
+	case event.keyPath == iamSTSRevocationsFile:
+		err = sys.loadSTSRevocations(ctx)
 	}
 	return err
 }
@@ -581,7 +612,7 @@ func (sys *IAMSys) InfoPolicy(policyName string) (*madmin.PolicyInfo, error) {
 		return nil, err
 	}
 
-	pdata, err := json.Marshal(d.Policy)
+	pdata, err := marshalIAMPolicy(d.Policy)
 	if err != nil {
 		return nil, err
 	}
@@ -870,6 +901,62 @@ func (sys *IAMSys) SetUserStatus(ctx context.Context, accessKey string, status m
 	return updatedAt, nil
 }
 
+// SetUserTags - sets the tags of a regular user or a service account.
+func (sys *IAMSys) SetUserTags(ctx context.Context, accessKey string, tags map[string]string) (updatedAt time.Time, err error) {
+	if !sys.Initialized() {
+		return updatedAt, errServerNotInitialized
+	}
+
+	if err = validateIAMTags(tags); err != nil {
+		return updatedAt, err
+	}
+
+	u, ok := sys.store.GetUser(accessKey)
+	if !ok {
+		return updatedAt, errNoSuchUser
+	}
+	// Users of external identity providers are not stored, only their
+	// service accounts can be tagged.
+	isSvc := u.Credentials.IsServiceAccount()
+	if !isSvc && sys.usersSysType != MinIOUsersSysType {
+		return updatedAt, errIAMActionNotAllowed
+	}
+
+	updatedAt, err = sys.store.SetUserTags(ctx, accessKey, tags)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	if isSvc {
+		sys.notifyForServiceAccount(ctx, accessKey)
+	} else {
+		sys.notifyForUser(ctx, accessKey, false)
+	}
+	return updatedAt, nil
+}
+
+// GetUserTags - returns the tags of a regular user or a service account.
+func (sys *IAMSys) GetUserTags(accessKey string) (map[string]string, error) {
+	if !sys.Initialized() {
+		return nil, errServerNotInitialized
+	}
+
+	u, ok := sys.store.GetUser(accessKey)
+	if !ok || u.Credentials.IsTemp() {
+		return nil, errNoSuchUser
+	}
+	return u.Tags, nil
+}
+
+// GetPrincipalTags - returns the tags of a user and of its groups.
+func (sys *IAMSys) GetPrincipalTags(user string, groups []string) map[string]string {
+	if !sys.Initialized() {
+		return nil
+	}
+
+	return sys.store.GetPrincipalTags(user, groups)
+}
+
 func (sys *IAMSys) notifyForServiceAccount(ctx context.Context, accessKey string) {
 	// Notify all other Minio peers to reload the service account
 	if !sys.HasWatcher() {
@@ -902,11 +989,11 @@ func (sys *IAMSys) NewServiceAccount(ctx context.Context, parentUser string, gro
 
 	var policyBuf []byte
 	if opts.sessionPolicy != nil {
-		err := opts.sessionPolicy.Validate()
+		err := validateIAMPolicy(*opts.sessionPolicy)
 		if err != nil {
 			return auth.Credentials{}, time.Time{}, err
 		}
-		policyBuf, err = json.Marshal(opts.sessionPolicy)
+		policyBuf, err = marshalIAMPolicy(opts.sessionPolicy)
 		if err != nil {
 			return auth.Credentials{}, time.Time{}, err
 		}
@@ -1054,7 +1141,7 @@ func (sys *IAMSys) getServiceAccount(ctx context.Context, accessKey string) (u U
 		if err != nil {
 			return u, nil, err
 		}
-		embeddedPolicy, err = iampolicy.ParseConfig(bytes.NewReader(policyBytes))
+		embeddedPolicy, err = parseIAMPolicy(bytes.NewReader(policyBytes))
 		if err != nil {
 			return u, nil, err
 		}
@@ -1218,9 +1305,9 @@ func (sys *IAMSys) purgeExpiredCredentialsForLDAP(ctx context.Context) {
 
 	expiredUsers, err := sys.ldapConfig.GetNonEligibleUserDistNames(allDistNames)
 	if err != nil {
-		// Log and return on error - perhaps it'll work the next time.
+		// Log and continue with the users of the reachable LDAP servers -
+		// perhaps the others will work the next time.
 		logger.LogIf(GlobalContext, err)
-		return
 	}
 
 	// We ignore any errors
@@ -1288,14 +1375,18 @@ func (sys *IAMSys) updateGroupMembershipsForLDAP(ctx context.Context) {
 	// 2. Query LDAP server for groups of the LDAP users collected.
 	updatedGroups, err := sys.ldapConfig.LookupGroupMemberships(parentUsers, parentUserToLDAPUsernameMap)
 	if err != nil {
-		// Log and return on error - perhaps it'll work the next time.
+		// Log and continue with the users of the reachable LDAP servers -
+		// perhaps the others will work the next time.
 		logger.LogIf(GlobalContext, err)
-		return
 	}
 
 	// 3. Update creds for those users whose groups are changed
 	for _, parentUser := range parentUsers {
-		currGroupsSet := updatedGroups[parentUser]
+		currGroupsSet, ok := updatedGroups[parentUser]
+		if !ok {
+			// The LDAP server of this user could not be queried.
+			continue
+		}
 		currGroups := currGroupsSet.ToSlice()
 		for _, cred := range parentUserToCredsMap[parentUser] {
 			gSet := set.CreateStringSet(cred.Groups...)
@@ -1414,6 +1505,38 @@ func (sys *IAMSys) SetGroupStatus(ctx context.Context, group string, enabled boo
 	return updatedAt, nil
 }
 
+// SetGroupTags - sets the tags of a group, inherited by its members.
+func (sys *IAMSys) SetGroupTags(ctx context.Context, group string, tags map[string]string) (updatedAt time.Time, err error) {
+	if !sys.Initialized() {
+		return updatedAt, errServerNotInitialized
+	}
+
+	if sys.usersSysType != MinIOUsersSysType {
+		return updatedAt, errIAMActionNotAllowed
+	}
+
+	if err = validateIAMTags(tags); err != nil {
+		return updatedAt, err
+	}
+
+	updatedAt, err = sys.store.SetGroupTags(ctx, group, tags)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	sys.notifyForGroup(ctx, group)
+	return updatedAt, nil
+}
+
+// GetGroupTags - returns the tags of a group.
+func (sys *IAMSys) GetGroupTags(group string) (map[string]string, error) {
+	if !sys.Initialized() {
+		return nil, errServerNotInitialized
+	}
+
+	return sys.store.GetGroupTags(group)
+}
+
 // GetGroupDescription - builds up group description
 func (sys *IAMSys) GetGroupDescription(group string) (gd madmin.GroupDesc, err error) {
 	if !sys.Initialized() {
@@ -1545,7 +1668,7 @@ func (sys *IAMSys) IsAllowedServiceAccount(args iampolicy.Args, parentUser strin
 	var combinedPolicy iampolicy.Policy
 	// Policies were found, evaluate all of them.
 	if !isOwnerDerived {
-		availablePoliciesStr, c := sys.store.FilterPolicies(strings.Join(svcPolicies, ","), "")
+		availablePoliciesStr, c := sys.store.FilterPrincipalPolicies(strings.Join(svcPolicies, ","), args.ConditionValues)
 		if availablePoliciesStr == "" {
 			return false
 		}
@@ -1588,7 +1711,7 @@ func (sys *IAMSys) IsAllowedServiceAccount(args iampolicy.Args, parentUser strin
 	}
 
 	// Check if policy is parseable.
-	subPolicy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(spolicyStr)))
+	subPolicy, err := parseSessionPolicy(spolicyStr, args.ConditionValues)
 	if err != nil {
 		// Log any error in input session policy config.
 		logger.LogIf(GlobalContext, err)
@@ -1607,6 +1730,24 @@ func (sys *IAMSys) IsAllowedServiceAccount(args iampolicy.Args, parentUser strin
 	return subPolicy.IsAllowed(parentArgs) && (isOwnerDerived || combinedPolicy.IsAllowed(parentArgs))
 }
 
+// ldapAttributePolicies returns the policies mapped to an LDAP user by their
+// attributes at login, skipping those which no longer exist. They are only
+// honored for LDAP sessions.
+func (sys *IAMSys) ldapAttributePolicies(claims map[string]interface{}) []string {
+	if _, ok := claims[ldapUser]; !ok {
+		return nil
+	}
+	policySet, ok := iampolicy.GetPoliciesFromClaims(claims, ldapPolicies)
+	if !ok {
+		return nil
+	}
+	policies, _ := sys.store.FilterPolicies(strings.Join(policySet.ToSlice(), ","), "")
+	if policies == "" {
+		return nil
+	}
+	return strings.Split(policies, ",")
+}
+
 // IsAllowedSTS is meant for STS based temporary credentials,
 // which implements claims validation and verification other than
 // applying policies.
@@ -1639,6 +1780,7 @@ func (sys *IAMSys) IsAllowedSTS(args iampolicy.Args, parentUser string) bool {
 			logger.LogIf(GlobalContext, fmt.Errorf("error fetching policies on %s: %v", parentUser, err))
 			return false
 		}
+		policies = append(policies, sys.ldapAttributePolicies(args.Claims)...)
 
 		// Finally, if there is no parent policy, check if a policy claim is
 		// present in the session token.
@@ -1665,7 +1807,7 @@ func (sys *IAMSys) IsAllowedSTS(args iampolicy.Args, parentUser string) bool {
 	var combinedPolicy iampolicy.Policy
 	if !isOwnerDerived {
 		var err error
-		combinedPolicy, err = sys.store.GetPolicy(strings.Join(policies, ","))
+		combinedPolicy, err = sys.store.GetPrincipalPolicy(strings.Join(policies, ","), args.ConditionValues)
 		if err == errNoSuchPolicy {
 			for _, pname := range policies {
 				_, err := sys.store.GetPolicy(pname)
@@ -1718,7 +1860,7 @@ func isAllowedBySessionPolicy(args iampolicy.Args) (hasSessionPolicy bool, isAll
 	}
 
 	// Check if policy is parseable.
-	subPolicy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(spolicyStr)))
+	subPolicy, err := parseSessionPolicy(spolicyStr, args.ConditionValues)
 	if err != nil {
 		// Log any error in input session policy config.
 		logger.LogIf(GlobalContext, err)
@@ -1756,12 +1898,17 @@ func (sys *IAMSys) IsAllowed(args iampolicy.Args) bool {
 		return true
 	}
 
+	if args.ConditionValues == nil {
+		args.ConditionValues = make(map[string][]string)
+	}
+
 	// If the credential is temporary, perform STS related checks.
 	ok, parentUser, err := sys.IsTempUser(args.AccountName)
 	if err != nil {
 		return false
 	}
 	if ok {
+		sys.setTagConditionValues(args, parentUser)
 		return sys.IsAllowedSTS(args, parentUser)
 	}
 
@@ -1771,9 +1918,12 @@ func (sys *IAMSys) IsAllowed(args iampolicy.Args) bool {
 		return false
 	}
 	if ok {
+		sys.setTagConditionValues(args, parentUser)
 		return sys.IsAllowedServiceAccount(args, parentUser)
 	}
 
+	sys.setTagConditionValues(args, args.AccountName)
+
 	// Continue with the assumption of a regular user
 	policies, err := sys.PolicyDBGet(args.AccountName, false, args.Groups...)
 	if err != nil {
@@ -1786,7 +1936,8 @@ func (sys *IAMSys) IsAllowed(args iampolicy.Args) bool {
 	}
 
 	// Policies were found, evaluate all of them.
-	return sys.GetCombinedPolicy(policies...).IsAllowed(args)
+	_, combinedPolicy := sys.store.FilterPrincipalPolicies(strings.Join(policies, ","), args.ConditionValues)
+	return combinedPolicy.IsAllowed(args)
 }
 
 // EnableLDAPSys - enable ldap system users type.
@@ -1797,7 +1948,9 @@ func (sys *IAMSys) EnableLDAPSys() {
 // NewIAMSys - creates new config system object.
 func NewIAMSys() *IAMSys {
 	return &IAMSys{
-		usersSysType: MinIOUsersSysType,
-		configLoaded: make(chan struct{}),
+		usersSysType:   MinIOUsersSysType,
+		configLoaded:   make(chan struct{}),
+		accessKeyUsage: newAccessKeyUsageTracker(),
+		stsRevocations: newSTSRevocations(),
 	}
 }
@@ -24,6 +24,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/minio/pkg/console"
@@ -209,6 +210,33 @@ func (e *metaCacheEntry) fileInfo(bucket string) (FileInfo, error) {
 	return getFileInfo(e.metadata, bucket, e.name, "", false)
 }
 
+// fileInfoAsOf returns the most recent version of the entry which is not
+// newer than asOf. errFileNotFound is returned if there is no such version
+// or if it is a delete marker.
+func (e *metaCacheEntry) fileInfoAsOf(bucket string, asOf time.Time) (FileInfo, error) {
+	fivs, err := e.fileInfoVersions(bucket)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return fileInfoVersionAsOf(fivs.Versions, asOf)
+}
+
+// fileInfoVersionAsOf returns the most recent of versions, sorted newest
+// first, which is not newer than asOf. errFileNotFound is returned if the
+// object did not exist or was deleted at asOf.
+func fileInfoVersionAsOf(versions []FileInfo, asOf time.Time) (FileInfo, error) {
+	for _, fi := range versions {
+		if fi.ModTime.After(asOf) {
+			continue
+		}
+		if fi.Deleted {
+			break
+		}
+		return fi, nil
+	}
+	return FileInfo{}, errFileNotFound
+}
+
 // xlmeta returns the decoded metadata.
 // This should not be called on directories.
 func (e *metaCacheEntry) xlmeta() (*xlMetaV2, error) {
@@ -515,6 +543,13 @@ func (m *metaCacheEntriesSorted) fileInfoVersions(bucket, prefix, delimiter, aft
 // fileInfos converts the metadata to ObjectInfo where possible.
 // Metadata that cannot be decoded is skipped.
 func (m *metaCacheEntriesSorted) fileInfos(bucket, prefix, delimiter string) (objects []ObjectInfo) {
+	return m.fileInfosAsOf(bucket, prefix, delimiter, time.Time{})
+}
+
+// fileInfosAsOf converts the metadata to ObjectInfo, resolving each object
+// to its version at asOf as done by fileInfoAsOf. Objects which did not
+// exist at asOf are skipped. A zero asOf resolves the latest version.
+func (m *metaCacheEntriesSorted) fileInfosAsOf(bucket, prefix, delimiter string, asOf time.Time) (objects []ObjectInfo) {
 	objects = make([]ObjectInfo, 0, m.len())
 	prevPrefix := ""
 
@@ -540,7 +575,15 @@ func (m *metaCacheEntriesSorted) fileInfos(bucket, prefix, delimiter string) (ob
 				}
 			}
 
-			fi, err := entry.fileInfo(bucket)
+			var (
+				fi  FileInfo
+				err error
+			)
+			if asOf.IsZero() {
+				fi, err = entry.fileInfo(bucket)
+			} else {
+				fi, err = entry.fileInfoAsOf(bucket, asOf)
+			}
 			if err == nil {
 				versioned := vcfg != nil && vcfg.Versioned(entry.name)
 				objects = append(objects, fi.ToObjectInfo(bucket, entry.name, versioned))
@@ -657,3 +657,57 @@ func Test_metaCacheEntries_resolve(t *testing.T) {
 		}
 	}
 }
+
+func Test_metaCacheEntry_fileInfoAsOf(t *testing.T) {
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var xl xlMetaV2
+	for i, deleted := range []bool{false, false, true, false} {
+		fi := FileInfo{
+			Volume:    "bucket",
+			Name:      "object",
+			VersionID: mustGetUUID(),
+			ModTime:   base.Add(time.Duration(i) * time.Hour),
+			Deleted:   deleted,
+		}
+		if !deleted {
+			fi.DataDir = mustGetUUID()
+			fi.Erasure = ErasureInfo{
+				Algorithm:    ReedSolomon.String(),
+				DataBlocks:   2,
+				ParityBlocks: 2,
+				BlockSize:    blockSizeV2,
+				Index:        1,
+				Distribution: []int{1, 2, 3, 4},
+			}
+		}
+		if err := xl.AddVersion(fi); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metadata, err := xl.AppendTo(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := metaCacheEntry{name: "object", metadata: metadata}
+
+	testCases := []struct {
+		asOf    time.Time
+		modTime time.Time
+		err     error
+	}{
+		{base.Add(-time.Minute), time.Time{}, errFileNotFound},
+		{base, base, nil},
+		{base.Add(90 * time.Minute), base.Add(time.Hour), nil},
+		{base.Add(2 * time.Hour), time.Time{}, errFileNotFound},
+		{base.Add(5 * time.Hour), base.Add(3 * time.Hour), nil},
+	}
+	for i, tc := range testCases {
+		fi, err := entry.fileInfoAsOf("bucket", tc.asOf)
+		if err != tc.err {
+			t.Fatalf("case %d: expected %v, got %v", i+1, tc.err, err)
+		}
+		if err == nil && !fi.ModTime.Equal(tc.modTime) {
+			t.Errorf("case %d: expected version of %v, got %v", i+1, tc.modTime, fi.ModTime)
+		}
+	}
+}
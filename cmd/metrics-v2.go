@@ -22,12 +22,14 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/minio/kes"
 	"github.com/minio/madmin-go"
@@ -51,6 +53,7 @@ func init() {
 		getClusterStorageMetrics(),
 		getClusterTierMetrics(),
 		getKMSMetrics(),
+		getDataUsageHistoryMetrics(),
 	}
 
 	peerMetricsGroups = []*MetricsGroup{
@@ -66,6 +69,9 @@ func init() {
 		getScannerNodeMetrics(),
 		getIAMNodeMetrics(),
 		getKMSNodeMetrics(),
+		getRateLimitNodeMetrics(),
+		getStorageClassNodeMetrics(),
+		getDriveHealthNodeMetrics(),
 	}
 
 	allMetricsGroups := func() (allMetrics []*MetricsGroup) {
@@ -126,6 +132,10 @@ const (
 	scannerSubsystem          MetricSubsystem = "scanner"
 	iamSubsystem              MetricSubsystem = "iam"
 	kmsSubsystem              MetricSubsystem = "kms"
+	rateLimitSubsystem        MetricSubsystem = "rate_limit"
+	storageClassSubsystem     MetricSubsystem = "storage_class"
+	driveHealthSubsystem      MetricSubsystem = "drive_health"
+	poolSubsystem             MetricSubsystem = "pool"
 )
 
 // MetricName are the individual names for the metric.
@@ -175,8 +185,9 @@ const (
 	usageInfo   MetricName = "usage_info"
 	versionInfo MetricName = "version_info"
 
-	sizeDistribution = "size_distribution"
-	ttfbDistribution = "ttfb_seconds_distribution"
+	sizeDistribution    = "size_distribution"
+	versionDistribution = "version_distribution"
+	ttfbDistribution    = "ttfb_seconds_distribution"
 
 	lastActivityTime = "last_activity_nano_seconds"
 	startTime        = "starttime_seconds"
@@ -427,6 +438,16 @@ func getBucketUsageQuotaTotalBytesMD() MetricDescription {
 	}
 }
 
+func getBucketUsageQuotaTotalObjectsMD() MetricDescription {
+	return MetricDescription{
+		Namespace: bucketMetricNamespace,
+		Subsystem: quotaSubsystem,
+		Name:      objectTotal,
+		Help:      "Total bucket quota number of objects",
+		Type:      gaugeMetric,
+	}
+}
+
 func getBucketTrafficReceivedBytes() MetricDescription {
 	return MetricDescription{
 		Namespace: bucketMetricNamespace,
@@ -527,6 +548,16 @@ func getBucketObjectDistributionMD() MetricDescription {
 	}
 }
 
+func getBucketObjectVersionsMD() MetricDescription {
+	return MetricDescription{
+		Namespace: bucketMetricNamespace,
+		Subsystem: objectsSubsystem,
+		Name:      versionDistribution,
+		Help:      "Distribution of the number of versions per object in the bucket, includes label for the bucket name",
+		Type:      histogramMetric,
+	}
+}
+
 func getInternodeFailedRequests() MetricDescription {
 	return MetricDescription{
 		Namespace: interNodeMetricNamespace,
@@ -1693,6 +1724,14 @@ func getBucketUsageMetrics() *MetricsGroup {
 				})
 			}
 
+			if quota != nil && quota.Objects > 0 {
+				metrics = append(metrics, Metric{
+					Description:    getBucketUsageQuotaTotalObjectsMD(),
+					Value:          float64(quota.Objects),
+					VariableLabels: map[string]string{"bucket": bucket},
+				})
+			}
+
 			recvBytes := globalBucketConnStats.getS3InputBytes(bucket)
 			if recvBytes > 0 {
 				metrics = append(metrics, Metric{
@@ -1744,6 +1783,13 @@ func getBucketUsageMetrics() *MetricsGroup {
 				HistogramBucketLabel: "range",
 				VariableLabels:       map[string]string{"bucket": bucket},
 			})
+
+			metrics = append(metrics, Metric{
+				Description:          getBucketObjectVersionsMD(),
+				Histogram:            usage.ObjectVersionsHistogram,
+				HistogramBucketLabel: "range",
+				VariableLabels:       map[string]string{"bucket": bucket},
+			})
 		}
 		return
 	})
@@ -1940,6 +1986,266 @@ func getClusterStorageMetrics() *MetricsGroup {
 	return mg
 }
 
+func getRateLimitNodeMetrics() *MetricsGroup {
+	mg := &MetricsGroup{}
+	mg.RegisterRead(func(_ context.Context) (metrics []Metric) {
+		stats := globalRateLimiter.getStats()
+		metrics = make([]Metric, 0, 3*len(stats))
+		for rule, st := range stats {
+			metrics = append(metrics, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: rateLimitSubsystem,
+					Name:      "requests_total",
+					Help:      "Total number of S3 requests charged to a rate limit rule",
+					Type:      counterMetric,
+				},
+				Value:          float64(st.Requests),
+				VariableLabels: map[string]string{"rule": rule},
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: rateLimitSubsystem,
+					Name:      "rejected_total",
+					Help:      "Total number of S3 requests rejected with SlowDown by a rate limit rule",
+					Type:      counterMetric,
+				},
+				Value:          float64(st.Rejected),
+				VariableLabels: map[string]string{"rule": rule},
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: rateLimitSubsystem,
+					Name:      "bytes_total",
+					Help:      "Total number of bytes received and sent charged to a rate limit rule",
+					Type:      counterMetric,
+				},
+				Value:          float64(st.Bytes),
+				VariableLabels: map[string]string{"rule": rule},
+			})
+		}
+		return metrics
+	})
+	return mg
+}
+
+func getStorageClassNodeMetrics() *MetricsGroup {
+	mg := &MetricsGroup{}
+	mg.RegisterRead(func(_ context.Context) (metrics []Metric) {
+		if !globalIsErasure {
+			return
+		}
+		parity := map[string]int{
+			storageclass.STANDARD: globalStorageClass.GetParityForSC(storageclass.STANDARD),
+			storageclass.RRS:      globalStorageClass.GetParityForSC(storageclass.RRS),
+		}
+		for _, c := range globalStorageClass.GetCustomClasses() {
+			parity[c.Name] = c.Parity
+		}
+		for sc, p := range parity {
+			metrics = append(metrics, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: storageClassSubsystem,
+					Name:      "parity",
+					Help:      "Number of parity drives objects of a storage class are written with",
+					Type:      gaugeMetric,
+				},
+				Value:          float64(p),
+				VariableLabels: map[string]string{"storage_class": sc},
+			})
+		}
+		for sc, st := range globalStorageClassStats.get() {
+			metrics = append(metrics, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: storageClassSubsystem,
+					Name:      "written_objects_total",
+					Help:      "Total number of objects written in a storage class by this node",
+					Type:      counterMetric,
+				},
+				Value:          float64(st.Objects),
+				VariableLabels: map[string]string{"storage_class": sc},
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: storageClassSubsystem,
+					Name:      "written_bytes_total",
+					Help:      "Total number of bytes written in a storage class by this node",
+					Type:      counterMetric,
+				},
+				Value:          float64(st.Bytes),
+				VariableLabels: map[string]string{"storage_class": sc},
+			})
+		}
+		return metrics
+	})
+	return mg
+}
+
+func getDriveHealthNodeMetrics() *MetricsGroup {
+	mg := &MetricsGroup{}
+	mg.RegisterRead(func(_ context.Context) (metrics []Metric) {
+		if !globalIsErasure {
+			return
+		}
+		for _, d := range globalDriveHealthMonitor.getDrives() {
+			if d.Error != "" {
+				continue
+			}
+			labels := map[string]string{"drive": d.Endpoint, "device": d.Device}
+			failing := 0
+			if d.Failing {
+				failing = 1
+			}
+			metrics = append(metrics, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: driveHealthSubsystem,
+					Name:      "temperature_celsius",
+					Help:      "Temperature of a drive reported by SMART/NVMe in celsius",
+					Type:      gaugeMetric,
+				},
+				Value:          float64(d.Temperature),
+				VariableLabels: labels,
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: driveHealthSubsystem,
+					Name:      "reallocated_sectors",
+					Help:      "Number of sectors reallocated by a drive",
+					Type:      gaugeMetric,
+				},
+				Value:          float64(d.ReallocatedSectors),
+				VariableLabels: labels,
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: driveHealthSubsystem,
+					Name:      "media_errors",
+					Help:      "Number of uncorrectable media errors reported by a drive",
+					Type:      gaugeMetric,
+				},
+				Value:          float64(d.MediaErrors),
+				VariableLabels: labels,
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: nodeMetricNamespace,
+					Subsystem: driveHealthSubsystem,
+					Name:      "failing",
+					Help:      "1 if a drive is predicted to fail, 0 otherwise",
+					Type:      gaugeMetric,
+				},
+				Value:          float64(failing),
+				VariableLabels: labels,
+			})
+			if d.WearLevel >= 0 {
+				metrics = append(metrics, Metric{
+					Description: MetricDescription{
+						Namespace: nodeMetricNamespace,
+						Subsystem: driveHealthSubsystem,
+						Name:      "wear_level_percent",
+						Help:      "Percentage of the rated endurance of a drive which is used",
+						Type:      gaugeMetric,
+					},
+					Value:          float64(d.WearLevel),
+					VariableLabels: labels,
+				})
+			}
+		}
+		return metrics
+	})
+	return mg
+}
+
+func getDataUsageHistoryMetrics() *MetricsGroup {
+	mg := &MetricsGroup{
+		cacheInterval: 10 * time.Minute,
+	}
+	mg.RegisterRead(func(ctx context.Context) (metrics []Metric) {
+		objLayer := newObjectLayerFn()
+		// Service not initialized yet
+		if objLayer == nil || globalIsGateway {
+			return
+		}
+
+		buckets, err := objLayer.ListBuckets(ctx, BucketOptions{})
+		if err != nil {
+			return
+		}
+		for _, bucket := range buckets {
+			h, err := loadBucketUsageHistory(ctx, objLayer, bucket.Name)
+			if err != nil {
+				continue
+			}
+			size, objects, ok := h.growth()
+			if !ok {
+				continue
+			}
+			labels := map[string]string{"bucket": bucket.Name}
+			metrics = append(metrics, Metric{
+				Description: MetricDescription{
+					Namespace: bucketMetricNamespace,
+					Subsystem: usageSubsystem,
+					Name:      "growth_bytes_per_day",
+					Help:      "Average daily growth of the bucket size in bytes over the last 30 days",
+					Type:      gaugeMetric,
+				},
+				Value:          size,
+				VariableLabels: labels,
+			}, Metric{
+				Description: MetricDescription{
+					Namespace: bucketMetricNamespace,
+					Subsystem: usageSubsystem,
+					Name:      "growth_objects_per_day",
+					Help:      "Average daily growth of the number of objects in the bucket over the last 30 days",
+					Type:      gaugeMetric,
+				},
+				Value:          objects,
+				VariableLabels: labels,
+			})
+		}
+
+		ph, err := loadPoolsUsageHistory(ctx, objLayer)
+		if err != nil {
+			return
+		}
+		for idx, h := range ph.Pools {
+			used, daysToFull, ok := h.growth()
+			if !ok {
+				continue
+			}
+			labels := map[string]string{"pool": strconv.Itoa(idx)}
+			metrics = append(metrics, Metric{
+				Description: MetricDescription{
+					Namespace: clusterMetricNamespace,
+					Subsystem: poolSubsystem,
+					Name:      "growth_bytes_per_day",
+					Help:      "Average daily growth of the raw capacity used in the pool over the last 30 days",
+					Type:      gaugeMetric,
+				},
+				Value:          used,
+				VariableLabels: labels,
+			})
+			if daysToFull >= 0 {
+				metrics = append(metrics, Metric{
+					Description: MetricDescription{
+						Namespace: clusterMetricNamespace,
+						Subsystem: poolSubsystem,
+						Name:      "days_to_full",
+						Help:      "Projected number of days until the pool is full at its growth rate",
+						Type:      gaugeMetric,
+					},
+					Value:          daysToFull,
+					VariableLabels: labels,
+				})
+			}
+		}
+		return metrics
+	})
+	return mg
+}
+
 func getKMSNodeMetrics() *MetricsGroup {
 	mg := &MetricsGroup{
 		cacheInterval: 10 * time.Second,
@@ -218,6 +218,7 @@ func (m *mrfState) healRoutine() {
 
 			// Heal objects
 			for _, u := range mrfOperations {
+				wait := healSleeper.Timer(m.ctx)
 				_, err := m.objectAPI.HealObject(m.ctx, u.bucket, u.object, u.versionID, mrfHealingOpts)
 				m.mu.Lock()
 				if err == nil {
@@ -231,6 +232,7 @@ func (m *mrfState) healRoutine() {
 
 				// Log healing error if any
 				logger.LogIf(m.ctx, err)
+				wait()
 			}
 
 			waitForLowHTTPReq()
@@ -0,0 +1,274 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/madmin-go"
+)
+
+const nodeCordonConfigFile = "node-cordon.json"
+
+var nodeCordonConfigPath = path.Join(minioConfigPrefix, nodeCordonConfigFile)
+
+var (
+	errNodeNotCordoned  = errors.New("node is not cordoned")
+	errNodeCordonQuorum = errors.New("cordoning the node would lose the write quorum of an erasure set")
+)
+
+// nodeCordon is a node cordoned for maintenance.
+type nodeCordon struct {
+	Node  string    `json:"node"`
+	Since time.Time `json:"since"`
+}
+
+type nodeCordonConfig struct {
+	Nodes map[string]nodeCordon `json:"nodes"`
+}
+
+// nodeCordonSys holds the nodes cordoned for maintenance. The other nodes
+// stop sending listing and lock requests to a cordoned node and the node
+// reports itself as not ready, so load balancers stop sending it requests
+// while the requests in flight finish.
+type nodeCordonSys struct {
+	// mu serializes the changes of the config.
+	mu sync.Mutex
+	// nodes holds the nodeCordonConfig which is never modified once
+	// stored.
+	nodes atomic.Value
+}
+
+func newNodeCordonSys() *nodeCordonSys {
+	sys := &nodeCordonSys{}
+	sys.nodes.Store(nodeCordonConfig{})
+	return sys
+}
+
+// isCordoned returns true if the node host:port is cordoned.
+func (sys *nodeCordonSys) isCordoned(node string) bool {
+	if sys == nil {
+		return false
+	}
+	_, ok := sys.nodes.Load().(nodeCordonConfig).Nodes[node]
+	return ok
+}
+
+// list returns the cordoned nodes sorted by name.
+func (sys *nodeCordonSys) list() []nodeCordon {
+	nodes := sys.nodes.Load().(nodeCordonConfig).Nodes
+	res := make([]nodeCordon, 0, len(nodes))
+	for _, n := range nodes {
+		res = append(res, n)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Node < res[j].Node
+	})
+	return res
+}
+
+// store replaces the cordoned nodes and catches up the writes missed by
+// the nodes which are not cordoned anymore.
+func (sys *nodeCordonSys) store(cfg nodeCordonConfig) {
+	prev := sys.nodes.Load().(nodeCordonConfig)
+	sys.nodes.Store(cfg)
+
+	for node := range prev.Nodes {
+		if _, ok := cfg.Nodes[node]; !ok {
+			go healNodeMRF(node)
+		}
+	}
+}
+
+// healNodeMRF heals the partial writes queued on this node for the
+// erasure sets with drives on node.
+func healNodeMRF(node string) {
+	for poolIdx, pool := range globalEndpoints {
+		if pool.DrivesPerSet == 0 {
+			continue
+		}
+		sets := make(map[int]struct{})
+		for i, ep := range pool.Endpoints {
+			if ep.Host == node {
+				sets[i/pool.DrivesPerSet] = struct{}{}
+			}
+		}
+		for setIdx := range sets {
+			globalMRFState.newSetReconnected(poolIdx, setIdx)
+		}
+	}
+}
+
+func readNodeCordonConfig(ctx context.Context, objAPI ObjectLayer) (cfg nodeCordonConfig, err error) {
+	data, err := readConfig(ctx, objAPI, nodeCordonConfigPath)
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Load reloads the cordoned nodes from the config store.
+func (sys *nodeCordonSys) Load(ctx context.Context, objAPI ObjectLayer) error {
+	sys.mu.Lock()
+	defer sys.mu.Unlock()
+
+	cfg, err := readNodeCordonConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	sys.store(cfg)
+	return nil
+}
+
+// update applies fn to the cordoned nodes, saves them and asks the other
+// nodes to reload them.
+func (sys *nodeCordonSys) update(ctx context.Context, objAPI ObjectLayer, fn func(nodes map[string]nodeCordon) error) error {
+	sys.mu.Lock()
+	defer sys.mu.Unlock()
+
+	// Serialize the changes of the cordoned nodes of all nodes, with a lock
+	// distinct from the one taken to write the config.
+	lk := objAPI.NewNSLock(minioMetaBucket, nodeCordonConfigPath+".lock")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	// Changes may have been made on other nodes.
+	cfg, err := readNodeCordonConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	nodes := make(map[string]nodeCordon, len(cfg.Nodes)+1)
+	for k, v := range cfg.Nodes {
+		nodes[k] = v
+	}
+	if err = fn(nodes); err != nil {
+		return err
+	}
+
+	cfg = nodeCordonConfig{Nodes: nodes}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err = saveConfig(ctx, objAPI, nodeCordonConfigPath, data); err != nil {
+		return err
+	}
+	sys.store(cfg)
+
+	if globalNotificationSys != nil {
+		for _, nerr := range globalNotificationSys.LoadNodeCordon() {
+			if nerr.Err != nil {
+				reqInfo := (&logger.ReqInfo{}).AppendTags("peerAddress", nerr.Host.String())
+				logger.LogIf(logger.SetReqInfo(ctx, reqInfo), nerr.Err)
+			}
+		}
+	}
+	return nil
+}
+
+// Cordon cordons node, as long as the erasure sets keep the write quorum of
+// the objects with the smallest parity without the drives of the cordoned
+// nodes.
+func (sys *nodeCordonSys) Cordon(ctx context.Context, objAPI ObjectLayer, node string) error {
+	z, ok := objAPI.(*erasureServerPools)
+	if !ok {
+		return NotImplemented{}
+	}
+	si, _ := objAPI.StorageInfo(ctx)
+	writeQuorums := erasureWriteQuorums(z)
+
+	return sys.update(ctx, objAPI, func(nodes map[string]nodeCordon) error {
+		if _, ok := nodes[node]; ok {
+			return nil
+		}
+		cordoned := make(map[string]struct{}, len(nodes)+1)
+		for n := range nodes {
+			cordoned[n] = struct{}{}
+		}
+		cordoned[node] = struct{}{}
+		if err := checkNodeCordonQuorum(si.Disks, writeQuorums, cordoned); err != nil {
+			return err
+		}
+		nodes[node] = nodeCordon{Node: node, Since: UTCNow()}
+		return nil
+	})
+}
+
+// Uncordon puts node back in service.
+func (sys *nodeCordonSys) Uncordon(ctx context.Context, objAPI ObjectLayer, node string) error {
+	return sys.update(ctx, objAPI, func(nodes map[string]nodeCordon) error {
+		if _, ok := nodes[node]; !ok {
+			return errNodeNotCordoned
+		}
+		delete(nodes, node)
+		return nil
+	})
+}
+
+// checkNodeCordonQuorum returns an error if an erasure set loses its write
+// quorum once the cordoned nodes are taken down, drives already offline
+// are not counted.
+func checkNodeCordonQuorum(disks []madmin.Disk, writeQuorums []int, cordoned map[string]struct{}) error {
+	type setIdx struct{ pool, set int }
+	online := make(map[setIdx]int)
+	for _, disk := range disks {
+		if disk.PoolIndex < 0 || disk.PoolIndex >= len(writeQuorums) {
+			continue
+		}
+		set := setIdx{disk.PoolIndex, disk.SetIndex}
+		if _, ok := online[set]; !ok {
+			online[set] = 0
+		}
+		if disk.State != madmin.DriveStateOk {
+			continue
+		}
+		if u, err := url.Parse(disk.Endpoint); err == nil {
+			if _, ok := cordoned[u.Host]; ok {
+				continue
+			}
+		}
+		online[set]++
+	}
+	for set, n := range online {
+		if n < writeQuorums[set.pool] {
+			return fmt.Errorf("%w: pool %d, set %d would have %d drives online, write quorum is %d",
+				errNodeCordonQuorum, set.pool+1, set.set+1, n, writeQuorums[set.pool])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/minio/madmin-go"
+)
+
+func TestCheckNodeCordonQuorum(t *testing.T) {
+	// One set of 4 drives spread over 4 nodes and one set of 4 drives
+	// spread over 2 nodes, with a write quorum of 3.
+	disks := []madmin.Disk{
+		{Endpoint: "http://node1:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node2:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node3:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node4:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node5:9000/d1", PoolIndex: 1, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node5:9000/d2", PoolIndex: 1, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node6:9000/d1", PoolIndex: 1, SetIndex: 0, State: madmin.DriveStateOk},
+		{Endpoint: "http://node6:9000/d2", PoolIndex: 1, SetIndex: 0, State: madmin.DriveStateOk},
+	}
+	writeQuorums := []int{3, 3}
+
+	testCases := []struct {
+		cordoned []string
+		disks    []madmin.Disk
+		ok       bool
+	}{
+		{[]string{"node1:9000"}, disks, true},
+		{[]string{"node1:9000", "node2:9000"}, disks, false},
+		{[]string{"node5:9000"}, disks, false},
+		// A drive of the set is already offline.
+		{[]string{"node2:9000"}, append([]madmin.Disk{{Endpoint: "http://node1:9000/d1", PoolIndex: 0, SetIndex: 0, State: madmin.DriveStateOffline}}, disks[1:]...), false},
+		{[]string{"node7:9000"}, disks, true},
+	}
+
+	for i, testCase := range testCases {
+		cordoned := make(map[string]struct{})
+		for _, node := range testCase.cordoned {
+			cordoned[node] = struct{}{}
+		}
+		err := checkNodeCordonQuorum(testCase.disks, writeQuorums, cordoned)
+		if testCase.ok && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+		if !testCase.ok && !errors.Is(err, errNodeCordonQuorum) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, errNodeCordonQuorum, err)
+		}
+	}
+}
+
+func TestNodeCordonSys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Shutdown(context.Background())
+	defer removeRoots(fsDirs)
+
+	sys := newNodeCordonSys()
+	if err = sys.Cordon(ctx, obj, "node1:9000"); err != nil {
+		t.Fatal(err)
+	}
+	if !sys.isCordoned("node1:9000") || sys.isCordoned("node2:9000") {
+		t.Fatalf("unexpected cordoned nodes %v", sys.list())
+	}
+
+	// The cordoned nodes survive a reload.
+	sys = newNodeCordonSys()
+	if err = sys.Load(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+	if nodes := sys.list(); len(nodes) != 1 || nodes[0].Node != "node1:9000" {
+		t.Fatalf("unexpected cordoned nodes after reload %v", nodes)
+	}
+
+	if err = sys.Uncordon(ctx, obj, "node1:9000"); err != nil {
+		t.Fatal(err)
+	}
+	if sys.isCordoned("node1:9000") {
+		t.Fatal("expected node to be uncordoned")
+	}
+	if err = sys.Uncordon(ctx, obj, "node1:9000"); !errors.Is(err, errNodeNotCordoned) {
+		t.Fatalf("expected %v, got %v", errNodeNotCordoned, err)
+	}
+}
+
+func TestErasureWriteQuorums(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Shutdown(context.Background())
+	defer removeRoots(fsDirs)
+	defer func(sc storageclass.Config, sys *BucketMetadataSys) {
+		globalStorageClass, globalBucketMetadataSys = sc, sys
+	}(globalStorageClass, globalBucketMetadataSys)
+	globalBucketMetadataSys = NewBucketMetadataSys()
+
+	z := obj.(*erasureServerPools)
+	testCases := []struct {
+		sc           storageclass.Config
+		bucketParity int
+		writeQuorum  int
+	}{
+		{storageclass.Config{Standard: storageclass.StorageClass{Parity: 4}, RRS: storageclass.StorageClass{Parity: 4}}, 0, 12},
+		{storageclass.Config{Standard: storageclass.StorageClass{Parity: 4}, RRS: storageclass.StorageClass{Parity: 2}}, 0, 14},
+		{storageclass.Config{Standard: storageclass.StorageClass{Parity: 4}, RRS: storageclass.StorageClass{Parity: 4},
+			Custom: map[string]storageclass.CustomClass{"COLD": {Name: "COLD", Parity: 3}}}, 0, 13},
+		{storageclass.Config{Standard: storageclass.StorageClass{Parity: 4}, RRS: storageclass.StorageClass{Parity: 4}}, 1, 15},
+	}
+	for i, testCase := range testCases {
+		globalStorageClass = testCase.sc
+		meta := newBucketMetadata("bucket")
+		meta.erasureConfig = &BucketErasureConfig{Parity: testCase.bucketParity}
+		globalBucketMetadataSys.Set("bucket", meta)
+
+		if writeQuorums := erasureWriteQuorums(z); len(writeQuorums) != 1 || writeQuorums[0] != testCase.writeQuorum {
+			t.Errorf("Test %d: expected write quorum %d, got %v", i+1, testCase.writeQuorum, writeQuorums)
+		}
+	}
+}
+
+func TestReadinessCheckCordonedNode(t *testing.T) {
+	defer func(isDistErasure bool, nodeName string, sys *nodeCordonSys) {
+		globalIsDistErasure, globalLocalNodeName, globalNodeCordonSys = isDistErasure, nodeName, sys
+	}(globalIsDistErasure, globalLocalNodeName, globalNodeCordonSys)
+
+	globalIsDistErasure = true
+	globalLocalNodeName = "node1:9000"
+	globalNodeCordonSys = newNodeCordonSys()
+	globalNodeCordonSys.nodes.Store(nodeCordonConfig{Nodes: map[string]nodeCordon{
+		"node1:9000": {Node: "node1:9000"},
+	}})
+
+	w := httptest.NewRecorder()
+	ReadinessCheckHandler(w, httptest.NewRequest(http.MethodGet, "/minio/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if status := w.Header().Get(xhttp.MinIOServerStatus); status != maintenance {
+		t.Fatalf("expected server status %s, got %s", maintenance, status)
+	}
+
+	globalLocalNodeName = "node2:9000"
+	w = httptest.NewRecorder()
+	ReadinessCheckHandler(w, httptest.NewRequest(http.MethodGet, "/minio/health/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -228,6 +228,58 @@ func (sys *NotificationSys) LoadGroup(group string) []NotificationPeerErr {
 	return ng.Wait()
 }
 
+// LoadSTSRevocations - reloads the revoked temporary credentials on all peers.
+func (sys *NotificationSys) LoadSTSRevocations() []NotificationPeerErr {
+	ng := WithNPeers(len(sys.peerClients))
+	for idx, client := range sys.peerClients {
+		if client == nil {
+			continue
+		}
+		client := client
+		ng.Go(GlobalContext, func() error { return client.LoadSTSRevocations() }, idx, *client.host)
+	}
+	return ng.Wait()
+}
+
+// LoadDriveMaintenance - reloads the drives under maintenance on all peers.
+func (sys *NotificationSys) LoadDriveMaintenance() []NotificationPeerErr {
+	ng := WithNPeers(len(sys.peerClients))
+	for idx, client := range sys.peerClients {
+		if client == nil {
+			continue
+		}
+		client := client
+		ng.Go(GlobalContext, func() error { return client.LoadDriveMaintenance() }, idx, *client.host)
+	}
+	return ng.Wait()
+}
+
+// LoadNodeCordon - reloads the cordoned nodes on all peers.
+func (sys *NotificationSys) LoadNodeCordon() []NotificationPeerErr {
+	ng := WithNPeers(len(sys.peerClients))
+	for idx, client := range sys.peerClients {
+		if client == nil {
+			continue
+		}
+		client := client
+		ng.Go(GlobalContext, func() error { return client.LoadNodeCordon() }, idx, *client.host)
+	}
+	return ng.Wait()
+}
+
+// LoadUnrecoverable - reloads the unrecoverable object versions on all peers.
+func (sys *NotificationSys) LoadUnrecoverable() []NotificationPeerErr {
+	ng := WithNPeers(len(sys.peerClients))
+	for idx, client := range sys.peerClients {
+		if client == nil {
+			continue
+		}
+		client := client
+		ng.Go(GlobalContext, func() error { return client.LoadUnrecoverable() }, idx, *client.host)
+	}
+	return ng.Wait()
+}
+
 // DeleteServiceAccount - deletes a specific service account across all peers
 func (sys *NotificationSys) DeleteServiceAccount(accessKey string) []NotificationPeerErr {
 	ng := WithNPeers(len(sys.peerClients))
@@ -1131,11 +1183,11 @@ func (sys *NotificationSys) GetLocalDiskIDs(ctx context.Context) (localDiskIDs [
 	return localDiskIDs
 }
 
-// returns all the peers that are currently online.
+// returns all the peers that are currently online and not cordoned.
 func (sys *NotificationSys) getOnlinePeers() []*peerRESTClient {
 	var peerClients []*peerRESTClient
 	for _, peerClient := range sys.allPeerClients {
-		if peerClient != nil && peerClient.IsOnline() {
+		if peerClient != nil && peerClient.IsOnline() && !globalNodeCordonSys.isCordoned(peerClient.host.String()) {
 			peerClients = append(peerClients, peerClient)
 		}
 	}
@@ -1230,6 +1282,9 @@ func (args eventArgs) ToEvent(escape bool) event.Event {
 	if args.RespElements["content-length"] != "" {
 		respElements["content-length"] = args.RespElements["content-length"]
 	}
+	if args.RespElements["x-minio-versions"] != "" {
+		respElements["x-minio-versions"] = args.RespElements["x-minio-versions"]
+	}
 	keyName := args.Object.Name
 	if escape {
 		keyName = url.QueryEscape(args.Object.Name)
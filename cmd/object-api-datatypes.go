@@ -57,6 +57,8 @@ type objectHistogramInterval struct {
 const (
 	// dataUsageBucketLen must be length of ObjectsHistogramIntervals
 	dataUsageBucketLen = 7
+	// dataUsageVersionLen must be length of ObjectsVersionCountIntervals
+	dataUsageVersionLen = 7
 )
 
 // ObjectsHistogramIntervals is the list of all intervals
@@ -71,6 +73,18 @@ var ObjectsHistogramIntervals = []objectHistogramInterval{
 	{"GREATER_THAN_512_MB", humanize.MiByte * 512, math.MaxInt64},
 }
 
+// ObjectsVersionCountIntervals is the list of all intervals
+// of object version counts to be included in objects histogram.
+var ObjectsVersionCountIntervals = []objectHistogramInterval{
+	{"UNVERSIONED", 0, 0},
+	{"SINGLE_VERSION", 1, 1},
+	{"BETWEEN_2_AND_10", 2, 9},
+	{"BETWEEN_10_AND_100", 10, 99},
+	{"BETWEEN_100_AND_1000", 100, 999},
+	{"BETWEEN_1000_AND_10000", 1000, 9999},
+	{"GREATER_THAN_10000", 10000, math.MaxInt64},
+}
+
 // BucketInfo - represents bucket metadata.
 type BucketInfo struct {
 	// Name of the bucket.
@@ -394,6 +394,13 @@ func (e BucketQuotaConfigNotFound) Error() string {
 	return "No quota config found for bucket : " + e.Bucket
 }
 
+// BucketErasureConfigNotFound - no bucket erasure config found.
+type BucketErasureConfigNotFound GenericError
+
+func (e BucketErasureConfigNotFound) Error() string {
+	return "No erasure config found for bucket : " + e.Bucket
+}
+
 // BucketQuotaExceeded - bucket quota exceeded.
 type BucketQuotaExceeded GenericError
 
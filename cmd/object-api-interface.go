@@ -267,6 +267,14 @@ type ObjectLayer interface {
 	DeleteObjectTags(context.Context, string, string, ObjectOptions) (ObjectInfo, error)
 }
 
+// pointInTimeLister is implemented by object layers which can present a
+// versioned bucket as it was at a point in time, by resolving each object
+// to its most recent version not newer than that time.
+type pointInTimeLister interface {
+	ListObjectsAsOf(ctx context.Context, bucket, prefix, marker, delimiter string, maxKeys int, asOf time.Time) (ListObjectsInfo, error)
+	GetObjectInfoAsOf(ctx context.Context, bucket, object string, asOf time.Time) (ObjectInfo, error)
+}
+
 // GetObject - TODO(aead): This function just acts as an adapter for GetObject tests and benchmarks
 // since the GetObject method of the ObjectLayer interface has been removed. Once, the
 // tests are adjusted to use GetObjectNInfo this function can be removed.
@@ -37,7 +37,6 @@ import (
 	objectlock "github.com/GuinsooLab/annastore/internal/bucket/object/lock"
 	"github.com/GuinsooLab/annastore/internal/bucket/replication"
 	"github.com/GuinsooLab/annastore/internal/config/dns"
-	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/crypto"
 	"github.com/GuinsooLab/annastore/internal/etag"
 	"github.com/GuinsooLab/annastore/internal/event"
@@ -377,6 +376,12 @@ func (api objectAPIHandlers) getObjectHandler(ctx context.Context, objectAPI Obj
 		return
 	}
 
+	// Resolve point in time requests to the version current at that time.
+	if err = resolveAsOfVersion(ctx, objectAPI, r, bucket, object, &opts); err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
 	getObjectNInfo := objectAPI.GetObjectNInfo
 	if api.CacheAPI() != nil {
 		getObjectNInfo = api.CacheAPI().GetObjectNInfo
@@ -599,7 +604,9 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		w.Header().Set(gzhttp.HeaderNoCompression, "true")
 	}
 
-	if r.Header.Get(xMinIOExtract) == "true" && strings.Contains(object, archivePattern) {
+	if ap, ok := globalObjectLambdaSys.Get(bucket); ok {
+		api.getObjectLambdaHandler(ctx, objectAPI, ap, object, w, r)
+	} else if r.Header.Get(xMinIOExtract) == "true" && strings.Contains(object, archivePattern) {
 		api.getObjectInArchiveFileHandler(ctx, objectAPI, bucket, object, w, r)
 	} else {
 		api.getObjectHandler(ctx, objectAPI, bucket, object, w, r)
@@ -657,6 +664,13 @@ func (api objectAPIHandlers) headObjectHandler(ctx context.Context, objectAPI Ob
 		writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(s3Error))
 		return
 	}
+
+	// Resolve point in time requests to the version current at that time.
+	if err = resolveAsOfVersion(ctx, objectAPI, r, bucket, object, &opts); err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+		return
+	}
+
 	// Get request range.
 	var rs *HTTPRangeSpec
 	rangeHeader := r.Header.Get(xhttp.Range)
@@ -1029,7 +1043,7 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	// Validate storage class metadata if present
 	dstSc := r.Header.Get(xhttp.AmzStorageClass)
-	if dstSc != "" && !storageclass.IsValid(dstSc) {
+	if dstSc != "" && !globalStorageClass.IsValid(dstSc) {
 		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL)
 		return
 	}
@@ -1608,7 +1622,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 
 	// Validate storage class metadata if present
 	if sc := r.Header.Get(xhttp.AmzStorageClass); sc != "" {
-		if !storageclass.IsValid(sc) {
+		if !globalStorageClass.IsValid(sc) {
 			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL)
 			return
 		}
@@ -1966,7 +1980,7 @@ func (api objectAPIHandlers) PutObjectExtractHandler(w http.ResponseWriter, r *h
 	// Validate storage class metadata if present
 	sc := r.Header.Get(xhttp.AmzStorageClass)
 	if sc != "" {
-		if !storageclass.IsValid(sc) {
+		if !globalStorageClass.IsValid(sc) {
 			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL)
 			return
 		}
@@ -0,0 +1,254 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/GuinsooLab/annastore/internal/event"
+	"github.com/GuinsooLab/annastore/internal/handlers"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/pkg/bucket/policy"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// Admin actions guarding the object lambda APIs, granted by "admin:*".
+const (
+	setObjectLambdaAction  iampolicy.AdminAction = "admin:SetObjectLambda"
+	listObjectLambdaAction iampolicy.AdminAction = "admin:ListObjectLambda"
+)
+
+// maxObjectLambdaConfigSize is the maximum size of an access point definition.
+const maxObjectLambdaConfigSize = 1 << 20
+
+// objectLambdaHookResponseHeaders are copied from the hook response.
+var objectLambdaHookResponseHeaders = []string{
+	xhttp.ContentType,
+	xhttp.ContentLength,
+	xhttp.ContentRange,
+	xhttp.ContentEncoding,
+	xhttp.ContentDisposition,
+	xhttp.CacheControl,
+	xhttp.ETag,
+	xhttp.AcceptRanges,
+}
+
+func toObjectLambdaAPIErr(err error) error {
+	switch err {
+	case errObjectLambdaNotFound:
+		return AdminError{
+			Code:       "XMinioAdminObjectLambdaNotFound",
+			Message:    err.Error(),
+			StatusCode: http.StatusNotFound,
+		}
+	case errObjectLambdaInvalidName, errObjectLambdaNameInUse, errObjectLambdaNoRules, errObjectLambdaInvalidHook:
+		return AdminError{
+			Code:       "XMinioAdminInvalidObjectLambda",
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return err
+}
+
+// SetObjectLambdaAccessPoint - PUT /minio/admin/v3/object-lambda/set-access-point
+// ----------
+// Creates or replaces an object lambda access point, the JSON encoded
+// ObjectLambdaAccessPoint is read from the request body.
+func (a adminAPIHandlers) SetObjectLambdaAccessPoint(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetObjectLambdaAccessPoint")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, setObjectLambdaAction)
+	if objectAPI == nil {
+		return
+	}
+
+	var ap ObjectLambdaAccessPoint
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxObjectLambdaConfigSize)).Decode(&ap); err != nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErrWithErr(ErrAdminConfigBadJSON, err), r.URL)
+		return
+	}
+
+	if err := globalObjectLambdaSys.Set(ctx, objectAPI, ap); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, toObjectLambdaAPIErr(err)), r.URL)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// ListObjectLambdaAccessPoints - GET /minio/admin/v3/object-lambda/list-access-points
+// ----------
+// Lists all object lambda access points, hook auth tokens are redacted.
+func (a adminAPIHandlers) ListObjectLambdaAccessPoints(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListObjectLambdaAccessPoints")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, listObjectLambdaAction)
+	if objectAPI == nil {
+		return
+	}
+
+	aps := globalObjectLambdaSys.List()
+	for i := range aps {
+		rules := make([]ObjectLambdaRule, len(aps[i].Rules))
+		for j, rule := range aps[i].Rules {
+			if rule.AuthToken != "" {
+				rule.AuthToken = "REDACTED"
+			}
+			rules[j] = rule
+		}
+		aps[i].Rules = rules
+	}
+
+	data, err := json.Marshal(aps)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, data)
+}
+
+// RemoveObjectLambdaAccessPoint - DELETE /minio/admin/v3/object-lambda/remove-access-point?name=<name>
+// ----------
+// Removes an object lambda access point.
+func (a adminAPIHandlers) RemoveObjectLambdaAccessPoint(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RemoveObjectLambdaAccessPoint")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, setObjectLambdaAction)
+	if objectAPI == nil {
+		return
+	}
+
+	if err := globalObjectLambdaSys.Delete(ctx, objectAPI, r.Form.Get("name")); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, toObjectLambdaAPIErr(err)), r.URL)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// getObjectLambdaHandler serves a GET on an object lambda access point. The
+// request is authorized against the original object, which is read with
+// GetObjectNInfo and streamed through the transformation hook of the
+// matching rule. Objects matching no rule are served unchanged.
+func (api objectAPIHandlers) getObjectLambdaHandler(ctx context.Context, objectAPI ObjectLayer, ap ObjectLambdaAccessPoint, object string, w http.ResponseWriter, r *http.Request) {
+	bucket := ap.Bucket
+	rule, ok := ap.match(object)
+	if !ok {
+		api.getObjectHandler(ctx, objectAPI, bucket, object, w, r)
+		return
+	}
+
+	cred, _, s3Error := checkRequestAuthTypeCredential(ctx, r, policy.GetObjectAction, bucket, object)
+	if s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL)
+		return
+	}
+	if r.Header.Get(xhttp.Range) != "" && !rule.SupportsRange {
+		writeErrorResponse(ctx, w, APIError{
+			Code:           "NotImplemented",
+			Description:    errObjectLambdaRangeForbidden.Error(),
+			HTTPStatusCode: http.StatusNotImplemented,
+		}, r.URL)
+		return
+	}
+
+	opts, err := getOpts(ctx, r, bucket, object)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
+	// The hook receives the whole object, ranges apply to its output.
+	gr, err := objectAPI.GetObjectNInfo(ctx, bucket, object, nil, r.Header, readLock, opts)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+	defer gr.Close()
+	objInfo := gr.ObjInfo
+
+	if objInfo.DeleteMarker {
+		writeErrorResponse(ctx, w, toAPIError(ctx, ObjectNotFound{Bucket: bucket, Object: object}), r.URL)
+		return
+	}
+
+	req, err := objectLambdaHookRequest(ctx, r, cred, ap, rule, gr)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL)
+		return
+	}
+
+	globalObjectLambdaSys.RLock()
+	client := globalObjectLambdaSys.client
+	globalObjectLambdaSys.RUnlock()
+	if client == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	resp, err := client.Do(req)
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		xhttp.DrainBody(resp.Body)
+		err = fmt.Errorf("transformation hook returned %s: %s", resp.Status, msg)
+	}
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponse(ctx, w, APIError{
+			Code:           "LambdaInvocationFailed",
+			Description:    err.Error(),
+			HTTPStatusCode: http.StatusBadGateway,
+		}, r.URL)
+		return
+	}
+	defer xhttp.DrainBody(resp.Body)
+
+	for _, k := range objectLambdaHookResponseHeaders {
+		if v := resp.Header.Get(k); v != "" {
+			w.Header().Set(k, v)
+		}
+	}
+	if objInfo.VersionID != "" {
+		w.Header()[xhttp.AmzVersionID] = []string{objInfo.VersionID}
+	}
+	w.WriteHeader(resp.StatusCode)
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+
+	sendEvent(eventArgs{
+		EventName:    event.ObjectAccessedGet,
+		BucketName:   bucket,
+		Object:       objInfo,
+		ReqParams:    extractReqParams(r),
+		RespElements: extractRespElements(w),
+		UserAgent:    r.UserAgent(),
+		Host:         handlers.GetSourceIP(r),
+	})
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/auth"
+	"github.com/GuinsooLab/annastore/internal/handlers"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+	"github.com/minio/minio-go/v7/pkg/signer"
+)
+
+const (
+	objectLambdaConfigFile = "object-lambda.json"
+
+	// objectLambdaRefreshInterval bounds how long other nodes serve a
+	// stale set of access points after a change.
+	objectLambdaRefreshInterval = time.Minute
+
+	// objectLambdaPresignExpiry is the validity of the presigned URL
+	// handed to the transformation hook.
+	objectLambdaPresignExpiry = 5 * time.Minute
+)
+
+// Headers sent to an object transformation hook along with the object.
+const (
+	objectLambdaAccessPoint  = "X-Minio-Lambda-Access-Point"
+	objectLambdaBucket       = "X-Minio-Lambda-Bucket"
+	objectLambdaObject       = "X-Minio-Lambda-Object"
+	objectLambdaVersionID    = "X-Minio-Lambda-Version-Id"
+	objectLambdaObjectSize   = "X-Minio-Lambda-Object-Size"
+	objectLambdaPresignedURL = "X-Minio-Lambda-Presigned-Url"
+	objectLambdaUser         = "X-Minio-Lambda-User"
+	objectLambdaSourceIP     = "X-Minio-Lambda-Source-Ip"
+)
+
+var objectLambdaConfigPath = path.Join(minioConfigPrefix, objectLambdaConfigFile)
+
+var (
+	errObjectLambdaNotFound       = errors.New("object lambda access point not found")
+	errObjectLambdaInvalidName    = errors.New("object lambda access point name must be a valid bucket name")
+	errObjectLambdaNameInUse      = errors.New("object lambda access point name is already used by a bucket")
+	errObjectLambdaNoRules        = errors.New("object lambda access point requires at least one rule")
+	errObjectLambdaInvalidHook    = errors.New("object lambda endpoint must be an http or https URL")
+	errObjectLambdaRangeForbidden = errors.New("transformation hook does not support range requests")
+)
+
+// ObjectLambdaRule routes the objects under Prefix through the
+// transformation hook at Endpoint.
+type ObjectLambdaRule struct {
+	Prefix   string `json:"prefix,omitempty"`
+	Endpoint string `json:"endpoint"`
+	// AuthToken is sent to the hook as a bearer token when set.
+	AuthToken string `json:"authToken,omitempty"`
+	// SupportsRange declares that the hook honors the Range header on the
+	// transformed content, range requests are rejected otherwise.
+	SupportsRange bool `json:"supportsRange,omitempty"`
+}
+
+// ObjectLambdaAccessPoint is an alias of Bucket, GET requests to objects of
+// the alias are streamed through the hook of the longest matching rule.
+// Objects matching no rule are returned unchanged.
+type ObjectLambdaAccessPoint struct {
+	Name   string             `json:"name"`
+	Bucket string             `json:"bucket"`
+	Rules  []ObjectLambdaRule `json:"rules"`
+}
+
+// Validate checks if the access point is well formed.
+func (ap ObjectLambdaAccessPoint) Validate() error {
+	if s3utils.CheckValidBucketNameStrict(ap.Name) != nil {
+		return errObjectLambdaInvalidName
+	}
+	if s3utils.CheckValidBucketNameStrict(ap.Bucket) != nil {
+		return BucketNameInvalid{Bucket: ap.Bucket}
+	}
+	if len(ap.Rules) == 0 {
+		return errObjectLambdaNoRules
+	}
+	for _, rule := range ap.Rules {
+		u, err := url.Parse(rule.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errObjectLambdaInvalidHook
+		}
+	}
+	return nil
+}
+
+// match returns the rule with the longest prefix matching object.
+func (ap ObjectLambdaAccessPoint) match(object string) (rule ObjectLambdaRule, ok bool) {
+	for _, r := range ap.Rules {
+		if !strings.HasPrefix(object, r.Prefix) {
+			continue
+		}
+		if !ok || len(r.Prefix) > len(rule.Prefix) {
+			rule, ok = r, true
+		}
+	}
+	return rule, ok
+}
+
+// objectLambdaSys holds the object lambda access points of the cluster.
+// Changes are persisted by the node receiving them and picked up by the
+// other nodes within objectLambdaRefreshInterval.
+type objectLambdaSys struct {
+	sync.RWMutex
+	accessPoints map[string]ObjectLambdaAccessPoint
+	client       *http.Client
+}
+
+var globalObjectLambdaSys = newObjectLambdaSys()
+
+func newObjectLambdaSys() *objectLambdaSys {
+	return &objectLambdaSys{
+		accessPoints: make(map[string]ObjectLambdaAccessPoint),
+	}
+}
+
+// Init loads the access points and keeps them refreshed.
+func (sys *objectLambdaSys) Init(ctx context.Context, objAPI ObjectLayer) {
+	sys.Lock()
+	sys.client = &http.Client{Transport: NewGatewayHTTPTransport()}
+	sys.Unlock()
+
+	if err := sys.refresh(ctx, objAPI); err != nil {
+		logger.LogIf(ctx, err)
+	}
+	go func() {
+		t := time.NewTicker(objectLambdaRefreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				logger.LogIf(ctx, sys.refresh(ctx, objAPI))
+			}
+		}
+	}()
+}
+
+func (sys *objectLambdaSys) refresh(ctx context.Context, objAPI ObjectLayer) error {
+	aps, err := loadObjectLambdaConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	sys.Lock()
+	sys.accessPoints = aps
+	sys.Unlock()
+	return nil
+}
+
+func loadObjectLambdaConfig(ctx context.Context, objAPI ObjectLayer) (map[string]ObjectLambdaAccessPoint, error) {
+	aps := make(map[string]ObjectLambdaAccessPoint)
+	data, err := readConfig(ctx, objAPI, objectLambdaConfigPath)
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return aps, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &aps); err != nil {
+		return nil, err
+	}
+	return aps, nil
+}
+
+// Get returns the access point with the given name.
+func (sys *objectLambdaSys) Get(name string) (ObjectLambdaAccessPoint, bool) {
+	sys.RLock()
+	defer sys.RUnlock()
+	ap, ok := sys.accessPoints[name]
+	return ap, ok
+}
+
+// List returns all access points sorted by name.
+func (sys *objectLambdaSys) List() []ObjectLambdaAccessPoint {
+	sys.RLock()
+	defer sys.RUnlock()
+	aps := make([]ObjectLambdaAccessPoint, 0, len(sys.accessPoints))
+	for _, ap := range sys.accessPoints {
+		aps = append(aps, ap)
+	}
+	sort.Slice(aps, func(i, j int) bool {
+		return aps[i].Name < aps[j].Name
+	})
+	return aps
+}
+
+// Set creates or replaces an access point.
+func (sys *objectLambdaSys) Set(ctx context.Context, objAPI ObjectLayer, ap ObjectLambdaAccessPoint) error {
+	if err := ap.Validate(); err != nil {
+		return err
+	}
+	if _, err := objAPI.GetBucketInfo(ctx, ap.Bucket, BucketOptions{}); err != nil {
+		return err
+	}
+	// The alias shares the bucket namespace of S3 requests.
+	if _, err := objAPI.GetBucketInfo(ctx, ap.Name, BucketOptions{}); err == nil {
+		return errObjectLambdaNameInUse
+	} else if !isErrBucketNotFound(err) {
+		return err
+	}
+	return sys.update(ctx, objAPI, func(aps map[string]ObjectLambdaAccessPoint) error {
+		aps[ap.Name] = ap
+		return nil
+	})
+}
+
+// Delete removes an access point.
+func (sys *objectLambdaSys) Delete(ctx context.Context, objAPI ObjectLayer, name string) error {
+	return sys.update(ctx, objAPI, func(aps map[string]ObjectLambdaAccessPoint) error {
+		if _, ok := aps[name]; !ok {
+			return errObjectLambdaNotFound
+		}
+		delete(aps, name)
+		return nil
+	})
+}
+
+// update applies fn to the persisted access points under a cluster lock.
+func (sys *objectLambdaSys) update(ctx context.Context, objAPI ObjectLayer, fn func(map[string]ObjectLambdaAccessPoint) error) error {
+	locker := objAPI.NewNSLock(minioMetaBucket, objectLambdaConfigPath)
+	lkctx, err := locker.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer locker.Unlock(lkctx.Cancel)
+
+	aps, err := loadObjectLambdaConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	if err = fn(aps); err != nil {
+		return err
+	}
+	data, err := json.Marshal(aps)
+	if err != nil {
+		return err
+	}
+	if err = saveConfig(ctx, objAPI, objectLambdaConfigPath, data); err != nil {
+		return err
+	}
+	sys.Lock()
+	sys.accessPoints = aps
+	sys.Unlock()
+	return nil
+}
+
+// objectLambdaPresignURL returns a URL to GET the original object, presigned
+// with the credentials of the caller. Anonymous callers get an unsigned URL.
+func objectLambdaPresignURL(r *http.Request, cred auth.Credentials, bucket, object, versionID string) (string, error) {
+	u := &url.URL{
+		Scheme: getURLScheme(globalIsTLS),
+		Host:   r.Host,
+		Path:   path.Join(SlashSeparator, bucket, object),
+	}
+	if versionID != "" {
+		u.RawQuery = url.Values{xhttp.VersionID: []string{versionID}}.Encode()
+	}
+	if cred.AccessKey == "" {
+		return u.String(), nil
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req = signer.PreSignV4(*req, cred.AccessKey, cred.SecretKey, cred.SessionToken,
+		globalSite.Region, int64(objectLambdaPresignExpiry/time.Second))
+	return req.URL.String(), nil
+}
+
+// objectLambdaHookRequest builds the request streaming gr to the hook of
+// rule, along with the context of the original request.
+func objectLambdaHookRequest(ctx context.Context, r *http.Request, cred auth.Credentials, ap ObjectLambdaAccessPoint, rule ObjectLambdaRule, gr *GetObjectReader) (*http.Request, error) {
+	oi := gr.ObjInfo
+	presigned, err := objectLambdaPresignURL(r, cred, oi.Bucket, oi.Name, oi.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	size, err := oi.GetActualSize()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.Endpoint, gr)
+	if err != nil {
+		return nil, err
+	}
+	user := cred.AccessKey
+	if cred.ParentUser != "" {
+		user = cred.ParentUser
+	}
+	req.ContentLength = size
+	req.Header.Set(xhttp.ContentType, oi.ContentType)
+	req.Header.Set(xhttp.ETag, "\""+oi.ETag+"\"")
+	req.Header.Set(objectLambdaAccessPoint, ap.Name)
+	req.Header.Set(objectLambdaBucket, oi.Bucket)
+	req.Header.Set(objectLambdaObject, oi.Name)
+	req.Header.Set(objectLambdaVersionID, oi.VersionID)
+	req.Header.Set(objectLambdaObjectSize, strconv.FormatInt(size, 10))
+	req.Header.Set(objectLambdaPresignedURL, presigned)
+	req.Header.Set(objectLambdaUser, user)
+	req.Header.Set(objectLambdaSourceIP, handlers.GetSourceIP(r))
+	if rule.SupportsRange {
+		if rng := r.Header.Get(xhttp.Range); rng != "" {
+			req.Header.Set(xhttp.Range, rng)
+		}
+	}
+	if rule.AuthToken != "" {
+		req.Header.Set(xhttp.Authorization, "Bearer "+rule.AuthToken)
+	}
+	return req, nil
+}
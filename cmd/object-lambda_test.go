@@ -0,0 +1,95 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/GuinsooLab/annastore/internal/auth"
+)
+
+func TestObjectLambdaAccessPointValidate(t *testing.T) {
+	rules := []ObjectLambdaRule{{Endpoint: "https://hook.example.com/redact"}}
+	testCases := []struct {
+		ap  ObjectLambdaAccessPoint
+		err error
+	}{
+		{ObjectLambdaAccessPoint{Name: "Bad_Name", Bucket: "bucket", Rules: rules}, errObjectLambdaInvalidName},
+		{ObjectLambdaAccessPoint{Name: "redacted", Bucket: "bucket"}, errObjectLambdaNoRules},
+		{ObjectLambdaAccessPoint{Name: "redacted", Bucket: "bucket", Rules: []ObjectLambdaRule{{Endpoint: "ftp://hook"}}}, errObjectLambdaInvalidHook},
+		{ObjectLambdaAccessPoint{Name: "redacted", Bucket: "bucket", Rules: rules}, nil},
+	}
+	for i, tc := range testCases {
+		if err := tc.ap.Validate(); err != tc.err {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.err, err)
+		}
+	}
+}
+
+func TestObjectLambdaAccessPointMatch(t *testing.T) {
+	ap := ObjectLambdaAccessPoint{
+		Rules: []ObjectLambdaRule{
+			{Prefix: "images/", Endpoint: "http://resize"},
+			{Prefix: "images/raw/", Endpoint: "http://convert"},
+			{Prefix: "docs/", Endpoint: "http://redact"},
+		},
+	}
+	testCases := []struct {
+		object   string
+		endpoint string
+	}{
+		{"images/a.png", "http://resize"},
+		{"images/raw/a.cr2", "http://convert"},
+		{"docs/a.pdf", "http://redact"},
+		{"other/a.txt", ""},
+	}
+	for _, tc := range testCases {
+		rule, ok := ap.match(tc.object)
+		if ok != (tc.endpoint != "") || rule.Endpoint != tc.endpoint {
+			t.Errorf("%s: expected %q, got %q", tc.object, tc.endpoint, rule.Endpoint)
+		}
+	}
+}
+
+func TestObjectLambdaPresignURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://localhost:9000/redacted/docs/a.pdf", nil)
+
+	u, err := objectLambdaPresignURL(r, auth.Credentials{}, "bucket", "docs/a.pdf", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "http://localhost:9000/bucket/docs/a.pdf" {
+		t.Fatalf("unexpected anonymous URL %s", u)
+	}
+
+	cred := auth.Credentials{AccessKey: "minio", SecretKey: "minio123"}
+	u, err = objectLambdaPresignURL(r, cred, "bucket", "docs/a.pdf", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := pu.Query()
+	if pu.Path != "/bucket/docs/a.pdf" || q.Get("versionId") != "v1" || q.Get("X-Amz-Signature") == "" {
+		t.Fatalf("unexpected presigned URL %s", u)
+	}
+}
@@ -32,7 +32,6 @@ import (
 	sse "github.com/GuinsooLab/annastore/internal/bucket/encryption"
 	objectlock "github.com/GuinsooLab/annastore/internal/bucket/object/lock"
 	"github.com/GuinsooLab/annastore/internal/bucket/replication"
-	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/crypto"
 	"github.com/GuinsooLab/annastore/internal/etag"
 	"github.com/GuinsooLab/annastore/internal/event"
@@ -102,7 +101,7 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 
 	// Validate storage class metadata if present
 	if sc := r.Header.Get(xhttp.AmzStorageClass); sc != "" {
-		if !storageclass.IsValid(sc) {
+		if !globalStorageClass.IsValid(sc) {
 			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL)
 			return
 		}
@@ -418,6 +418,46 @@ func (client *peerRESTClient) LoadGroup(group string) error {
 	return nil
 }
 
+// LoadSTSRevocations - send load STS revocations command to peers.
+func (client *peerRESTClient) LoadSTSRevocations() error {
+	respBody, err := client.call(peerRESTMethodLoadSTSRevocations, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	defer http.DrainBody(respBody)
+	return nil
+}
+
+// LoadDriveMaintenance - send load drive maintenance command to peers.
+func (client *peerRESTClient) LoadDriveMaintenance() error {
+	respBody, err := client.call(peerRESTMethodLoadDriveMaintenance, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	defer http.DrainBody(respBody)
+	return nil
+}
+
+// LoadNodeCordon - send load node cordon command to peers.
+func (client *peerRESTClient) LoadNodeCordon() error {
+	respBody, err := client.call(peerRESTMethodLoadNodeCordon, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	defer http.DrainBody(respBody)
+	return nil
+}
+
+// LoadUnrecoverable - send load unrecoverable objects command to peers.
+func (client *peerRESTClient) LoadUnrecoverable() error {
+	respBody, err := client.call(peerRESTMethodLoadUnrecoverable, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	defer http.DrainBody(respBody)
+	return nil
+}
+
 type binaryInfo struct {
 	URL         *url.URL
 	Sha256Sum   []byte
@@ -18,7 +18,7 @@
 package cmd
 
 const (
-	peerRESTVersion       = "v24" // Change ServerUpdate to DownloadBinary and CommitBinary
+	peerRESTVersion       = "v28" // Add LoadUnrecoverable
 	peerRESTVersionPrefix = SlashSeparator + peerRESTVersion
 	peerRESTPrefix        = minioReservedBucketPath + "/peer"
 	peerRESTPath          = peerRESTPrefix + peerRESTVersionPrefix
@@ -52,6 +52,10 @@ const (
 	peerRESTMethodLoadPolicyMapping           = "/loadpolicymapping"
 	peerRESTMethodDeletePolicy                = "/deletepolicy"
 	peerRESTMethodLoadGroup                   = "/loadgroup"
+	peerRESTMethodLoadSTSRevocations          = "/loadstsrevocations"
+	peerRESTMethodLoadDriveMaintenance        = "/loaddrivemaintenance"
+	peerRESTMethodLoadNodeCordon              = "/loadnodecordon"
+	peerRESTMethodLoadUnrecoverable           = "/loadunrecoverable"
 	peerRESTMethodStartProfiling              = "/startprofiling"
 	peerRESTMethodDownloadProfilingData       = "/downloadprofilingdata"
 	peerRESTMethodCycleBloom                  = "/cyclebloom"
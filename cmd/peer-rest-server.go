@@ -269,6 +269,76 @@ func (s *peerRESTServer) LoadGroupHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// LoadSTSRevocationsHandler - reloads the revoked temporary credentials.
+func (s *peerRESTServer) LoadSTSRevocationsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.IsValid(w, r) {
+		s.writeErrorResponse(w, errors.New("Invalid request"))
+		return
+	}
+
+	if err := globalIAMSys.LoadSTSRevocations(r.Context()); err != nil {
+		s.writeErrorResponse(w, err)
+		return
+	}
+}
+
+// LoadDriveMaintenanceHandler - reloads the drives under maintenance.
+func (s *peerRESTServer) LoadDriveMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.IsValid(w, r) {
+		s.writeErrorResponse(w, errors.New("Invalid request"))
+		return
+	}
+
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		s.writeErrorResponse(w, errServerNotInitialized)
+		return
+	}
+
+	if err := globalDriveMaintenanceSys.Load(r.Context(), objAPI); err != nil {
+		s.writeErrorResponse(w, err)
+		return
+	}
+}
+
+// LoadNodeCordonHandler - reloads the cordoned nodes.
+func (s *peerRESTServer) LoadNodeCordonHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.IsValid(w, r) {
+		s.writeErrorResponse(w, errors.New("Invalid request"))
+		return
+	}
+
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		s.writeErrorResponse(w, errServerNotInitialized)
+		return
+	}
+
+	if err := globalNodeCordonSys.Load(r.Context(), objAPI); err != nil {
+		s.writeErrorResponse(w, err)
+		return
+	}
+}
+
+// LoadUnrecoverableHandler - reloads the unrecoverable object versions.
+func (s *peerRESTServer) LoadUnrecoverableHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.IsValid(w, r) {
+		s.writeErrorResponse(w, errors.New("Invalid request"))
+		return
+	}
+
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		s.writeErrorResponse(w, errServerNotInitialized)
+		return
+	}
+
+	if err := globalUnrecoverableSys.Load(r.Context(), objAPI); err != nil {
+		s.writeErrorResponse(w, err)
+		return
+	}
+}
+
 // StartProfilingHandler - Issues the start profiling command.
 func (s *peerRESTServer) StartProfilingHandler(w http.ResponseWriter, r *http.Request) {
 	if !s.IsValid(w, r) {
@@ -1320,6 +1390,10 @@ func registerPeerRESTHandlers(router *mux.Router) {
 	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadUser).HandlerFunc(httpTraceAll(server.LoadUserHandler)).Queries(restQueries(peerRESTUser, peerRESTUserTemp)...)
 	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadServiceAccount).HandlerFunc(httpTraceAll(server.LoadServiceAccountHandler)).Queries(restQueries(peerRESTUser)...)
 	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadGroup).HandlerFunc(httpTraceAll(server.LoadGroupHandler)).Queries(restQueries(peerRESTGroup)...)
+	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadSTSRevocations).HandlerFunc(httpTraceAll(server.LoadSTSRevocationsHandler))
+	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadDriveMaintenance).HandlerFunc(httpTraceHdrs(server.LoadDriveMaintenanceHandler))
+	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadNodeCordon).HandlerFunc(httpTraceHdrs(server.LoadNodeCordonHandler))
+	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodLoadUnrecoverable).HandlerFunc(httpTraceHdrs(server.LoadUnrecoverableHandler))
 
 	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodStartProfiling).HandlerFunc(httpTraceAll(server.StartProfilingHandler)).Queries(restQueries(peerRESTProfiler)...)
 	subrouter.Methods(http.MethodPost).Path(peerRESTVersionPrefix + peerRESTMethodDownloadProfilingData).HandlerFunc(httpTraceHdrs(server.DownloadProfilingDataHandler))
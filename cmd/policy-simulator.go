@@ -0,0 +1,460 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/GuinsooLab/annastore/internal/arn"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/minio/pkg/bucket/policy"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// Principal types of a policy simulation.
+const (
+	simPrincipalAnonymous      = "anonymous"
+	simPrincipalOwner          = "owner"
+	simPrincipalUser           = "user"
+	simPrincipalGroup          = "group"
+	simPrincipalServiceAccount = "serviceAccount"
+	simPrincipalSTS            = "sts"
+)
+
+// Sources of the policies evaluated by a policy simulation.
+const (
+	simSourceOwner          = "owner"
+	simSourcePlugin         = "plugin"
+	simSourceIAM            = "iam"
+	simSourceSession        = "sessionPolicy"
+	simSourceServiceAccount = "serviceAccountPolicy"
+	simSourceBucket         = "bucketPolicy"
+)
+
+var (
+	errSimulationNoAction        = errors.New("action is required")
+	errSimulationInvalidAction   = errors.New("unsupported action")
+	errSimulationPrincipal       = errors.New("only one of accessKey, group and claims may be set")
+	errSimulationNoParent        = errors.New("claims must carry the parent user of the session")
+	errSimulationAnonymousBucket = errors.New("bucket is required for anonymous requests")
+)
+
+// policySimulationRequest is the request of the policy simulator. The
+// principal is an existing user, service account or STS credential given by
+// its access key, a group, the claims of an STS session, or anonymous when
+// none are set.
+type policySimulationRequest struct {
+	AccessKey string                 `json:"accessKey,omitempty"`
+	Group     string                 `json:"group,omitempty"`
+	Claims    map[string]interface{} `json:"claims,omitempty"`
+	// Groups of an STS session given by its claims, e.g. LDAP groups.
+	Groups []string `json:"groups,omitempty"`
+
+	Action string `json:"action"`
+	Bucket string `json:"bucket,omitempty"`
+	Object string `json:"object,omitempty"`
+	// ObjectTags are the object tags of the request, as in the
+	// x-amz-tagging header.
+	ObjectTags string `json:"objectTags,omitempty"`
+	// Conditions override the condition values of the request.
+	Conditions map[string][]string `json:"conditions,omitempty"`
+}
+
+// policySimulationStatement is a statement matching a simulated request.
+type policySimulationStatement struct {
+	Policy    string      `json:"policy,omitempty"`
+	Index     int         `json:"index"`
+	Effect    string      `json:"effect"`
+	Statement interface{} `json:"statement"`
+}
+
+// policySimulationEvaluation is the evaluation of the policies of a source.
+type policySimulationEvaluation struct {
+	Source            string                      `json:"source"`
+	Policies          []string                    `json:"policies,omitempty"`
+	Allowed           bool                        `json:"allowed"`
+	MatchedStatements []policySimulationStatement `json:"matchedStatements,omitempty"`
+}
+
+// policySimulationResult is the decision of the policy simulator.
+type policySimulationResult struct {
+	Allowed bool `json:"allowed"`
+	// DecidedBy is the source denying the request, or allowing it when
+	// allowed.
+	DecidedBy       string                       `json:"decidedBy"`
+	PrincipalType   string                       `json:"principalType"`
+	ParentUser      string                       `json:"parentUser,omitempty"`
+	RoleArn         string                       `json:"roleArn,omitempty"`
+	Groups          []string                     `json:"groups,omitempty"`
+	Evaluations     []policySimulationEvaluation `json:"evaluations,omitempty"`
+	ConditionValues map[string][]string          `json:"conditionValues,omitempty"`
+	Notes           []string                     `json:"notes,omitempty"`
+}
+
+func (res *policySimulationResult) notef(format string, a ...interface{}) {
+	res.Notes = append(res.Notes, fmt.Sprintf(format, a...))
+}
+
+// simulatePolicy evaluates a request like checkRequestAuthTypeCredential,
+// anonymous requests against the bucket policy and others with
+// IAMSys.IsAllowed, and returns the decision with the statements matching
+// the request.
+func simulatePolicy(ctx context.Context, req policySimulationRequest) (res policySimulationResult, err error) {
+	if req.Action == "" {
+		return res, errSimulationNoAction
+	}
+	action := iampolicy.Action(req.Action)
+	if !action.IsValid() {
+		return res, errSimulationInvalidAction
+	}
+	set := 0
+	for _, s := range []bool{req.AccessKey != "", req.Group != "", req.Claims != nil} {
+		if s {
+			set++
+		}
+	}
+	if set > 1 {
+		return res, errSimulationPrincipal
+	}
+
+	if req.AccessKey == "" && req.Group == "" && req.Claims == nil {
+		if req.Bucket == "" {
+			return res, errSimulationAnonymousBucket
+		}
+		res = simulateBucketPolicy(req, policy.Action(action))
+		if !res.Allowed && action == iampolicy.ListBucketVersionsAction {
+			// Like S3, s3:ListBucket allows listing versions.
+			res = simulateBucketPolicy(req, policy.ListBucketAction)
+			res.notef("evaluated %s as the fallback of %s", policy.ListBucketAction, action)
+		}
+		return res, nil
+	}
+
+	res, err = globalIAMSys.simulate(ctx, req, action)
+	if err == nil && !res.Allowed && action == iampolicy.ListBucketVersionsAction {
+		res, err = globalIAMSys.simulate(ctx, req, iampolicy.ListBucketAction)
+		res.notef("evaluated %s as the fallback of %s", iampolicy.ListBucketAction, action)
+	}
+	return res, err
+}
+
+// simulationConditionValues returns the condition values of a request of
+// the principal, built like those of an S3 request by getConditionValues.
+func simulationConditionValues(req policySimulationRequest, username string, claims map[string]interface{}) map[string][]string {
+	r := &http.Request{
+		Header: make(http.Header),
+		Form:   make(url.Values),
+		URL:    &url.URL{},
+	}
+	if req.ObjectTags != "" {
+		r.Header.Set(xhttp.AmzObjectTagging, req.ObjectTags)
+	}
+	values := getConditionValues(r, "", username, claims)
+	for k, v := range req.Conditions {
+		if isTagConditionName(k) {
+			// Tag condition values are those of the principal, bucket
+			// and object tags.
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+func simulateBucketPolicy(req policySimulationRequest, action policy.Action) (res policySimulationResult) {
+	res.PrincipalType = simPrincipalAnonymous
+	args := policy.Args{
+		Action:          action,
+		BucketName:      req.Bucket,
+		ConditionValues: simulationConditionValues(req, "", nil),
+		ObjectName:      req.Object,
+	}
+	res.Allowed = globalPolicySys.IsAllowed(args)
+	res.DecidedBy = simSourceBucket
+	res.ConditionValues = args.ConditionValues
+
+	eval := policySimulationEvaluation{Source: simSourceBucket, Allowed: res.Allowed}
+	p, err := globalPolicySys.Get(req.Bucket)
+	if err != nil {
+		res.notef("no bucket policy applies to %s: %v", req.Bucket, err)
+	} else {
+		for i, st := range p.Statements {
+			if st.Effect.IsAllowed(true) == st.IsAllowed(args) {
+				eval.MatchedStatements = append(eval.MatchedStatements, policySimulationStatement{
+					Index:     i,
+					Effect:    string(st.Effect),
+					Statement: st,
+				})
+			}
+		}
+	}
+	res.Evaluations = append(res.Evaluations, eval)
+	return res
+}
+
+// simulate evaluates the request of an IAM principal with the same
+// functions as IsAllowed and traces the evaluated policies.
+func (sys *IAMSys) simulate(ctx context.Context, req policySimulationRequest, action iampolicy.Action) (res policySimulationResult, err error) {
+	if !sys.Initialized() {
+		return res, errServerNotInitialized
+	}
+
+	args := iampolicy.Args{
+		Action:     action,
+		BucketName: req.Bucket,
+		ObjectName: req.Object,
+	}
+
+	var parentUser string
+	switch {
+	case req.Group != "":
+		res.PrincipalType = simPrincipalGroup
+		args.Groups = []string{req.Group}
+
+	case req.Claims != nil:
+		res.PrincipalType = simPrincipalSTS
+		claims := make(map[string]interface{}, len(req.Claims))
+		for k, v := range req.Claims {
+			claims[k] = v
+		}
+		// The session policy of a token is passed base64 encoded and
+		// decoded when the request is authenticated.
+		delete(claims, sessionPolicyNameExtracted)
+		if sp, ok := claims[iampolicy.SessionPolicyName].(string); ok {
+			spBytes, err := base64.StdEncoding.DecodeString(sp)
+			if err != nil {
+				return res, err
+			}
+			claims[sessionPolicyNameExtracted] = string(spBytes)
+		}
+		parentUser, _ = claims[parentClaim].(string)
+		if parentUser == "" {
+			return res, errSimulationNoParent
+		}
+		args.Claims = claims
+		args.Groups = req.Groups
+
+	default:
+		u, ok := sys.GetUser(ctx, req.AccessKey)
+		if !ok {
+			return res, errNoSuchUser
+		}
+		cred := u.Credentials
+		args.AccountName = cred.AccessKey
+		args.Groups = cred.Groups
+		res.PrincipalType = simPrincipalUser
+		switch {
+		case cred.AccessKey == globalActiveCred.AccessKey:
+			res.PrincipalType = simPrincipalOwner
+			args.IsOwner = true
+		case cred.IsTemp():
+			res.PrincipalType = simPrincipalSTS
+			args.Claims, err = getClaimsFromTokenWithSecret(cred.SessionToken, globalActiveCred.SecretKey)
+		case cred.IsServiceAccount():
+			res.PrincipalType = simPrincipalServiceAccount
+			args.Claims, err = getClaimsFromTokenWithSecret(cred.SessionToken, cred.SecretKey)
+		}
+		if err != nil {
+			return res, err
+		}
+		parentUser = cred.ParentUser
+		if !cred.IsValid() {
+			res.notef("credentials of %s are disabled or expired, requests are rejected before authorization", cred.AccessKey)
+		}
+	}
+	username := args.AccountName
+	if username == "" {
+		username = parentUser
+	}
+	args.ConditionValues = simulationConditionValues(req, username, args.Claims)
+
+	res.ParentUser = parentUser
+	res.RoleArn = args.GetRoleArn()
+	res.Groups = args.Groups
+	res.ConditionValues = args.ConditionValues
+
+	if authz := newGlobalAuthZPluginFn(); authz != nil {
+		res.DecidedBy = simSourcePlugin
+		res.Allowed, err = authz.IsAllowed(args)
+		if err != nil {
+			res.notef("access management plugin failed: %v", err)
+		}
+		res.Evaluations = append(res.Evaluations, policySimulationEvaluation{
+			Source:  simSourcePlugin,
+			Allowed: res.Allowed,
+		})
+		return res, nil
+	}
+
+	if args.IsOwner {
+		res.Allowed = true
+		res.DecidedBy = simSourceOwner
+		return res, nil
+	}
+
+	// The decision is that of the same evaluation as an S3 request, which
+	// also sets the dynamic condition values used to trace the policies.
+	switch res.PrincipalType {
+	case simPrincipalGroup:
+		sys.setTagConditionValues(args, "")
+	case simPrincipalSTS:
+		if req.Claims != nil {
+			sys.setTagConditionValues(args, parentUser)
+			res.Allowed = sys.IsAllowedSTS(args, parentUser)
+			break
+		}
+		fallthrough
+	default:
+		res.Allowed = sys.IsAllowed(args)
+	}
+
+	// Trace the mapped policies like IsAllowedSTS, IsAllowedServiceAccount
+	// and IsAllowed.
+	var policies []string
+	isOwnerDerived := parentUser != "" && parentUser == globalActiveCred.AccessKey
+	switch {
+	case isOwnerDerived:
+		res.Evaluations = append(res.Evaluations, policySimulationEvaluation{Source: simSourceOwner, Allowed: true})
+	case res.PrincipalType == simPrincipalGroup:
+		policies, err = sys.PolicyDBGet(req.Group, true)
+	case res.PrincipalType == simPrincipalUser:
+		policies, err = sys.PolicyDBGet(args.AccountName, false, args.Groups...)
+	case res.RoleArn != "":
+		policies = sys.rolesPolicies(res.RoleArn)
+	default:
+		policies, err = sys.PolicyDBGet(parentUser, false, args.Groups...)
+		if attrPolicies := sys.ldapAttributePolicies(args.Claims); len(attrPolicies) > 0 {
+			policies = append(policies, attrPolicies...)
+			res.notef("LDAP attribute policies %s apply", strings.Join(attrPolicies, ","))
+		}
+		if err == nil && len(policies) == 0 {
+			policySet, _ := iampolicy.GetPoliciesFromClaims(args.Claims, iamPolicyClaimNameOpenID())
+			policies = policySet.ToSlice()
+			if len(policies) > 0 {
+				res.notef("policies of the %s claim apply, %s has no mapped policies", iamPolicyClaimNameOpenID(), parentUser)
+			}
+		}
+	}
+	if err != nil {
+		return res, err
+	}
+	if !isOwnerDerived {
+		eval := sys.traceIAMPolicies(simSourceIAM, policies, args, &res)
+		res.Evaluations = append(res.Evaluations, eval)
+	}
+	if res.PrincipalType == simPrincipalGroup {
+		res.Allowed = res.Evaluations[0].Allowed
+	}
+
+	// Trace the session policy of STS credentials and the embedded policy
+	// of service accounts.
+	if spStr, ok := args.Claims[sessionPolicyNameExtracted].(string); ok {
+		source := simSourceSession
+		if res.PrincipalType == simPrincipalServiceAccount {
+			source = simSourceServiceAccount
+			if pt, _ := args.Claims[iamPolicyClaimNameSA()].(string); pt == inheritedPolicyType {
+				spStr = ""
+			}
+		}
+		if spStr != "" {
+			eval := policySimulationEvaluation{Source: source}
+			sp, err := parseSessionPolicy(spStr, args.ConditionValues)
+			if err != nil {
+				res.notef("invalid %s: %v", source, err)
+			} else {
+				eval.Allowed = sp.IsAllowed(args)
+				eval.MatchedStatements = matchingIAMStatements("", *sp, args)
+			}
+			res.Evaluations = append(res.Evaluations, eval)
+		}
+	}
+
+	res.DecidedBy = simSourceIAM
+	if !res.Allowed {
+		for _, eval := range res.Evaluations {
+			if !eval.Allowed {
+				res.DecidedBy = eval.Source
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// rolesPolicies returns the policies of a role ARN.
+func (sys *IAMSys) rolesPolicies(roleArn string) []string {
+	arn, err := arn.Parse(roleArn)
+	if err != nil {
+		return nil
+	}
+	return newMappedPolicy(sys.rolesMap[arn]).toSlice()
+}
+
+// traceIAMPolicies evaluates the combined named policies and returns the
+// statements of each policy matching the request.
+func (sys *IAMSys) traceIAMPolicies(source string, policies []string, args iampolicy.Args, res *policySimulationResult) policySimulationEvaluation {
+	eval := policySimulationEvaluation{Source: source}
+	if len(policies) == 0 {
+		res.notef("no policies are mapped to the principal")
+		return eval
+	}
+	available, combined := sys.store.FilterPrincipalPolicies(strings.Join(policies, ","), args.ConditionValues)
+	for _, name := range policies {
+		if !strings.Contains(","+available+",", ","+name+",") {
+			res.notef("policy %s does not exist", name)
+		}
+	}
+	eval.Policies = strings.Split(available, ",")
+	if available == "" {
+		eval.Policies = nil
+		return eval
+	}
+	sort.Strings(eval.Policies)
+	eval.Allowed = combined.IsAllowed(args)
+	for _, name := range eval.Policies {
+		p, err := sys.store.GetPrincipalPolicy(name, args.ConditionValues)
+		if err != nil {
+			continue
+		}
+		eval.MatchedStatements = append(eval.MatchedStatements, matchingIAMStatements(name, p, args)...)
+	}
+	return eval
+}
+
+// matchingIAMStatements returns the statements of p matching a request,
+// allow statements allowing and deny statements denying it.
+func matchingIAMStatements(name string, p iampolicy.Policy, args iampolicy.Args) (matched []policySimulationStatement) {
+	for i, st := range p.Statements {
+		if st.Effect.IsAllowed(true) == st.IsAllowed(args) {
+			matched = append(matched, policySimulationStatement{
+				Policy:    name,
+				Index:     i,
+				Effect:    string(st.Effect),
+				Statement: st,
+			})
+		}
+	}
+	return matched
+}
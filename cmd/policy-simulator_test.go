@@ -0,0 +1,112 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+func TestSimulatePolicyRequestValidation(t *testing.T) {
+	testCases := []struct {
+		req     policySimulationRequest
+		wantErr error
+	}{
+		{
+			req:     policySimulationRequest{AccessKey: "alice"},
+			wantErr: errSimulationNoAction,
+		},
+		{
+			req:     policySimulationRequest{AccessKey: "alice", Action: "s3:NoSuchAction"},
+			wantErr: errSimulationInvalidAction,
+		},
+		{
+			req:     policySimulationRequest{AccessKey: "alice", Group: "dev", Action: "s3:GetObject"},
+			wantErr: errSimulationPrincipal,
+		},
+		{
+			req:     policySimulationRequest{Action: "s3:GetObject"},
+			wantErr: errSimulationAnonymousBucket,
+		},
+	}
+	for i, tc := range testCases {
+		if _, err := simulatePolicy(context.Background(), tc.req); err != tc.wantErr {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.wantErr, err)
+		}
+	}
+}
+
+func TestMatchingIAMStatements(t *testing.T) {
+	p, err := parseIAMPolicy(strings.NewReader(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": ["s3:*"],
+      "Resource": ["arn:aws:s3:::data/*"]
+    },
+    {
+      "Effect": "Deny",
+      "Action": ["s3:DeleteObject"],
+      "Resource": ["arn:aws:s3:::data/locked/*"]
+    },
+    {
+      "Effect": "Allow",
+      "Action": ["s3:GetObject"],
+      "Resource": ["arn:aws:s3:::home/${aws:PrincipalTag/team}/*"]
+    }
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		action      iampolicy.Action
+		bucket      string
+		object      string
+		wantIndexes []int
+	}{
+		{action: iampolicy.GetObjectAction, bucket: "data", object: "a.txt", wantIndexes: []int{0}},
+		{action: iampolicy.DeleteObjectAction, bucket: "data", object: "locked/a.txt", wantIndexes: []int{0, 1}},
+		{action: iampolicy.GetObjectAction, bucket: "home", object: "red/a.txt", wantIndexes: []int{2}},
+		{action: iampolicy.GetObjectAction, bucket: "home", object: "blue/a.txt"},
+	}
+	for i, tc := range testCases {
+		args := iampolicy.Args{
+			AccountName:     "alice",
+			Action:          tc.action,
+			BucketName:      tc.bucket,
+			ObjectName:      tc.object,
+			ConditionValues: map[string][]string{principalTagPrefix + "team": {"red"}},
+		}
+		matched := matchingIAMStatements("test", newPolicyDoc(*p).principalPolicy(args.ConditionValues), args)
+		if len(matched) != len(tc.wantIndexes) {
+			t.Errorf("case %d: expected statements %v, got %v", i+1, tc.wantIndexes, matched)
+			continue
+		}
+		for j, m := range matched {
+			if m.Index != tc.wantIndexes[j] || m.Policy != "test" {
+				t.Errorf("case %d: expected statements %v, got %v", i+1, tc.wantIndexes, matched)
+			}
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/auth"
+	"github.com/GuinsooLab/annastore/internal/config/ratelimit"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/gorilla/mux"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+)
+
+// Number of limit states of a rule applying to each access key, group or
+// bucket separately above which the least recently used ones are dropped.
+const rateLimitMaxStates = 10000
+
+// newBurstLimiter returns a limiter of rate tokens per second allowing the
+// tokens of burst at once, at least one.
+func newBurstLimiter(r float64, burst time.Duration) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(r), int(math.Min(math.Max(r*burst.Seconds(), 1), math.MaxInt32)))
+}
+
+// rateLimitDelay returns the duration until lim has a token, 0 if it has
+// one, without taking it.
+func rateLimitDelay(lim *rate.Limiter, now time.Time) time.Duration {
+	r := lim.ReserveN(now, 1)
+	defer r.CancelAt(now)
+	return r.DelayFrom(now)
+}
+
+// rateLimitStats - the requests and bytes charged to a rule, and the
+// requests it rejected, since the server started.
+type rateLimitStats struct {
+	Requests uint64
+	Rejected uint64
+	Bytes    uint64
+}
+
+// rateLimitState - the limiters of a rule for one of its subjects.
+type rateLimitState struct {
+	rule *rateLimitRule
+
+	requests  *rate.Limiter
+	bandwidth *rate.Limiter
+}
+
+// wait returns the duration until a request is allowed, 0 if it is.
+func (s *rateLimitState) wait(now time.Time) (d time.Duration) {
+	if s.requests != nil {
+		d = rateLimitDelay(s.requests, now)
+	}
+	if s.bandwidth != nil {
+		if bd := rateLimitDelay(s.bandwidth, now); bd > d {
+			d = bd
+		}
+	}
+	return d
+}
+
+// takeRequest charges a request.
+func (s *rateLimitState) takeRequest(now time.Time) {
+	atomic.AddUint64(&s.rule.stats.Requests, 1)
+	if s.requests == nil {
+		return
+	}
+	s.requests.ReserveN(now, 1)
+}
+
+// takeBytes charges n bytes received or sent. Bytes are taken once
+// transferred, so that the bandwidth is in debt after transferring more
+// bytes than available.
+func (s *rateLimitState) takeBytes(now time.Time, n int64) {
+	atomic.AddUint64(&s.rule.stats.Bytes, uint64(n))
+	if s.bandwidth == nil {
+		return
+	}
+	// At most burst tokens are reserved at once.
+	for burst := int64(s.bandwidth.Burst()); n > 0; n -= burst {
+		if n < burst {
+			burst = n
+		}
+		s.bandwidth.ReserveN(now, int(burst))
+	}
+}
+
+// rateLimitRule - a rate limit rule and the limit states of its subjects.
+type rateLimitRule struct {
+	ratelimit.Rule
+	stats *rateLimitStats
+
+	mu sync.Mutex
+	// states by subject, a single state with an empty subject unless
+	// the rule applies to each subject separately. The least recently
+	// used states are dropped past rateLimitMaxStates.
+	states *lru.Cache
+}
+
+// state returns the limit state of a subject.
+func (r *rateLimitRule) state(subject string) *rateLimitState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.states.Get(subject); ok {
+		return s.(*rateLimitState)
+	}
+	s := &rateLimitState{rule: r}
+	if r.Requests > 0 {
+		s.requests = newBurstLimiter(r.Requests, r.Burst)
+	}
+	if r.Bandwidth > 0 {
+		s.bandwidth = newBurstLimiter(float64(r.Bandwidth), r.Burst)
+	}
+	r.states.Add(subject, s)
+	return s
+}
+
+// subjects returns the subjects of the rule a request on bucket by id is
+// charged to, nil when the rule does not apply. id is nil for requests
+// which are not authenticated yet.
+func (r *rateLimitRule) subjects(bucket string, id *rateLimitIdentity) []string {
+	switch r.Type {
+	case ratelimit.RuleBucket:
+		if bucket == "" {
+			return nil
+		}
+		if r.Subject == ratelimit.Any {
+			return []string{bucket}
+		}
+		if r.Subject == bucket {
+			return []string{""}
+		}
+	case ratelimit.RuleAccessKey:
+		if id == nil {
+			return nil
+		}
+		if r.Subject == ratelimit.Any {
+			return []string{id.user}
+		}
+		if r.Subject == id.user || r.Subject == id.accessKey {
+			return []string{""}
+		}
+	case ratelimit.RuleGroup:
+		if id == nil {
+			return nil
+		}
+		if r.Subject == ratelimit.Any {
+			return id.groups
+		}
+		for _, group := range id.groups {
+			if r.Subject == group {
+				return []string{""}
+			}
+		}
+	}
+	return nil
+}
+
+// rateLimiter - enforces the rate limit rules of this node.
+type rateLimiter struct {
+	mu    sync.RWMutex
+	rules []*rateLimitRule
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{}
+}
+
+// update replaces the rules, keeping the stats of the rules which are still
+// configured.
+func (l *rateLimiter) update(cfg ratelimit.Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]*rateLimitStats, len(l.rules))
+	for _, r := range l.rules {
+		stats[r.Name] = r.stats
+	}
+	rules := make([]*rateLimitRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		states, _ := lru.New(rateLimitMaxStates)
+		r := &rateLimitRule{
+			Rule:   rule,
+			stats:  stats[rule.Name],
+			states: states,
+		}
+		if r.stats == nil {
+			r.stats = &rateLimitStats{}
+		}
+		rules = append(rules, r)
+	}
+	l.rules = rules
+}
+
+func (l *rateLimiter) getRules() []*rateLimitRule {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.rules
+}
+
+// states returns the limit states a request on bucket by id is charged to.
+func (l *rateLimiter) states(bucket string, id *rateLimitIdentity) (states []*rateLimitState) {
+	for _, r := range l.getRules() {
+		for _, subject := range r.subjects(bucket, id) {
+			states = append(states, r.state(subject))
+		}
+	}
+	return states
+}
+
+// getStats returns the stats of the rules by name.
+func (l *rateLimiter) getStats() map[string]rateLimitStats {
+	rules := l.getRules()
+	stats := make(map[string]rateLimitStats, len(rules))
+	for _, r := range rules {
+		stats[r.Name] = rateLimitStats{
+			Requests: atomic.LoadUint64(&r.stats.Requests),
+			Rejected: atomic.LoadUint64(&r.stats.Rejected),
+			Bytes:    atomic.LoadUint64(&r.stats.Bytes),
+		}
+	}
+	return stats
+}
+
+// rateLimitIdentity - the access key of a request, the user it belongs to
+// and their groups.
+type rateLimitIdentity struct {
+	accessKey string
+	// user is the parent user of service accounts and temporary
+	// credentials, or else the access key.
+	user   string
+	groups []string
+}
+
+func rateLimitIdentityOf(cred auth.Credentials) *rateLimitIdentity {
+	id := &rateLimitIdentity{
+		accessKey: cred.AccessKey,
+		user:      cred.AccessKey,
+		groups:    cred.Groups,
+	}
+	if cred.ParentUser != "" {
+		id.user = cred.ParentUser
+	}
+	if globalIAMSys.Initialized() {
+		id.groups = append(id.groups, globalIAMSys.store.GetUserGroups(id.user)...)
+	}
+	return id
+}
+
+// claimedAccessKey returns the access key a request claims to be signed
+// with, without verifying its signature.
+func claimedAccessKey(r *http.Request) string {
+	query := r.URL.Query()
+	if accessKey := query.Get(xhttp.AmzAccessKeyID); accessKey != "" {
+		return accessKey
+	}
+	credElement := "Credential=" + query.Get(xhttp.AmzCredential)
+	if authz := r.Header.Get(xhttp.Authorization); strings.HasPrefix(authz, signV4Algorithm) {
+		credElement = strings.TrimSpace(strings.Split(strings.TrimPrefix(authz, signV4Algorithm), ",")[0])
+	} else if strings.HasPrefix(authz, signV2Algorithm+" ") {
+		keySign := strings.TrimPrefix(authz, signV2Algorithm+" ")
+		if i := strings.LastIndex(keySign, ":"); i > 0 {
+			return strings.TrimSpace(keySign[:i])
+		}
+		return ""
+	}
+	ch, s3Err := parseCredentialHeader(credElement, "", serviceS3)
+	if s3Err != ErrNone {
+		return ""
+	}
+	return ch.accessKey
+}
+
+// claimedRateLimitIdentity returns the identity of the access key a request
+// claims to be signed with, nil if unknown. It is only used to reject the
+// requests of an identity exceeding its limits: the requests are charged
+// once authenticated, so that unsigned requests claiming the access key of
+// someone else cannot exhaust their limits.
+func claimedRateLimitIdentity(r *http.Request) *rateLimitIdentity {
+	accessKey := claimedAccessKey(r)
+	switch {
+	case accessKey == "":
+		return nil
+	case accessKey == globalActiveCred.AccessKey:
+		return rateLimitIdentityOf(globalActiveCred)
+	case !globalIAMSys.Initialized():
+		return nil
+	}
+	// Only look up the cached users, the store is not queried for
+	// requests which are not authenticated yet.
+	u, ok := globalIAMSys.store.GetUser(accessKey)
+	if !ok {
+		return nil
+	}
+	return rateLimitIdentityOf(u.Credentials)
+}
+
+// rateLimitReqKey is the context key of the rate limit state of a request.
+type rateLimitReqKey struct{}
+
+// rateLimitReq - the limit states a request is charged to.
+type rateLimitReq struct {
+	mu            sync.Mutex
+	authenticated bool
+	states        []*rateLimitState
+}
+
+// rateLimitAuthenticated - charges a request authenticated with cred to the
+// rate limits of its access key and groups.
+func rateLimitAuthenticated(r *http.Request, cred auth.Credentials) {
+	req, ok := r.Context().Value(rateLimitReqKey{}).(*rateLimitReq)
+	if !ok || cred.AccessKey == "" {
+		return
+	}
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if req.authenticated {
+		return
+	}
+	req.authenticated = true
+
+	now := time.Now()
+	for _, s := range globalRateLimiter.states("", rateLimitIdentityOf(cred)) {
+		s.takeRequest(now)
+		req.states = append(req.states, s)
+	}
+}
+
+// countingReadCloser counts the bytes read from a request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+// rateLimitMiddleware - rejects with SlowDown the S3 requests on a bucket or
+// signed with an access key exceeding their rate limits, and charges the
+// requests and the bytes they transfer to these limits.
+func rateLimitMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(globalRateLimiter.getRules()) == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		bucketStates := globalRateLimiter.states(mux.Vars(r)["bucket"], nil)
+		states := bucketStates
+		if id := claimedRateLimitIdentity(r); id != nil {
+			states = append(states, globalRateLimiter.states("", id)...)
+		}
+
+		var wait time.Duration
+		for _, s := range states {
+			if d := s.wait(now); d > 0 {
+				atomic.AddUint64(&s.rule.stats.Rejected, 1)
+				if d > wait {
+					wait = d
+				}
+			}
+		}
+		if wait > 0 {
+			w.Header().Set(xhttp.RetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrSlowDown), r.URL)
+			return
+		}
+
+		for _, s := range bucketStates {
+			s.takeRequest(now)
+		}
+		req := &rateLimitReq{states: bucketStates}
+		r = r.WithContext(context.WithValue(r.Context(), rateLimitReqKey{}, req))
+		var body *countingReadCloser
+		if r.Body != nil {
+			body = &countingReadCloser{ReadCloser: r.Body}
+			r.Body = body
+		}
+		lw := logger.NewResponseWriter(w)
+
+		h.ServeHTTP(lw, r)
+
+		n := int64(lw.Size())
+		if body != nil {
+			n += atomic.LoadInt64(&body.n)
+		}
+		now = time.Now()
+		req.mu.Lock()
+		defer req.mu.Unlock()
+		for _, s := range req.states {
+			s.takeBytes(now, n)
+		}
+	})
+}
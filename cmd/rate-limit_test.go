@@ -0,0 +1,205 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/auth"
+	"github.com/GuinsooLab/annastore/internal/config/ratelimit"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/gorilla/mux"
+)
+
+func TestRateLimitState(t *testing.T) {
+	now := time.Now()
+	r := &rateLimitRule{stats: &rateLimitStats{}}
+	s := &rateLimitState{rule: r, requests: newBurstLimiter(2, 2*time.Second)}
+	for i := 0; i < 4; i++ {
+		if d := s.wait(now); d != 0 {
+			t.Fatalf("request %d: expected no wait, got %s", i+1, d)
+		}
+		s.takeRequest(now)
+	}
+	if d := s.wait(now); d != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %s", d)
+	}
+	if d := s.wait(now.Add(2 * time.Second)); d != 0 {
+		t.Fatalf("expected no wait once refilled, got %s", d)
+	}
+
+	// Bytes are taken once transferred, in debt.
+	s = &rateLimitState{rule: r, bandwidth: newBurstLimiter(1000, time.Second)}
+	s.takeBytes(now, 3000)
+	if d := s.wait(now); d < 2*time.Second || d > 2001*time.Millisecond {
+		t.Fatalf("expected to wait 2.001s, got %s", d)
+	}
+	if d := s.wait(now.Add(3 * time.Second)); d != 0 {
+		t.Fatalf("expected no wait once refilled, got %s", d)
+	}
+	if r.stats.Requests != 4 || r.stats.Bytes != 3000 {
+		t.Fatalf("unexpected stats %+v", *r.stats)
+	}
+}
+
+func TestRateLimitRuleStates(t *testing.T) {
+	l := newRateLimiter()
+	l.update(ratelimit.Config{Rules: []ratelimit.Rule{
+		{Name: "bucket", Type: ratelimit.RuleBucket, Subject: ratelimit.Any, Requests: 1, Burst: time.Second},
+	}})
+	r := l.getRules()[0]
+
+	// The least recently used states are dropped.
+	first := r.state("bucket0")
+	for i := 1; i <= rateLimitMaxStates; i++ {
+		r.state("bucket" + strconv.Itoa(i))
+	}
+	if n := r.states.Len(); n != rateLimitMaxStates {
+		t.Fatalf("expected %d states, got %d", rateLimitMaxStates, n)
+	}
+	// bucket1 is used again, bucket2 is dropped in place of bucket0.
+	used := r.state("bucket1")
+	if r.state("bucket0") == first {
+		t.Fatal("expected the least recently used state to be dropped")
+	}
+	if r.state("bucket1") != used || r.states.Contains("bucket2") {
+		t.Fatal("expected the recently used state to be kept")
+	}
+}
+
+func TestRateLimitRuleSubjects(t *testing.T) {
+	id := &rateLimitIdentity{accessKey: "svcacct", user: "alice", groups: []string{"finance", "hr"}}
+	testCases := []struct {
+		rule     ratelimit.Rule
+		bucket   string
+		id       *rateLimitIdentity
+		subjects []string
+	}{
+		{rule: ratelimit.Rule{Type: ratelimit.RuleBucket, Subject: "photos"}, bucket: "photos", subjects: []string{""}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleBucket, Subject: "photos"}, bucket: "videos"},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleBucket, Subject: ratelimit.Any}, bucket: "videos", subjects: []string{"videos"}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleBucket, Subject: ratelimit.Any}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleAccessKey, Subject: "alice"}, bucket: "photos"},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleAccessKey, Subject: "alice"}, id: id, subjects: []string{""}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleAccessKey, Subject: "svcacct"}, id: id, subjects: []string{""}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleAccessKey, Subject: "bob"}, id: id},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleAccessKey, Subject: ratelimit.Any}, id: id, subjects: []string{"alice"}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleGroup, Subject: "hr"}, id: id, subjects: []string{""}},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleGroup, Subject: "it"}, id: id},
+		{rule: ratelimit.Rule{Type: ratelimit.RuleGroup, Subject: ratelimit.Any}, id: id, subjects: []string{"finance", "hr"}},
+	}
+	for i, tc := range testCases {
+		r := &rateLimitRule{Rule: tc.rule}
+		if subjects := r.subjects(tc.bucket, tc.id); !reflect.DeepEqual(subjects, tc.subjects) {
+			t.Errorf("case %d: expected subjects %q, got %q", i+1, tc.subjects, subjects)
+		}
+	}
+}
+
+func TestClaimedAccessKey(t *testing.T) {
+	testCases := []struct {
+		query, authz string
+		accessKey    string
+	}{
+		{authz: "AWS4-HMAC-SHA256 Credential=alice/20220901/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=abcd", accessKey: "alice"},
+		{query: "X-Amz-Credential=alice%2F20220901%2Fus-east-1%2Fs3%2Faws4_request", accessKey: "alice"},
+		{authz: "AWS alice:c2lnbmF0dXJl", accessKey: "alice"},
+		{query: "AWSAccessKeyId=alice&Signature=abcd", accessKey: "alice"},
+		{authz: "AWS4-HMAC-SHA256 Credential=alice/20220901/us-east-1/sts/aws4_request, SignedHeaders=host, Signature=abcd"},
+		{authz: "Bearer token"},
+		{},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/bucket?"+tc.query, nil)
+		if tc.authz != "" {
+			r.Header.Set(xhttp.Authorization, tc.authz)
+		}
+		if accessKey := claimedAccessKey(r); accessKey != tc.accessKey {
+			t.Errorf("case %d: expected %q, got %q", i+1, tc.accessKey, accessKey)
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	activeCred := globalActiveCred
+	defer func() {
+		globalActiveCred = activeCred
+		globalRateLimiter.update(ratelimit.Config{})
+	}()
+	globalActiveCred = auth.Credentials{AccessKey: "ratelimited", SecretKey: "ratelimitedsecret"}
+	globalRateLimiter.update(ratelimit.Config{Rules: []ratelimit.Rule{
+		{Name: "bucket", Type: ratelimit.RuleBucket, Subject: "limited", Requests: 1, Burst: time.Second},
+		{Name: "user", Type: ratelimit.RuleAccessKey, Subject: "ratelimited", Bandwidth: 1000, Burst: time.Second},
+	}})
+
+	router := mux.NewRouter()
+	router.Use(rateLimitMiddleware)
+	router.Path("/{bucket}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(xhttp.Authorization) != "" {
+			rateLimitAuthenticated(r, globalActiveCred)
+		}
+		w.Write(make([]byte, 2000))
+	})
+
+	serve := func(bucket string, signed bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/"+bucket, nil)
+		if signed {
+			r.Header.Set(xhttp.Authorization, "AWS ratelimited:c2lnbmF0dXJl")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// The bandwidth of the user is exceeded after the first request, but
+	// not the one of unauthenticated requests.
+	if w := serve("other", true); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	w := serve("other", true)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if retryAfter, err := strconv.Atoi(w.Header().Get(xhttp.RetryAfter)); err != nil || retryAfter < 1 || retryAfter > 3 {
+		t.Fatalf("expected to retry after 1 to 3 seconds, got %q", w.Header().Get(xhttp.RetryAfter))
+	}
+	if w := serve("other", false); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	// The bucket allows one request at once.
+	if w := serve("limited", false); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := serve("limited", false); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	stats := globalRateLimiter.getStats()
+	if st := stats["bucket"]; st.Requests != 1 || st.Rejected != 1 || st.Bytes == 0 {
+		t.Errorf("unexpected bucket rule stats %+v", st)
+	}
+	if st := stats["user"]; st.Requests != 1 || st.Rejected != 1 || st.Bytes < 2000 {
+		t.Errorf("unexpected user rule stats %+v", st)
+	}
+}
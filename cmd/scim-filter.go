@@ -0,0 +1,339 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// scimAttr is an attribute of a SCIM resource filters may refer to.
+type scimAttr struct {
+	// caseExact attributes are compared case-sensitively.
+	caseExact bool
+	// boolean attributes are compared with true or false.
+	boolean bool
+}
+
+// Attributes of SCIM users and groups filters may refer to, the
+// sub-attribute "value" of multi-valued attributes is implied.
+var (
+	scimUserAttrs = map[string]scimAttr{
+		"id":         {caseExact: true},
+		"externalid": {caseExact: true},
+		"username":   {},
+		"active":     {boolean: true},
+		"groups":     {caseExact: true},
+	}
+	scimGroupAttrs = map[string]scimAttr{
+		"id":          {caseExact: true},
+		"externalid":  {caseExact: true},
+		"displayname": {},
+		"members":     {caseExact: true},
+	}
+	// Attributes of the members of a group, in a value path such as
+	// members[value eq "alice"].
+	scimMemberAttrs = map[string]scimAttr{
+		"value": {caseExact: true},
+	}
+)
+
+// scimFilter is a parsed SCIM filter (RFC 7644 section 3.4.2.2).
+type scimFilter interface {
+	// match returns whether the filter matches a resource, values
+	// returns the values of an attribute of the resource.
+	match(values func(attr string) []string) bool
+}
+
+// scimLogicalFilter is an "and" or "or" expression.
+type scimLogicalFilter struct {
+	and         bool
+	left, right scimFilter
+}
+
+func (f scimLogicalFilter) match(values func(attr string) []string) bool {
+	if f.and {
+		return f.left.match(values) && f.right.match(values)
+	}
+	return f.left.match(values) || f.right.match(values)
+}
+
+// scimNotFilter is a "not" expression.
+type scimNotFilter struct {
+	filter scimFilter
+}
+
+func (f scimNotFilter) match(values func(attr string) []string) bool {
+	return !f.filter.match(values)
+}
+
+// scimAttrFilter compares the values of an attribute, a multi-valued
+// attribute matches when one of its values does.
+type scimAttrFilter struct {
+	attr  string
+	op    string
+	value string
+	scimAttr
+}
+
+func (f scimAttrFilter) match(values func(attr string) []string) bool {
+	vals := values(f.attr)
+	if f.op == "pr" {
+		return len(vals) > 0
+	}
+	if f.op == "ne" {
+		return !scimAttrFilter{attr: f.attr, op: "eq", value: f.value, scimAttr: f.scimAttr}.match(values)
+	}
+	for _, v := range vals {
+		value := f.value
+		if !f.caseExact {
+			v, value = strings.ToLower(v), strings.ToLower(value)
+		}
+		switch f.op {
+		case "eq":
+			if v == value {
+				return true
+			}
+		case "co":
+			if strings.Contains(v, value) {
+				return true
+			}
+		case "sw":
+			if strings.HasPrefix(v, value) {
+				return true
+			}
+		case "ew":
+			if strings.HasSuffix(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var errSCIMFilterEnd = errors.New("unexpected end of filter")
+
+// scimFilterParser is a recursive descent parser of SCIM filters.
+type scimFilterParser struct {
+	tokens []string
+	pos    int
+	attrs  map[string]scimAttr
+}
+
+// parseSCIMFilter parses a SCIM filter on the attributes attrs. The
+// comparison operators eq, ne, co, sw, ew and pr are supported, combined
+// with and, or, not and parentheses.
+func parseSCIMFilter(filter string, attrs map[string]scimAttr) (scimFilter, error) {
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &scimFilterParser{tokens: tokens, attrs: attrs}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+// tokenizeSCIMFilter splits a filter into parentheses, quoted strings and
+// words.
+func tokenizeSCIMFilter(filter string) (tokens []string, err error) {
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(filter) && filter[j] != '"'; j++ {
+				if filter[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(filter) {
+				return nil, errors.New("unterminated string in filter")
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := strings.IndexFunc(filter[i:], func(r rune) bool {
+				return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+			})
+			if j < 0 {
+				j = len(filter) - i
+			}
+			tokens = append(tokens, filter[i:i+j])
+			i += j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty filter")
+	}
+	return tokens, nil
+}
+
+// next returns the next token, or "" at the end of the filter.
+func (p *scimFilterParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+// peekKeyword returns whether the next token is the keyword kw.
+func (p *scimFilterParser) peekKeyword(kw string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], kw)
+}
+
+func (p *scimFilterParser) parseOr() (scimFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = scimLogicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseAnd() (scimFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = scimLogicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseUnary() (scimFilter, error) {
+	not := false
+	if p.peekKeyword("not") {
+		p.pos++
+		not = true
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != "(" {
+			return nil, errors.New("not must be followed by a parenthesized filter")
+		}
+	}
+
+	var f scimFilter
+	var err error
+	if p.pos < len(p.tokens) && p.tokens[p.pos] == "(" {
+		p.pos++
+		if f, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+		if t := p.next(); t != ")" {
+			if t == "" {
+				return nil, errSCIMFilterEnd
+			}
+			return nil, fmt.Errorf("expected ) instead of %q in filter", t)
+		}
+	} else if f, err = p.parseAttrExpr(); err != nil {
+		return nil, err
+	}
+
+	if not {
+		return scimNotFilter{filter: f}, nil
+	}
+	return f, nil
+}
+
+func (p *scimFilterParser) parseAttrExpr() (scimFilter, error) {
+	name := p.next()
+	if name == "" {
+		return nil, errSCIMFilterEnd
+	}
+	attrName := strings.ToLower(name)
+	// Strip the schema URN of the attribute, if any.
+	if i := strings.LastIndex(attrName, ":"); i >= 0 {
+		attrName = attrName[i+1:]
+	}
+	attrName = strings.TrimSuffix(attrName, ".value")
+	attr, ok := p.attrs[attrName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported attribute %q in filter", name)
+	}
+
+	op := strings.ToLower(p.next())
+	switch op {
+	case "":
+		return nil, errSCIMFilterEnd
+	case "pr":
+		return scimAttrFilter{attr: attrName, op: op, scimAttr: attr}, nil
+	case "eq", "ne", "co", "sw", "ew":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q in filter", op)
+	}
+
+	value := p.next()
+	switch {
+	case value == "":
+		return nil, errSCIMFilterEnd
+	case strings.HasPrefix(value, `"`):
+		if attr.boolean {
+			return nil, fmt.Errorf("attribute %q must be compared with true or false", name)
+		}
+		if err := json.Unmarshal([]byte(value), &value); err != nil {
+			return nil, fmt.Errorf("invalid string %s in filter", value)
+		}
+	case strings.EqualFold(value, "true") || strings.EqualFold(value, "false"):
+		if !attr.boolean {
+			return nil, fmt.Errorf("attribute %q must be compared with a string", name)
+		}
+		value = strings.ToLower(value)
+	case strings.EqualFold(value, "null"):
+		// Comparing with null tests whether the attribute is present.
+		f := scimFilter(scimAttrFilter{attr: attrName, op: "pr", scimAttr: attr})
+		if op == "eq" {
+			f = scimNotFilter{filter: f}
+		} else if op != "ne" {
+			return nil, fmt.Errorf("operator %q cannot be used with null", op)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %q in filter", value)
+	}
+	if attr.boolean && op != "eq" && op != "ne" {
+		return nil, fmt.Errorf("operator %q cannot be used with boolean attribute %q", op, name)
+	}
+	return scimAttrFilter{attr: attrName, op: op, value: value, scimAttr: attr}, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import "testing"
+
+func TestParseSCIMFilter(t *testing.T) {
+	user := map[string][]string{
+		"id":       {"alice@example.com"},
+		"username": {"alice@example.com"},
+		"active":   {"true"},
+		"groups":   {"finance", "staff"},
+	}
+	values := func(attr string) []string {
+		return user[attr]
+	}
+
+	testCases := []struct {
+		filter  string
+		match   bool
+		wantErr bool
+	}{
+		{filter: `userName eq "alice@example.com"`, match: true},
+		{filter: `userName eq "ALICE@example.com"`, match: true},
+		{filter: `id eq "ALICE@example.com"`, match: false},
+		{filter: `USERNAME Eq "alice@example.com"`, match: true},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice@example.com"`, match: true},
+		{filter: `userName ne "alice@example.com"`, match: false},
+		{filter: `userName co "example"`, match: true},
+		{filter: `userName sw "alice"`, match: true},
+		{filter: `userName ew ".org"`, match: false},
+		{filter: `userName pr`, match: true},
+		{filter: `userName eq null`, match: false},
+		{filter: `active eq true`, match: true},
+		{filter: `active eq False`, match: false},
+		{filter: `groups eq "staff"`, match: true},
+		{filter: `groups.value eq "admins"`, match: false},
+		{filter: `active eq false or groups eq "finance"`, match: true},
+		{filter: `active eq false or groups eq "finance" and userName sw "bob"`, match: false},
+		{filter: `(active eq false or groups eq "finance") and userName sw "alice"`, match: true},
+		{filter: `not (userName sw "alice")`, match: false},
+		{filter: `userName eq "alice\"@example.com"`, match: false},
+		{filter: ``, wantErr: true},
+		{filter: `userName`, wantErr: true},
+		{filter: `userName eq`, wantErr: true},
+		{filter: `userName gt "a"`, wantErr: true},
+		{filter: `emails eq "alice@example.com"`, wantErr: true},
+		{filter: `userName eq alice`, wantErr: true},
+		{filter: `userName eq "alice`, wantErr: true},
+		{filter: `active eq "true"`, wantErr: true},
+		{filter: `active co true`, wantErr: true},
+		{filter: `(userName pr`, wantErr: true},
+		{filter: `userName pr)`, wantErr: true},
+		{filter: `not userName pr`, wantErr: true},
+	}
+
+	for i, testCase := range testCases {
+		f, err := parseSCIMFilter(testCase.filter, scimUserAttrs)
+		if testCase.wantErr {
+			if err == nil {
+				t.Errorf("Test %d: expected an error parsing %q", i+1, testCase.filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: unexpected error parsing %q: %v", i+1, testCase.filter, err)
+			continue
+		}
+		if match := f.match(values); match != testCase.match {
+			t.Errorf("Test %d: expected %q to match %v, got %v", i+1, testCase.filter, testCase.match, match)
+		}
+	}
+}
@@ -0,0 +1,1339 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/auth"
+	xhttp "github.com/GuinsooLab/annastore/internal/http"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/gorilla/mux"
+	"github.com/minio/madmin-go"
+	"github.com/minio/minio-go/v7/pkg/set"
+)
+
+const (
+	scimPathPrefix = minioReservedBucketPath + "/scim"
+	scimAPIVersion = "/v2"
+
+	scimUsersPath  = "/Users"
+	scimGroupsPath = "/Groups"
+
+	// Means response type is SCIM JSON.
+	mimeSCIMJSON mimeType = "application/scim+json"
+
+	scimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	scimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// Maximum number of resources returned by a list request.
+	scimMaxResults = 1000
+
+	// Maximum size of a SCIM request body.
+	scimMaxBodySize = 1 << 20
+)
+
+// scimMeta is the metadata of a SCIM resource.
+type scimMeta struct {
+	ResourceType string     `json:"resourceType"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// scimMember is a value of the members of a group or the groups of a user.
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// scimUser is a SCIM user, mapped to an IAM user whose access key is its
+// id and userName, and secret key its password.
+type scimUser struct {
+	Schemas    []string     `json:"schemas"`
+	ID         string       `json:"id,omitempty"`
+	ExternalID string       `json:"externalId,omitempty"`
+	UserName   string       `json:"userName"`
+	Password   string       `json:"password,omitempty"`
+	Active     *bool        `json:"active,omitempty"`
+	Groups     []scimMember `json:"groups,omitempty"`
+	Meta       *scimMeta    `json:"meta,omitempty"`
+}
+
+// scimGroup is a SCIM group, mapped to an IAM group whose name is its id
+// and displayName.
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members,omitempty"`
+	Meta        *scimMeta    `json:"meta,omitempty"`
+}
+
+// scimListResponse is the response of a list request.
+type scimListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// scimPatchRequest is the request of a PATCH operation.
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+// scimPatchOperation is an operation of a PATCH request.
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// scimError is the error response of a SCIM request.
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+
+	statusCode int
+}
+
+func (e scimError) Error() string {
+	return e.Detail
+}
+
+// newSCIMError returns a SCIM error with an HTTP status code, a SCIM error
+// type and a detail message.
+func newSCIMError(statusCode int, scimType string, format string, a ...interface{}) scimError {
+	return scimError{
+		Schemas:    []string{scimSchemaError},
+		Status:     strconv.Itoa(statusCode),
+		SCIMType:   scimType,
+		Detail:     fmt.Sprintf(format, a...),
+		statusCode: statusCode,
+	}
+}
+
+// toSCIMError converts an IAM error to a SCIM error.
+func toSCIMError(ctx context.Context, err error) scimError {
+	var serr scimError
+	switch {
+	case errors.As(err, &serr):
+		return serr
+	case errors.Is(err, errNoSuchUser):
+		return newSCIMError(http.StatusNotFound, "", "User not found")
+	case errors.Is(err, errNoSuchGroup):
+		return newSCIMError(http.StatusNotFound, "", "Group not found")
+	case errors.Is(err, errIAMActionNotAllowed):
+		return newSCIMError(http.StatusForbidden, "", "%v", err)
+	case errors.Is(err, auth.ErrInvalidAccessKeyLength), errors.Is(err, auth.ErrInvalidSecretKeyLength),
+		errors.Is(err, errInvalidArgument):
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "%v", err)
+	case errors.Is(err, errServerNotInitialized):
+		return newSCIMError(http.StatusServiceUnavailable, "", "%v", err)
+	}
+	logger.LogIf(ctx, err)
+	return newSCIMError(http.StatusInternalServerError, "", "%v", err)
+}
+
+// writeSCIMResponse writes a SCIM JSON response.
+func writeSCIMResponse(w http.ResponseWriter, statusCode int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeResponse(w, http.StatusInternalServerError, nil, mimeNone)
+		return
+	}
+	writeResponse(w, statusCode, data, mimeSCIMJSON)
+}
+
+// writeSCIMError writes the SCIM error response of an error.
+func writeSCIMError(ctx context.Context, w http.ResponseWriter, err error) {
+	serr := toSCIMError(ctx, err)
+	if serr.statusCode == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+	}
+	writeSCIMResponse(w, serr.statusCode, serr)
+}
+
+// scimAPIHandlers implements the SCIM 2.0 (RFC 7644) provisioning API of
+// IAM users and groups.
+type scimAPIHandlers struct{}
+
+// registerSCIMRouter - registers the SCIM provisioning API.
+func registerSCIMRouter(router *mux.Router) {
+	scim := scimAPIHandlers{}
+
+	scimRouter := router.PathPrefix(scimPathPrefix + scimAPIVersion).Subrouter()
+
+	scimRouter.Methods(http.MethodGet).Path("/ServiceProviderConfig").HandlerFunc(httpTraceHdrs(scim.ServiceProviderConfig))
+	scimRouter.Methods(http.MethodGet).Path("/ResourceTypes").HandlerFunc(httpTraceHdrs(scim.ResourceTypes))
+
+	scimRouter.Methods(http.MethodGet).Path(scimUsersPath).HandlerFunc(httpTraceHdrs(scim.ListUsers))
+	scimRouter.Methods(http.MethodPost).Path(scimUsersPath).HandlerFunc(httpTraceHdrs(scim.CreateUser))
+	scimRouter.Methods(http.MethodGet).Path(scimUsersPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.GetUser))
+	scimRouter.Methods(http.MethodPut).Path(scimUsersPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.ReplaceUser))
+	scimRouter.Methods(http.MethodPatch).Path(scimUsersPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.PatchUser))
+	scimRouter.Methods(http.MethodDelete).Path(scimUsersPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.DeleteUser))
+
+	scimRouter.Methods(http.MethodGet).Path(scimGroupsPath).HandlerFunc(httpTraceHdrs(scim.ListGroups))
+	scimRouter.Methods(http.MethodPost).Path(scimGroupsPath).HandlerFunc(httpTraceHdrs(scim.CreateGroup))
+	scimRouter.Methods(http.MethodGet).Path(scimGroupsPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.GetGroup))
+	scimRouter.Methods(http.MethodPut).Path(scimGroupsPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.ReplaceGroup))
+	scimRouter.Methods(http.MethodPatch).Path(scimGroupsPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.PatchGroup))
+	scimRouter.Methods(http.MethodDelete).Path(scimGroupsPath + "/{id}").HandlerFunc(httpTraceHdrs(scim.DeleteGroup))
+}
+
+// isSCIMReq - returns true if the request is for the SCIM API.
+func isSCIMReq(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, scimPathPrefix+SlashSeparator)
+}
+
+// validateSCIMReq authenticates a SCIM request with the configured bearer
+// token and returns the id of the requested resource, if any. An error
+// response is written when the request is not valid.
+func validateSCIMReq(ctx context.Context, w http.ResponseWriter, r *http.Request) (id string, ok bool) {
+	if !globalSCIMConfig.Enabled {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusNotFound, "", "SCIM provisioning is not enabled"))
+		return "", false
+	}
+	if newObjectLayerFn() == nil || !globalIAMSys.Initialized() {
+		writeSCIMError(ctx, w, errServerNotInitialized)
+		return "", false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get(xhttp.Authorization), jwtAlgorithm+" ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(globalSCIMConfig.Token)) != 1 {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusUnauthorized, "", "Invalid bearer token"))
+		return "", false
+	}
+
+	if id = mux.Vars(r)["id"]; id != "" {
+		var err error
+		if id, err = url.PathUnescape(id); err != nil {
+			writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "invalidValue", "Invalid resource id"))
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// decodeSCIMRequest decodes the JSON body of a SCIM request.
+func decodeSCIMRequest(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(io.LimitReader(r.Body, scimMaxBodySize)).Decode(v); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidSyntax", "Invalid request body: %v", err)
+	}
+	return nil
+}
+
+// scimLocation returns the URL of a SCIM resource.
+func scimLocation(r *http.Request, resourcePath, id string) string {
+	return getURLScheme(globalIsTLS) + "://" + r.Host + scimPathPrefix + scimAPIVersion + resourcePath + SlashSeparator + url.PathEscape(id)
+}
+
+// scimLastModified returns the last modification time of a resource, nil
+// if unknown.
+func scimLastModified(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// scimListParams returns the filter and the page requested by a list
+// request.
+func scimListParams(r *http.Request, attrs map[string]scimAttr) (filter scimFilter, startIndex, count int, err error) {
+	q := r.URL.Query()
+	if f := q.Get("filter"); f != "" {
+		if filter, err = parseSCIMFilter(f, attrs); err != nil {
+			return nil, 0, 0, newSCIMError(http.StatusBadRequest, "invalidFilter", "%v", err)
+		}
+	}
+	startIndex, count = 1, scimMaxResults
+	if s := q.Get("startIndex"); s != "" {
+		if startIndex, err = strconv.Atoi(s); err != nil {
+			return nil, 0, 0, newSCIMError(http.StatusBadRequest, "invalidValue", "Invalid startIndex %q", s)
+		}
+		if startIndex < 1 {
+			startIndex = 1
+		}
+	}
+	if s := q.Get("count"); s != "" {
+		if count, err = strconv.Atoi(s); err != nil {
+			return nil, 0, 0, newSCIMError(http.StatusBadRequest, "invalidValue", "Invalid count %q", s)
+		}
+		if count < 0 {
+			count = 0
+		}
+		if count > scimMaxResults {
+			count = scimMaxResults
+		}
+	}
+	return filter, startIndex, count, nil
+}
+
+// scimListPage returns the list response of the page of sorted resources
+// starting at startIndex.
+func scimListPage(resources []interface{}, startIndex, count int) scimListResponse {
+	resp := scimListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+	if start := startIndex - 1; start < len(resources) {
+		end := start + count
+		if end > len(resources) {
+			end = len(resources)
+		}
+		resp.Resources = resources[start:end]
+	}
+	resp.ItemsPerPage = len(resp.Resources)
+	return resp
+}
+
+// ServiceProviderConfig - GET /minio/scim/v2/ServiceProviderConfig
+func (s scimAPIHandlers) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMServiceProviderConfig")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	if _, ok := validateSCIMReq(ctx, w, r); !ok {
+		return
+	}
+
+	type supported struct {
+		Supported  bool `json:"supported"`
+		MaxResults int  `json:"maxResults,omitempty"`
+	}
+	writeSCIMResponse(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{scimSchemaServiceProviderConfig},
+		"patch":          supported{Supported: true},
+		"bulk":           supported{Supported: false},
+		"filter":         supported{Supported: true, MaxResults: scimMaxResults},
+		"changePassword": supported{Supported: true},
+		"sort":           supported{Supported: false},
+		"etag":           supported{Supported: false},
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the bearer token configured in identity_scim",
+		}},
+	})
+}
+
+// ResourceTypes - GET /minio/scim/v2/ResourceTypes
+func (s scimAPIHandlers) ResourceTypes(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMResourceTypes")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	if _, ok := validateSCIMReq(ctx, w, r); !ok {
+		return
+	}
+
+	resourceType := func(name, endpoint, schema string) interface{} {
+		return map[string]interface{}{
+			"schemas":  []string{scimSchemaResourceType},
+			"id":       name,
+			"name":     name,
+			"endpoint": endpoint,
+			"schema":   schema,
+		}
+	}
+	writeSCIMResponse(w, http.StatusOK, scimListPage([]interface{}{
+		resourceType("User", scimUsersPath, scimSchemaUser),
+		resourceType("Group", scimGroupsPath, scimSchemaGroup),
+	}, 1, scimMaxResults))
+}
+
+// toSCIMUser returns the SCIM user of an IAM user.
+func toSCIMUser(r *http.Request, accessKey string, info madmin.UserInfo, res scimResource) scimUser {
+	active := info.Status == madmin.AccountEnabled
+	u := scimUser{
+		Schemas:    []string{scimSchemaUser},
+		ID:         accessKey,
+		ExternalID: res.ExternalID,
+		UserName:   accessKey,
+		Active:     &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			LastModified: scimLastModified(info.UpdatedAt),
+			Location:     scimLocation(r, scimUsersPath, accessKey),
+		},
+	}
+	groups := append([]string(nil), info.MemberOf...)
+	sort.Strings(groups)
+	for _, group := range groups {
+		u.Groups = append(u.Groups, scimMember{
+			Value:   group,
+			Display: group,
+			Ref:     scimLocation(r, scimGroupsPath, group),
+		})
+	}
+	return u
+}
+
+// getSCIMUser returns the IAM user of a SCIM user. Only the users created
+// with SCIM are SCIM users, the other IAM users cannot be managed with it.
+func getSCIMUser(ctx context.Context, accessKey string) (madmin.UserInfo, scimResource, error) {
+	res, err := getSCIMResource(ctx, accessKey, false)
+	if err != nil {
+		return madmin.UserInfo{}, res, err
+	}
+	if ok, _, err := globalIAMSys.IsTempUser(accessKey); err != nil || ok {
+		return madmin.UserInfo{}, res, errNoSuchUser
+	}
+	if ok, _, err := globalIAMSys.IsServiceAccount(accessKey); err != nil || ok {
+		return madmin.UserInfo{}, res, errNoSuchUser
+	}
+	info, err := globalIAMSys.GetUserInfo(ctx, accessKey)
+	return info, res, err
+}
+
+// scimAccountStatus returns the IAM account status of the active attribute.
+func scimAccountStatus(active bool) madmin.AccountStatus {
+	if active {
+		return madmin.AccountEnabled
+	}
+	return madmin.AccountDisabled
+}
+
+// scimBool parses a boolean attribute value, some clients send booleans as
+// "True" and "False" strings.
+func scimBool(name string, value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err = strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+	return false, newSCIMError(http.StatusBadRequest, "invalidValue", "Attribute %s must be a boolean", name)
+}
+
+// scimString parses a string attribute value.
+func scimString(name string, value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", newSCIMError(http.StatusBadRequest, "invalidValue", "Attribute %s must be a string", name)
+	}
+	return s, nil
+}
+
+// scimAttrName returns the lower-cased name of an attribute without its
+// schema URN.
+func scimAttrName(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// scimPatchAttrs returns the attributes set by a PATCH operation, either
+// the attribute of its path or the attributes of its value without a path.
+func scimPatchAttrs(op scimPatchOperation) (map[string]json.RawMessage, error) {
+	if op.Path != "" {
+		return map[string]json.RawMessage{scimAttrName(op.Path): op.Value}, nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(op.Value, &values); err != nil {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "The value of an operation without a path must be an object")
+	}
+	attrs := make(map[string]json.RawMessage, len(values))
+	for name, v := range values {
+		attrs[scimAttrName(name)] = v
+	}
+	return attrs, nil
+}
+
+// decodeSCIMPatch decodes a PATCH request and validates its operations.
+func decodeSCIMPatch(r *http.Request) (scimPatchRequest, error) {
+	var req scimPatchRequest
+	if err := decodeSCIMRequest(r, &req); err != nil {
+		return req, err
+	}
+	for i := range req.Operations {
+		op := &req.Operations[i]
+		switch op.Op = strings.ToLower(op.Op); op.Op {
+		case "add", "remove", "replace":
+		default:
+			return req, newSCIMError(http.StatusBadRequest, "invalidSyntax", "Unsupported operation %q", op.Op)
+		}
+		if op.Op != "remove" && len(op.Value) == 0 {
+			return req, newSCIMError(http.StatusBadRequest, "invalidValue", "Operation %s requires a value", op.Op)
+		}
+		if op.Op == "remove" && op.Path == "" {
+			return req, newSCIMError(http.StatusBadRequest, "noTarget", "Operation remove requires a path")
+		}
+	}
+	return req, nil
+}
+
+// updateSCIMUser updates the password and status of an IAM user, nil
+// values are left unchanged.
+func updateSCIMUser(ctx context.Context, accessKey string, password *string, active *bool) error {
+	info, _, err := getSCIMUser(ctx, accessKey)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ureq      madmin.AddOrUpdateUserReq
+		updatedAt time.Time
+	)
+	switch {
+	case password != nil:
+		ureq = madmin.AddOrUpdateUserReq{
+			SecretKey: *password,
+			Status:    info.Status,
+		}
+		if active != nil {
+			ureq.Status = scimAccountStatus(*active)
+		}
+		updatedAt, err = globalIAMSys.CreateUser(ctx, accessKey, ureq)
+	case active != nil:
+		ureq = madmin.AddOrUpdateUserReq{
+			Status: scimAccountStatus(*active),
+		}
+		updatedAt, err = globalIAMSys.SetUserStatus(ctx, accessKey, ureq.Status)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return globalSiteReplicationSys.IAMChangeHook(ctx, madmin.SRIAMItem{
+		Type: madmin.SRIAMItemIAMUser,
+		IAMUser: &madmin.SRIAMUser{
+			AccessKey:   accessKey,
+			IsDeleteReq: false,
+			UserReq:     &ureq,
+		},
+		UpdatedAt: updatedAt,
+	})
+}
+
+// writeSCIMUser writes the SCIM user of an IAM user.
+func writeSCIMUser(ctx context.Context, w http.ResponseWriter, r *http.Request, statusCode int, accessKey string) {
+	info, res, err := getSCIMUser(ctx, accessKey)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	writeSCIMResponse(w, statusCode, toSCIMUser(r, accessKey, info, res))
+}
+
+// ListUsers - GET /minio/scim/v2/Users?filter=...&startIndex=...&count=...
+func (s scimAPIHandlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMListUsers")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	if _, ok := validateSCIMReq(ctx, w, r); !ok {
+		return
+	}
+
+	filter, startIndex, count, err := scimListParams(r, scimUserAttrs)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	index, err := loadSCIMIndex(ctx)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	users, err := globalIAMSys.ListUsers(ctx)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	accessKeys := make([]string, 0, len(index.Users))
+	for accessKey := range users {
+		if _, ok := index.Users[accessKey]; ok {
+			accessKeys = append(accessKeys, accessKey)
+		}
+	}
+	sort.Strings(accessKeys)
+
+	resources := make([]interface{}, 0, len(accessKeys))
+	for _, accessKey := range accessKeys {
+		info, res := users[accessKey], index.Users[accessKey]
+		if filter != nil && !filter.match(func(attr string) []string {
+			switch attr {
+			case "id", "username":
+				return []string{accessKey}
+			case "externalid":
+				return []string{res.ExternalID}
+			case "active":
+				return []string{strconv.FormatBool(info.Status == madmin.AccountEnabled)}
+			case "groups":
+				return info.MemberOf
+			}
+			return nil
+		}) {
+			continue
+		}
+		resources = append(resources, toSCIMUser(r, accessKey, info, res))
+	}
+	writeSCIMResponse(w, http.StatusOK, scimListPage(resources, startIndex, count))
+}
+
+// CreateUser - POST /minio/scim/v2/Users
+func (s scimAPIHandlers) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMCreateUser")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	if _, ok := validateSCIMReq(ctx, w, r); !ok {
+		return
+	}
+
+	var u scimUser
+	if err := decodeSCIMRequest(r, &u); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if u.UserName == "" || hasSpaceBE(u.UserName) {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "invalidValue", "Invalid userName %q", u.UserName))
+		return
+	}
+	if u.UserName == globalActiveCred.AccessKey {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusConflict, "uniqueness", "User %s already exists", u.UserName))
+		return
+	}
+	if _, _, err := globalIAMSys.IsTempUser(u.UserName); !errors.Is(err, errNoSuchUser) {
+		if err == nil {
+			err = newSCIMError(http.StatusConflict, "uniqueness", "User %s already exists", u.UserName)
+		}
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	ureq := madmin.AddOrUpdateUserReq{
+		SecretKey: u.Password,
+		Status:    madmin.AccountEnabled,
+	}
+	if ureq.SecretKey == "" {
+		// Users provisioned without a password get a random secret key,
+		// to be reset by an administrator before use.
+		_, secretKey, err := auth.GenerateCredentials()
+		if err != nil {
+			writeSCIMError(ctx, w, err)
+			return
+		}
+		ureq.SecretKey = secretKey
+	}
+	if u.Active != nil {
+		ureq.Status = scimAccountStatus(*u.Active)
+	}
+
+	// The user is recorded as a SCIM user before it is created, so that
+	// it can always be deprovisioned.
+	if err := setSCIMResource(ctx, u.UserName, false, scimResource{ExternalID: u.ExternalID}); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	updatedAt, err := globalIAMSys.CreateUser(ctx, u.UserName, ureq)
+	if err != nil {
+		logger.LogIf(ctx, deleteSCIMResource(ctx, u.UserName, false))
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	if err := globalSiteReplicationSys.IAMChangeHook(ctx, madmin.SRIAMItem{
+		Type: madmin.SRIAMItemIAMUser,
+		IAMUser: &madmin.SRIAMUser{
+			AccessKey:   u.UserName,
+			IsDeleteReq: false,
+			UserReq:     &ureq,
+		},
+		UpdatedAt: updatedAt,
+	}); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	writeSCIMUser(ctx, w, r, http.StatusCreated, u.UserName)
+}
+
+// GetUser - GET /minio/scim/v2/Users/{id}
+func (s scimAPIHandlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMGetUser")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	writeSCIMUser(ctx, w, r, http.StatusOK, id)
+}
+
+// ReplaceUser - PUT /minio/scim/v2/Users/{id}
+func (s scimAPIHandlers) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMReplaceUser")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	var u scimUser
+	if err := decodeSCIMRequest(r, &u); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if u.UserName != "" && u.UserName != id {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "mutability", "Attribute userName cannot be changed"))
+		return
+	}
+
+	var password *string
+	if u.Password != "" {
+		password = &u.Password
+	}
+	active := true
+	if u.Active != nil {
+		active = *u.Active
+	}
+	if err := updateSCIMUser(ctx, id, password, &active); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if err := setSCIMResource(ctx, id, false, scimResource{ExternalID: u.ExternalID}); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	writeSCIMUser(ctx, w, r, http.StatusOK, id)
+}
+
+// PatchUser - PATCH /minio/scim/v2/Users/{id}
+//
+// Only the active, password, userName and externalId attributes are
+// mapped, other attributes are ignored.
+func (s scimAPIHandlers) PatchUser(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMPatchUser")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeSCIMPatch(r)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	var (
+		password   *string
+		active     *bool
+		externalID *string
+	)
+	for _, op := range req.Operations {
+		attrs, err := scimPatchAttrs(op)
+		if err != nil {
+			writeSCIMError(ctx, w, err)
+			return
+		}
+		for name, value := range attrs {
+			switch name {
+			case "active", "password", "username":
+				if op.Op == "remove" {
+					writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "mutability", "Attribute %s cannot be removed", name))
+					return
+				}
+			}
+			switch name {
+			case "active":
+				b, err := scimBool(name, value)
+				if err != nil {
+					writeSCIMError(ctx, w, err)
+					return
+				}
+				active = &b
+			case "password":
+				s, err := scimString(name, value)
+				if err != nil {
+					writeSCIMError(ctx, w, err)
+					return
+				}
+				password = &s
+			case "username":
+				s, err := scimString(name, value)
+				if err != nil {
+					writeSCIMError(ctx, w, err)
+					return
+				}
+				if s != id {
+					writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "mutability", "Attribute userName cannot be changed"))
+					return
+				}
+			case "externalid":
+				var s string
+				if op.Op != "remove" {
+					if s, err = scimString(name, value); err != nil {
+						writeSCIMError(ctx, w, err)
+						return
+					}
+				}
+				externalID = &s
+			}
+		}
+	}
+
+	if err := updateSCIMUser(ctx, id, password, active); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if externalID != nil {
+		if err := setSCIMResource(ctx, id, false, scimResource{ExternalID: *externalID}); err != nil {
+			writeSCIMError(ctx, w, err)
+			return
+		}
+	}
+
+	writeSCIMUser(ctx, w, r, http.StatusOK, id)
+}
+
+// DeleteUser - DELETE /minio/scim/v2/Users/{id}
+func (s scimAPIHandlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMDeleteUser")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if _, _, err := getSCIMUser(ctx, id); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	if err := globalIAMSys.DeleteUser(ctx, id, true); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if err := deleteSCIMResource(ctx, id, false); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	if err := globalSiteReplicationSys.IAMChangeHook(ctx, madmin.SRIAMItem{
+		Type: madmin.SRIAMItemIAMUser,
+		IAMUser: &madmin.SRIAMUser{
+			AccessKey:   id,
+			IsDeleteReq: true,
+		},
+		UpdatedAt: UTCNow(),
+	}); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, nil, mimeNone)
+}
+
+// toSCIMGroup returns the SCIM group of an IAM group.
+func toSCIMGroup(r *http.Request, gd madmin.GroupDesc, res scimResource, withMembers bool) scimGroup {
+	g := scimGroup{
+		Schemas:     []string{scimSchemaGroup},
+		ID:          gd.Name,
+		ExternalID:  res.ExternalID,
+		DisplayName: gd.Name,
+		Meta: &scimMeta{
+			ResourceType: "Group",
+			LastModified: scimLastModified(gd.UpdatedAt),
+			Location:     scimLocation(r, scimGroupsPath, gd.Name),
+		},
+	}
+	if !withMembers {
+		return g
+	}
+	members := append([]string(nil), gd.Members...)
+	sort.Strings(members)
+	for _, member := range members {
+		g.Members = append(g.Members, scimMember{
+			Value:   member,
+			Display: member,
+			Ref:     scimLocation(r, scimUsersPath, member),
+		})
+	}
+	return g
+}
+
+// scimMemberValues parses the members of a group, a single member or an
+// array of members.
+func scimMemberValues(value json.RawMessage) ([]string, error) {
+	var members []scimMember
+	if err := json.Unmarshal(value, &members); err != nil {
+		var member scimMember
+		if err = json.Unmarshal(value, &member); err != nil {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "Invalid group members")
+		}
+		members = []scimMember{member}
+	}
+	values := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.Value == "" {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "Group members must have a value")
+		}
+		values = append(values, m.Value)
+	}
+	return values, nil
+}
+
+// getSCIMGroup returns the IAM group of a SCIM group. Only the groups
+// created with SCIM are SCIM groups, the other IAM groups cannot be managed
+// with it.
+func getSCIMGroup(ctx context.Context, group string) (madmin.GroupDesc, scimResource, error) {
+	res, err := getSCIMResource(ctx, group, true)
+	if err != nil {
+		return madmin.GroupDesc{}, res, err
+	}
+	gd, err := globalIAMSys.GetGroupDescription(group)
+	return gd, res, err
+}
+
+// updateSCIMGroupMembers adds or removes the members of an IAM group, only
+// SCIM users can be added.
+func updateSCIMGroupMembers(ctx context.Context, group string, members []string, isRemove bool) error {
+	var (
+		updatedAt time.Time
+		err       error
+	)
+	if !isRemove && len(members) > 0 {
+		index, err := loadSCIMIndex(ctx)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if _, ok := index.Users[member]; !ok {
+				return newSCIMError(http.StatusBadRequest, "invalidValue", "Group members must be SCIM users")
+			}
+		}
+	}
+	if isRemove {
+		updatedAt, err = globalIAMSys.RemoveUsersFromGroup(ctx, group, members)
+	} else {
+		updatedAt, err = globalIAMSys.AddUsersToGroup(ctx, group, members)
+	}
+	if errors.Is(err, errNoSuchUser) {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "Group members must be existing users")
+	}
+	if err != nil {
+		return err
+	}
+
+	return globalSiteReplicationSys.IAMChangeHook(ctx, madmin.SRIAMItem{
+		Type: madmin.SRIAMItemGroupInfo,
+		GroupInfo: &madmin.SRGroupInfo{
+			UpdateReq: madmin.GroupAddRemove{
+				Group:    group,
+				Members:  members,
+				IsRemove: isRemove,
+			},
+		},
+		UpdatedAt: updatedAt,
+	})
+}
+
+// setSCIMGroupMembers sets the members of an existing IAM group.
+func setSCIMGroupMembers(ctx context.Context, group string, current, members []string) error {
+	currentSet, membersSet := set.CreateStringSet(current...), set.CreateStringSet(members...)
+	if added := membersSet.Difference(currentSet); !added.IsEmpty() {
+		if err := updateSCIMGroupMembers(ctx, group, added.ToSlice(), false); err != nil {
+			return err
+		}
+	}
+	if removed := currentSet.Difference(membersSet); !removed.IsEmpty() {
+		if err := updateSCIMGroupMembers(ctx, group, removed.ToSlice(), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSCIMGroup writes the SCIM group of an IAM group.
+func writeSCIMGroup(ctx context.Context, w http.ResponseWriter, r *http.Request, statusCode int, group string) {
+	gd, res, err := getSCIMGroup(ctx, group)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	writeSCIMResponse(w, statusCode, toSCIMGroup(r, gd, res, true))
+}
+
+// ListGroups - GET /minio/scim/v2/Groups?filter=...&startIndex=...&count=...
+func (s scimAPIHandlers) ListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMListGroups")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	if _, ok := validateSCIMReq(ctx, w, r); !ok {
+		return
+	}
+
+	filter, startIndex, count, err := scimListParams(r, scimGroupAttrs)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	withMembers := true
+	for _, attr := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if scimAttrName(strings.TrimSpace(attr)) == "members" {
+			withMembers = false
+		}
+	}
+
+	index, err := loadSCIMIndex(ctx)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	groups := make([]string, 0, len(index.Groups))
+	for group := range index.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	resources := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		res := index.Groups[group]
+		gd, err := globalIAMSys.GetGroupDescription(group)
+		if errors.Is(err, errNoSuchGroup) {
+			// Removed since listed.
+			continue
+		}
+		if err != nil {
+			writeSCIMError(ctx, w, err)
+			return
+		}
+		if filter != nil && !filter.match(func(attr string) []string {
+			switch attr {
+			case "id", "displayname":
+				return []string{group}
+			case "externalid":
+				return []string{res.ExternalID}
+			case "members":
+				return gd.Members
+			}
+			return nil
+		}) {
+			continue
+		}
+		resources = append(resources, toSCIMGroup(r, gd, res, withMembers))
+	}
+	writeSCIMResponse(w, http.StatusOK, scimListPage(resources, startIndex, count))
+}
+
+// CreateGroup - POST /minio/scim/v2/Groups
+func (s scimAPIHandlers) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMCreateGroup")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	if _, ok := validateSCIMReq(ctx, w, r); !ok {
+		return
+	}
+
+	var g scimGroup
+	if err := decodeSCIMRequest(r, &g); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if g.DisplayName == "" || hasSpaceBE(g.DisplayName) {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "invalidValue", "Invalid displayName %q", g.DisplayName))
+		return
+	}
+	if _, err := globalIAMSys.GetGroupDescription(g.DisplayName); !errors.Is(err, errNoSuchGroup) {
+		if err == nil {
+			err = newSCIMError(http.StatusConflict, "uniqueness", "Group %s already exists", g.DisplayName)
+		}
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	members := make([]string, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, m.Value)
+	}
+	// The group is recorded as a SCIM group before it is created, so
+	// that it can always be deprovisioned.
+	if err := setSCIMResource(ctx, g.DisplayName, true, scimResource{ExternalID: g.ExternalID}); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	// An empty group is created without members.
+	if err := updateSCIMGroupMembers(ctx, g.DisplayName, members, false); err != nil {
+		logger.LogIf(ctx, deleteSCIMResource(ctx, g.DisplayName, true))
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	writeSCIMGroup(ctx, w, r, http.StatusCreated, g.DisplayName)
+}
+
+// GetGroup - GET /minio/scim/v2/Groups/{id}
+func (s scimAPIHandlers) GetGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMGetGroup")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	writeSCIMGroup(ctx, w, r, http.StatusOK, id)
+}
+
+// ReplaceGroup - PUT /minio/scim/v2/Groups/{id}
+func (s scimAPIHandlers) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMReplaceGroup")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	var g scimGroup
+	if err := decodeSCIMRequest(r, &g); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if g.DisplayName != "" && g.DisplayName != id {
+		writeSCIMError(ctx, w, newSCIMError(http.StatusBadRequest, "mutability", "Attribute displayName cannot be changed"))
+		return
+	}
+
+	gd, _, err := getSCIMGroup(ctx, id)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	members := make([]string, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, m.Value)
+	}
+	if err = setSCIMGroupMembers(ctx, id, gd.Members, members); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if err = setSCIMResource(ctx, id, true, scimResource{ExternalID: g.ExternalID}); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	writeSCIMGroup(ctx, w, r, http.StatusOK, id)
+}
+
+// applySCIMGroupPatch returns the members of a group after applying the
+// operations of a PATCH request, and its externalId when changed. Only the
+// members, displayName and externalId attributes are mapped, other
+// attributes are ignored.
+func applySCIMGroupPatch(group string, members []string, req scimPatchRequest) ([]string, *string, error) {
+	result := set.CreateStringSet(members...)
+	var externalID *string
+	for _, op := range req.Operations {
+		// Remove the members matching a value path filter, e.g.
+		// members[value eq "alice"].
+		if path := strings.ToLower(op.Path); strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]") {
+			if op.Op != "remove" {
+				return nil, nil, newSCIMError(http.StatusBadRequest, "invalidPath", "Only members can be removed with a value filter")
+			}
+			filter, err := parseSCIMFilter(op.Path[len("members["):len(op.Path)-1], scimMemberAttrs)
+			if err != nil {
+				return nil, nil, newSCIMError(http.StatusBadRequest, "invalidFilter", "%v", err)
+			}
+			for _, member := range result.ToSlice() {
+				if filter.match(func(string) []string { return []string{member} }) {
+					result.Remove(member)
+				}
+			}
+			continue
+		}
+
+		attrs, err := scimPatchAttrs(op)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, value := range attrs {
+			switch name {
+			case "displayname":
+				if op.Op == "remove" {
+					return nil, nil, newSCIMError(http.StatusBadRequest, "mutability", "Attribute displayName cannot be removed")
+				}
+				s, err := scimString(name, value)
+				if err != nil {
+					return nil, nil, err
+				}
+				if s != group {
+					return nil, nil, newSCIMError(http.StatusBadRequest, "mutability", "Attribute displayName cannot be changed")
+				}
+			case "externalid":
+				var s string
+				if op.Op != "remove" {
+					if s, err = scimString(name, value); err != nil {
+						return nil, nil, err
+					}
+				}
+				externalID = &s
+			case "members":
+				var values []string
+				if len(value) > 0 && string(value) != "null" {
+					if values, err = scimMemberValues(value); err != nil {
+						return nil, nil, err
+					}
+				}
+				switch op.Op {
+				case "add":
+					for _, v := range values {
+						result.Add(v)
+					}
+				case "replace":
+					result = set.CreateStringSet(values...)
+				case "remove":
+					if len(values) == 0 {
+						// Without a value all members are removed.
+						result = set.NewStringSet()
+					}
+					for _, v := range values {
+						result.Remove(v)
+					}
+				}
+			}
+		}
+	}
+	return result.ToSlice(), externalID, nil
+}
+
+// PatchGroup - PATCH /minio/scim/v2/Groups/{id}
+func (s scimAPIHandlers) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMPatchGroup")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeSCIMPatch(r)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	gd, _, err := getSCIMGroup(ctx, id)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	members, externalID, err := applySCIMGroupPatch(id, gd.Members, req)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if err = setSCIMGroupMembers(ctx, id, gd.Members, members); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if externalID != nil {
+		if err = setSCIMResource(ctx, id, true, scimResource{ExternalID: *externalID}); err != nil {
+			writeSCIMError(ctx, w, err)
+			return
+		}
+	}
+
+	writeSCIMGroup(ctx, w, r, http.StatusOK, id)
+}
+
+// DeleteGroup - DELETE /minio/scim/v2/Groups/{id}
+func (s scimAPIHandlers) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SCIMDeleteGroup")
+
+	defer logger.AuditLog(ctx, w, r, nil)
+
+	id, ok := validateSCIMReq(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	gd, _, err := getSCIMGroup(ctx, id)
+	if err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	// Only empty groups can be removed, remove the members first.
+	if len(gd.Members) > 0 {
+		if err = updateSCIMGroupMembers(ctx, id, gd.Members, true); err != nil {
+			writeSCIMError(ctx, w, err)
+			return
+		}
+	}
+	if err = updateSCIMGroupMembers(ctx, id, nil, true); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+	if err = deleteSCIMResource(ctx, id, true); err != nil {
+		writeSCIMError(ctx, w, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, nil, mimeNone)
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/minio/madmin-go"
+)
+
+const testSCIMToken = "scim-test-token-0123456789abcdefghij"
+
+func TestIAMWithSCIMServerSuite(t *testing.T) {
+	for i, testCase := range iamTestSuites {
+		t.Run(
+			fmt.Sprintf("Test: %d, ServerType: %s", i+1, testCase.ServerTypeDescription),
+			func(t *testing.T) {
+				c := &check{t, testCase.serverType}
+				suite := testCase
+
+				suite.SetUpSuite(c)
+				suite.SetUpSCIM(c)
+				suite.TestSCIMUsers(c)
+				suite.TestSCIMGroups(c)
+				suite.TearDownSuite(c)
+			},
+		)
+	}
+}
+
+func (s *TestSuiteIAM) SetUpSCIM(c *check) {
+	ctx, cancel := context.WithTimeout(context.Background(), testDefaultTimeout)
+	defer cancel()
+
+	_, err := s.adm.SetConfigKV(ctx, "identity_scim token="+testSCIMToken)
+	if err != nil {
+		c.Fatalf("unable to setup SCIM for tests: %v", err)
+	}
+
+	s.RestartIAMSuite(c)
+}
+
+// scimRequest sends a SCIM request and decodes the response into resp.
+func (s *TestSuiteIAM) scimRequest(c *check, method, path string, body, resp interface{}) int {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.Fatalf("unable to marshal SCIM request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.endPoint+scimPathPrefix+scimAPIVersion+path, reader)
+	if err != nil {
+		c.Fatalf("unable to create SCIM request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testSCIMToken)
+	req.Header.Set("Content-Type", string(mimeSCIMJSON))
+
+	res, err := s.TestSuiteCommon.client.Do(req)
+	if err != nil {
+		c.Fatalf("SCIM request %s %s failed: %v", method, path, err)
+	}
+	defer res.Body.Close()
+	if resp != nil && res.StatusCode < 300 {
+		if err = json.NewDecoder(res.Body).Decode(resp); err != nil {
+			c.Fatalf("unable to decode SCIM response of %s %s: %v", method, path, err)
+		}
+	}
+	return res.StatusCode
+}
+
+func (s *TestSuiteIAM) TestSCIMUsers(c *check) {
+	ctx, cancel := context.WithTimeout(context.Background(), testDefaultTimeout)
+	defer cancel()
+
+	// Requests without the bearer token are rejected.
+	req, err := http.NewRequest(http.MethodGet, s.endPoint+scimPathPrefix+scimAPIVersion+scimUsersPath, nil)
+	if err != nil {
+		c.Fatalf("unable to create SCIM request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer invalid")
+	res, err := s.TestSuiteCommon.client.Do(req)
+	if err != nil {
+		c.Fatalf("SCIM request failed: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		c.Fatalf("expected status %d with an invalid token, got %d", http.StatusUnauthorized, res.StatusCode)
+	}
+
+	accessKey, secretKey := "scim-user", "scim-user-secret"
+	var u scimUser
+	if status := s.scimRequest(c, http.MethodPost, scimUsersPath, scimUser{
+		Schemas:    []string{scimSchemaUser},
+		ExternalID: "ext-1",
+		UserName:   accessKey,
+		Password:   secretKey,
+	}, &u); status != http.StatusCreated {
+		c.Fatalf("expected status %d creating a user, got %d", http.StatusCreated, status)
+	}
+	if u.ID != accessKey || u.Active == nil || !*u.Active {
+		c.Fatalf("unexpected user created: %#v", u)
+	}
+	u = scimUser{}
+	if status := s.scimRequest(c, http.MethodGet, scimUsersPath+"/"+accessKey, nil, &u); status != http.StatusOK {
+		c.Fatalf("expected status %d getting a user, got %d", http.StatusOK, status)
+	}
+	if u.ExternalID != "ext-1" {
+		c.Fatalf("expected the externalId to be stored, got %q", u.ExternalID)
+	}
+	if status := s.scimRequest(c, http.MethodPost, scimUsersPath, scimUser{
+		Schemas:  []string{scimSchemaUser},
+		UserName: accessKey,
+	}, nil); status != http.StatusConflict {
+		c.Fatalf("expected status %d creating an existing user, got %d", http.StatusConflict, status)
+	}
+
+	// The user can access the storage.
+	err = s.adm.SetPolicy(ctx, "readwrite", accessKey, false)
+	if err != nil {
+		c.Fatalf("unable to set policy: %v", err)
+	}
+	client := s.getUserClient(c, accessKey, secretKey, "")
+	if _, err = client.ListBuckets(ctx); err != nil {
+		c.Fatalf("user should be able to list buckets: %v", err)
+	}
+	cred, err := s.adm.AddServiceAccount(ctx, madmin.AddServiceAccountReq{TargetUser: accessKey})
+	if err != nil {
+		c.Fatalf("unable to add service account: %v", err)
+	}
+	svcClient := s.getUserClient(c, cred.AccessKey, cred.SecretKey, "")
+	if _, err = svcClient.ListBuckets(ctx); err != nil {
+		c.Fatalf("service account should be able to list buckets: %v", err)
+	}
+
+	// Users not created with SCIM cannot be managed with it.
+	if err = s.adm.AddUser(ctx, "scim-admin-user", "scim-admin-user-secret"); err != nil {
+		c.Fatalf("unable to add user: %v", err)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if status := s.scimRequest(c, method, scimUsersPath+"/scim-admin-user", nil, nil); status != http.StatusNotFound {
+			c.Fatalf("expected status %d for %s of a user not created with SCIM, got %d", http.StatusNotFound, method, status)
+		}
+	}
+	if status := s.scimRequest(c, http.MethodPatch, scimUsersPath+"/scim-admin-user", scimPatchRequest{
+		Schemas: []string{scimSchemaPatchOp},
+		Operations: []scimPatchOperation{
+			{Op: "replace", Path: "password", Value: json.RawMessage(`"scim-new-secret"`)},
+		},
+	}, nil); status != http.StatusNotFound {
+		c.Fatalf("expected status %d changing a user not created with SCIM, got %d", http.StatusNotFound, status)
+	}
+
+	var list scimListResponse
+	filter := url.QueryEscape(fmt.Sprintf("userName eq %q", accessKey))
+	if status := s.scimRequest(c, http.MethodGet, scimUsersPath+"?filter="+filter, nil, &list); status != http.StatusOK {
+		c.Fatalf("expected status %d listing users, got %d", http.StatusOK, status)
+	}
+	if list.TotalResults != 1 || len(list.Resources) != 1 {
+		c.Fatalf("expected a single user matching the filter, got %d", list.TotalResults)
+	}
+	if status := s.scimRequest(c, http.MethodGet, scimUsersPath, nil, &list); status != http.StatusOK {
+		c.Fatalf("expected status %d listing users, got %d", http.StatusOK, status)
+	}
+	if list.TotalResults != 1 {
+		c.Fatalf("expected only the SCIM user to be listed, got %d", list.TotalResults)
+	}
+
+	// Deactivating the user disables its access.
+	if status := s.scimRequest(c, http.MethodPatch, scimUsersPath+"/"+accessKey, scimPatchRequest{
+		Schemas: []string{scimSchemaPatchOp},
+		Operations: []scimPatchOperation{
+			{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		},
+	}, &u); status != http.StatusOK {
+		c.Fatalf("expected status %d deactivating a user, got %d", http.StatusOK, status)
+	}
+	if u.Active == nil || *u.Active {
+		c.Fatalf("expected user to be inactive: %#v", u)
+	}
+	if _, err = client.ListBuckets(ctx); err == nil {
+		c.Fatalf("deactivated user should not be able to list buckets")
+	}
+	if _, err = svcClient.ListBuckets(ctx); err == nil {
+		c.Fatalf("service account of a deactivated user should not be able to list buckets")
+	}
+	info, err := s.adm.GetUserInfo(ctx, accessKey)
+	if err != nil {
+		c.Fatalf("unable to get user info: %v", err)
+	}
+	if info.Status != madmin.AccountDisabled {
+		c.Fatalf("expected user to be disabled, got %s", info.Status)
+	}
+
+	if status := s.scimRequest(c, http.MethodDelete, scimUsersPath+"/"+accessKey, nil, nil); status != http.StatusNoContent {
+		c.Fatalf("expected status %d deleting a user, got %d", http.StatusNoContent, status)
+	}
+	if status := s.scimRequest(c, http.MethodGet, scimUsersPath+"/"+accessKey, nil, nil); status != http.StatusNotFound {
+		c.Fatalf("expected status %d getting a deleted user, got %d", http.StatusNotFound, status)
+	}
+}
+
+func (s *TestSuiteIAM) TestSCIMGroups(c *check) {
+	ctx, cancel := context.WithTimeout(context.Background(), testDefaultTimeout)
+	defer cancel()
+
+	users := []string{"scim-alice", "scim-bob"}
+	for _, user := range users {
+		if status := s.scimRequest(c, http.MethodPost, scimUsersPath, scimUser{
+			Schemas:  []string{scimSchemaUser},
+			UserName: user,
+		}, nil); status != http.StatusCreated {
+			c.Fatalf("expected status %d creating a user, got %d", http.StatusCreated, status)
+		}
+	}
+	if err := s.adm.AddUser(ctx, "scim-carol", "scim-carol-secret"); err != nil {
+		c.Fatalf("unable to add user: %v", err)
+	}
+
+	group := "scim-group"
+	var g scimGroup
+	if status := s.scimRequest(c, http.MethodPost, scimGroupsPath, scimGroup{
+		Schemas:     []string{scimSchemaGroup},
+		DisplayName: group,
+		Members:     []scimMember{{Value: users[0]}},
+	}, &g); status != http.StatusCreated {
+		c.Fatalf("expected status %d creating a group, got %d", http.StatusCreated, status)
+	}
+	if g.ID != group || len(g.Members) != 1 {
+		c.Fatalf("unexpected group created: %#v", g)
+	}
+
+	// Add bob and remove alice.
+	if status := s.scimRequest(c, http.MethodPatch, scimGroupsPath+"/"+group, scimPatchRequest{
+		Schemas: []string{scimSchemaPatchOp},
+		Operations: []scimPatchOperation{
+			{Op: "add", Path: "members", Value: json.RawMessage(fmt.Sprintf(`[{"value":%q}]`, users[1]))},
+			{Op: "remove", Path: fmt.Sprintf(`members[value eq %q]`, users[0])},
+		},
+	}, &g); status != http.StatusOK {
+		c.Fatalf("expected status %d patching a group, got %d", http.StatusOK, status)
+	}
+	gd, err := s.adm.GetGroupDescription(ctx, group)
+	if err != nil {
+		c.Fatalf("unable to get group description: %v", err)
+	}
+	if len(gd.Members) != 1 || gd.Members[0] != users[1] {
+		c.Fatalf("expected group members %v, got %v", users[1:], gd.Members)
+	}
+
+	// Members must be SCIM users.
+	for _, member := range []string{"scim-nobody", "scim-carol"} {
+		if status := s.scimRequest(c, http.MethodPatch, scimGroupsPath+"/"+group, scimPatchRequest{
+			Schemas: []string{scimSchemaPatchOp},
+			Operations: []scimPatchOperation{
+				{Op: "add", Value: json.RawMessage(fmt.Sprintf(`{"members":[{"value":%q}]}`, member))},
+			},
+		}, nil); status != http.StatusBadRequest {
+			c.Fatalf("expected status %d adding %s, got %d", http.StatusBadRequest, member, status)
+		}
+	}
+
+	// Groups not created with SCIM cannot be managed with it.
+	if err = s.adm.UpdateGroupMembers(ctx, madmin.GroupAddRemove{Group: "scim-admin-group", Members: []string{"scim-carol"}}); err != nil {
+		c.Fatalf("unable to add group: %v", err)
+	}
+	if status := s.scimRequest(c, http.MethodPatch, scimGroupsPath+"/scim-admin-group", scimPatchRequest{
+		Schemas: []string{scimSchemaPatchOp},
+		Operations: []scimPatchOperation{
+			{Op: "add", Path: "members", Value: json.RawMessage(fmt.Sprintf(`[{"value":%q}]`, users[1]))},
+		},
+	}, nil); status != http.StatusNotFound {
+		c.Fatalf("expected status %d changing a group not created with SCIM, got %d", http.StatusNotFound, status)
+	}
+
+	var list scimListResponse
+	filter := url.QueryEscape(fmt.Sprintf("displayName eq %q and members eq %q", group, users[1]))
+	if status := s.scimRequest(c, http.MethodGet, scimGroupsPath+"?filter="+filter, nil, &list); status != http.StatusOK {
+		c.Fatalf("expected status %d listing groups, got %d", http.StatusOK, status)
+	}
+	if list.TotalResults != 1 {
+		c.Fatalf("expected a single group matching the filter, got %d", list.TotalResults)
+	}
+
+	if status := s.scimRequest(c, http.MethodDelete, scimGroupsPath+"/"+group, nil, nil); status != http.StatusNoContent {
+		c.Fatalf("expected status %d deleting a group, got %d", http.StatusNoContent, status)
+	}
+	groups, err := s.adm.ListGroups(ctx)
+	if err != nil {
+		c.Fatalf("unable to list groups: %v", err)
+	}
+	for _, gr := range groups {
+		if strings.EqualFold(gr, group) {
+			c.Fatalf("group %s should have been deleted", group)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// IAM file recording the users and groups provisioned with SCIM, the SCIM
+// endpoint only manages those.
+const scimIndexFile = iamConfigPrefix + "/scim.json"
+
+const scimIndexVersion = 1
+
+// scimResource - a user or a group provisioned with SCIM.
+type scimResource struct {
+	ExternalID string `json:"externalId,omitempty"`
+}
+
+// scimIndex - the users and groups provisioned with SCIM.
+type scimIndex struct {
+	Version int                     `json:"version"`
+	Users   map[string]scimResource `json:"users"`
+	Groups  map[string]scimResource `json:"groups"`
+}
+
+func newSCIMIndex() scimIndex {
+	return scimIndex{
+		Version: scimIndexVersion,
+		Users:   make(map[string]scimResource),
+		Groups:  make(map[string]scimResource),
+	}
+}
+
+// loadSCIMIndex reads the SCIM index, empty when nothing was provisioned.
+func loadSCIMIndex(ctx context.Context) (scimIndex, error) {
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return scimIndex{}, errServerNotInitialized
+	}
+	return readSCIMIndex(ctx, objAPI)
+}
+
+func readSCIMIndex(ctx context.Context, objAPI ObjectLayer) (scimIndex, error) {
+	index := newSCIMIndex()
+	data, err := readConfig(ctx, objAPI, scimIndexFile)
+	if errors.Is(err, errConfigNotFound) {
+		return index, nil
+	}
+	if err != nil {
+		return index, err
+	}
+	if err = json.Unmarshal(data, &index); err != nil {
+		return index, err
+	}
+	if index.Users == nil {
+		index.Users = make(map[string]scimResource)
+	}
+	if index.Groups == nil {
+		index.Groups = make(map[string]scimResource)
+	}
+	return index, nil
+}
+
+// updateSCIMIndex applies fn to the SCIM index and saves it, under a
+// cluster wide lock.
+func updateSCIMIndex(ctx context.Context, fn func(index *scimIndex) error) error {
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	lk := objAPI.NewNSLock(minioMetaBucket, scimIndexFile+".lock")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	index, err := readSCIMIndex(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	if err = fn(&index); err != nil {
+		return err
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return saveConfig(ctx, objAPI, scimIndexFile, data)
+}
+
+// getSCIMResource returns the SCIM attributes of a provisioned user, or
+// group when isGroup is set.
+func getSCIMResource(ctx context.Context, name string, isGroup bool) (scimResource, error) {
+	index, err := loadSCIMIndex(ctx)
+	if err != nil {
+		return scimResource{}, err
+	}
+	resources, errNotFound := index.Users, errNoSuchUser
+	if isGroup {
+		resources, errNotFound = index.Groups, errNoSuchGroup
+	}
+	res, ok := resources[name]
+	if !ok {
+		return res, errNotFound
+	}
+	return res, nil
+}
+
+// setSCIMResource records a provisioned user, or group when isGroup is set.
+func setSCIMResource(ctx context.Context, name string, isGroup bool, res scimResource) error {
+	return updateSCIMIndex(ctx, func(index *scimIndex) error {
+		if isGroup {
+			index.Groups[name] = res
+		} else {
+			index.Users[name] = res
+		}
+		return nil
+	})
+}
+
+// deleteSCIMResource forgets a provisioned user, or group when isGroup is
+// set.
+func deleteSCIMResource(ctx context.Context, name string, isGroup bool) error {
+	return updateSCIMIndex(ctx, func(index *scimIndex) error {
+		if isGroup {
+			delete(index.Groups, name)
+		} else {
+			delete(index.Users, name)
+		}
+		return nil
+	})
+}
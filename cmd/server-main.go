@@ -540,6 +540,8 @@ func serverMain(ctx *cli.Context) {
 	initAutoHeal(GlobalContext, newObject)
 	initHealMRF(GlobalContext, newObject)
 	initBackgroundExpiry(GlobalContext, newObject)
+	initDriveHealthMonitor(GlobalContext)
+	initBackgroundSchedule(GlobalContext)
 
 	if globalActiveCred.Equal(auth.DefaultCredentials) {
 		msg := fmt.Sprintf("WARNING: Detected default credentials '%s', we recommend that you change these values with 'ANNASTORE_ROOT_USER' and 'ANNASTORE_ROOT_PASSWORD' environment variables",
@@ -604,6 +606,15 @@ func serverMain(ctx *cli.Context) {
 		// Initialize quota manager.
 		globalBucketQuotaSys.Init(newObject)
 
+		// Load the drives under maintenance.
+		logger.LogIf(GlobalContext, globalDriveMaintenanceSys.Load(GlobalContext, newObject))
+
+		// Load the nodes cordoned for maintenance.
+		logger.LogIf(GlobalContext, globalNodeCordonSys.Load(GlobalContext, newObject))
+
+		// Load the unrecoverable object versions.
+		initUnrecoverable(GlobalContext, newObject)
+
 		initDataScanner(GlobalContext, newObject)
 
 		// List buckets to heal, and be re-used for loading configs.
@@ -626,6 +637,15 @@ func serverMain(ctx *cli.Context) {
 		// Initialize site replication manager.
 		globalSiteReplicationSys.Init(GlobalContext, newObject)
 
+		// Initialize batch jobs and resume interrupted ones.
+		initBatchJobPool(GlobalContext, newObject)
+
+		// Resume the heal sequences interrupted by a restart.
+		go globalAllHealState.resumeHealSequences(GlobalContext, newObject)
+
+		// Initialize object lambda access points.
+		globalObjectLambdaSys.Init(GlobalContext, newObject)
+
 		// Initialize bucket notification targets.
 		globalNotificationSys.InitBucketTargets(GlobalContext, newObject)
 
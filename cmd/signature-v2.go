@@ -183,6 +183,8 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 
 	r.Form.Del(xhttp.Expires)
 
+	globalIAMSys.recordAccessKeyUsage(r, cred)
+	rateLimitAuthenticated(r, cred)
 	return ErrNone
 }
 
@@ -281,6 +283,8 @@ func doesSignV2Match(r *http.Request) APIErrorCode {
 	if !compareSignatureV2(v2Auth, expectedAuth) {
 		return ErrSignatureDoesNotMatch
 	}
+	globalIAMSys.recordAccessKeyUsage(r, cred)
+	rateLimitAuthenticated(r, cred)
 	return ErrNone
 }
 
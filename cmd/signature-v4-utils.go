@@ -162,6 +162,9 @@ func checkKeyValid(r *http.Request, accessKey string) (auth.Credentials, bool, A
 			return cred, false, ErrInvalidAccessKeyID
 		}
 		cred = u.Credentials
+		if cred.IsTemp() && globalIAMSys.IsSTSSessionRevoked(cred.AccessKey) {
+			return cred, false, ErrInvalidToken
+		}
 	}
 
 	claims, s3Err := checkClaimsFromToken(r, cred)
@@ -325,6 +325,8 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 	if !compareSignatureV4(req.Form.Get(xhttp.AmzSignature), newSignature) {
 		return ErrSignatureDoesNotMatch
 	}
+	globalIAMSys.recordAccessKeyUsage(r, cred)
+	rateLimitAuthenticated(r, cred)
 	return ErrNone
 }
 
@@ -389,6 +391,8 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string, st
 	if !compareSignatureV4(newSignature, signV4Values.Signature) {
 		return ErrSignatureDoesNotMatch
 	}
+	globalIAMSys.recordAccessKeyUsage(r, cred)
+	rateLimitAuthenticated(r, cred)
 
 	// Return error none.
 	return ErrNone
@@ -551,12 +551,14 @@ func (c *SiteReplicationSys) PeerJoinReq(ctx context.Context, arg madmin.SRPeerJ
 // used to validate that all peers have the same IDP.
 func (c *SiteReplicationSys) GetIDPSettings(ctx context.Context) madmin.IDPSettings {
 	s := madmin.IDPSettings{}
+	// Only the settings of the primary LDAP configuration are compared.
+	ldapCfg := globalLDAPConfig.Primary()
 	s.LDAP = madmin.LDAPSettings{
 		IsLDAPEnabled:          globalLDAPConfig.Enabled,
-		LDAPUserDNSearchBase:   globalLDAPConfig.UserDNSearchBaseDistName,
-		LDAPUserDNSearchFilter: globalLDAPConfig.UserDNSearchFilter,
-		LDAPGroupSearchBase:    globalLDAPConfig.GroupSearchBaseDistName,
-		LDAPGroupSearchFilter:  globalLDAPConfig.GroupSearchFilter,
+		LDAPUserDNSearchBase:   ldapCfg.UserDNSearchBaseDistName,
+		LDAPUserDNSearchFilter: ldapCfg.UserDNSearchFilter,
+		LDAPGroupSearchBase:    ldapCfg.GroupSearchBaseDistName,
+		LDAPGroupSearchFilter:  ldapCfg.GroupSearchFilter,
 	}
 	s.OpenID = globalOpenIDConfig.GetSettings()
 	if s.OpenID.Enabled {
@@ -1124,7 +1126,7 @@ func (c *SiteReplicationSys) PeerSvcAccChangeHandler(ctx context.Context, change
 		var sp *iampolicy.Policy
 		var err error
 		if len(change.Create.SessionPolicy) > 0 {
-			sp, err = iampolicy.ParseConfig(bytes.NewReader(change.Create.SessionPolicy))
+			sp, err = parseIAMPolicy(bytes.NewReader(change.Create.SessionPolicy))
 			if err != nil {
 				return wrapSRErr(err)
 			}
@@ -1150,7 +1152,7 @@ func (c *SiteReplicationSys) PeerSvcAccChangeHandler(ctx context.Context, change
 		var sp *iampolicy.Policy
 		var err error
 		if len(change.Update.SessionPolicy) > 0 {
-			sp, err = iampolicy.ParseConfig(bytes.NewReader(change.Update.SessionPolicy))
+			sp, err = parseIAMPolicy(bytes.NewReader(change.Update.SessionPolicy))
 			if err != nil {
 				return wrapSRErr(err)
 			}
@@ -1249,12 +1251,12 @@ func (c *SiteReplicationSys) PeerSTSAccHandler(ctx context.Context, stsCred *mad
 	switch {
 	case isLDAPSTS:
 		// Need to lookup the groups from LDAP.
-		_, ldapGroups, err := globalLDAPConfig.LookupUserDN(ldapUser)
+		user, err := globalLDAPConfig.LookupUserDN(ldapUser)
 		if err != nil {
 			return fmt.Errorf("unable to query LDAP server for %s: %w", ldapUser, err)
 		}
 
-		cred.Groups = ldapGroups
+		cred.Groups = user.Groups
 	}
 
 	// Set these credentials to IAM.
@@ -1428,7 +1430,7 @@ func (c *SiteReplicationSys) PeerBucketSSEConfigHandler(ctx context.Context, buc
 }
 
 // PeerBucketQuotaConfigHandler - copies/deletes policy to local cluster.
-func (c *SiteReplicationSys) PeerBucketQuotaConfigHandler(ctx context.Context, bucket string, quota *madmin.BucketQuota, updatedAt time.Time) error {
+func (c *SiteReplicationSys) PeerBucketQuotaConfigHandler(ctx context.Context, bucket string, quota *BucketQuota, updatedAt time.Time) error {
 	// skip overwrite if local update is newer than peer update.
 	if !updatedAt.IsZero() {
 		if _, updateTm, err := globalBucketMetadataSys.GetQuotaConfig(ctx, bucket); err == nil && updateTm.After(updatedAt) {
@@ -1676,7 +1678,7 @@ func (c *SiteReplicationSys) syncToAllPeers(ctx context.Context) error {
 		}
 
 		for pname, pdoc := range allPolicyDocs {
-			policyJSON, err := json.Marshal(pdoc.Policy)
+			policyJSON, err := marshalIAMPolicy(pdoc.Policy)
 			if err != nil {
 				return wrapSRErr(err)
 			}
@@ -1781,7 +1783,7 @@ func (c *SiteReplicationSys) syncToAllPeers(ctx context.Context) error {
 
 			var policyJSON []byte
 			if policy != nil {
-				policyJSON, err = json.Marshal(policy)
+				policyJSON, err = marshalIAMPolicy(policy)
 				if err != nil {
 					return wrapSRErr(err)
 				}
@@ -2666,7 +2668,7 @@ func (c *SiteReplicationSys) siteReplicationStatus(ctx context.Context, objAPI O
 			var policies []*iampolicy.Policy
 			uPolicyCount := 0
 			for _, ps := range pslc {
-				plcy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(ps.SRIAMPolicy.Policy)))
+				plcy, err := parseIAMPolicy(bytes.NewReader([]byte(ps.SRIAMPolicy.Policy)))
 				if err != nil {
 					continue
 				}
@@ -2709,7 +2711,7 @@ func (c *SiteReplicationSys) siteReplicationStatus(ctx context.Context, objAPI O
 			olockConfigSet := set.NewStringSet()
 			policies := make([]*bktpolicy.Policy, numSites)
 			replCfgs := make([]*sreplication.Config, numSites)
-			quotaCfgs := make([]*madmin.BucketQuota, numSites)
+			quotaCfgs := make([]*BucketQuota, numSites)
 			sseCfgSet := set.NewStringSet()
 			versionCfgSet := set.NewStringSet()
 			var tagCount, olockCfgCount, sseCfgCount, versionCfgCount int
@@ -2826,7 +2828,7 @@ func (c *SiteReplicationSys) siteReplicationStatus(ctx context.Context, objAPI O
 					isBucketMarkedDeleted = !bi.DeletedAt.IsZero() && (bi.CreatedAt.IsZero() || bi.DeletedAt.After(bi.CreatedAt))
 					hasBucket = !bi.CreatedAt.IsZero()
 				}
-				quotaCfgSet := hasBucket && quotaCfgs[i] != nil && *quotaCfgs[i] != madmin.BucketQuota{}
+				quotaCfgSet := hasBucket && quotaCfgs[i] != nil && *quotaCfgs[i] != BucketQuota{}
 				ss := madmin.SRBucketStatsSummary{
 					DeploymentID:             s.DeploymentID,
 					HasBucket:                hasBucket,
@@ -2976,7 +2978,7 @@ func isGroupDescReplicated(cntReplicated, total int, gds []madmin.GroupDesc) boo
 	return true
 }
 
-func isBktQuotaCfgReplicated(total int, quotaCfgs []*madmin.BucketQuota) bool {
+func isBktQuotaCfgReplicated(total int, quotaCfgs []*BucketQuota) bool {
 	numquotaCfgs := 0
 	for _, q := range quotaCfgs {
 		if q == nil {
@@ -2988,7 +2990,7 @@ func isBktQuotaCfgReplicated(total int, quotaCfgs []*madmin.BucketQuota) bool {
 	if numquotaCfgs > 0 && numquotaCfgs != total {
 		return false
 	}
-	var prev *madmin.BucketQuota
+	var prev *BucketQuota
 	for i, q := range quotaCfgs {
 		if q == nil {
 			return false
@@ -2997,7 +2999,7 @@ func isBktQuotaCfgReplicated(total int, quotaCfgs []*madmin.BucketQuota) bool {
 			prev = q
 			continue
 		}
-		if prev.Quota != q.Quota || prev.Type != q.Type {
+		if *prev != *q {
 			return false
 		}
 	}
@@ -3266,7 +3268,7 @@ func (c *SiteReplicationSys) SiteReplicationMetaInfo(ctx context.Context, objAPI
 		}
 		info.Policies = make(map[string]madmin.SRIAMPolicy, len(allPolicies))
 		for pname, policyDoc := range allPolicies {
-			policyJSON, err := json.Marshal(policyDoc.Policy)
+			policyJSON, err := marshalIAMPolicy(policyDoc.Policy)
 			if err != nil {
 				return info, wrapSRErr(err)
 			}
@@ -4574,7 +4576,7 @@ func (c *SiteReplicationSys) healUsers(ctx context.Context, objAPI ObjectLayer,
 
 			var policyJSON []byte
 			if policy != nil {
-				policyJSON, err = json.Marshal(policy)
+				policyJSON, err = marshalIAMPolicy(policy)
 				if err != nil {
 					logger.LogIf(ctx, fmt.Errorf("Error healing service account %s from peer site %s -> %s : %w", user, latestPeerName, peerName, err))
 					continue
@@ -0,0 +1,72 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sync"
+
+	"github.com/GuinsooLab/annastore/internal/config/storageclass"
+)
+
+// storageClassWritten counts the objects and bytes written in a storage class.
+type storageClassWritten struct {
+	Objects uint64
+	Bytes   uint64
+}
+
+// storageClassStats holds the objects and bytes written per storage class
+// by this node.
+type storageClassStats struct {
+	mu    sync.Mutex
+	stats map[string]storageClassWritten
+}
+
+func newStorageClassStats() *storageClassStats {
+	return &storageClassStats{
+		stats: make(map[string]storageClassWritten),
+	}
+}
+
+// written records an object of size bytes written in storage class sc,
+// internal writes to the meta bucket are not counted.
+func (s *storageClassStats) written(bucket, sc string, size int64) {
+	if isMinioMetaBucketName(bucket) {
+		return
+	}
+	if sc == "" {
+		sc = storageclass.STANDARD
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats[sc]
+	st.Objects++
+	if size > 0 {
+		st.Bytes += uint64(size)
+	}
+	s.stats[sc] = st
+}
+
+func (s *storageClassStats) get() map[string]storageClassWritten {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make(map[string]storageClassWritten, len(s.stats))
+	for sc, st := range s.stats {
+		stats[sc] = st
+	}
+	return stats
+}
@@ -57,6 +57,9 @@ var errFaultyDisk = StorageErr("drive is faulty")
 // errDiskAccessDenied - we don't have write permissions on disk.
 var errDiskAccessDenied = StorageErr("drive access denied")
 
+// errDiskReadOnly - drive was made read-only as it is predicted to fail.
+var errDiskReadOnly = StorageErr("drive is read-only")
+
 // errFileNotFound - cannot find the file.
 var errFileNotFound = StorageErr("file not found")
 
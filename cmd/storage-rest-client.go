@@ -73,6 +73,8 @@ func toStorageErr(err error) error {
 		return errUnexpected
 	case errDiskFull.Error():
 		return errDiskFull
+	case errDiskReadOnly.Error():
+		return errDiskReadOnly
 	case errVolumeNotFound.Error():
 		return errVolumeNotFound
 	case errVolumeExists.Error():
@@ -135,6 +135,8 @@ func calculateSeedSignature(r *http.Request) (cred auth.Credentials, signature s
 	if !compareSignatureV4(newSignature, signV4Values.Signature) {
 		return cred, "", "", time.Time{}, ErrSignatureDoesNotMatch
 	}
+	globalIAMSys.recordAccessKeyUsage(r, cred)
+	rateLimitAuthenticated(r, cred)
 
 	// Return caculated signature.
 	return cred, newSignature, region, date, ErrNone
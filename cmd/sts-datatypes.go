@@ -216,3 +216,18 @@ type AssumeRoleWithCustomTokenResponse struct {
 		RequestID string `xml:"RequestId,omitempty"`
 	} `xml:"ResponseMetadata,omitempty"`
 }
+
+// AssumeRoleWithSAMLResponse contains the result of a successful
+// AssumeRoleWithSAML request.
+type AssumeRoleWithSAMLResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithSAMLResponse" json:"-"`
+	Result  struct {
+		Credentials auth.Credentials `xml:"Credentials,omitempty"`
+		Subject     string           `xml:"Subject,omitempty"`
+		Issuer      string           `xml:"Issuer,omitempty"`
+		Audience    string           `xml:"Audience,omitempty"`
+	} `xml:"AssumeRoleWithSAMLResult"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
@@ -91,6 +91,8 @@ const (
 	ErrSTSMalformedPolicyDocument
 	ErrSTSInsecureConnection
 	ErrSTSInvalidClientCertificate
+	ErrSTSSAMLExpiredToken
+	ErrSTSInvalidIdentityToken
 	ErrSTSNotInitialized
 	ErrSTSUpstreamError
 	ErrSTSInternalError
@@ -154,6 +156,16 @@ var stsErrCodes = stsErrorCodeMap{
 		Description:    "The provided client certificate is invalid. Retry with a different certificate.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrSTSSAMLExpiredToken: {
+		Code:           "ExpiredToken",
+		Description:    "The SAML assertion that was passed is expired or is not valid. Sign in with the identity provider again and then retry the request.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSInvalidIdentityToken: {
+		Code:           "InvalidIdentityToken",
+		Description:    "The SAML assertion that was passed could not be validated by MinIO.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrSTSNotInitialized: {
 		Code:           "STSNotInitialized",
 		Description:    "STS API not initialized, please try again.",
@@ -25,12 +25,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/GuinsooLab/annastore/internal/auth"
 	"github.com/GuinsooLab/annastore/internal/config/identity/openid"
+	idsaml "github.com/GuinsooLab/annastore/internal/config/identity/saml"
 	"github.com/GuinsooLab/annastore/internal/hash/sha256"
 	xhttp "github.com/GuinsooLab/annastore/internal/http"
 	"github.com/GuinsooLab/annastore/internal/logger"
@@ -53,6 +55,7 @@ const (
 	stsDurationSeconds        = "DurationSeconds"
 	stsLDAPUsername           = "LDAPUsername"
 	stsLDAPPassword           = "LDAPPassword"
+	stsSAMLAssertion          = "SAMLAssertion"
 
 	// STS API action constants
 	clientGrants        = "AssumeRoleWithClientGrants"
@@ -60,6 +63,7 @@ const (
 	ldapIdentity        = "AssumeRoleWithLDAPIdentity"
 	clientCertificate   = "AssumeRoleWithCertificate"
 	customTokenIdentity = "AssumeRoleWithCustomToken"
+	samlIdentity        = "AssumeRoleWithSAML"
 	assumeRole          = "AssumeRole"
 
 	stsRequestBodyLimit = 10 * (1 << 20) // 10 MiB
@@ -77,10 +81,21 @@ const (
 	ldapUser  = "ldapUser"
 	ldapUserN = "ldapUsername"
 
+	// LDAP claim key of the policies mapped by the user attributes
+	ldapPolicies = "ldapPolicies"
+
 	// Role Claim key
 	roleArnClaim = "roleArn"
 )
 
+// deleteLDAPClaims removes the LDAP claims from the claims of another
+// identity provider.
+func deleteLDAPClaims(claims map[string]interface{}) {
+	delete(claims, ldapUser)
+	delete(claims, ldapUserN)
+	delete(claims, ldapPolicies)
+}
+
 // stsAPIHandlers implements and provides http handlers for AWS STS API.
 type stsAPIHandlers struct{}
 
@@ -135,6 +150,11 @@ func registerSTSRouter(router *mux.Router) {
 	stsRouter.Methods(http.MethodPost).HandlerFunc(httpTraceAll(sts.AssumeRoleWithCustomToken)).
 		Queries(stsAction, customTokenIdentity).
 		Queries(stsVersion, stsAPIVersion)
+
+	// AssumeRoleWithSAML
+	stsRouter.Methods(http.MethodPost).HandlerFunc(httpTraceAll(sts.AssumeRoleWithSAML)).
+		Queries(stsAction, samlIdentity).
+		Queries(stsVersion, stsAPIVersion)
 }
 
 func checkAssumeRoleAuth(ctx context.Context, r *http.Request) (user auth.Credentials, isErrCodeSTS bool, stsErr STSErrorCode) {
@@ -229,7 +249,7 @@ func (sts *stsAPIHandlers) AssumeRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(sessionPolicyStr) > 0 {
-		sessionPolicy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(sessionPolicyStr)))
+		sessionPolicy, err := parseIAMPolicy(bytes.NewReader([]byte(sessionPolicyStr)))
 		if err != nil {
 			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
 			return
@@ -242,6 +262,37 @@ func (sts *stsAPIHandlers) AssumeRole(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	sessionTags, transitiveTagKeys, err := parseSessionTags(r.Form)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	// Session tags require the permission to tag users and can only
+	// restate the tags of the user or its groups, e.g. to make them
+	// transitive.
+	if len(sessionTags) > 0 {
+		if !globalIAMSys.IsAllowed(iampolicy.Args{
+			AccountName:     user.AccessKey,
+			Groups:          user.Groups,
+			Action:          tagSessionAction,
+			ConditionValues: getConditionValues(r, "", user.AccessKey, nil),
+			IsOwner:         user.AccessKey == globalActiveCred.AccessKey,
+		}) {
+			writeSTSErrorResponse(ctx, w, true, ErrSTSAccessDenied,
+				fmt.Errorf("Session tags require the %s action", tagSessionAction))
+			return
+		}
+		principalTags := globalIAMSys.GetPrincipalTags(user.AccessKey, user.Groups)
+		for k, v := range sessionTags {
+			if pv, ok := principalTags[k]; !ok || pv != v {
+				writeSTSErrorResponse(ctx, w, true, ErrSTSAccessDenied,
+					fmt.Errorf("Session tag %s is not a principal tag of the user", k))
+				return
+			}
+		}
+	}
+
 	duration, err := openid.GetDefaultExpiration(r.Form.Get(stsDurationSeconds))
 	if err != nil {
 		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
@@ -250,6 +301,7 @@ func (sts *stsAPIHandlers) AssumeRole(w http.ResponseWriter, r *http.Request) {
 
 	claims[expClaim] = UTCNow().Add(duration).Unix()
 	claims[parentClaim] = user.AccessKey
+	setSessionTagClaims(claims, sessionTags, transitiveTagKeys)
 
 	// Validate that user.AccessKey's policies can be retrieved - it may not
 	// be in case the user is disabled.
@@ -328,6 +380,9 @@ func (sts *stsAPIHandlers) AssumeRoleWithSSO(w http.ResponseWriter, r *http.Requ
 	case ldapIdentity:
 		sts.AssumeRoleWithLDAPIdentity(w, r)
 		return
+	case samlIdentity:
+		sts.AssumeRoleWithSAML(w, r)
+		return
 	case clientGrants, webIdentity:
 	default:
 		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("Unsupported action %s", action))
@@ -415,7 +470,7 @@ func (sts *stsAPIHandlers) AssumeRoleWithSSO(w http.ResponseWriter, r *http.Requ
 	}
 
 	if len(sessionPolicyStr) > 0 {
-		sessionPolicy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(sessionPolicyStr)))
+		sessionPolicy, err := parseIAMPolicy(bytes.NewReader([]byte(sessionPolicyStr)))
 		if err != nil {
 			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
 			return
@@ -430,6 +485,21 @@ func (sts *stsAPIHandlers) AssumeRoleWithSSO(w http.ResponseWriter, r *http.Requ
 		claims[iampolicy.SessionPolicyName] = base64.StdEncoding.EncodeToString([]byte(sessionPolicyStr))
 	}
 
+	// Session tags are only taken from the token of the identity
+	// provider, never from the request.
+	sessionTags, transitiveTagKeys, err := openIDSessionTags(claims)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+	delete(claims, sessionTagsClaim)
+	delete(claims, transitiveTagKeysClaim)
+	setSessionTagClaims(claims, sessionTags, transitiveTagKeys)
+
+	// The LDAP claims are only set by AssumeRoleWithLDAPIdentity, the
+	// identity provider must not be able to grant LDAP attribute policies.
+	deleteLDAPClaims(claims)
+
 	secret := globalActiveCred.SecretKey
 	cred, err := auth.GetNewCredentialsWithMetadata(claims, secret)
 	if err != nil {
@@ -581,7 +651,7 @@ func (sts *stsAPIHandlers) AssumeRoleWithLDAPIdentity(w http.ResponseWriter, r *
 	}
 
 	if len(sessionPolicyStr) > 0 {
-		sessionPolicy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(sessionPolicyStr)))
+		sessionPolicy, err := parseIAMPolicy(bytes.NewReader([]byte(sessionPolicyStr)))
 		if err != nil {
 			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
 			return
@@ -594,16 +664,18 @@ func (sts *stsAPIHandlers) AssumeRoleWithLDAPIdentity(w http.ResponseWriter, r *
 		}
 	}
 
-	ldapUserDN, groupDistNames, err := globalLDAPConfig.Bind(ldapUsername, ldapPassword)
+	user, err := globalLDAPConfig.Bind(ldapUsername, ldapPassword)
 	if err != nil {
 		err = fmt.Errorf("LDAP server error: %w", err)
 		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
 		return
 	}
+	ldapUserDN, groupDistNames := user.DN, user.Groups
 
-	// Check if this user or their groups have a policy applied.
-	ldapPolicies, _ := globalIAMSys.PolicyDBGet(ldapUserDN, false, groupDistNames...)
-	if len(ldapPolicies) == 0 && newGlobalAuthZPluginFn() == nil {
+	// Check if this user, their groups or their attributes have a policy
+	// applied.
+	mappedPolicies, _ := globalIAMSys.PolicyDBGet(ldapUserDN, false, groupDistNames...)
+	if len(mappedPolicies) == 0 && len(user.Policies) == 0 && newGlobalAuthZPluginFn() == nil {
 		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue,
 			fmt.Errorf("expecting a policy to be set for user `%s` or one of their groups: `%s` - rejecting this request",
 				ldapUserDN, strings.Join(groupDistNames, "`,`")))
@@ -616,9 +688,25 @@ func (sts *stsAPIHandlers) AssumeRoleWithLDAPIdentity(w http.ResponseWriter, r *
 		return
 	}
 
+	// Session tags are the configured attributes of the user, all of
+	// them transitive.
+	if err = validateIAMTags(user.Tags); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+	transitiveTagKeys := make([]string, 0, len(user.Tags))
+	for k := range user.Tags {
+		transitiveTagKeys = append(transitiveTagKeys, k)
+	}
+	sort.Strings(transitiveTagKeys)
+
 	claims[expClaim] = UTCNow().Add(expiryDur).Unix()
 	claims[ldapUser] = ldapUserDN
 	claims[ldapUserN] = ldapUsername
+	if len(user.Policies) > 0 {
+		claims[ldapPolicies] = strings.Join(user.Policies, ",")
+	}
+	setSessionTagClaims(claims, user.Tags, transitiveTagKeys)
 
 	if len(sessionPolicyStr) > 0 {
 		claims[iampolicy.SessionPolicyName] = base64.StdEncoding.EncodeToString([]byte(sessionPolicyStr))
@@ -920,6 +1008,7 @@ func (sts *stsAPIHandlers) AssumeRoleWithCustomToken(w http.ResponseWriter, r *h
 			claims[k] = v
 		}
 	}
+	deleteLDAPClaims(claims)
 
 	tmpCredentials, err := auth.GetNewCredentialsWithMetadata(claims, globalActiveCred.SecretKey)
 	if err != nil {
@@ -955,3 +1044,192 @@ func (sts *stsAPIHandlers) AssumeRoleWithCustomToken(w http.ResponseWriter, r *h
 	response.Metadata.RequestID = w.Header().Get(xhttp.AmzRequestID)
 	writeSuccessResponseXML(w, encodeResponse(response))
 }
+
+// AssumeRoleWithSAML - implementation of AWS STS API AssumeRoleWithSAML,
+// exchanging a SAML 2.0 response signed by the configured identity
+// provider for temporary credentials.
+//
+// API endpoint: https://minio:9000?Action=AssumeRoleWithSAML&Version=2011-06-15
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithSAML.html
+func (sts *stsAPIHandlers) AssumeRoleWithSAML(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, samlIdentity)
+
+	claims := make(map[string]interface{})
+	defer logger.AuditLog(ctx, w, r, claims, stsSAMLAssertion)
+
+	if !globalSAMLConfig.Enabled {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSNotInitialized, errors.New("STS API 'AssumeRoleWithSAML' is disabled"))
+		return
+	}
+
+	// Parse the incoming form data.
+	if err := parseForm(r); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	if r.Form.Get(stsVersion) != stsAPIVersion {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMissingParameter,
+			fmt.Errorf("Invalid STS API version %s, expecting %s", r.Form.Get(stsVersion), stsAPIVersion))
+		return
+	}
+
+	action := r.Form.Get(stsAction)
+	if action != samlIdentity {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("Unsupported action %s", action))
+		return
+	}
+
+	samlAssertion := r.Form.Get(stsSAMLAssertion)
+	if samlAssertion == "" {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMissingParameter, fmt.Errorf("SAMLAssertion cannot be empty"))
+		return
+	}
+
+	now := UTCNow()
+	a, err := globalSAMLConfig.Validate(ctx, samlAssertion, now)
+	if err != nil {
+		switch err {
+		case idsaml.ErrAssertionExpired:
+			writeSTSErrorResponse(ctx, w, true, ErrSTSSAMLExpiredToken, err)
+		case idsaml.ErrResponseTooLarge:
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		default:
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidIdentityToken, err)
+		}
+		return
+	}
+
+	sessionPolicyStr := r.Form.Get(stsPolicy)
+	// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithSAML.html
+	// The plain text that you use for both inline and managed session
+	// policies shouldn't exceed 2048 characters.
+	if len(sessionPolicyStr) > 2048 {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("Session policy should not exceed 2048 characters"))
+		return
+	}
+
+	if len(sessionPolicyStr) > 0 {
+		sessionPolicy, err := parseIAMPolicy(bytes.NewReader([]byte(sessionPolicyStr)))
+		if err != nil {
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+			return
+		}
+
+		// Version in policy must not be empty
+		if sessionPolicy.Version == "" {
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("Version needs to be specified in session policy"))
+			return
+		}
+	}
+
+	// The parent user is derived from the IdP and the subject so that
+	// sessions of the same SAML user share a stable identity.
+	h := sha256.Sum256([]byte("saml:" + a.Subject + ":" + a.Issuer))
+	parentUser := base64.RawURLEncoding.EncodeToString(h[:])
+
+	var policyName string
+	var groups []string
+	roleArnStr := r.Form.Get(stsRoleArn)
+	if globalSAMLConfig.RolePolicy != "" {
+		// All users assume the configured role, the policies are
+		// those of the role rather than those of the assertion.
+		roleArn, _, err := globalIAMSys.GetRolePolicy(roleArnStr)
+		if err != nil || roleArn != globalSAMLConfig.RoleARN {
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue,
+				fmt.Errorf("Error processing %s parameter: %s is not the SAML role", stsRoleArn, roleArnStr))
+			return
+		}
+		claims[roleArnClaim] = roleArn.String()
+	} else {
+		// When the IdP lists the roles a user may assume, the requested
+		// role must be one of them.
+		if roles := a.Roles(); roleArnStr != "" && len(roles) > 0 {
+			found := false
+			for _, role := range roles {
+				if role == roleArnStr {
+					found = true
+					break
+				}
+			}
+			if !found {
+				writeSTSErrorResponse(ctx, w, true, ErrSTSAccessDenied,
+					fmt.Errorf("Role %s is not allowed by the SAML assertion", roleArnStr))
+				return
+			}
+		}
+
+		policyName = globalIAMSys.CurrentPolicies(strings.Join(a.Policies(globalSAMLConfig.ClaimName), ","))
+		groups = a.Groups(globalSAMLConfig.GroupsAttribute)
+
+		groupPolicies, _ := globalIAMSys.PolicyDBGet(parentUser, false, groups...)
+		if policyName == "" && len(groupPolicies) == 0 && newGlobalAuthZPluginFn() == nil {
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue,
+				fmt.Errorf("%s SAML attribute or groups must be mapped to existing policies for subject %s", globalSAMLConfig.ClaimName, a.Subject))
+			return
+		}
+		claims[iamPolicyClaimNameOpenID()] = policyName
+	}
+
+	expiryDur, err := globalSAMLConfig.GetExpiryDuration(r.Form.Get(stsDurationSeconds), a, now)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	// Session tags are only taken from the principal tag attributes of
+	// the assertion, never from the request.
+	sessionTags, transitiveTagKeys := a.SessionTags()
+	if err = validateIAMTags(sessionTags); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	claims[expClaim] = now.Add(expiryDur).Unix()
+	claims[subClaim] = a.Subject
+	claims[issClaim] = a.Issuer
+	claims[parentClaim] = parentUser
+	setSessionTagClaims(claims, sessionTags, transitiveTagKeys)
+
+	if len(sessionPolicyStr) > 0 {
+		claims[iampolicy.SessionPolicyName] = base64.StdEncoding.EncodeToString([]byte(sessionPolicyStr))
+	}
+
+	cred, err := auth.GetNewCredentialsWithMetadata(claims, globalActiveCred.SecretKey)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInternalError, err)
+		return
+	}
+
+	cred.ParentUser = parentUser
+	cred.Groups = groups
+
+	updatedAt, err := globalIAMSys.SetTempUser(ctx, cred.AccessKey, cred, policyName)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInternalError, err)
+		return
+	}
+
+	// Call hook for site replication.
+	if err := globalSiteReplicationSys.IAMChangeHook(ctx, madmin.SRIAMItem{
+		Type: madmin.SRIAMItemSTSAcc,
+		STSCredential: &madmin.SRSTSCredential{
+			AccessKey:           cred.AccessKey,
+			SecretKey:           cred.SecretKey,
+			SessionToken:        cred.SessionToken,
+			ParentUser:          cred.ParentUser,
+			ParentPolicyMapping: policyName,
+		},
+		UpdatedAt: updatedAt,
+	}); err != nil {
+		logger.LogIf(ctx, err)
+	}
+
+	response := new(AssumeRoleWithSAMLResponse)
+	response.Result.Credentials = cred
+	response.Result.Subject = a.Subject
+	response.Result.Issuer = a.Issuer
+	response.Result.Audience = globalSAMLConfig.SPEntityID
+	response.ResponseMetadata.RequestID = w.Header().Get(xhttp.AmzRequestID)
+	writeSuccessResponseXML(w, encodeResponse(response))
+}
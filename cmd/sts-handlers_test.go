@@ -19,7 +19,9 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"testing"
@@ -37,6 +39,7 @@ func runAllIAMSTSTests(suite *TestSuiteIAM, c *check) {
 	suite.TestSTSForRoot(c)
 	suite.TestSTS(c)
 	suite.TestSTSWithGroupPolicy(c)
+	suite.TestSTSRevocation(c)
 	suite.TearDownSuite(c)
 }
 
@@ -154,6 +157,91 @@ func (s *TestSuiteIAM) TestSTS(c *check) {
 	}
 }
 
+func (s *TestSuiteIAM) TestSTSRevocation(c *check) {
+	ctx, cancel := context.WithTimeout(context.Background(), testDefaultTimeout)
+	defer cancel()
+
+	accessKey, secretKey := mustGenerateCredentials(c)
+	err := s.adm.SetUser(ctx, accessKey, secretKey, madmin.AccountEnabled)
+	if err != nil {
+		c.Fatalf("Unable to set user: %v", err)
+	}
+	err = s.adm.SetPolicy(ctx, "readwrite", accessKey, false)
+	if err != nil {
+		c.Fatalf("Unable to set policy: %v", err)
+	}
+
+	assumeRole := func() (*minio.Client, string) {
+		value, err := (&cr.STSAssumeRole{
+			Client:      s.TestSuiteCommon.client,
+			STSEndpoint: s.endPoint,
+			Options: cr.STSAssumeRoleOptions{
+				AccessKey: accessKey,
+				SecretKey: secretKey,
+			},
+		}).Retrieve()
+		if err != nil {
+			c.Fatalf("err calling assumeRole: %v", err)
+		}
+		return s.getUserClient(c, value.AccessKeyID, value.SecretAccessKey, value.SessionToken), value.AccessKeyID
+	}
+	revoke := func(filter stsRevocationFilter) []revokedSTSSession {
+		data, err := json.Marshal(filter)
+		if err != nil {
+			c.Fatalf("Unable to marshal filter: %v", err)
+		}
+		resp, err := s.adm.ExecuteMethod(ctx, http.MethodPost, madmin.RequestData{
+			RelPath: adminAPIVersionPrefix + "/revoke-sts-sessions",
+			Content: data,
+		})
+		if err != nil {
+			c.Fatalf("Unable to revoke STS sessions: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			c.Fatalf("Unable to revoke STS sessions: status %d", resp.StatusCode)
+		}
+		var revoked []revokedSTSSession
+		if err = json.NewDecoder(resp.Body).Decode(&revoked); err != nil {
+			c.Fatalf("Unable to decode revoked sessions: %v", err)
+		}
+		return revoked
+	}
+
+	client1, accessKey1 := assumeRole()
+	client2, _ := assumeRole()
+	for _, client := range []*minio.Client{client1, client2} {
+		if _, err = client.ListBuckets(ctx); err != nil {
+			c.Fatalf("STS credentials should be able to list buckets: %v", err)
+		}
+	}
+
+	// Revoke a single session by its access key.
+	if revoked := revoke(stsRevocationFilter{AccessKey: accessKey1}); len(revoked) != 1 || revoked[0].AccessKey != accessKey1 {
+		c.Fatalf("Expected session %s to be revoked, got %v", accessKey1, revoked)
+	}
+	if _, err = client1.ListBuckets(ctx); err == nil || minio.ToErrorResponse(err).Code != "InvalidTokenId" {
+		c.Fatalf("Revoked STS credentials should be rejected, got %v", err)
+	}
+	if _, err = client2.ListBuckets(ctx); err != nil {
+		c.Fatalf("STS credentials not revoked should be able to list buckets: %v", err)
+	}
+
+	// Revoke the remaining sessions of the user issued until now.
+	if revoked := revoke(stsRevocationFilter{ParentUser: accessKey, IssuedBefore: time.Now().Add(time.Second)}); len(revoked) != 2 {
+		c.Fatalf("Expected 2 sessions to be revoked, got %v", revoked)
+	}
+	if _, err = client2.ListBuckets(ctx); err == nil {
+		c.Fatalf("Revoked STS credentials should be rejected")
+	}
+
+	// New sessions are not revoked.
+	client3, _ := assumeRole()
+	if _, err = client3.ListBuckets(ctx); err != nil {
+		c.Fatalf("New STS credentials should be able to list buckets: %v", err)
+	}
+}
+
 func (s *TestSuiteIAM) TestSTSWithGroupPolicy(c *check) {
 	ctx, cancel := context.WithTimeout(context.Background(), testDefaultTimeout)
 	defer cancel()
@@ -0,0 +1,106 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/logger"
+)
+
+// samlAssertionsPrefix is where the IDs of accepted SAML assertions are
+// recorded until they expire, grouped by the hour they expire in.
+var samlAssertionsPrefix = path.Join(minioConfigPrefix, "saml", "assertions")
+
+const samlAssertionsHourFormat = "2006010215"
+
+// samlReplayCache records the accepted SAML assertions in the backend so
+// that an assertion exchanged on one node is refused on all others.
+type samlReplayCache struct {
+	mu sync.Mutex
+	// purgedUntil is the start of the first expiry hour not purged yet.
+	purgedUntil time.Time
+}
+
+// samlAssertionPath returns the object recording an assertion id.
+func samlAssertionPath(id string, expiry time.Time) string {
+	sum := sha256.Sum256([]byte(id))
+	hour := expiry.UTC().Truncate(time.Hour).Format(samlAssertionsHourFormat)
+	return path.Join(samlAssertionsPrefix, hour, hex.EncodeToString(sum[:]))
+}
+
+// Add records id until expiry, it returns false if id is already recorded.
+func (c *samlReplayCache) Add(ctx context.Context, id string, expiry time.Time) (bool, error) {
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return false, errServerNotInitialized
+	}
+	c.purge(ctx, objAPI, UTCNow())
+
+	configFile := samlAssertionPath(id, expiry)
+	lk := objAPI.NewNSLock(minioMetaBucket, configFile+".lock")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return false, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	_, err = readConfig(ctx, objAPI, configFile)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, errConfigNotFound) {
+		return false, err
+	}
+	if err = saveConfig(ctx, objAPI, configFile, []byte(expiry.UTC().Format(time.RFC3339))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// purge removes the assertions which expired in the hours before the
+// previous one, each node purges the hours passed since it last did.
+func (c *samlReplayCache) purge(ctx context.Context, objAPI ObjectLayer, now time.Time) {
+	until := now.UTC().Truncate(time.Hour).Add(-time.Hour)
+
+	c.mu.Lock()
+	from := c.purgedUntil
+	if from.IsZero() {
+		from = until.Add(-24 * time.Hour)
+	}
+	if !from.Before(until) {
+		c.mu.Unlock()
+		return
+	}
+	c.purgedUntil = until
+	c.mu.Unlock()
+
+	for hour := from; hour.Before(until); hour = hour.Add(time.Hour) {
+		err := deleteConfig(ctx, objAPI, path.Join(samlAssertionsPrefix, hour.Format(samlAssertionsHourFormat))+SlashSeparator)
+		if err != nil && !errors.Is(err, errConfigNotFound) {
+			logger.LogIf(ctx, err)
+		}
+	}
+}
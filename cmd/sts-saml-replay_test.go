@@ -0,0 +1,68 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSAMLReplayCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	setObjectLayer(obj)
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+
+	expiry := UTCNow().Add(5 * time.Minute)
+	c := &samlReplayCache{}
+	if ok, err := c.Add(ctx, "idp/_a1", expiry); err != nil || !ok {
+		t.Fatalf("expected the assertion to be accepted, got %v, %v", ok, err)
+	}
+
+	// Another node shares the recorded assertions.
+	other := &samlReplayCache{}
+	if ok, err := other.Add(ctx, "idp/_a1", expiry); err != nil || ok {
+		t.Fatalf("expected the assertion to be refused, got %v, %v", ok, err)
+	}
+	if ok, err := other.Add(ctx, "idp/_a2", expiry); err != nil || !ok {
+		t.Fatalf("expected the assertion to be accepted, got %v, %v", ok, err)
+	}
+
+	// Assertions which expired before the previous hour are purged.
+	old := UTCNow().Add(-3 * time.Hour)
+	if err = saveConfig(ctx, obj, samlAssertionPath("idp/_a3", old), []byte(old.Format(time.RFC3339))); err != nil {
+		t.Fatal(err)
+	}
+	c.purgedUntil = time.Time{}
+	c.purge(ctx, obj, UTCNow())
+	if _, err = readConfig(ctx, obj, samlAssertionPath("idp/_a3", old)); err != errConfigNotFound {
+		t.Fatalf("expected the expired assertion to be purged, got %v", err)
+	}
+	if _, err = readConfig(ctx, obj, samlAssertionPath("idp/_a1", expiry)); err != nil {
+		t.Fatalf("expected the assertion to be kept, got %v", err)
+	}
+}
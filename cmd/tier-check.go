@@ -0,0 +1,255 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/bucket/lifecycle"
+	"github.com/GuinsooLab/annastore/internal/logger"
+)
+
+// maxTierCheckReportEntries bounds the number of missing and orphaned
+// objects reported by a single tier check, counters are always exact.
+const maxTierCheckReportEntries = 10000
+
+// tierCheckOrphanGrace is the minimum age of a remote object before it may be
+// considered orphaned, so that in-flight transitions which have written the
+// remote object but not yet committed the local metadata are never reported.
+const tierCheckOrphanGrace = time.Hour
+
+// remoteObjectInfo describes an object stored on a warm backend.
+type remoteObjectInfo struct {
+	Name      string
+	VersionID remoteVersionID
+	ModTime   time.Time
+}
+
+// warmBackendLister is implemented by warm backends which can enumerate the
+// objects they store. It is used to find remote objects which are no longer
+// referenced by any object version in this deployment.
+type warmBackendLister interface {
+	List(ctx context.Context, prefix string, fn func(remoteObjectInfo) error) error
+}
+
+// tierCheckOpts controls a tier consistency check.
+type tierCheckOpts struct {
+	// RemoveOrphans removes unreferenced remote objects when set.
+	RemoveOrphans bool
+}
+
+// TierCheckMissing is a transitioned object version whose remote object
+// could not be read from the warm backend.
+type TierCheckMissing struct {
+	Bucket          string `json:"bucket"`
+	Object          string `json:"object"`
+	VersionID       string `json:"versionId,omitempty"`
+	RemoteObject    string `json:"remoteObject"`
+	RemoteVersionID string `json:"remoteVersionId,omitempty"`
+	Error           string `json:"error"`
+}
+
+// TierCheckOrphan is a remote object not referenced by any object version.
+type TierCheckOrphan struct {
+	RemoteObject    string    `json:"remoteObject"`
+	RemoteVersionID string    `json:"remoteVersionId,omitempty"`
+	ModTime         time.Time `json:"modTime"`
+	Removed         bool      `json:"removed,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// TierCheckResult is the report of a tier consistency check. Intermediate
+// results streamed to the client only carry progress counters.
+type TierCheckResult struct {
+	Tier             string             `json:"tier"`
+	Started          time.Time          `json:"started"`
+	Finished         time.Time          `json:"finished,omitempty"`
+	Checked          uint64             `json:"checked"`
+	MissingCount     uint64             `json:"missingCount"`
+	Missing          []TierCheckMissing `json:"missing,omitempty"`
+	OrphanSupported  bool               `json:"orphanSupported"`
+	RemoteScanned    uint64             `json:"remoteScanned"`
+	OrphanCount      uint64             `json:"orphanCount"`
+	OrphansRemoved   uint64             `json:"orphansRemoved"`
+	Orphans          []TierCheckOrphan  `json:"orphans,omitempty"`
+	Truncated        bool               `json:"truncated,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	Done             bool               `json:"done"`
+	referencedRemote map[string]struct{}
+}
+
+func tierCheckRefKey(name string, rv remoteVersionID) string {
+	return name + "\x00" + string(rv)
+}
+
+// progress returns a copy of the counters of r, safe to report while the
+// check is still running.
+func (r *TierCheckResult) progress() TierCheckResult {
+	return TierCheckResult{
+		Tier:            r.Tier,
+		Started:         r.Started,
+		Checked:         atomic.LoadUint64(&r.Checked),
+		MissingCount:    atomic.LoadUint64(&r.MissingCount),
+		OrphanSupported: r.OrphanSupported,
+		RemoteScanned:   atomic.LoadUint64(&r.RemoteScanned),
+		OrphanCount:     atomic.LoadUint64(&r.OrphanCount),
+		OrphansRemoved:  atomic.LoadUint64(&r.OrphansRemoved),
+	}
+}
+
+// newTierCheckResult returns the result of a new check of tierName. Its
+// fields not updated atomically are set before the check starts, so that
+// progress may be reported concurrently.
+func newTierCheckResult(tierName string) (*TierCheckResult, error) {
+	w, err := globalTierConfigMgr.getDriver(tierName)
+	if err != nil {
+		return nil, err
+	}
+	_, listable := w.(warmBackendLister)
+	result := &TierCheckResult{
+		Tier:            tierName,
+		Started:         UTCNow(),
+		OrphanSupported: listable,
+	}
+	if listable {
+		result.referencedRemote = make(map[string]struct{})
+	}
+	return result, nil
+}
+
+// checkTier cross-checks every object version transitioned to the tier of
+// result against its warm backend. It reports transitioned versions whose
+// remote object is missing and, when the backend can be listed, remote
+// objects written by this deployment which no version references anymore.
+func checkTier(ctx context.Context, objAPI ObjectLayer, opts tierCheckOpts, result *TierCheckResult) error {
+	tierName := result.Tier
+	w, err := globalTierConfigMgr.getDriver(tierName)
+	if err != nil {
+		return err
+	}
+	lister, listable := w.(warmBackendLister)
+	listable = listable && result.OrphanSupported
+
+	buckets, err := objAPI.ListBuckets(ctx, BucketOptions{})
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		if err = checkTierBucket(ctx, objAPI, w, bucket.Name, tierName, result); err != nil {
+			return err
+		}
+	}
+
+	if !listable {
+		return nil
+	}
+
+	// Only objects written by this deployment are considered, see genTransitionObjName.
+	olderThan := result.Started.Add(-tierCheckOrphanGrace)
+	err = lister.List(ctx, globalDeploymentID+slashSeparator, func(ri remoteObjectInfo) error {
+		atomic.AddUint64(&result.RemoteScanned, 1)
+		if _, ok := result.referencedRemote[tierCheckRefKey(ri.Name, ri.VersionID)]; ok {
+			return nil
+		}
+		if ri.ModTime.After(olderThan) {
+			return nil
+		}
+		atomic.AddUint64(&result.OrphanCount, 1)
+		orphan := TierCheckOrphan{
+			RemoteObject:    ri.Name,
+			RemoteVersionID: string(ri.VersionID),
+			ModTime:         ri.ModTime,
+		}
+		if opts.RemoveOrphans {
+			if rerr := w.Remove(ctx, ri.Name, ri.VersionID); rerr != nil {
+				orphan.Error = rerr.Error()
+			} else {
+				orphan.Removed = true
+				atomic.AddUint64(&result.OrphansRemoved, 1)
+			}
+		}
+		if len(result.Orphans) < maxTierCheckReportEntries {
+			result.Orphans = append(result.Orphans, orphan)
+		} else {
+			result.Truncated = true
+		}
+		return nil
+	})
+	return err
+}
+
+func checkTierBucket(ctx context.Context, objAPI ObjectLayer, w WarmBackend, bucket, tierName string, result *TierCheckResult) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objInfoCh := make(chan ObjectInfo)
+	if err := objAPI.Walk(ctx, bucket, "", objInfoCh, ObjectOptions{}); err != nil {
+		return err
+	}
+	for oi := range objInfoCh {
+		if oi.TransitionedObject.Tier != tierName || oi.TransitionedObject.Status != lifecycle.TransitionComplete {
+			continue
+		}
+		rv := remoteVersionID(oi.TransitionedObject.VersionID)
+		if result.referencedRemote != nil {
+			result.referencedRemote[tierCheckRefKey(oi.TransitionedObject.Name, rv)] = struct{}{}
+		}
+		atomic.AddUint64(&result.Checked, 1)
+
+		err := statWarmBackendObject(ctx, w, oi.TransitionedObject.Name, rv)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		atomic.AddUint64(&result.MissingCount, 1)
+		logger.LogIf(ctx, err, logger.Application)
+		if len(result.Missing) < maxTierCheckReportEntries {
+			result.Missing = append(result.Missing, TierCheckMissing{
+				Bucket:          oi.Bucket,
+				Object:          oi.Name,
+				VersionID:       oi.VersionID,
+				RemoteObject:    oi.TransitionedObject.Name,
+				RemoteVersionID: string(rv),
+				Error:           err.Error(),
+			})
+		} else {
+			result.Truncated = true
+		}
+	}
+	return ctx.Err()
+}
+
+// statWarmBackendObject verifies that object exists on w by reading at most
+// its first byte, since WarmBackend does not offer a stat primitive. A range
+// request is not used as it fails on empty objects.
+func statWarmBackendObject(ctx context.Context, w WarmBackend, object string, rv remoteVersionID) error {
+	r, err := w.Get(ctx, object, rv, WarmBackendGetOpts{})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if _, err = io.CopyN(io.Discard, r, 1); err == io.EOF {
+		err = nil
+	}
+	return err
+}
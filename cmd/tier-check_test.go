@@ -0,0 +1,73 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestWarmBackendFSList(t *testing.T) {
+	ctx := context.Background()
+	d, err := newWarmBackendFS("file://"+t.TempDir(), "tier", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects := map[string]remoteVersionID{}
+	for _, object := range []string{"dep1/bucket/aa/bb/uuid1", "dep1/bucket/aa/bb/uuid2", "dep2/bucket/cc/dd/uuid3"} {
+		rv, err := d.Put(ctx, object, bytes.NewReader([]byte(object)), int64(len(object)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		objects[object] = rv
+	}
+	// Empty objects must be reported as present.
+	rv, err := d.Put(ctx, "dep1/bucket/ee/ff/empty", bytes.NewReader(nil), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects["dep1/bucket/ee/ff/empty"] = rv
+
+	listed := map[string]remoteVersionID{}
+	if err = d.List(ctx, "dep1/", func(ri remoteObjectInfo) error {
+		listed[ri.Name] = ri.VersionID
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 3 {
+		t.Fatalf("expected 3 objects with prefix dep1/, got %v", listed)
+	}
+	for name, rv := range listed {
+		if objects[name] != rv {
+			t.Fatalf("%s: expected version %s, got %s", name, objects[name], rv)
+		}
+		if err = statWarmBackendObject(ctx, d, name, rv); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	if err = d.Remove(ctx, "dep1/bucket/aa/bb/uuid1", objects["dep1/bucket/aa/bb/uuid1"]); err != nil {
+		t.Fatal(err)
+	}
+	if err = statWarmBackendObject(ctx, d, "dep1/bucket/aa/bb/uuid1", objects["dep1/bucket/aa/bb/uuid1"]); err == nil {
+		t.Fatal("expected removed object to be missing")
+	}
+}
@@ -21,8 +21,8 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
-	"github.com/GuinsooLab/annastore/internal/config/storageclass"
 	"github.com/GuinsooLab/annastore/internal/logger"
 	"github.com/gorilla/mux"
 	jsoniter "github.com/json-iterator/go"
@@ -100,8 +100,7 @@ func (api adminAPIHandlers) AddTierHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Disallow remote tiers with internal storage class names
-	switch cfg.Name {
-	case storageclass.STANDARD, storageclass.RRS:
+	if globalStorageClass.IsValid(cfg.Name) {
 		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, errTierReservedName), r.URL)
 		return
 	}
@@ -301,3 +300,74 @@ func (api adminAPIHandlers) TierStatsHandler(w http.ResponseWriter, r *http.Requ
 	}
 	writeSuccessResponseJSON(w, data)
 }
+
+// TierCheckHandler - POST /minio/admin/v3/tier/{tier}/check?remove-orphans=true
+// ----------
+// Cross-checks all object versions transitioned to the tier against the warm
+// backend, streaming progress until the final report with Done set to true.
+func (api adminAPIHandlers) TierCheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "TierCheck")
+
+	defer logger.AuditLog(ctx, w, r, mustGetClaimsFromToken(r))
+
+	if globalIsGateway {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL)
+		return
+	}
+
+	opts := tierCheckOpts{
+		RemoveOrphans: r.Form.Get("remove-orphans") == "true",
+	}
+	var action iampolicy.AdminAction = iampolicy.ListTierAction
+	if opts.RemoveOrphans {
+		action = iampolicy.SetTierAction
+	}
+	objAPI, _ := validateAdminReq(ctx, w, r, action)
+	if objAPI == nil || globalNotificationSys == nil || globalTierConfigMgr == nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tier := vars["tier"]
+	if !globalTierConfigMgr.IsTierValid(tier) {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, errTierNotFound), r.URL)
+		return
+	}
+
+	result, err := newTierCheckResult(tier)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- checkTier(ctx, objAPI, opts, result)
+	}()
+
+	keepAliveTicker := time.NewTicker(5 * time.Second)
+	defer keepAliveTicker.Stop()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAliveTicker.C:
+			// Report progress, which also keeps the client connected.
+			if err := enc.Encode(result.progress()); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		case err := <-doneCh:
+			if err != nil {
+				result.Error = err.Error()
+			}
+			result.Finished = UTCNow()
+			result.Done = true
+			enc.Encode(result)
+			w.(http.Flusher).Flush()
+			return
+		}
+	}
+}
@@ -0,0 +1,587 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/hash"
+	"github.com/GuinsooLab/annastore/internal/logger"
+	"github.com/minio/madmin-go"
+)
+
+const (
+	unrecoverableConfigFile = "unrecoverable.json"
+
+	// interval between two checks of the unrecoverable objects still
+	// existing, the objects deleted since are forgotten.
+	unrecoverableCleanupInterval = time.Hour
+
+	// interval during which the unrecoverable objects found are
+	// gathered before being recorded together.
+	unrecoverableFlushInterval = 10 * time.Second
+)
+
+var unrecoverableConfigPath = path.Join(minioConfigPrefix, unrecoverableConfigFile)
+
+var (
+	errUnrecoverableNotFound    = errors.New("object version is not unrecoverable")
+	errUnrecoverableQuarantined = errors.New("object version was already quarantined")
+	errUnrecoverableChanged     = errors.New("object version was overwritten or became readable since it was found unrecoverable")
+)
+
+// UnrecoverableShard is the state of an erasure shard of an
+// unrecoverable object version.
+type UnrecoverableShard struct {
+	Endpoint string `json:"endpoint"`
+	// Shard is the index of the shard on the drive, data shards come
+	// first from 1, zero when the erasure distribution is unknown.
+	Shard int `json:"shard"`
+	// State is one of the madmin.DriveState* values.
+	State string `json:"state"`
+}
+
+// UnrecoverableObject is an object version which lost more shards
+// than its parity allows, found while healing it.
+type UnrecoverableObject struct {
+	Bucket       string               `json:"bucket"`
+	Object       string               `json:"object"`
+	VersionID    string               `json:"versionId"`
+	ModTime      time.Time            `json:"modTime"`
+	DataDir      string               `json:"dataDir,omitempty"`
+	Pool         int                  `json:"pool"`
+	Set          int                  `json:"set"`
+	DataBlocks   int                  `json:"dataBlocks"`
+	ParityBlocks int                  `json:"parityBlocks"`
+	Shards       []UnrecoverableShard `json:"shards"`
+	Detected     time.Time            `json:"detected"`
+	// Quarantined is the location of the shards moved by the
+	// quarantine API, as bucket/prefix.
+	Quarantined string `json:"quarantined,omitempty"`
+}
+
+// MissingShards returns the shards missing or corrupted.
+func (o UnrecoverableObject) MissingShards() []int {
+	var shards []int
+	for _, s := range o.Shards {
+		if s.State != madmin.DriveStateOk && s.State != madmin.DriveStateOffline && s.Shard > 0 {
+			shards = append(shards, s.Shard)
+		}
+	}
+	sort.Ints(shards)
+	return shards
+}
+
+type unrecoverableConfig struct {
+	Objects map[string]UnrecoverableObject `json:"objects"`
+}
+
+func unrecoverableKey(bucket, object, versionID string) string {
+	if versionID == "" {
+		versionID = nullVersionID
+	}
+	return pathJoin(bucket, object) + "?" + versionID
+}
+
+// unrecoverableSys holds the index of the unrecoverable object
+// versions of the cluster. Changes are persisted under a cluster lock
+// by the node making them and reloaded by the other nodes.
+type unrecoverableSys struct {
+	// mu serializes the changes of the index on this node.
+	mu sync.Mutex
+	// objects holds the unrecoverableConfig which is never modified
+	// once stored, it is read for every object response.
+	objects atomic.Value
+
+	// pendingMu protects pending, the object versions found
+	// unrecoverable and not recorded yet.
+	pendingMu sync.Mutex
+	pending   map[string]UnrecoverableObject
+}
+
+func newUnrecoverableSys() *unrecoverableSys {
+	sys := &unrecoverableSys{}
+	sys.objects.Store(unrecoverableConfig{})
+	return sys
+}
+
+// Contains returns true if the object version last modified at modTime
+// is unrecoverable.
+func (sys *unrecoverableSys) Contains(bucket, object, versionID string, modTime time.Time) bool {
+	if sys == nil {
+		return false
+	}
+	cfg := sys.objects.Load().(unrecoverableConfig)
+	if len(cfg.Objects) == 0 {
+		return false
+	}
+	o, ok := cfg.Objects[unrecoverableKey(bucket, object, versionID)]
+	return ok && (o.ModTime.IsZero() || o.ModTime.Equal(modTime))
+}
+
+// List returns the unrecoverable object versions in bucket under prefix,
+// in all buckets when bucket is empty.
+func (sys *unrecoverableSys) List(bucket, prefix string) []UnrecoverableObject {
+	cfg := sys.objects.Load().(unrecoverableConfig)
+	objects := make([]UnrecoverableObject, 0, len(cfg.Objects))
+	for _, o := range cfg.Objects {
+		if (bucket != "" && o.Bucket != bucket) || !HasPrefix(o.Object, prefix) {
+			continue
+		}
+		objects = append(objects, o)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Bucket != objects[j].Bucket {
+			return objects[i].Bucket < objects[j].Bucket
+		}
+		if objects[i].Object != objects[j].Object {
+			return objects[i].Object < objects[j].Object
+		}
+		return objects[i].VersionID < objects[j].VersionID
+	})
+	return objects
+}
+
+func (sys *unrecoverableSys) get(bucket, object, versionID string) (UnrecoverableObject, bool) {
+	o, ok := sys.objects.Load().(unrecoverableConfig).Objects[unrecoverableKey(bucket, object, versionID)]
+	return o, ok
+}
+
+func readUnrecoverableConfig(ctx context.Context, objAPI ObjectLayer) (cfg unrecoverableConfig, err error) {
+	data, err := readConfig(ctx, objAPI, unrecoverableConfigPath)
+	if err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Load reloads the unrecoverable object versions from the config store.
+func (sys *unrecoverableSys) Load(ctx context.Context, objAPI ObjectLayer) error {
+	sys.mu.Lock()
+	defer sys.mu.Unlock()
+
+	cfg, err := readUnrecoverableConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	sys.objects.Store(cfg)
+	return nil
+}
+
+// update applies fn to the unrecoverable object versions, saves them if
+// fn changed them and asks the other nodes to reload them.
+func (sys *unrecoverableSys) update(ctx context.Context, objAPI ObjectLayer, fn func(objects map[string]UnrecoverableObject) (bool, error)) error {
+	sys.mu.Lock()
+	defer sys.mu.Unlock()
+
+	// Unrecoverable objects are found by every node.
+	locker := objAPI.NewNSLock(minioMetaBucket, unrecoverableConfigPath+".lock")
+	lkctx, err := locker.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer locker.Unlock(lkctx.Cancel)
+
+	cfg, err := readUnrecoverableConfig(ctx, objAPI)
+	if err != nil {
+		return err
+	}
+	objects := make(map[string]UnrecoverableObject, len(cfg.Objects)+1)
+	for k, v := range cfg.Objects {
+		objects[k] = v
+	}
+	changed, err := fn(objects)
+	if err != nil || !changed {
+		sys.objects.Store(cfg)
+		return err
+	}
+
+	cfg = unrecoverableConfig{Objects: objects}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err = saveConfig(ctx, objAPI, unrecoverableConfigPath, data); err != nil {
+		return err
+	}
+	sys.objects.Store(cfg)
+
+	if globalNotificationSys != nil {
+		for _, nerr := range globalNotificationSys.LoadUnrecoverable() {
+			if nerr.Err != nil {
+				reqInfo := (&logger.ReqInfo{}).AppendTags("peerAddress", nerr.Host.String())
+				logger.LogIf(logger.SetReqInfo(ctx, reqInfo), nerr.Err)
+			}
+		}
+	}
+	return nil
+}
+
+// found records an object version found unrecoverable while healing it,
+// the object versions found are recorded together after
+// unrecoverableFlushInterval.
+func (sys *unrecoverableSys) found(ctx context.Context, o UnrecoverableObject) {
+	if sys == nil {
+		return
+	}
+	if old, ok := sys.get(o.Bucket, o.Object, o.VersionID); ok && old.Quarantined == "" &&
+		old.ModTime.Equal(o.ModTime) && fmt.Sprint(old.Shards) == fmt.Sprint(o.Shards) {
+		return
+	}
+
+	sys.pendingMu.Lock()
+	defer sys.pendingMu.Unlock()
+	if sys.pending == nil {
+		sys.pending = make(map[string]UnrecoverableObject)
+		time.AfterFunc(unrecoverableFlushInterval, func() {
+			sys.flush(GlobalContext)
+		})
+	}
+	sys.pending[unrecoverableKey(o.Bucket, o.Object, o.VersionID)] = o
+}
+
+// flush records the object versions found unrecoverable since the
+// last flush.
+func (sys *unrecoverableSys) flush(ctx context.Context) {
+	sys.pendingMu.Lock()
+	pending := sys.pending
+	sys.pending = nil
+	sys.pendingMu.Unlock()
+
+	objAPI := newObjectLayerFn()
+	if len(pending) == 0 || objAPI == nil {
+		return
+	}
+
+	err := sys.update(ctx, objAPI, func(objects map[string]UnrecoverableObject) (bool, error) {
+		for key, o := range pending {
+			if old, ok := objects[key]; ok {
+				if old.Quarantined != "" {
+					continue
+				}
+				if old.ModTime.Equal(o.ModTime) {
+					o.Detected = old.Detected
+				}
+			}
+			objects[key] = o
+		}
+		return true, nil
+	})
+	if err != nil {
+		logger.LogIf(ctx, fmt.Errorf("Unable to record %d unrecoverable objects: %w", len(pending), err))
+		return
+	}
+	for _, o := range pending {
+		logger.LogIf(ctx, fmt.Errorf("Object %s (version %s) is unrecoverable, shards %v are missing or corrupted",
+			pathJoin(o.Bucket, o.Object), o.VersionID, o.MissingShards()))
+	}
+}
+
+// forgetPending removes the object version from the ones not recorded
+// yet.
+func (sys *unrecoverableSys) forgetPending(bucket, object, versionID string) {
+	sys.pendingMu.Lock()
+	defer sys.pendingMu.Unlock()
+	delete(sys.pending, unrecoverableKey(bucket, object, versionID))
+}
+
+// healed forgets an object version healed or removed since it was found
+// unrecoverable.
+func (sys *unrecoverableSys) healed(ctx context.Context, bucket, object, versionID string) {
+	sys.forgetPending(bucket, object, versionID)
+	if o, ok := sys.get(bucket, object, versionID); !ok || o.Quarantined != "" {
+		return
+	}
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return
+	}
+	logger.LogIf(ctx, sys.Forget(ctx, objAPI, bucket, object, versionID))
+}
+
+// Forget removes the object version from the unrecoverable ones.
+func (sys *unrecoverableSys) Forget(ctx context.Context, objAPI ObjectLayer, bucket, object, versionID string) error {
+	sys.forgetPending(bucket, object, versionID)
+	return sys.update(ctx, objAPI, func(objects map[string]UnrecoverableObject) (bool, error) {
+		key := unrecoverableKey(bucket, object, versionID)
+		if _, ok := objects[key]; !ok {
+			return false, errUnrecoverableNotFound
+		}
+		delete(objects, key)
+		return true, nil
+	})
+}
+
+// cleanup forgets the unrecoverable object versions deleted since they
+// were found, the quarantined ones are kept.
+func (sys *unrecoverableSys) cleanup(ctx context.Context, objAPI ObjectLayer) error {
+	var gone []string
+	for _, o := range sys.List("", "") {
+		if o.Quarantined != "" {
+			continue
+		}
+		_, err := objAPI.GetObjectInfo(ctx, o.Bucket, o.Object, ObjectOptions{VersionID: o.VersionID, NoLock: true})
+		if isErrObjectNotFound(err) || isErrVersionNotFound(err) || isErrBucketNotFound(err) {
+			gone = append(gone, unrecoverableKey(o.Bucket, o.Object, o.VersionID))
+		}
+	}
+	if len(gone) == 0 {
+		return nil
+	}
+	return sys.update(ctx, objAPI, func(objects map[string]UnrecoverableObject) (bool, error) {
+		for _, key := range gone {
+			delete(objects, key)
+		}
+		return true, nil
+	})
+}
+
+// initUnrecoverable loads the unrecoverable object versions, the first
+// node also forgets the deleted ones periodically.
+func initUnrecoverable(ctx context.Context, objAPI ObjectLayer) {
+	logger.LogIf(ctx, globalUnrecoverableSys.Load(ctx, objAPI))
+	if !globalEndpoints.FirstLocal() {
+		return
+	}
+
+	go func() {
+		t := time.NewTimer(unrecoverableCleanupInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				logger.LogIf(ctx, globalUnrecoverableSys.cleanup(ctx, objAPI))
+				t.Reset(unrecoverableCleanupInterval)
+			}
+		}
+	}()
+}
+
+// recordUnrecoverable records the object version as unrecoverable when it
+// lost more shards than its parity, drives offline are not counted as the
+// object may be read again once they are back.
+func (er erasureObjects) recordUnrecoverable(ctx context.Context, bucket, object, versionID string, fi FileInfo, errs, dataErrs []error) {
+	o := UnrecoverableObject{
+		Bucket:       bucket,
+		Object:       decodeDirObject(object),
+		VersionID:    versionID,
+		Pool:         er.poolIndex,
+		Set:          er.setIndex,
+		ParityBlocks: er.defaultParityCount,
+		Detected:     UTCNow(),
+	}
+	if fi.IsValid() {
+		o.ModTime = fi.ModTime
+		o.DataDir = fi.DataDir
+		o.ParityBlocks = fi.Erasure.ParityBlocks
+	}
+	endpoints := er.getEndpoints()
+	o.DataBlocks = len(endpoints) - o.ParityBlocks
+
+	var lost int
+	for i, ep := range endpoints {
+		err := errs[i]
+		if err == nil && i < len(dataErrs) {
+			err = dataErrs[i]
+		}
+		s := UnrecoverableShard{Endpoint: ep.String(), State: madmin.DriveStateCorrupt}
+		switch err {
+		case nil:
+			s.State = madmin.DriveStateOk
+		case errDiskNotFound:
+			s.State = madmin.DriveStateOffline
+		case errFileNotFound, errFileVersionNotFound, errVolumeNotFound:
+			s.State = madmin.DriveStateMissing
+		}
+		if s.State == madmin.DriveStateMissing || s.State == madmin.DriveStateCorrupt {
+			lost++
+		}
+		if fi.IsValid() && i < len(fi.Erasure.Distribution) {
+			s.Shard = fi.Erasure.Distribution[i]
+		}
+		o.Shards = append(o.Shards, s)
+	}
+	if lost <= o.ParityBlocks {
+		return
+	}
+	globalUnrecoverableSys.found(ctx, o)
+}
+
+// stillUnrecoverable returns true if the object version found
+// unrecoverable was neither overwritten nor healed since, the caller
+// must hold the object write lock.
+func (er erasureObjects) stillUnrecoverable(ctx context.Context, bucket, object, versionID string, o UnrecoverableObject) bool {
+	disks := er.getDisks()
+	metas, errs := readAllFileInfo(ctx, disks, bucket, object, versionID, true)
+
+	latest := -1
+	for i, meta := range metas {
+		if errs[i] != nil || !meta.IsValid() {
+			continue
+		}
+		if o.ModTime.IsZero() {
+			// Recorded without metadata, use the most recent one.
+			if latest < 0 || meta.ModTime.After(metas[latest].ModTime) {
+				latest = i
+			}
+			continue
+		}
+		if meta.ModTime.After(o.ModTime) {
+			// Overwritten since.
+			return false
+		}
+		if meta.ModTime.Equal(o.ModTime) && meta.DataDir == o.DataDir {
+			latest = i
+		}
+	}
+	if latest < 0 {
+		return false
+	}
+
+	available, _, _ := disksWithAllParts(ctx, disks, metas, errs, metas[latest], bucket, object, madmin.HealDeepScan)
+	var readable int
+	for _, disk := range available {
+		if disk != nil {
+			readable++
+		}
+	}
+	return readable < metas[latest].Erasure.DataBlocks
+}
+
+// quarantineObject moves the shards left of an unrecoverable object
+// version, with its xl.meta from every drive, to dstBucket under
+// <bucket>/<object>/<versionID>/<drive index>/ and removes the object
+// version. The details of the object are written next to them in
+// unrecoverable.json.
+func (z *erasureServerPools) quarantineObject(ctx context.Context, bucket, object, versionID, dstBucket string) (UnrecoverableObject, error) {
+	o, ok := globalUnrecoverableSys.get(bucket, object, versionID)
+	if !ok {
+		return o, errUnrecoverableNotFound
+	}
+	if o.Quarantined != "" {
+		return o, errUnrecoverableQuarantined
+	}
+	if o.Pool >= len(z.serverPools) || o.Set >= len(z.serverPools[o.Pool].sets) {
+		return o, errInvalidArgument
+	}
+	er := z.serverPools[o.Pool].sets[o.Set]
+	encObject := encodeDirObject(object)
+
+	lk := er.NewNSLock(bucket, encObject)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return o, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	readVersionID := o.VersionID
+	if readVersionID == nullVersionID {
+		readVersionID = ""
+	}
+	if !er.stillUnrecoverable(ctx, bucket, encObject, readVersionID, o) {
+		return o, errUnrecoverableChanged
+	}
+
+	dstPrefix := pathJoin(bucket, object, o.VersionID)
+	put := func(name string, size int64, rc io.ReadCloser) error {
+		defer rc.Close()
+		hr, err := hash.NewReader(rc, size, "", "", size)
+		if err != nil {
+			return err
+		}
+		_, err = z.PutObject(ctx, dstBucket, pathJoin(dstPrefix, name), NewPutObjReader(hr), ObjectOptions{})
+		return err
+	}
+
+	for i, disk := range er.getDisks() {
+		if disk == nil || !disk.IsOnline() {
+			continue
+		}
+		drive := strconv.Itoa(i)
+		raw, err := disk.ReadAll(ctx, bucket, pathJoin(encObject, xlStorageFormatFile))
+		if err != nil {
+			continue
+		}
+		if err = put(pathJoin(drive, xlStorageFormatFile), int64(len(raw)), io.NopCloser(bytes.NewReader(raw))); err != nil {
+			return o, err
+		}
+
+		fi, err := disk.ReadVersion(ctx, bucket, encObject, readVersionID, false)
+		if err != nil || fi.DataDir == "" {
+			continue
+		}
+		for _, part := range fi.Parts {
+			partPath := pathJoin(fi.DataDir, fmt.Sprintf("part.%d", part.Number))
+			st, err := disk.StatInfoFile(ctx, bucket, pathJoin(encObject, partPath), false)
+			if err != nil || len(st) == 0 {
+				continue
+			}
+			rc, err := disk.ReadFileStream(ctx, bucket, pathJoin(encObject, partPath), 0, st[0].Size)
+			if err != nil {
+				continue
+			}
+			if err = put(pathJoin(drive, partPath), st[0].Size, rc); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	o.Quarantined = pathJoin(dstBucket, dstPrefix)
+	data, err := json.Marshal(o)
+	if err != nil {
+		return o, err
+	}
+	if err = put(unrecoverableConfigFile, int64(len(data)), io.NopCloser(bytes.NewReader(data))); err != nil {
+		return o, err
+	}
+
+	// Remove the object version from all drives.
+	if err = er.deleteObjectVersion(ctx, bucket, encObject, 1, FileInfo{
+		VersionID: readVersionID,
+	}, false); err != nil {
+		return o, err
+	}
+	NSUpdated(bucket, object)
+
+	err = globalUnrecoverableSys.update(ctx, z, func(objects map[string]UnrecoverableObject) (bool, error) {
+		objects[unrecoverableKey(bucket, object, versionID)] = o
+		return true, nil
+	})
+	return o, err
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+)
+
+func TestUnrecoverableObjectMissingShards(t *testing.T) {
+	o := UnrecoverableObject{
+		Shards: []UnrecoverableShard{
+			{Shard: 3, State: madmin.DriveStateCorrupt},
+			{Shard: 1, State: madmin.DriveStateOk},
+			{Shard: 4, State: madmin.DriveStateOffline},
+			{Shard: 2, State: madmin.DriveStateMissing},
+			{Shard: 0, State: madmin.DriveStateMissing},
+		},
+	}
+	if got, want := o.MissingShards(), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestUnrecoverableSys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	obj, fsDirs, err := prepareErasure16(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	setObjectLayer(obj)
+	// Do not leave the object layer to the following tests.
+	defer setObjectLayer(nil)
+
+	defer func(sys *unrecoverableSys) { globalUnrecoverableSys = sys }(globalUnrecoverableSys)
+	globalUnrecoverableSys = newUnrecoverableSys()
+
+	const bucket, object = "bucket", "object"
+	for _, b := range []string{bucket, "quarantine"} {
+		if err = obj.MakeBucketWithLocation(ctx, b, MakeBucketOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Large enough for the shards not to be inlined.
+	data := bytes.Repeat([]byte("a"), 4<<20)
+	if _, err = obj.PutObject(ctx, bucket, object, mustGetPutObjReader(t, bytes.NewReader(data), int64(len(data)), "", ""), ObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	z := obj.(*erasureServerPools)
+	er := z.serverPools[0].sets[0]
+	fi, _, _, err := er.getObjectFileInfo(ctx, bucket, object, ObjectOptions{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost := func(n int) []error {
+		errs := make([]error, len(er.getDisks()))
+		for i := 0; i < n; i++ {
+			errs[i] = errFileNotFound
+		}
+		return errs
+	}
+
+	// Losing as many shards as the parity is not unrecoverable.
+	er.recordUnrecoverable(ctx, bucket, object, nullVersionID, fi, lost(fi.Erasure.ParityBlocks), nil)
+	globalUnrecoverableSys.flush(ctx)
+	if globalUnrecoverableSys.Contains(bucket, object, "", fi.ModTime) {
+		t.Fatal("expected the object not to be unrecoverable")
+	}
+
+	er.recordUnrecoverable(ctx, bucket, object, nullVersionID, fi, lost(fi.Erasure.ParityBlocks+1), nil)
+	if globalUnrecoverableSys.Contains(bucket, object, "", fi.ModTime) {
+		t.Fatal("expected the object to be recorded once flushed")
+	}
+	globalUnrecoverableSys.flush(ctx)
+	if !globalUnrecoverableSys.Contains(bucket, object, "", fi.ModTime) {
+		t.Fatal("expected the object to be unrecoverable")
+	}
+	// A new object of the same name is not unrecoverable.
+	if globalUnrecoverableSys.Contains(bucket, object, "", fi.ModTime.Add(time.Second)) {
+		t.Fatal("expected an object overwritten not to be unrecoverable")
+	}
+	objects := globalUnrecoverableSys.List(bucket, "")
+	if len(objects) != 1 || len(objects[0].MissingShards()) != fi.Erasure.ParityBlocks+1 {
+		t.Fatalf("unexpected unrecoverable objects %#v", objects)
+	}
+	if objects = globalUnrecoverableSys.List("", "obj"); len(objects) != 1 {
+		t.Fatalf("unexpected unrecoverable objects %#v", objects)
+	}
+	if objects = globalUnrecoverableSys.List("", "other"); len(objects) != 0 {
+		t.Fatalf("unexpected unrecoverable objects %#v", objects)
+	}
+
+	// The index is persisted.
+	reloaded := newUnrecoverableSys()
+	if err = reloaded.Load(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Contains(bucket, object, nullVersionID, fi.ModTime) {
+		t.Fatal("expected the object to be unrecoverable once reloaded")
+	}
+
+	// The object is forgotten once healed.
+	if _, err = obj.HealObject(ctx, bucket, object, "", madmin.HealOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if globalUnrecoverableSys.Contains(bucket, object, "", fi.ModTime) {
+		t.Fatal("expected the healed object to be forgotten")
+	}
+
+	er.recordUnrecoverable(ctx, bucket, object, nullVersionID, fi, lost(fi.Erasure.ParityBlocks+1), nil)
+	globalUnrecoverableSys.flush(ctx)
+
+	// The healed object is readable, it is not quarantined.
+	if _, err = z.quarantineObject(ctx, bucket, object, "", "quarantine"); err != errUnrecoverableChanged {
+		t.Fatalf("expected %v, got %v", errUnrecoverableChanged, err)
+	}
+	disks := er.getDisks()
+	for i := 0; i <= fi.Erasure.ParityBlocks; i++ {
+		if err = disks[i].Delete(ctx, bucket, pathJoin(object, fi.DataDir), DeleteOptions{Recursive: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o, err := z.quarantineObject(ctx, bucket, object, "", "quarantine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Quarantined != "quarantine/bucket/object/null" {
+		t.Errorf("unexpected quarantine location %s", o.Quarantined)
+	}
+	if _, err = obj.GetObjectInfo(ctx, bucket, object, ObjectOptions{}); !isErrObjectNotFound(err) {
+		t.Errorf("expected the object to be removed, got %v", err)
+	}
+	for _, name := range []string{"0/xl.meta", strconv.Itoa(len(disks)-1) + "/" + fi.DataDir + "/part.1", "unrecoverable.json"} {
+		if _, err = obj.GetObjectInfo(ctx, "quarantine", "bucket/object/null/"+name, ObjectOptions{}); err != nil {
+			t.Errorf("expected %s to be quarantined, got %v", name, err)
+		}
+	}
+	if _, err = z.quarantineObject(ctx, bucket, object, "", "quarantine"); err != errUnrecoverableQuarantined {
+		t.Errorf("expected %v, got %v", errUnrecoverableQuarantined, err)
+	}
+
+	if err = globalUnrecoverableSys.Forget(ctx, obj, bucket, object, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = globalUnrecoverableSys.Forget(ctx, obj, bucket, object, ""); err != errUnrecoverableNotFound {
+		t.Errorf("expected %v, got %v", errUnrecoverableNotFound, err)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/minio/madmin-go"
+)
+
+// fsTierScheme is the endpoint scheme which selects the filesystem warm
+// backend, e.g. file:///mnt/nfs/archive. The path must be mounted at the
+// same location on every node of the deployment.
+const fsTierScheme = "file"
+
+const fsTierTmpDir = ".tmp"
+
+var (
+	errFSTierInvalidPath = errors.New("filesystem tier path must be absolute")
+	errFSTierNotDir      = errors.New("filesystem tier path is not a directory")
+)
+
+// warmBackendFS transitions objects onto a mounted POSIX path (NFS, HSM
+// mounts, slow HDD arrays). Every put is stored under a new UUID, which is
+// returned as the remote version id, so that puts of the same object never
+// overwrite or remove each other's data:
+//
+//	<root>/<bucket>/<prefix>/<object>/<uuid>
+//
+// The segments of the object name are used as directories, like objects on
+// the drives of the deployment, and must not be longer than NAME_MAX.
+//
+// Every write is fsync'ed along with its parent directories before it is
+// acknowledged, so a transition is never recorded for data that could still
+// be lost to a crash of the NFS client or server.
+type warmBackendFS struct {
+	root string
+}
+
+var _ WarmBackend = (*warmBackendFS)(nil)
+
+// isFSTierEndpoint returns true if endpoint refers to a filesystem tier.
+func isFSTierEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	return err == nil && u.Scheme == fsTierScheme
+}
+
+// newWarmBackendFS returns a filesystem warm backend rooted at
+// endpoint/bucket/prefix, creating the directory tree if needed.
+func newWarmBackendFS(endpoint, bucket, prefix string) (*warmBackendFS, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host != "" || !filepath.IsAbs(u.Path) {
+		return nil, errFSTierInvalidPath
+	}
+	root := filepath.Join(u.Path, bucket, strings.Trim(prefix, slashSeparator))
+	if err = mkdirAll(pathJoin(root, fsTierTmpDir), 0o777); err != nil {
+		return nil, err
+	}
+	fi, err := Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, errFSTierNotDir
+	}
+	return &warmBackendFS{root: root}, nil
+}
+
+// objectDir returns the directory holding the versions of object.
+func (fs *warmBackendFS) objectDir(object string) (string, error) {
+	if err := checkPathLength(object); err != nil {
+		return "", err
+	}
+	for _, segment := range strings.Split(object, slashSeparator) {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", errFileAccessDenied
+		}
+	}
+	if object == fsTierTmpDir || strings.HasPrefix(object, fsTierTmpDir+slashSeparator) {
+		return "", errFileAccessDenied
+	}
+	return filepath.Join(fs.root, filepath.FromSlash(object)), nil
+}
+
+// objectPath returns the on-disk location of object stored with version rv.
+func (fs *warmBackendFS) objectPath(object string, rv remoteVersionID) (string, error) {
+	if _, err := uuid.Parse(string(rv)); err != nil {
+		return "", errFileNotFound
+	}
+	dir, err := fs.objectDir(object)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, string(rv)), nil
+}
+
+func (fs *warmBackendFS) Put(ctx context.Context, object string, r io.Reader, length int64) (remoteVersionID, error) {
+	dir, err := fs.objectDir(object)
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir := filepath.Join(fs.root, fsTierTmpDir)
+	f, err := os.CreateTemp(tmpDir, "put-")
+	if err != nil {
+		return "", osErrToFileErr(err)
+	}
+	tmpPath := f.Name()
+	defer Remove(tmpPath)
+
+	n, err := io.Copy(f, r)
+	if err == nil && length >= 0 && n != length {
+		err = IncompleteBody{Bucket: fs.root, Object: object}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rv := mustGetUUID()
+	dst := filepath.Join(dir, rv)
+	err = renameAll(tmpPath, dst)
+	if err == errFileNotFound {
+		// A concurrent Remove may have pruned the parents created for
+		// this put, create them again.
+		err = renameAll(tmpPath, dst)
+	}
+	if err != nil {
+		return "", err
+	}
+	// Make the rename and any newly created parents durable.
+	for dir := filepath.Dir(dst); len(dir) > len(fs.root); dir = filepath.Dir(dir) {
+		if err = fsyncDir(dir); err != nil {
+			return "", err
+		}
+	}
+	if err = fsyncDir(fs.root); err != nil {
+		return "", err
+	}
+	return remoteVersionID(rv), nil
+}
+
+func (fs *warmBackendFS) Get(ctx context.Context, object string, rv remoteVersionID, opts WarmBackendGetOpts) (io.ReadCloser, error) {
+	fpath, err := fs.objectPath(object, rv)
+	if err != nil {
+		return nil, ObjectNotFound{Bucket: fs.root, Object: object}
+	}
+	f, err := Open(fpath)
+	if err != nil {
+		if osIsNotExist(err) {
+			return nil, ObjectNotFound{Bucket: fs.root, Object: object}
+		}
+		return nil, osErrToFileErr(err)
+	}
+	if opts.startOffset > 0 {
+		if _, err = f.Seek(opts.startOffset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if opts.length > 0 {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(f, opts.length), f}, nil
+	}
+	return f, nil
+}
+
+func (fs *warmBackendFS) Remove(ctx context.Context, object string, rv remoteVersionID) error {
+	fpath, err := fs.objectPath(object, rv)
+	if err != nil {
+		// Nothing could have been stored under an invalid version id.
+		return nil
+	}
+	if err = Remove(fpath); err != nil && !osIsNotExist(err) {
+		return osErrToFileErr(err)
+	}
+	// Prune the now possibly empty object directories, stopping at the
+	// first directory which is still in use.
+	for dir := filepath.Dir(fpath); len(dir) > len(fs.root); dir = filepath.Dir(dir) {
+		if Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (fs *warmBackendFS) InUse(ctx context.Context) (bool, error) {
+	entries, err := os.ReadDir(fs.root)
+	if err != nil {
+		return false, osErrToFileErr(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != fsTierTmpDir {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fsyncDir flushes directory entries of dir to stable storage.
+func fsyncDir(dir string) error {
+	d, err := Open(dir)
+	if err != nil {
+		return osErrToFileErr(err)
+	}
+	defer d.Close()
+	if err = d.Sync(); err != nil {
+		return fmt.Errorf("fsync %s: %w", dir, err)
+	}
+	return nil
+}
+
+// newWarmBackendFSFromTier returns a filesystem warm backend if the endpoint
+// of an S3 compatible tier configuration uses the file:// scheme.
+func newWarmBackendFSFromTier(tier madmin.TierConfig) (*warmBackendFS, bool, error) {
+	var endpoint, bucket, prefix string
+	switch tier.Type {
+	case madmin.S3:
+		endpoint, bucket, prefix = tier.S3.Endpoint, tier.S3.Bucket, tier.S3.Prefix
+	case madmin.MinIO:
+		endpoint, bucket, prefix = tier.MinIO.Endpoint, tier.MinIO.Bucket, tier.MinIO.Prefix
+	default:
+		return nil, false, nil
+	}
+	if !isFSTierEndpoint(endpoint) {
+		return nil, false, nil
+	}
+	d, err := newWarmBackendFS(endpoint, bucket, prefix)
+	return d, true, err
+}
+
+// List walks all objects stored on the filesystem tier whose name starts
+// with prefix.
+func (fs *warmBackendFS) List(ctx context.Context, prefix string, fn func(remoteObjectInfo) error) error {
+	return filepath.WalkDir(fs.root, func(fpath string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if osIsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			if entry.Name() == fsTierTmpDir && filepath.Dir(fpath) == fs.root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// <object>/<uuid>
+		if _, err = uuid.Parse(entry.Name()); err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(fs.root, filepath.Dir(fpath))
+		if err != nil {
+			return err
+		}
+		object := filepath.ToSlash(rel)
+		if object == "." || !strings.HasPrefix(object, prefix) {
+			return nil
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		return fn(remoteObjectInfo{
+			Name:      object,
+			VersionID: remoteVersionID(entry.Name()),
+			ModTime:   fi.ModTime(),
+		})
+	})
+}
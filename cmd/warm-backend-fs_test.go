@@ -0,0 +1,134 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/minio/madmin-go"
+)
+
+func TestWarmBackendFS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tier := madmin.TierConfig{
+		Version: madmin.TierConfigVer,
+		Type:    madmin.MinIO,
+		Name:    "ARCHIVE",
+		MinIO: &madmin.TierMinIO{
+			Endpoint: "file://" + dir,
+			Bucket:   "tier",
+			Prefix:   "cold/",
+		},
+	}
+	d, ok, err := newWarmBackendFSFromTier(tier)
+	if !ok || err != nil {
+		t.Fatalf("expected filesystem backend, got %v %v", ok, err)
+	}
+	if err = checkWarmBackend(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	if inUse, err := d.InUse(ctx); err != nil || inUse {
+		t.Fatalf("expected empty tier, got %v %v", inUse, err)
+	}
+
+	data := []byte("hello, warm tier")
+	object := "deployment/bucket/ab/cd/abcd-uuid"
+	rv, err := d.Put(ctx, object, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = uuid.Parse(string(rv)); err != nil {
+		t.Fatalf("expected a uuid version id, got %q", rv)
+	}
+	if inUse, err := d.InUse(ctx); err != nil || !inUse {
+		t.Fatalf("expected tier in use, got %v %v", inUse, err)
+	}
+
+	r, err := d.Get(ctx, object, rv, WarmBackendGetOpts{startOffset: 7, length: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "warm" {
+		t.Fatalf("expected range 'warm', got %q", got)
+	}
+
+	if _, err = d.Put(ctx, "short", bytes.NewReader(data), int64(len(data)+1)); err == nil {
+		t.Fatal("expected incomplete body error")
+	}
+
+	// Puts of the same content are stored apart, removing one of them
+	// leaves the other one readable.
+	rv2, err := d.Put(ctx, object, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv2 == rv {
+		t.Fatalf("expected distinct version ids, got %q twice", rv)
+	}
+	if err = d.Remove(ctx, object, rv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.Get(ctx, object, rv, WarmBackendGetOpts{}); !isErrObjectNotFound(err) {
+		t.Fatalf("expected object not found, got %v", err)
+	}
+	r, err = d.Get(ctx, object, rv2, WarmBackendGetOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	var listed []remoteObjectInfo
+	if err = d.List(ctx, "deployment/", func(ri remoteObjectInfo) error {
+		listed = append(listed, ri)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Name != object || listed[0].VersionID != rv2 {
+		t.Fatalf("expected %s version %s listed, got %v", object, rv2, listed)
+	}
+	if err = d.Remove(ctx, object, rv2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = d.Put(ctx, strings.Repeat("a", 256), bytes.NewReader(data), int64(len(data))); err != errFileNameTooLong {
+		t.Fatalf("expected %v, got %v", errFileNameTooLong, err)
+	}
+	if inUse, err := d.InUse(ctx); err != nil || inUse {
+		t.Fatalf("expected empty tier after remove, got %v %v", inUse, err)
+	}
+
+	if _, ok, _ = newWarmBackendFSFromTier(madmin.TierConfig{
+		Type:  madmin.MinIO,
+		MinIO: &madmin.TierMinIO{Endpoint: "https://play.min.io"},
+	}); ok {
+		t.Fatal("https endpoint must not select the filesystem backend")
+	}
+}
@@ -106,6 +106,44 @@ func (s3 *warmBackendS3) InUse(ctx context.Context) (bool, error) {
 	return len(result.CommonPrefixes) > 0 || len(result.Contents) > 0, nil
 }
 
+// List lists all object versions on the remote tier whose name starts with
+// prefix, relative to the tier's configured prefix.
+func (s3 *warmBackendS3) List(ctx context.Context, prefix string, fn func(remoteObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objCh := s3.client.ListObjects(ctx, s3.Bucket, minio.ListObjectsOptions{
+		Prefix:       s3.getDest(prefix),
+		Recursive:    true,
+		WithVersions: true,
+	})
+	for obj := range objCh {
+		if obj.Err != nil {
+			return s3.ToObjectError(obj.Err)
+		}
+		if obj.IsDeleteMarker {
+			continue
+		}
+		rv := obj.VersionID
+		if rv == nullVersionID {
+			// Put returns an empty version id on unversioned buckets.
+			rv = ""
+		}
+		name := obj.Key
+		if s3.Prefix != "" {
+			name = strings.TrimPrefix(name, s3.Prefix+slashSeparator)
+		}
+		if err := fn(remoteObjectInfo{
+			Name:      name,
+			VersionID: remoteVersionID(rv),
+			ModTime:   obj.LastModified,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newWarmBackendS3(conf madmin.TierS3) (*warmBackendS3, error) {
 	u, err := url.Parse(conf.Endpoint)
 	if err != nil {
@@ -47,6 +47,10 @@ const probeObject = "probeobject"
 // checkWarmBackend checks if tier config credentials have sufficient privileges
 // to perform all operations defined in the WarmBackend interface.
 func checkWarmBackend(ctx context.Context, w WarmBackend) error {
+	// Every node checks the tier when loading its config, probe a distinct
+	// object so that a node never removes the probe of another one.
+	probeObject := probeObject + "-" + mustGetUUID()
+
 	var empty bytes.Reader
 	rv, err := w.Put(ctx, probeObject, &empty, 0)
 	if err != nil {
@@ -134,6 +138,18 @@ type remoteVersionID string
 // newWarmBackend instantiates the tier type specific WarmBackend, runs
 // checkWarmBackend on it.
 func newWarmBackend(ctx context.Context, tier madmin.TierConfig) (d WarmBackend, err error) {
+	// S3 compatible tiers with a file:// endpoint are backed by a locally
+	// mounted filesystem instead of a remote object store.
+	if fsd, ok, err := newWarmBackendFSFromTier(tier); ok {
+		if err != nil {
+			return nil, err
+		}
+		if err = checkWarmBackend(ctx, fsd); err != nil {
+			return nil, err
+		}
+		return fsd, nil
+	}
+
 	switch tier.Type {
 	case madmin.S3:
 		d, err = newWarmBackendS3(*tier.S3)
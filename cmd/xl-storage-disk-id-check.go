@@ -71,6 +71,19 @@ const (
 	storageMetricLast
 )
 
+// isWrite returns true for the storage operations modifying the drive.
+func (s storageMetric) isWrite() bool {
+	switch s {
+	case storageMetricMakeVolBulk, storageMetricMakeVol, storageMetricDeleteVol,
+		storageMetricAppendFile, storageMetricCreateFile, storageMetricRenameFile,
+		storageMetricRenameData, storageMetricDelete, storageMetricDeleteVersions,
+		storageMetricWriteAll, storageMetricDeleteVersion, storageMetricWriteMetadata,
+		storageMetricUpdateMetadata:
+		return true
+	}
+	return false
+}
+
 // Detects change in underlying disk.
 type xlStorageDiskIDCheck struct {
 	// apiCalls should be placed first so alignment is guaranteed for atomic operations.
@@ -80,6 +93,22 @@ type xlStorageDiskIDCheck struct {
 	storage      *xlStorage
 	health       *diskHealthTracker
 	metricsCache timedValue
+
+	// readOnly is set to 1 when writes to the drive are refused.
+	readOnly int32
+}
+
+// setReadOnly stops or resumes writing to the drive, reads are still served.
+func (p *xlStorageDiskIDCheck) setReadOnly(readOnly bool) {
+	var v int32
+	if readOnly {
+		v = 1
+	}
+	atomic.StoreInt32(&p.readOnly, v)
+}
+
+func (p *xlStorageDiskIDCheck) isReadOnly() bool {
+	return atomic.LoadInt32(&p.readOnly) == 1
 }
 
 func (p *xlStorageDiskIDCheck) getMetrics() DiskMetrics {
@@ -405,7 +434,7 @@ func (p *xlStorageDiskIDCheck) DeleteVersions(ctx context.Context, volume string
 	ctx, done, err := p.TrackDiskHealth(ctx, storageMetricDeleteVersions, volume, path)
 	if err != nil {
 		for i := range errs {
-			errs[i] = ctx.Err()
+			errs[i] = err
 		}
 		return errs
 	}
@@ -657,6 +686,10 @@ func (p *xlStorageDiskIDCheck) TrackDiskHealth(ctx context.Context, s storageMet
 		return ctx, done, errFaultyDisk
 	}
 
+	if s.isWrite() && (p.isReadOnly() || globalDriveMaintenanceSys.isCordoned(p.diskID)) {
+		return ctx, done, errDiskReadOnly
+	}
+
 	// Verify if the disk is not stale
 	// - missing format.json (unformatted drive)
 	// - format.json is valid but invalid 'uuid'
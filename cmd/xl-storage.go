@@ -519,6 +519,7 @@ func (s *xlStorage) NSScanner(ctx context.Context, cache dataUsageCache, updates
 
 		versioned := vcfg != nil && vcfg.Versioned(item.objectPath())
 
+		var versionsSize int64
 		for _, version := range fivs.Versions {
 			oi := version.ToObjectInfo(item.bucket, item.objectPath(), versioned)
 			done = globalScannerMetrics.time(scannerMetricApplyVersion)
@@ -526,6 +527,10 @@ func (s *xlStorage) NSScanner(ctx context.Context, cache dataUsageCache, updates
 			done()
 			if oi.VersionID != "" && sz == oi.Size {
 				sizeS.versions++
+				versionsSize += sz
+			}
+			if oi.DeleteMarker {
+				sizeS.deleteMarkers++
 			}
 			sizeS.totalSize += sz
 
@@ -543,6 +548,7 @@ func (s *xlStorage) NSScanner(ctx context.Context, cache dataUsageCache, updates
 			}
 			sizeS.tiers[tier] = sizeS.tiers[tier].add(oi.tierStats())
 		}
+		item.alertExcessVersions(ctx, sizeS.versions, versionsSize)
 
 		// apply tier sweep action on free versions
 		for _, freeVersion := range fivs.FreeVersions {
@@ -283,6 +283,7 @@ type ObjectOpts struct {
 	TransitionStatus string
 	RestoreOngoing   bool
 	RestoreExpires   time.Time
+	StorageClass     string
 }
 
 // ExpiredObjectDeleteMarker returns true if an object version referred to by o
@@ -337,7 +338,8 @@ func (lc Lifecycle) ComputeAction(obj ObjectOpts) Action {
 		}
 
 		if !rule.NoncurrentVersionTransition.IsNull() {
-			if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() && !obj.DeleteMarker && obj.TransitionStatus != TransitionComplete {
+			if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() && !obj.DeleteMarker && obj.TransitionStatus != TransitionComplete &&
+				obj.StorageClass != rule.NoncurrentVersionTransition.StorageClass {
 				// Non current versions should be transitioned if their age exceeds non current days configuration
 				// https://docs.aws.amazon.com/AmazonS3/latest/dev/intro-lifecycle-rules.html#intro-lifecycle-rules-actions
 				if due, ok := rule.NoncurrentVersionTransition.NextDue(obj); ok && time.Now().UTC().After(due) {
@@ -360,7 +362,9 @@ func (lc Lifecycle) ComputeAction(obj ObjectOpts) Action {
 			}
 
 			if obj.TransitionStatus != TransitionComplete {
-				if due, ok := rule.Transition.NextDue(obj); ok {
+				// Objects already stored in the storage class they
+				// transition to, a custom one, have nothing to do.
+				if due, ok := rule.Transition.NextDue(obj); ok && obj.StorageClass != rule.Transition.StorageClass {
 					if time.Now().UTC().After(due) {
 						action = TransitionAction
 					}
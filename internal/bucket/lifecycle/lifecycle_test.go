@@ -232,6 +232,7 @@ func TestComputeActions(t *testing.T) {
 		isNoncurrent           bool
 		objectSuccessorModTime time.Time
 		versionID              string
+		storageClass           string
 	}{
 		// Empty object name (unexpected case) should always return NoneAction
 		{
@@ -411,6 +412,14 @@ func TestComputeActions(t *testing.T) {
 			objectModTime:  time.Now().Add(-1 * time.Nanosecond).UTC(), // Created now
 			expectedAction: TransitionAction,
 		},
+		// Should not transition when already in the storage class transitioned to
+		{
+			inputConfig:    `<BucketLifecycleConfiguration><Rule><Filter></Filter><Status>Enabled</Status><Transition><Days>0</Days><StorageClass>LOGS</StorageClass></Transition></Rule></BucketLifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectModTime:  time.Now().Add(-1 * time.Nanosecond).UTC(), // Created now
+			storageClass:   "LOGS",
+			expectedAction: NoneAction,
+		},
 		// Should transition immediately when NoncurrentVersion Transition days is zero
 		{
 			inputConfig:            `<BucketLifecycleConfiguration><Rule><Filter></Filter><Status>Enabled</Status><NoncurrentVersionTransition><NoncurrentDays>0</NoncurrentDays><StorageClass>S3TIER-1</StorageClass></NoncurrentVersionTransition></Rule></BucketLifecycleConfiguration>`,
@@ -457,6 +466,7 @@ func TestComputeActions(t *testing.T) {
 				IsLatest:         !tc.isNoncurrent,
 				SuccessorModTime: tc.objectSuccessorModTime,
 				VersionID:        tc.versionID,
+				StorageClass:     tc.storageClass,
 			}); resultAction != tc.expectedAction {
 				t.Fatalf("Expected action: `%v`, got: `%v`", tc.expectedAction, resultAction)
 			}
@@ -70,28 +70,34 @@ const (
 
 // Top level config constants.
 const (
-	CredentialsSubSys    = "credentials"
-	PolicyOPASubSys      = "policy_opa"
-	PolicyPluginSubSys   = "policy_plugin"
-	IdentityOpenIDSubSys = "identity_openid"
-	IdentityLDAPSubSys   = "identity_ldap"
-	IdentityTLSSubSys    = "identity_tls"
-	IdentityPluginSubSys = "identity_plugin"
-	CacheSubSys          = "cache"
-	SiteSubSys           = "site"
-	RegionSubSys         = "region"
-	EtcdSubSys           = "etcd"
-	StorageClassSubSys   = "storage_class"
-	APISubSys            = "api"
-	CompressionSubSys    = "compression"
-	LoggerWebhookSubSys  = "logger_webhook"
-	AuditWebhookSubSys   = "audit_webhook"
-	AuditKafkaSubSys     = "audit_kafka"
-	HealSubSys           = "heal"
-	ScannerSubSys        = "scanner"
-	CrawlerSubSys        = "crawler"
-	SubnetSubSys         = "subnet"
-	CallhomeSubSys       = "callhome"
+	CredentialsSubSys        = "credentials"
+	PolicyOPASubSys          = "policy_opa"
+	PolicyPluginSubSys       = "policy_plugin"
+	IdentityOpenIDSubSys     = "identity_openid"
+	IdentityLDAPSubSys       = "identity_ldap"
+	IdentityTLSSubSys        = "identity_tls"
+	IdentityPluginSubSys     = "identity_plugin"
+	IdentitySAMLSubSys       = "identity_saml"
+	IdentitySCIMSubSys       = "identity_scim"
+	CacheSubSys              = "cache"
+	SiteSubSys               = "site"
+	RegionSubSys             = "region"
+	EtcdSubSys               = "etcd"
+	StorageClassSubSys       = "storage_class"
+	StorageClassCustomSubSys = "storage_class_custom"
+	APISubSys                = "api"
+	CompressionSubSys        = "compression"
+	LoggerWebhookSubSys      = "logger_webhook"
+	AuditWebhookSubSys       = "audit_webhook"
+	AuditKafkaSubSys         = "audit_kafka"
+	HealSubSys               = "heal"
+	ScannerSubSys            = "scanner"
+	CrawlerSubSys            = "crawler"
+	SubnetSubSys             = "subnet"
+	CallhomeSubSys           = "callhome"
+	RateLimitSubSys          = "rate_limit"
+	DriveHealthSubSys        = "drive_health"
+	ScheduleSubSys           = "schedule"
 
 	// Add new constants here if you add new fields to config.
 )
@@ -152,6 +158,8 @@ var SubSystems = set.CreateStringSet(
 	IdentityOpenIDSubSys,
 	IdentityTLSSubSys,
 	IdentityPluginSubSys,
+	IdentitySAMLSubSys,
+	IdentitySCIMSubSys,
 	ScannerSubSys,
 	HealSubSys,
 	NotifyAMQPSubSys,
@@ -166,6 +174,10 @@ var SubSystems = set.CreateStringSet(
 	NotifyWebhookSubSys,
 	SubnetSubSys,
 	CallhomeSubSys,
+	RateLimitSubSys,
+	StorageClassCustomSubSys,
+	DriveHealthSubSys,
+	ScheduleSubSys,
 )
 
 // SubSystemsDynamic - all sub-systems that have dynamic config.
@@ -180,6 +192,10 @@ var SubSystemsDynamic = set.CreateStringSet(
 	AuditWebhookSubSys,
 	AuditKafkaSubSys,
 	StorageClassSubSys,
+	RateLimitSubSys,
+	StorageClassCustomSubSys,
+	DriveHealthSubSys,
+	ScheduleSubSys,
 )
 
 // SubSystemsSingleTargets - subsystems which only support single target.
@@ -194,11 +210,13 @@ var SubSystemsSingleTargets = set.CreateStringSet([]string{
 	CompressionSubSys,
 	PolicyOPASubSys,
 	PolicyPluginSubSys,
-	IdentityLDAPSubSys,
 	IdentityTLSSubSys,
 	IdentityPluginSubSys,
+	IdentitySAMLSubSys,
+	IdentitySCIMSubSys,
 	HealSubSys,
 	ScannerSubSys,
+	DriveHealthSubSys,
 }...)
 
 // Constant separators
@@ -1072,7 +1090,7 @@ func getEnvVarName(subSys, target, param string) string {
 		Default, target)
 }
 
-var resolvableSubsystems = set.CreateStringSet(IdentityOpenIDSubSys)
+var resolvableSubsystems = set.CreateStringSet(IdentityOpenIDSubSys, IdentityLDAPSubSys, RateLimitSubSys, StorageClassCustomSubSys, ScheduleSubSys)
 
 // ValueSource represents the source of a config parameter value.
 type ValueSource uint8
@@ -0,0 +1,137 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package drivehealth
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/minio/pkg/env"
+)
+
+// Drive health config constants.
+const (
+	Interval              = "interval"
+	MaxTemperature        = "max_temperature"
+	MaxWear               = "max_wear"
+	MaxReallocatedSectors = "max_reallocated_sectors"
+	Action                = "action"
+
+	EnvEnable                = "MINIO_DRIVE_HEALTH_ENABLE"
+	EnvInterval              = "MINIO_DRIVE_HEALTH_INTERVAL"
+	EnvMaxTemperature        = "MINIO_DRIVE_HEALTH_MAX_TEMPERATURE"
+	EnvMaxWear               = "MINIO_DRIVE_HEALTH_MAX_WEAR"
+	EnvMaxReallocatedSectors = "MINIO_DRIVE_HEALTH_MAX_REALLOCATED_SECTORS"
+	EnvAction                = "MINIO_DRIVE_HEALTH_ACTION"
+)
+
+// FailureAction is what is done with a drive predicted to fail.
+type FailureAction string
+
+// Failure actions.
+const (
+	// ActionNone only raises an alert.
+	ActionNone FailureAction = "none"
+	// ActionReadOnly stops writing to the drive, reads are still served.
+	ActionReadOnly FailureAction = "readonly"
+	// ActionHeal heals the drive, rewriting the shards it lost or
+	// corrupted, the drive still has to be replaced.
+	ActionHeal FailureAction = "heal"
+)
+
+// DefaultKVS - default KV config for drive health monitoring
+var DefaultKVS = config.KVS{
+	config.KV{
+		Key:   config.Enable,
+		Value: config.EnableOn,
+	},
+	config.KV{
+		Key:   Interval,
+		Value: "5m",
+	},
+	config.KV{
+		Key:   MaxTemperature,
+		Value: "65",
+	},
+	config.KV{
+		Key:   MaxWear,
+		Value: "90",
+	},
+	config.KV{
+		Key:   MaxReallocatedSectors,
+		Value: "100",
+	},
+	config.KV{
+		Key:   Action,
+		Value: string(ActionNone),
+	},
+}
+
+// Config - drive health monitoring settings.
+type Config struct {
+	Enabled  bool          `json:"enabled"`
+	Interval time.Duration `json:"interval"`
+	// MaxTemperature in degrees Celsius above which an alert is raised.
+	MaxTemperature int `json:"maxTemperature"`
+	// MaxWear is the percentage of the rated endurance used from which
+	// a drive is considered failing.
+	MaxWear int `json:"maxWear"`
+	// MaxReallocatedSectors is the number of reallocated sectors from
+	// which a drive is considered failing.
+	MaxReallocatedSectors uint64        `json:"maxReallocatedSectors"`
+	Action                FailureAction `json:"action"`
+}
+
+// LookupConfig - lookup drive health config and override with valid
+// environment settings if any.
+func LookupConfig(kvs config.KVS) (cfg Config, err error) {
+	if err = config.CheckValidKeys(config.DriveHealthSubSys, kvs, DefaultKVS); err != nil {
+		return cfg, err
+	}
+
+	cfg.Enabled, err = config.ParseBool(env.Get(EnvEnable, kvs.GetWithDefault(config.Enable, DefaultKVS)))
+	if err != nil {
+		return cfg, fmt.Errorf("'drive_health:enable' value invalid: %w", err)
+	}
+	cfg.Interval, err = time.ParseDuration(env.Get(EnvInterval, kvs.GetWithDefault(Interval, DefaultKVS)))
+	if err != nil || cfg.Interval < time.Minute {
+		return cfg, config.Errorf("'drive_health:interval' value invalid, expected a duration of at least 1m")
+	}
+	cfg.MaxTemperature, err = strconv.Atoi(env.Get(EnvMaxTemperature, kvs.GetWithDefault(MaxTemperature, DefaultKVS)))
+	if err != nil {
+		return cfg, fmt.Errorf("'drive_health:max_temperature' value invalid: %w", err)
+	}
+	cfg.MaxWear, err = strconv.Atoi(env.Get(EnvMaxWear, kvs.GetWithDefault(MaxWear, DefaultKVS)))
+	if err != nil || cfg.MaxWear <= 0 {
+		return cfg, config.Errorf("'drive_health:max_wear' value invalid, expected a positive percentage")
+	}
+	cfg.MaxReallocatedSectors, err = strconv.ParseUint(env.Get(EnvMaxReallocatedSectors, kvs.GetWithDefault(MaxReallocatedSectors, DefaultKVS)), 10, 64)
+	if err != nil {
+		return cfg, fmt.Errorf("'drive_health:max_reallocated_sectors' value invalid: %w", err)
+	}
+
+	cfg.Action = FailureAction(env.Get(EnvAction, kvs.GetWithDefault(Action, DefaultKVS)))
+	switch cfg.Action {
+	case ActionNone, ActionReadOnly, ActionHeal:
+	default:
+		return cfg, config.Errorf("'drive_health:action' value %q invalid, expected one of none, readonly or heal", cfg.Action)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package drivehealth
+
+import "github.com/GuinsooLab/annastore/internal/config"
+
+// Help template for drive health monitoring.
+var (
+	defaultHelpPostfix = func(key string) string {
+		return config.DefaultHelpPostfix(DefaultKVS, key)
+	}
+
+	// Help provides help for config values
+	Help = config.HelpKVS{
+		config.HelpKV{
+			Key:         config.Enable,
+			Description: `poll the SMART/NVMe health of the local drives` + defaultHelpPostfix(config.Enable),
+			Optional:    true,
+			Type:        "on|off",
+		},
+		config.HelpKV{
+			Key:         Interval,
+			Description: `interval between two polls of the drives` + defaultHelpPostfix(Interval),
+			Optional:    true,
+			Type:        "duration",
+		},
+		config.HelpKV{
+			Key:         MaxTemperature,
+			Description: `temperature in degrees Celsius above which an alert is raised` + defaultHelpPostfix(MaxTemperature),
+			Optional:    true,
+			Type:        "int",
+		},
+		config.HelpKV{
+			Key:         MaxWear,
+			Description: `percentage of the rated endurance used from which a drive is considered failing` + defaultHelpPostfix(MaxWear),
+			Optional:    true,
+			Type:        "int",
+		},
+		config.HelpKV{
+			Key:         MaxReallocatedSectors,
+			Description: `number of reallocated sectors from which a drive is considered failing` + defaultHelpPostfix(MaxReallocatedSectors),
+			Optional:    true,
+			Type:        "int",
+		},
+		config.HelpKV{
+			Key:         Action,
+			Description: `action on a failing drive: 'none' to only alert, 'readonly' to stop writing to it or 'heal' to heal the shards it lost` + defaultHelpPostfix(Action),
+			Optional:    true,
+			Type:        "none|readonly|heal",
+		},
+	}
+)
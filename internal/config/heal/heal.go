@@ -31,13 +31,15 @@ import (
 
 // Compression environment variables
 const (
-	Bitrot  = "bitrotscan"
-	Sleep   = "max_sleep"
-	IOCount = "max_io"
-
-	EnvBitrot  = "MINIO_HEAL_BITROTSCAN"
-	EnvSleep   = "MINIO_HEAL_MAX_SLEEP"
-	EnvIOCount = "MINIO_HEAL_MAX_IO"
+	Bitrot       = "bitrotscan"
+	Sleep        = "max_sleep"
+	IOCount      = "max_io"
+	ReportBucket = "report_bucket"
+
+	EnvBitrot       = "MINIO_HEAL_BITROTSCAN"
+	EnvSleep        = "MINIO_HEAL_MAX_SLEEP"
+	EnvIOCount      = "MINIO_HEAL_MAX_IO"
+	EnvReportBucket = "MINIO_HEAL_REPORT_BUCKET"
 )
 
 var configMutex sync.RWMutex
@@ -51,6 +53,10 @@ type Config struct {
 	Sleep   time.Duration `json:"sleep"`
 	IOCount int           `json:"iocount"`
 
+	// bucket where the per-object results of manual heal sequences
+	// are written, no report is written when empty.
+	ReportBucket string `json:"reportBucket"`
+
 	// Cached value from Bitrot field
 	cache struct {
 		// -1: bitrot enabled, 0: bitrot disabled, > 0: bitrot cycle
@@ -68,6 +74,13 @@ func (opts Config) BitrotScanCycle() (d time.Duration) {
 	return opts.cache.bitrotCycle
 }
 
+// GetReportBucket returns the bucket where heal reports are written.
+func (opts Config) GetReportBucket() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return opts.ReportBucket
+}
+
 // Wait waits for IOCount to go down or max sleep to elapse before returning.
 // usually used in healing paths to wait for specified amount of time to
 // throttle healing.
@@ -111,6 +124,7 @@ func (opts *Config) Update(nopts Config) {
 	opts.Bitrot = nopts.Bitrot
 	opts.IOCount = nopts.IOCount
 	opts.Sleep = nopts.Sleep
+	opts.ReportBucket = nopts.ReportBucket
 
 	opts.cache.bitrotCycle, _ = parseBitrotConfig(nopts.Bitrot)
 }
@@ -129,6 +143,10 @@ var DefaultKVS = config.KVS{
 		Key:   IOCount,
 		Value: "100",
 	},
+	config.KV{
+		Key:   ReportBucket,
+		Value: "",
+	},
 }
 
 const minimumBitrotCycleInMonths = 1
@@ -180,5 +198,6 @@ func LookupConfig(kvs config.KVS) (cfg Config, err error) {
 	if err != nil {
 		return cfg, fmt.Errorf("'heal:max_io' value invalid: %w", err)
 	}
+	cfg.ReportBucket = env.Get(EnvReportBucket, kvs.GetWithDefault(ReportBucket, DefaultKVS))
 	return cfg, nil
 }
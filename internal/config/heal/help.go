@@ -45,5 +45,11 @@ var (
 			Optional:    true,
 			Type:        "int",
 		},
+		config.HelpKV{
+			Key:         ReportBucket,
+			Description: `bucket where the per-object results of manual heal sequences are written`,
+			Optional:    true,
+			Type:        "string",
+		},
 	}
 )
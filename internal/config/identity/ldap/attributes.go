@@ -0,0 +1,115 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	ldap "github.com/go-ldap/ldap/v3"
+	"github.com/minio/minio-go/v7/pkg/set"
+	"github.com/minio/pkg/wildcard"
+)
+
+// AttributePolicyRule maps policies to the users with a value of an
+// attribute matching a pattern.
+type AttributePolicyRule struct {
+	Attribute string
+	// Pattern is a case-insensitive wildcard pattern, e.g. "*engineering*".
+	Pattern  string
+	Policies []string
+}
+
+// parseAttributePolicyRules parses attribute policy rules separated by
+// ";", each of the form "attribute=pattern:policy1,policy2", e.g.
+// "department=engineering:readwrite;title=*manager*:consoleAdmin".
+func parseAttributePolicyRules(s string) (rules []AttributePolicyRule, err error) {
+	for _, r := range strings.Split(s, ";") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		i := strings.Index(r, "=")
+		j := strings.LastIndex(r, ":")
+		if i <= 0 || j < i {
+			return nil, config.Errorf("invalid attribute policy rule %q, expected attribute=pattern:policies", r)
+		}
+		rule := AttributePolicyRule{
+			Attribute: strings.TrimSpace(r[:i]),
+			Pattern:   strings.TrimSpace(r[i+1 : j]),
+		}
+		for _, p := range strings.Split(r[j+1:], ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				rule.Policies = append(rule.Policies, p)
+			}
+		}
+		if rule.Attribute == "" || rule.Pattern == "" || len(rule.Policies) == 0 {
+			return nil, config.Errorf("invalid attribute policy rule %q, expected attribute=pattern:policies", r)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// userAttributes returns the user attributes used by the attribute policy
+// rules and the tag attributes.
+func (l *Config) userAttributes() []string {
+	attributes := set.CreateStringSet(l.TagAttributes...)
+	for _, rule := range l.AttributePolicyRules {
+		attributes.Add(rule.Attribute)
+	}
+	return attributes.ToSlice()
+}
+
+// attributePolicies returns the sorted policies of the attribute policy
+// rules matching the attributes of a user entry.
+func (l *Config) attributePolicies(entry *ldap.Entry) []string {
+	policies := set.NewStringSet()
+	for _, rule := range l.AttributePolicyRules {
+		for _, v := range entry.GetEqualFoldAttributeValues(rule.Attribute) {
+			if wildcard.Match(strings.ToLower(rule.Pattern), strings.ToLower(v)) {
+				for _, p := range rule.Policies {
+					policies.Add(p)
+				}
+				break
+			}
+		}
+	}
+	if policies.IsEmpty() {
+		return nil
+	}
+	res := policies.ToSlice()
+	sort.Strings(res)
+	return res
+}
+
+// attributeTags returns the first value of each tag attribute of a user
+// entry, keyed by the attribute name.
+func (l *Config) attributeTags(entry *ldap.Entry) map[string]string {
+	var tags map[string]string
+	for _, attr := range l.TagAttributes {
+		if v := entry.GetEqualFoldAttributeValues(attr); len(v) > 0 {
+			if tags == nil {
+				tags = make(map[string]string, len(l.TagAttributes))
+			}
+			tags[attr] = v[0]
+		}
+	}
+	return tags
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"reflect"
+	"testing"
+
+	ldap "github.com/go-ldap/ldap/v3"
+)
+
+func TestParseAttributePolicyRules(t *testing.T) {
+	testCases := []struct {
+		rules     string
+		expected  []AttributePolicyRule
+		expectErr bool
+	}{
+		{rules: ""},
+		{
+			rules: "department=engineering:readwrite",
+			expected: []AttributePolicyRule{
+				{Attribute: "department", Pattern: "engineering", Policies: []string{"readwrite"}},
+			},
+		},
+		{
+			rules: " department = eng* : readwrite, diagnostics ; title=*manager*:consoleAdmin;",
+			expected: []AttributePolicyRule{
+				{Attribute: "department", Pattern: "eng*", Policies: []string{"readwrite", "diagnostics"}},
+				{Attribute: "title", Pattern: "*manager*", Policies: []string{"consoleAdmin"}},
+			},
+		},
+		{rules: "department:readwrite", expectErr: true},
+		{rules: "department=engineering", expectErr: true},
+		{rules: "=engineering:readwrite", expectErr: true},
+		{rules: "department=:readwrite", expectErr: true},
+		{rules: "department=engineering:", expectErr: true},
+	}
+	for i, tc := range testCases {
+		rules, err := parseAttributePolicyRules(tc.rules)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("case %d: expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(rules, tc.expected) {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.expected, rules)
+		}
+	}
+}
+
+func TestAttributePolicies(t *testing.T) {
+	rules, err := parseAttributePolicyRules("department=engineering:readwrite;title=*Manager*:consoleAdmin,readwrite;memberOf=cn=auditors,*:readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := Config{AttributePolicyRules: rules}
+
+	testCases := []struct {
+		attributes map[string][]string
+		expected   []string
+	}{
+		{attributes: map[string][]string{"department": {"sales"}}},
+		{
+			attributes: map[string][]string{"Department": {"Engineering"}},
+			expected:   []string{"readwrite"},
+		},
+		{
+			attributes: map[string][]string{"department": {"engineering"}, "title": {"engineering manager"}},
+			expected:   []string{"consoleAdmin", "readwrite"},
+		},
+		{
+			attributes: map[string][]string{"memberOf": {"cn=devs,dc=example,dc=com", "cn=auditors,dc=example,dc=com"}},
+			expected:   []string{"readonly"},
+		},
+	}
+	for i, tc := range testCases {
+		entry := ldap.NewEntry("uid=alice,dc=example,dc=com", tc.attributes)
+		if policies := l.attributePolicies(entry); !reflect.DeepEqual(policies, tc.expected) {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.expected, policies)
+		}
+	}
+}
+
+func TestAttributeTags(t *testing.T) {
+	l := Config{TagAttributes: []string{"department", "title"}}
+	entry := ldap.NewEntry("uid=alice,dc=example,dc=com", map[string][]string{
+		"Department": {"engineering", "sales"},
+		"mail":       {"alice@example.com"},
+	})
+	if tags := l.attributeTags(entry); !reflect.DeepEqual(tags, map[string]string{"department": "engineering"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if tags := (&Config{}).attributeTags(entry); tags != nil {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+}
@@ -191,6 +191,22 @@ func (l *Config) Validate() Validation {
     Enter an LDAP search filter template using at least one of these.`,
 			}
 		}
+
+		if l.GroupSearchNested && !strings.Contains(l.GroupSearchFilter, "%d") {
+			return Validation{
+				Result: GroupSearchParamsMisconfigured,
+				Detail: `GroupSearchFilter must contain "%d" to resolve nested groups`,
+				Suggestion: `Nested groups are found with the group search filter template where
+    "%d" gets replaced by the DN of a group the user is a member of.
+    Enter an LDAP search filter template using "%d", e.g. "(&(objectclass=groupOfNames)(member=%d))"`,
+			}
+		}
+	} else if l.GroupSearchNested {
+		return Validation{
+			Result:     GroupSearchParamsMisconfigured,
+			Detail:     "Group search is not configured",
+			Suggestion: "Nested group resolution requires the Group Search Base DN and the Group Search Filter.",
+		}
 	}
 
 	return Validation{
@@ -19,10 +19,10 @@ package ldap
 
 import (
 	"crypto/x509"
+	"strings"
 	"time"
 
 	"github.com/GuinsooLab/annastore/internal/config"
-	"github.com/minio/pkg/env"
 )
 
 const (
@@ -38,6 +38,9 @@ const (
 type Config struct {
 	Enabled bool `json:"enabled"`
 
+	// Name of the configuration, config.Default for the un-named one.
+	Name string `json:"-"`
+
 	// E.g. "ldap.minio.io:636"
 	ServerAddr string `json:"serverAddr"`
 
@@ -51,10 +54,24 @@ type Config struct {
 	GroupSearchBaseDistNames []string `json:"-"` // Generated field
 	GroupSearchFilter        string   `json:"groupSearchFilter"`
 
+	// Resolve groups that are members of the user's groups.
+	GroupSearchNested bool `json:"-"`
+
 	// Lookup bind LDAP service account
 	LookupBindDN       string `json:"lookupBindDN"`
 	LookupBindPassword string `json:"lookupBindPassword"`
 
+	// Domains of usernames, e.g. "corp.example.com" for
+	// "alice@corp.example.com" or "CORP" for "CORP\alice", which select
+	// this configuration.
+	UsernameDomains []string `json:"-"`
+
+	// Rules mapping policies to users by their attributes.
+	AttributePolicyRules []AttributePolicyRule `json:"-"`
+
+	// Attributes of users passed as session tags of their STS credentials.
+	TagAttributes []string `json:"-"`
+
 	stsExpiryDuration time.Duration // contains converted value
 	tlsSkipVerify     bool          // allows skipping TLS verification
 	serverInsecure    bool          // allows plain text connection to LDAP server
@@ -69,6 +86,7 @@ func (l *Config) Clone() Config {
 	}
 	cfg := Config{
 		Enabled:                   l.Enabled,
+		Name:                      l.Name,
 		ServerAddr:                l.ServerAddr,
 		UserDNSearchBaseDistName:  l.UserDNSearchBaseDistName,
 		UserDNSearchBaseDistNames: l.UserDNSearchBaseDistNames,
@@ -76,8 +94,12 @@ func (l *Config) Clone() Config {
 		GroupSearchBaseDistName:   l.GroupSearchBaseDistName,
 		GroupSearchBaseDistNames:  l.GroupSearchBaseDistNames,
 		GroupSearchFilter:         l.GroupSearchFilter,
+		GroupSearchNested:         l.GroupSearchNested,
 		LookupBindDN:              l.LookupBindDN,
 		LookupBindPassword:        l.LookupBindPassword,
+		UsernameDomains:           l.UsernameDomains,
+		AttributePolicyRules:      l.AttributePolicyRules,
+		TagAttributes:             l.TagAttributes,
 		stsExpiryDuration:         l.stsExpiryDuration,
 		tlsSkipVerify:             l.tlsSkipVerify,
 		serverInsecure:            l.serverInsecure,
@@ -99,6 +121,10 @@ const (
 	TLSSkipVerify      = "tls_skip_verify"
 	ServerInsecure     = "server_insecure"
 	ServerStartTLS     = "server_starttls"
+	GroupSearchNested  = "group_search_nested"
+	UsernameDomains    = "username_domains"
+	AttributePolicies  = "attribute_policies"
+	TagAttributes      = "tag_attributes"
 
 	EnvServerAddr         = "MINIO_IDENTITY_LDAP_SERVER_ADDR"
 	EnvTLSSkipVerify      = "MINIO_IDENTITY_LDAP_TLS_SKIP_VERIFY"
@@ -111,6 +137,10 @@ const (
 	EnvGroupSearchBaseDN  = "MINIO_IDENTITY_LDAP_GROUP_SEARCH_BASE_DN"
 	EnvLookupBindDN       = "MINIO_IDENTITY_LDAP_LOOKUP_BIND_DN"
 	EnvLookupBindPassword = "MINIO_IDENTITY_LDAP_LOOKUP_BIND_PASSWORD"
+	EnvGroupSearchNested  = "MINIO_IDENTITY_LDAP_GROUP_SEARCH_NESTED"
+	EnvUsernameDomains    = "MINIO_IDENTITY_LDAP_USERNAME_DOMAINS"
+	EnvAttributePolicies  = "MINIO_IDENTITY_LDAP_ATTRIBUTE_POLICIES"
+	EnvTagAttributes      = "MINIO_IDENTITY_LDAP_TAG_ATTRIBUTES"
 )
 
 var removedKeys = []string{
@@ -164,6 +194,22 @@ var (
 			Key:   LookupBindPassword,
 			Value: "",
 		},
+		config.KV{
+			Key:   GroupSearchNested,
+			Value: config.EnableOff,
+		},
+		config.KV{
+			Key:   UsernameDomains,
+			Value: "",
+		},
+		config.KV{
+			Key:   AttributePolicies,
+			Value: "",
+		},
+		config.KV{
+			Key:   TagAttributes,
+			Value: "",
+		},
 	}
 )
 
@@ -172,20 +218,12 @@ func Enabled(kvs config.KVS) bool {
 	return kvs.Get(ServerAddr) != ""
 }
 
-// Lookup - initializes LDAP config, overrides config, if any ENV values are set.
-func Lookup(kvs config.KVS, rootCAs *x509.CertPool) (l Config, err error) {
-	l = Config{}
+// lookupServerConfig - initializes the LDAP config of the named target,
+// getCfgVal returns the parameters of the target with any ENV values applied.
+func lookupServerConfig(name string, getCfgVal func(key string) string, rootCAs *x509.CertPool) (l Config, err error) {
+	l = Config{Name: name}
 
-	// Purge all removed keys first
-	for _, k := range removedKeys {
-		kvs.Delete(k)
-	}
-
-	if err = config.CheckValidKeys(config.IdentityLDAPSubSys, kvs, DefaultKVS); err != nil {
-		return l, err
-	}
-
-	ldapServer := env.Get(EnvServerAddr, kvs.Get(ServerAddr))
+	ldapServer := getCfgVal(ServerAddr)
 	if ldapServer == "" {
 		return l, nil
 	}
@@ -195,19 +233,19 @@ func Lookup(kvs config.KVS, rootCAs *x509.CertPool) (l Config, err error) {
 	l.stsExpiryDuration = defaultLDAPExpiry
 
 	// LDAP connection configuration
-	if v := env.Get(EnvServerInsecure, kvs.Get(ServerInsecure)); v != "" {
+	if v := getCfgVal(ServerInsecure); v != "" {
 		l.serverInsecure, err = config.ParseBool(v)
 		if err != nil {
 			return l, err
 		}
 	}
-	if v := env.Get(EnvServerStartTLS, kvs.Get(ServerStartTLS)); v != "" {
+	if v := getCfgVal(ServerStartTLS); v != "" {
 		l.serverStartTLS, err = config.ParseBool(v)
 		if err != nil {
 			return l, err
 		}
 	}
-	if v := env.Get(EnvTLSSkipVerify, kvs.Get(TLSSkipVerify)); v != "" {
+	if v := getCfgVal(TLSSkipVerify); v != "" {
 		l.tlsSkipVerify, err = config.ParseBool(v)
 		if err != nil {
 			return l, err
@@ -215,16 +253,44 @@ func Lookup(kvs config.KVS, rootCAs *x509.CertPool) (l Config, err error) {
 	}
 
 	// Lookup bind user configuration
-	l.LookupBindDN = env.Get(EnvLookupBindDN, kvs.Get(LookupBindDN))
-	l.LookupBindPassword = env.Get(EnvLookupBindPassword, kvs.Get(LookupBindPassword))
+	l.LookupBindDN = getCfgVal(LookupBindDN)
+	l.LookupBindPassword = getCfgVal(LookupBindPassword)
 
 	// User DN search configuration
-	l.UserDNSearchFilter = env.Get(EnvUserDNSearchFilter, kvs.Get(UserDNSearchFilter))
-	l.UserDNSearchBaseDistName = env.Get(EnvUserDNSearchBaseDN, kvs.Get(UserDNSearchBaseDN))
+	l.UserDNSearchFilter = getCfgVal(UserDNSearchFilter)
+	l.UserDNSearchBaseDistName = getCfgVal(UserDNSearchBaseDN)
+	if l.UserDNSearchBaseDistName != "" {
+		l.UserDNSearchBaseDistNames = strings.Split(l.UserDNSearchBaseDistName, dnDelimiter)
+	}
 
 	// Group search params configuration
-	l.GroupSearchFilter = env.Get(EnvGroupSearchFilter, kvs.Get(GroupSearchFilter))
-	l.GroupSearchBaseDistName = env.Get(EnvGroupSearchBaseDN, kvs.Get(GroupSearchBaseDN))
+	l.GroupSearchFilter = getCfgVal(GroupSearchFilter)
+	l.GroupSearchBaseDistName = getCfgVal(GroupSearchBaseDN)
+	if l.GroupSearchBaseDistName != "" {
+		l.GroupSearchBaseDistNames = strings.Split(l.GroupSearchBaseDistName, dnDelimiter)
+	}
+	if v := getCfgVal(GroupSearchNested); v != "" {
+		l.GroupSearchNested, err = config.ParseBool(v)
+		if err != nil {
+			return l, err
+		}
+	}
+
+	// Configuration selection and attribute policies
+	for _, domain := range strings.Split(getCfgVal(UsernameDomains), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			l.UsernameDomains = append(l.UsernameDomains, domain)
+		}
+	}
+	l.AttributePolicyRules, err = parseAttributePolicyRules(getCfgVal(AttributePolicies))
+	if err != nil {
+		return l, err
+	}
+	for _, attr := range strings.Split(getCfgVal(TagAttributes), ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			l.TagAttributes = append(l.TagAttributes, attr)
+		}
+	}
 
 	// Validate and test configuration.
 	valResult := l.Validate()
@@ -0,0 +1,386 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/minio/madmin-go"
+	"github.com/minio/minio-go/v7/pkg/set"
+)
+
+// ErrConfigNotFound - represents a non-existing LDAP configuration error.
+var ErrConfigNotFound = errors.New("LDAP configuration not found")
+
+// Configs holds the enabled LDAP configurations of the identity_ldap
+// sub-system targets. Users are looked up in the configuration selected by
+// the domain of their username, or else in each configuration in order.
+type Configs struct {
+	Enabled bool
+
+	// Enabled configurations, the default one first and the named ones
+	// sorted by name.
+	Cfgs []*Config
+}
+
+// LookupConfig - initializes the LDAP configurations of all targets,
+// overrides config, if any ENV values are set. The configurations failing
+// validation are still enabled and the first validation error is returned.
+func LookupConfig(s config.Config, rootCAs *x509.CertPool) (c Configs, err error) {
+	targets, err := s.GetAvailableTargets(config.IdentityLDAPSubSys)
+	if err != nil {
+		return c, err
+	}
+
+	var firstErr error
+	for _, name := range targets {
+		kvs := s[config.IdentityLDAPSubSys][name]
+		// Purge all removed keys first
+		for _, k := range removedKeys {
+			kvs.Delete(k)
+		}
+		if err = config.CheckValidKeys(config.IdentityLDAPSubSys, kvs, DefaultKVS); err != nil {
+			return c, err
+		}
+
+		getCfgVal := func(key string) string {
+			val, _ := s.ResolveConfigParam(config.IdentityLDAPSubSys, name, key)
+			return val
+		}
+		l, err := lookupServerConfig(name, getCfgVal, rootCAs)
+		if err != nil {
+			var v Validation
+			if !errors.As(err, &v) {
+				return c, err
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if !l.Enabled {
+			continue
+		}
+		c.Cfgs = append(c.Cfgs, &l)
+	}
+
+	// A username domain must select a single configuration.
+	domains := make(map[string]string)
+	for _, l := range c.Cfgs {
+		for _, domain := range l.UsernameDomains {
+			domain = strings.ToLower(domain)
+			if other, ok := domains[domain]; ok {
+				return c, config.Errorf("Username domain %s is present with LDAP configurations %s and %s", domain, other, l.Name)
+			}
+			domains[domain] = l.Name
+		}
+	}
+
+	c.Enabled = len(c.Cfgs) > 0
+	return c, firstErr
+}
+
+// Clone returns a cloned copy of the LDAP configurations.
+func (c *Configs) Clone() Configs {
+	if c == nil {
+		return Configs{}
+	}
+	cfgs := Configs{
+		Enabled: c.Enabled,
+		Cfgs:    make([]*Config, 0, len(c.Cfgs)),
+	}
+	for _, l := range c.Cfgs {
+		lc := l.Clone()
+		cfgs.Cfgs = append(cfgs.Cfgs, &lc)
+	}
+	return cfgs
+}
+
+// Primary returns the first enabled configuration.
+func (c Configs) Primary() Config {
+	if len(c.Cfgs) == 0 {
+		return Config{}
+	}
+	return c.Cfgs[0].Clone()
+}
+
+// splitUsernameDomain returns the domain of a username of the form
+// user@domain or DOMAIN\user, and the username without a DOMAIN\ prefix.
+func splitUsernameDomain(username string) (domain, name string) {
+	if i := strings.Index(username, `\`); i > 0 {
+		return username[:i], username[i+1:]
+	}
+	if i := strings.LastIndex(username, "@"); i > 0 {
+		return username[i+1:], username
+	}
+	return "", username
+}
+
+// hasUsernameDomain returns whether domain selects the configuration.
+func (l *Config) hasUsernameDomain(domain string) bool {
+	for _, d := range l.UsernameDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchUsername returns the username to search for in the LDAP server,
+// without the DOMAIN\ prefix selecting the configuration.
+func (l *Config) searchUsername(username string) string {
+	if domain, name := splitUsernameDomain(username); domain != "" && l.hasUsernameDomain(domain) {
+		return name
+	}
+	return username
+}
+
+// selectConfigs returns the configurations to look up a username in and the
+// username to search for.
+func (c Configs) selectConfigs(username string) ([]*Config, string) {
+	domain, name := splitUsernameDomain(username)
+	if domain != "" {
+		for _, l := range c.Cfgs {
+			if l.hasUsernameDomain(domain) {
+				return []*Config{l}, name
+			}
+		}
+	}
+	return c.Cfgs, username
+}
+
+// findUser calls fn with each selected configuration until one finds the
+// user, the error of the first configuration which could not look up the
+// user is returned when none does.
+func (c Configs) findUser(username string, fn func(l *Config, username string) (User, error)) (User, error) {
+	if !c.Enabled {
+		return User{}, errors.New("LDAP is not configured")
+	}
+	cfgs, name := c.selectConfigs(username)
+	var firstErr error
+	for _, l := range cfgs {
+		u, err := fn(l, name)
+		if err == nil {
+			return u, nil
+		}
+		var lerr lookupError
+		if !errors.As(err, &lerr) {
+			// The user was found, do not try other configurations.
+			return User{}, err
+		}
+		if firstErr == nil || errors.Is(firstErr, errUserDNNotFound) && !errors.Is(err, errUserDNNotFound) {
+			firstErr = err
+		}
+	}
+	if len(cfgs) > 1 {
+		return User{}, fmt.Errorf("%w (tried %d LDAP configurations)", firstErr, len(cfgs))
+	}
+	return User{}, firstErr
+}
+
+// Bind - binds to the LDAP server of the user and returns the user with
+// their groups and attribute policies.
+func (c Configs) Bind(username, password string) (User, error) {
+	return c.findUser(username, func(l *Config, username string) (User, error) {
+		return l.Bind(username, password)
+	})
+}
+
+// LookupUserDN searches for the full DN, groups and attribute policies of a
+// given username.
+func (c Configs) LookupUserDN(username string) (User, error) {
+	return c.findUser(username, func(l *Config, username string) (User, error) {
+		return l.LookupUserDN(username)
+	})
+}
+
+// GetExpiryDuration - return parsed expiry duration.
+func (c Configs) GetExpiryDuration(dsecs string) (time.Duration, error) {
+	return c.Primary().GetExpiryDuration(dsecs)
+}
+
+// configForDN returns the configuration of a user DN.
+func (c Configs) configForDN(dn string) *Config {
+	for _, l := range c.Cfgs {
+		if l.IsLDAPUserDN(dn) {
+			return l
+		}
+	}
+	return nil
+}
+
+// IsLDAPUserDN determines if the given string could be a user DN from one of
+// the LDAP configurations.
+func (c Configs) IsLDAPUserDN(user string) bool {
+	return c.configForDN(user) != nil
+}
+
+// groupByConfig groups user DNs by their configuration.
+func (c Configs) groupByConfig(userDistNames []string) map[*Config][]string {
+	res := make(map[*Config][]string)
+	for _, dn := range userDistNames {
+		if l := c.configForDN(dn); l != nil {
+			res[l] = append(res[l], dn)
+		}
+	}
+	return res
+}
+
+// GetNonEligibleUserDistNames - find user accounts (DNs) that are no longer
+// present in their LDAP server or do not meet filter criteria anymore. The
+// users of configurations failing the lookup are skipped and the first error
+// is returned.
+func (c Configs) GetNonEligibleUserDistNames(userDistNames []string) ([]string, error) {
+	var (
+		res      []string
+		firstErr error
+	)
+	for l, dns := range c.groupByConfig(userDistNames) {
+		nonEligible, err := l.GetNonEligibleUserDistNames(dns)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", l.Name, err)
+			}
+			continue
+		}
+		res = append(res, nonEligible...)
+	}
+	return res, firstErr
+}
+
+// LookupGroupMemberships - for each DN finds the set of LDAP groups they are a
+// member of. The users of configurations failing the lookup are absent from
+// the result and the first error is returned.
+func (c Configs) LookupGroupMemberships(userDistNames []string, userDNToUsernameMap map[string]string) (map[string]set.StringSet, error) {
+	var firstErr error
+	res := make(map[string]set.StringSet, len(userDistNames))
+	for l, dns := range c.groupByConfig(userDistNames) {
+		groups, err := l.LookupGroupMemberships(dns, userDNToUsernameMap)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", l.Name, err)
+			}
+			continue
+		}
+		for dn, g := range groups {
+			res[dn] = g
+		}
+	}
+	return res, firstErr
+}
+
+// status returns the status of the named configuration, "online" or the
+// validation error.
+func (c Configs) status(name string) (enabled bool, status string) {
+	for _, l := range c.Cfgs {
+		if l.Name != name {
+			continue
+		}
+		lc := l.Clone()
+		if v := lc.Validate(); !v.IsOk() {
+			return true, v.Error()
+		}
+		return true, string(madmin.ItemOnline)
+	}
+	return false, "disabled"
+}
+
+// ListItem is an LDAP configuration listed by GetConfigList.
+type ListItem struct {
+	madmin.IDPListItem
+	Status string `json:"status"`
+}
+
+// GetConfigList - list LDAP configurations with their status.
+func (c Configs) GetConfigList(s config.Config) ([]ListItem, error) {
+	targets, err := s.GetAvailableTargets(config.IdentityLDAPSubSys)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ListItem, 0, len(targets))
+	for _, name := range targets {
+		enabled, status := c.status(name)
+		res = append(res, ListItem{
+			IDPListItem: madmin.IDPListItem{
+				Type:    "ldap",
+				Name:    name,
+				Enabled: enabled,
+			},
+			Status: status,
+		})
+	}
+	return res, nil
+}
+
+// GetConfigInfo - returns the configuration and status of the named LDAP
+// configuration.
+func (c Configs) GetConfigInfo(s config.Config, cfgName string) ([]madmin.IDPCfgInfo, error) {
+	targets, err := s.GetAvailableTargets(config.IdentityLDAPSubSys)
+	if err != nil {
+		return nil, err
+	}
+	present := false
+	for _, name := range targets {
+		if name == cfgName {
+			present = true
+			break
+		}
+	}
+	if !present {
+		return nil, ErrConfigNotFound
+	}
+
+	kvsrcs, err := s.GetResolvedConfigParams(config.IdentityLDAPSubSys, cfgName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]madmin.IDPCfgInfo, 0, len(kvsrcs)+1)
+	for _, kvsrc := range kvsrcs {
+		// skip default values.
+		if kvsrc.Src == config.ValueSourceDef {
+			continue
+		}
+		res = append(res, madmin.IDPCfgInfo{
+			Key:   kvsrc.Key,
+			Value: kvsrc.Value,
+			IsCfg: true,
+			IsEnv: kvsrc.Src == config.ValueSourceEnv,
+		})
+	}
+
+	_, status := c.status(cfgName)
+	res = append(res, madmin.IDPCfgInfo{
+		Key:   "status",
+		Value: status,
+		IsCfg: false,
+	})
+
+	// sort the structs by the key
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSplitUsernameDomain(t *testing.T) {
+	testCases := []struct {
+		username, domain, name string
+	}{
+		{username: "alice", name: "alice"},
+		{username: `CORP\alice`, domain: "CORP", name: "alice"},
+		{username: "alice@corp.example.com", domain: "corp.example.com", name: "alice@corp.example.com"},
+		{username: `\alice`, name: `\alice`},
+		{username: "@alice", name: "@alice"},
+	}
+	for i, tc := range testCases {
+		domain, name := splitUsernameDomain(tc.username)
+		if domain != tc.domain || name != tc.name {
+			t.Errorf("case %d: expected (%q, %q), got (%q, %q)", i+1, tc.domain, tc.name, domain, name)
+		}
+	}
+}
+
+func TestConfigsSelectConfigs(t *testing.T) {
+	corp := &Config{Name: "_", UsernameDomains: []string{"CORP", "corp.example.com"}}
+	partner := &Config{Name: "partner", UsernameDomains: []string{"PARTNER"}}
+	other := &Config{Name: "other"}
+	c := Configs{Enabled: true, Cfgs: []*Config{corp, other, partner}}
+
+	testCases := []struct {
+		username string
+		cfgs     []*Config
+		name     string
+	}{
+		{username: "alice", cfgs: c.Cfgs, name: "alice"},
+		{username: `corp\alice`, cfgs: []*Config{corp}, name: "alice"},
+		{username: "alice@Corp.Example.com", cfgs: []*Config{corp}, name: "alice@Corp.Example.com"},
+		{username: `PARTNER\bob`, cfgs: []*Config{partner}, name: "bob"},
+		{username: `UNKNOWN\bob`, cfgs: c.Cfgs, name: `UNKNOWN\bob`},
+	}
+	for i, tc := range testCases {
+		cfgs, name := c.selectConfigs(tc.username)
+		if name != tc.name || len(cfgs) != len(tc.cfgs) {
+			t.Errorf("case %d: expected %d configs and %q, got %d configs and %q", i+1, len(tc.cfgs), tc.name, len(cfgs), name)
+			continue
+		}
+		for j := range cfgs {
+			if cfgs[j] != tc.cfgs[j] {
+				t.Errorf("case %d: expected config %s, got %s", i+1, tc.cfgs[j].Name, cfgs[j].Name)
+			}
+		}
+	}
+
+	if got := partner.searchUsername(`partner\bob`); got != "bob" {
+		t.Errorf("expected bob, got %s", got)
+	}
+	if got := corp.searchUsername(`partner\bob`); got != `partner\bob` {
+		t.Errorf(`expected partner\bob, got %s`, got)
+	}
+}
+
+func TestConfigsFindUser(t *testing.T) {
+	c := Configs{Enabled: true, Cfgs: []*Config{{Name: "_"}, {Name: "a"}, {Name: "b"}}}
+	errUnreachable := errors.New("unreachable")
+	errBind := errors.New("invalid credentials")
+
+	testCases := []struct {
+		results   map[string]error
+		expected  string
+		expectErr error
+	}{
+		// The first configuration finding the user is used.
+		{results: map[string]error{"_": lookupError{errUserDNNotFound}}, expected: "a"},
+		// Errors after the user was found are not retried.
+		{results: map[string]error{"_": lookupError{errUserDNNotFound}, "a": errBind}, expectErr: errBind},
+		// Unreachable servers are reported over users not found.
+		{
+			results:   map[string]error{"_": lookupError{errUserDNNotFound}, "a": lookupError{errUnreachable}, "b": lookupError{errUserDNNotFound}},
+			expectErr: errUnreachable,
+		},
+		{
+			results:   map[string]error{"_": lookupError{errUserDNNotFound}, "a": lookupError{errUserDNNotFound}, "b": lookupError{errUserDNNotFound}},
+			expectErr: errUserDNNotFound,
+		},
+	}
+	for i, tc := range testCases {
+		u, err := c.findUser("alice", func(l *Config, username string) (User, error) {
+			if err := tc.results[l.Name]; err != nil {
+				return User{}, err
+			}
+			return User{ConfigName: l.Name}, nil
+		})
+		if tc.expectErr != nil {
+			if !errors.Is(err, tc.expectErr) {
+				t.Errorf("case %d: expected error %v, got %v", i+1, tc.expectErr, err)
+			}
+			continue
+		}
+		if err != nil || u.ConfigName != tc.expected {
+			t.Errorf("case %d: expected config %s, got %s (%v)", i+1, tc.expected, u.ConfigName, err)
+		}
+	}
+}
@@ -70,6 +70,30 @@ var (
 			Optional:    true,
 			Type:        "list",
 		},
+		config.HelpKV{
+			Key:         GroupSearchNested,
+			Description: `resolve nested group memberships by searching the groups of each group found, requires "%d" in the group search filter` + defaultHelpPostfix(GroupSearchNested),
+			Optional:    true,
+			Type:        "on|off",
+		},
+		config.HelpKV{
+			Key:         UsernameDomains,
+			Description: `"," separated list of username domains selecting this configuration e.g. "CORP,corp.example.com"` + defaultHelpPostfix(UsernameDomains),
+			Optional:    true,
+			Type:        "csv",
+		},
+		config.HelpKV{
+			Key:         AttributePolicies,
+			Description: `";" separated list of user attribute rules mapping policies e.g. "department=engineering:readwrite"` + defaultHelpPostfix(AttributePolicies),
+			Optional:    true,
+			Type:        "list",
+		},
+		config.HelpKV{
+			Key:         TagAttributes,
+			Description: `"," separated list of user attributes passed as session tags e.g. "department,title"` + defaultHelpPostfix(TagAttributes),
+			Optional:    true,
+			Type:        "csv",
+		},
 		config.HelpKV{
 			Key:         TLSSkipVerify,
 			Description: `trust server TLS without verification` + defaultHelpPostfix(TLSSkipVerify),
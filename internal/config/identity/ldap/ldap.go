@@ -31,6 +31,37 @@ import (
 	"github.com/minio/minio-go/v7/pkg/set"
 )
 
+// maxNestedGroupDepth is the maximum depth of nested groups resolved.
+const maxNestedGroupDepth = 10
+
+var errUserDNNotFound = errors.New("not found")
+
+// lookupError is an error of a configuration before the user was found, on
+// which the next configuration is tried.
+type lookupError struct {
+	err error
+}
+
+func (e lookupError) Error() string {
+	return e.err.Error()
+}
+
+func (e lookupError) Unwrap() error {
+	return e.err
+}
+
+// User is an LDAP user found by Bind or LookupUserDN.
+type User struct {
+	DN     string
+	Groups []string
+	// Policies are the policies mapped to the user by attribute rules.
+	Policies []string
+	// Tags are the values of the tag attributes of the user.
+	Tags map[string]string
+	// ConfigName is the name of the configuration the user was found with.
+	ConfigName string
+}
+
 func getGroups(conn *ldap.Conn, sreq *ldap.SearchRequest) ([]string, error) {
 	var groups []string
 	sres, err := conn.Search(sreq)
@@ -67,33 +98,45 @@ func (l *Config) lookupBind(conn *ldap.Conn) error {
 // assumed to be using the lookup bind service account. It is required that the
 // search result in at most one result.
 func (l *Config) lookupUserDN(conn *ldap.Conn, username string) (string, error) {
+	entry, err := l.lookupUserEntry(conn, username)
+	if err != nil {
+		return "", err
+	}
+	return entry.DN, nil
+}
+
+// lookupUserEntry searches for the user entry given their username, with
+// the attributes of the attribute policy rules and the tag attributes.
+func (l *Config) lookupUserEntry(conn *ldap.Conn, username string) (*ldap.Entry, error) {
 	filter := strings.ReplaceAll(l.UserDNSearchFilter, "%s", ldap.EscapeFilter(username))
-	var foundDistNames []string
+	attributes := l.userAttributes()
+	if len(attributes) == 0 {
+		attributes = []string{} // only need DN, so no pass no attributes here
+	}
+	var foundEntries []*ldap.Entry
 	for _, userSearchBase := range l.UserDNSearchBaseDistNames {
 		searchRequest := ldap.NewSearchRequest(
 			userSearchBase,
 			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 			filter,
-			[]string{}, // only need DN, so no pass no attributes here
+			attributes,
 			nil,
 		)
 
 		searchResult, err := conn.Search(searchRequest)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
-		for _, entry := range searchResult.Entries {
-			foundDistNames = append(foundDistNames, entry.DN)
-		}
+		foundEntries = append(foundEntries, searchResult.Entries...)
 	}
-	if len(foundDistNames) == 0 {
-		return "", fmt.Errorf("User DN for %s not found", username)
+	if len(foundEntries) == 0 {
+		return nil, fmt.Errorf("User DN for %s %w", username, errUserDNNotFound)
 	}
-	if len(foundDistNames) != 1 {
-		return "", fmt.Errorf("Multiple DNs for %s found - please fix the search filter", username)
+	if len(foundEntries) != 1 {
+		return nil, fmt.Errorf("Multiple DNs for %s found - please fix the search filter", username)
 	}
-	return foundDistNames[0], nil
+	return foundEntries[0], nil
 }
 
 func (l *Config) searchForUserGroups(conn *ldap.Conn, username, bindDN string) ([]string, error) {
@@ -122,78 +165,132 @@ func (l *Config) searchForUserGroups(conn *ldap.Conn, username, bindDN string) (
 		}
 	}
 
+	if l.GroupSearchNested && len(groups) > 0 {
+		return l.searchNestedGroups(conn, groups)
+	}
+	return groups, nil
+}
+
+// searchNestedGroups adds the groups that the given groups are members of,
+// recursively, to groups.
+func (l *Config) searchNestedGroups(conn *ldap.Conn, groups []string) ([]string, error) {
+	seen := set.CreateStringSet(groups...)
+	pending := groups
+	for depth := 0; len(pending) > 0 && depth < maxNestedGroupDepth; depth++ {
+		var next []string
+		for _, groupDN := range pending {
+			for _, groupSearchBase := range l.GroupSearchBaseDistNames {
+				filter := strings.ReplaceAll(l.GroupSearchFilter, "%s", ldap.EscapeFilter(groupDN))
+				filter = strings.ReplaceAll(filter, "%d", ldap.EscapeFilter(groupDN))
+				searchRequest := ldap.NewSearchRequest(
+					groupSearchBase,
+					ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+					filter,
+					nil,
+					nil,
+				)
+
+				parents, err := getGroups(conn, searchRequest)
+				if err != nil {
+					return nil, fmt.Errorf("Error finding groups of %s: %w", groupDN, err)
+				}
+				for _, parent := range parents {
+					if seen.Contains(parent) {
+						continue
+					}
+					seen.Add(parent)
+					next = append(next, parent)
+					groups = append(groups, parent)
+				}
+			}
+		}
+		pending = next
+	}
 	return groups, nil
 }
 
-// LookupUserDN searches for the full DN and groups of a given username
-func (l *Config) LookupUserDN(username string) (string, []string, error) {
+// LookupUserDN searches for the full DN, groups and attribute policies of a
+// given username
+func (l *Config) LookupUserDN(username string) (User, error) {
 	conn, err := l.Connect()
 	if err != nil {
-		return "", nil, err
+		return User{}, lookupError{err}
 	}
 	defer conn.Close()
 
 	// Bind to the lookup user account
 	if err = l.lookupBind(conn); err != nil {
-		return "", nil, err
+		return User{}, lookupError{err}
 	}
 
 	// Lookup user DN
-	bindDN, err := l.lookupUserDN(conn, username)
+	entry, err := l.lookupUserEntry(conn, username)
 	if err != nil {
 		errRet := fmt.Errorf("Unable to find user DN: %w", err)
-		return "", nil, errRet
+		return User{}, lookupError{errRet}
 	}
 
-	groups, err := l.searchForUserGroups(conn, username, bindDN)
+	groups, err := l.searchForUserGroups(conn, username, entry.DN)
 	if err != nil {
-		return "", nil, err
+		return User{}, err
 	}
 
-	return bindDN, groups, nil
+	return User{
+		DN:         entry.DN,
+		Groups:     groups,
+		Policies:   l.attributePolicies(entry),
+		Tags:       l.attributeTags(entry),
+		ConfigName: l.Name,
+	}, nil
 }
 
 // Bind - binds to ldap, searches LDAP and returns the distinguished name of the
-// user and the list of groups.
-func (l *Config) Bind(username, password string) (string, []string, error) {
+// user, the list of groups and the attribute policies.
+func (l *Config) Bind(username, password string) (User, error) {
 	conn, err := l.Connect()
 	if err != nil {
-		return "", nil, err
+		return User{}, lookupError{err}
 	}
 	defer conn.Close()
 
-	var bindDN string
 	// Bind to the lookup user account
 	if err = l.lookupBind(conn); err != nil {
-		return "", nil, err
+		return User{}, lookupError{err}
 	}
 
 	// Lookup user DN
-	bindDN, err = l.lookupUserDN(conn, username)
+	entry, err := l.lookupUserEntry(conn, username)
 	if err != nil {
 		errRet := fmt.Errorf("Unable to find user DN: %w", err)
-		return "", nil, errRet
+		return User{}, lookupError{errRet}
 	}
+	bindDN := entry.DN
 
 	// Authenticate the user credentials.
 	err = conn.Bind(bindDN, password)
 	if err != nil {
 		errRet := fmt.Errorf("LDAP auth failed for DN %s: %w", bindDN, err)
-		return "", nil, errRet
+		return User{}, errRet
 	}
 
 	// Bind to the lookup user account again to perform group search.
 	if err = l.lookupBind(conn); err != nil {
-		return "", nil, err
+		return User{}, err
 	}
 
 	// User groups lookup.
 	groups, err := l.searchForUserGroups(conn, username, bindDN)
 	if err != nil {
-		return "", nil, err
+		return User{}, err
 	}
 
-	return bindDN, groups, nil
+	return User{
+		DN:         bindDN,
+		Groups:     groups,
+		Policies:   l.attributePolicies(entry),
+		Tags:       l.attributeTags(entry),
+		ConfigName: l.Name,
+	}, nil
 }
 
 // Connect connect to ldap server.
@@ -323,7 +420,7 @@ func (l *Config) LookupGroupMemberships(userDistNames []string, userDNToUsername
 
 	res := make(map[string]set.StringSet, len(userDistNames))
 	for _, userDistName := range userDistNames {
-		username := userDNToUsernameMap[userDistName]
+		username := l.searchUsername(userDNToUsernameMap[userDistName])
 		groups, err := l.searchForUserGroups(conn, username, userDistName)
 		if err != nil {
 			return nil, err
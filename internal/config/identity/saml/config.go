@@ -0,0 +1,227 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package saml
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/arn"
+	"github.com/GuinsooLab/annastore/internal/auth"
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/minio/pkg/env"
+)
+
+// SAML identity config and env variables
+const (
+	IdPMetadataURL  = "idp_metadata_url"
+	SPEntityID      = "sp_entity_id"
+	ACSURL          = "acs_url"
+	ClaimName       = "claim_name"
+	GroupsAttribute = "groups_attribute"
+	RolePolicy      = "role_policy"
+	RoleID          = "role_id"
+
+	EnvIdentitySAMLIdPMetadataURL  = "MINIO_IDENTITY_SAML_IDP_METADATA_URL"
+	EnvIdentitySAMLSPEntityID      = "MINIO_IDENTITY_SAML_SP_ENTITY_ID"
+	EnvIdentitySAMLACSURL          = "MINIO_IDENTITY_SAML_ACS_URL"
+	EnvIdentitySAMLClaimName       = "MINIO_IDENTITY_SAML_CLAIM_NAME"
+	EnvIdentitySAMLGroupsAttribute = "MINIO_IDENTITY_SAML_GROUPS_ATTRIBUTE"
+	EnvIdentitySAMLRolePolicy      = "MINIO_IDENTITY_SAML_ROLE_POLICY"
+	EnvIdentitySAMLRoleID          = "MINIO_IDENTITY_SAML_ROLE_ID"
+
+	defaultClaimName = "policy"
+)
+
+// DefaultKVS - default config for SAML config
+var DefaultKVS = config.KVS{
+	config.KV{
+		Key:   IdPMetadataURL,
+		Value: "",
+	},
+	config.KV{
+		Key:   SPEntityID,
+		Value: "",
+	},
+	config.KV{
+		Key:   ACSURL,
+		Value: "",
+	},
+	config.KV{
+		Key:   ClaimName,
+		Value: defaultClaimName,
+	},
+	config.KV{
+		Key:   GroupsAttribute,
+		Value: "",
+	},
+	config.KV{
+		Key:   RolePolicy,
+		Value: "",
+	},
+	config.KV{
+		Key:   RoleID,
+		Value: "",
+	},
+}
+
+// Allows only Base64 URL encoding characters.
+var validRoleIDRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ReplayCache records the IDs of accepted assertions until they expire,
+// so that a captured assertion cannot be exchanged again on any node.
+type ReplayCache interface {
+	// Add records id until expiry. It returns false if id is already
+	// recorded and has not expired yet.
+	Add(ctx context.Context, id string, expiry time.Time) (bool, error)
+}
+
+// Config - SAML identity provider configuration.
+type Config struct {
+	Enabled bool
+
+	// SPEntityID is the audience assertions must be issued for.
+	SPEntityID string
+
+	// ACSURL is the recipient assertions must be addressed to.
+	ACSURL string
+
+	// ClaimName is the attribute carrying policy names.
+	ClaimName string
+
+	// GroupsAttribute is the attribute carrying group names, whose
+	// policy mappings apply to the user.
+	GroupsAttribute string
+
+	// RolePolicy, when set, is applied to all users assuming RoleARN
+	// instead of the policies carried by the assertion.
+	RolePolicy string
+	RoleARN    arn.ARN
+
+	// Replays refuses assertions which were already accepted.
+	Replays ReplayCache
+
+	metadata *idpMetadata
+}
+
+// Enabled returns if SAML is enabled.
+func Enabled(kvs config.KVS) bool {
+	return kvs.Get(IdPMetadataURL) != ""
+}
+
+// LookupConfig - lookup SAML config from config, override with any ENVs,
+// and load the IdP metadata.
+func LookupConfig(kvs config.KVS, transport http.RoundTripper, serverRegion string) (c Config, err error) {
+	if err = config.CheckValidKeys(config.IdentitySAMLSubSys, kvs, DefaultKVS); err != nil {
+		return c, err
+	}
+
+	metadataURL := env.Get(EnvIdentitySAMLIdPMetadataURL, kvs.Get(IdPMetadataURL))
+	if metadataURL == "" {
+		return c, nil
+	}
+
+	c = Config{
+		SPEntityID:      env.Get(EnvIdentitySAMLSPEntityID, kvs.Get(SPEntityID)),
+		ACSURL:          env.Get(EnvIdentitySAMLACSURL, kvs.Get(ACSURL)),
+		ClaimName:       env.Get(EnvIdentitySAMLClaimName, kvs.Get(ClaimName)),
+		GroupsAttribute: env.Get(EnvIdentitySAMLGroupsAttribute, kvs.Get(GroupsAttribute)),
+		RolePolicy:      env.Get(EnvIdentitySAMLRolePolicy, kvs.Get(RolePolicy)),
+	}
+	if c.SPEntityID == "" {
+		return c, config.Errorf("A service provider entity ID must be specified for SAML")
+	}
+	if c.ACSURL == "" {
+		return c, config.Errorf("An ACS URL must be specified for SAML")
+	}
+	if c.ClaimName == "" {
+		c.ClaimName = defaultClaimName
+	}
+
+	if c.metadata, err = newIdPMetadata(metadataURL, transport, time.Now()); err != nil {
+		return c, config.Errorf("%v", err)
+	}
+
+	if c.RolePolicy != "" {
+		resourceID := "saml-"
+		roleID := env.Get(EnvIdentitySAMLRoleID, kvs.Get(RoleID))
+		if roleID == "" {
+			// We use a hash of the IdP entity ID so that the ARN
+			// remains constant across restarts.
+			h := sha1.New()
+			h.Write([]byte(c.IssuerID()))
+			resourceID += base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+		} else {
+			if !validRoleIDRegex.MatchString(roleID) {
+				return c, config.Errorf("Role ID must match the regexp `^[a-zA-Z0-9_-]+$`")
+			}
+			resourceID += roleID
+		}
+		c.RoleARN, err = arn.NewIAMRoleARN(resourceID, serverRegion)
+		if err != nil {
+			return c, config.Errorf("unable to generate ARN from the SAML config: %v", err)
+		}
+	}
+
+	c.Enabled = true
+	return c, nil
+}
+
+// IssuerID returns the entity ID of the configured identity provider.
+func (c *Config) IssuerID() string {
+	if c.metadata == nil {
+		return ""
+	}
+	return c.metadata.entityID()
+}
+
+// GetRoleInfo - returns ARN to policies map if a role policy is configured.
+func (c *Config) GetRoleInfo() map[arn.ARN]string {
+	if !c.Enabled || c.RolePolicy == "" {
+		return nil
+	}
+	return map[arn.ARN]string{
+		c.RoleARN: c.RolePolicy,
+	}
+}
+
+// GetExpiryDuration returns the requested session duration, limited by the
+// end of the IdP session when the assertion carries one.
+func (c *Config) GetExpiryDuration(dsecs string, a Assertion, now time.Time) (time.Duration, error) {
+	d := time.Hour
+	if dsecs != "" {
+		var err error
+		if d, err = time.ParseDuration(dsecs + "s"); err != nil {
+			return 0, auth.ErrInvalidDuration
+		}
+		// The value can range from 900 seconds (15 minutes) up to 12 hours.
+		if d < 15*time.Minute || d > 12*time.Hour {
+			return 0, auth.ErrInvalidDuration
+		}
+	}
+	if !a.SessionNotOnOrAfter.IsZero() {
+		if left := a.SessionNotOnOrAfter.Sub(now); left < d {
+			d = left
+		}
+	}
+	return d, nil
+}
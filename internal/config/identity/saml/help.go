@@ -0,0 +1,75 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package saml
+
+import "github.com/GuinsooLab/annastore/internal/config"
+
+// Help template for SAML identity feature.
+var (
+	defaultHelpPostfix = func(key string) string {
+		return config.DefaultHelpPostfix(DefaultKVS, key)
+	}
+
+	Help = config.HelpKVS{
+		config.HelpKV{
+			Key:         IdPMetadataURL,
+			Description: `IdP metadata URL or file path e.g. "https://idp.example.com/metadata.xml"` + defaultHelpPostfix(IdPMetadataURL),
+			Type:        "url",
+		},
+		config.HelpKV{
+			Key:         SPEntityID,
+			Description: `service provider entity ID, the audience of assertions e.g. "https://minio.example.com"` + defaultHelpPostfix(SPEntityID),
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         ACSURL,
+			Description: `URL assertions must be addressed to e.g. "https://minio.example.com/"` + defaultHelpPostfix(ACSURL),
+			Type:        "url",
+		},
+		config.HelpKV{
+			Key:         ClaimName,
+			Description: `SAML attribute carrying the policy names, combined with the policies of the groups` + defaultHelpPostfix(ClaimName),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         GroupsAttribute,
+			Description: `SAML attribute carrying the group names whose policy mappings apply` + defaultHelpPostfix(GroupsAttribute),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         RolePolicy,
+			Description: `policies applied to all SAML users assuming the role, instead of their attributes e.g. "readonly"` + defaultHelpPostfix(RolePolicy),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         RoleID,
+			Description: "unique ID to generate the role ARN" + defaultHelpPostfix(RoleID),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         config.Comment,
+			Description: config.DefaultComment,
+			Optional:    true,
+			Type:        "sentence",
+		},
+	}
+)
@@ -0,0 +1,212 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxMetadataSize = 10 << 20
+
+	// metadataMaxAge is how long the IdP metadata is used before it is
+	// fetched again, so that removed signing certificates stop being
+	// trusted.
+	metadataMaxAge = 24 * time.Hour
+
+	// minMetadataRefreshInterval limits how often a signature failure
+	// causes the IdP metadata to be fetched again.
+	minMetadataRefreshInterval = time.Minute
+)
+
+// IdPMetadata is the part of the IdP metadata needed to validate
+// assertions: the IdP entity ID and its signing certificates.
+type IdPMetadata struct {
+	EntityID     string
+	Certificates []*x509.Certificate
+}
+
+// parseMetadata parses an EntityDescriptor, or the first EntityDescriptor
+// with an IDPSSODescriptor of an EntitiesDescriptor.
+func parseMetadata(doc []byte) (IdPMetadata, error) {
+	root, err := parseXML(doc)
+	if err != nil {
+		return IdPMetadata{}, err
+	}
+	var entity node
+	switch {
+	case root.is(nsMetadata, "EntityDescriptor"):
+		entity = root
+	case root.is(nsMetadata, "EntitiesDescriptor"):
+		for _, e := range root.childrenNamed(nsMetadata, "EntityDescriptor") {
+			if e.child(nsMetadata, "IDPSSODescriptor").Element != nil {
+				entity = e
+				break
+			}
+		}
+	}
+	if entity.Element == nil {
+		return IdPMetadata{}, errors.New("SAML: metadata has no IdP EntityDescriptor")
+	}
+	idp := entity.child(nsMetadata, "IDPSSODescriptor")
+	if idp.Element == nil {
+		return IdPMetadata{}, errors.New("SAML: metadata has no IDPSSODescriptor")
+	}
+
+	md := IdPMetadata{EntityID: entity.attr("entityID")}
+	if md.EntityID == "" {
+		return md, errors.New("SAML: metadata entityID missing")
+	}
+	for _, kd := range idp.childrenNamed(nsMetadata, "KeyDescriptor") {
+		if use := kd.attr("use"); use != "" && use != "signing" {
+			continue
+		}
+		keyInfo := kd.child(nsDSig, "KeyInfo")
+		if keyInfo.Element == nil {
+			continue
+		}
+		for _, data := range keyInfo.childrenNamed(nsDSig, "X509Data") {
+			for _, c := range data.childrenNamed(nsDSig, "X509Certificate") {
+				der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c.text()), ""))
+				if err != nil {
+					return md, fmt.Errorf("SAML: invalid IdP certificate: %w", err)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return md, fmt.Errorf("SAML: invalid IdP certificate: %w", err)
+				}
+				md.Certificates = append(md.Certificates, cert)
+			}
+		}
+	}
+	if len(md.Certificates) == 0 {
+		return md, errors.New("SAML: metadata has no IdP signing certificate")
+	}
+	return md, nil
+}
+
+// fetchMetadata reads the IdP metadata from an http(s) URL or a file.
+func fetchMetadata(location string, transport http.RoundTripper) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxMetadataSize))
+	}
+
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SAML: fetching IdP metadata failed with %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+}
+
+// idpMetadata holds the current IdP metadata and fetches it again from its
+// location when it is older than metadataMaxAge or when a signature does not
+// verify, so that rotated IdP signing certificates are picked up.
+type idpMetadata struct {
+	location  string
+	transport http.RoundTripper
+
+	mu        sync.RWMutex
+	md        IdPMetadata
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+func newIdPMetadata(location string, transport http.RoundTripper, now time.Time) (*idpMetadata, error) {
+	m := &idpMetadata{location: location, transport: transport}
+	md, err := m.fetch()
+	if err != nil {
+		return nil, err
+	}
+	m.md, m.fetchedAt, m.triedAt = md, now, now
+	return m, nil
+}
+
+func (m *idpMetadata) fetch() (IdPMetadata, error) {
+	data, err := fetchMetadata(m.location, m.transport)
+	if err != nil {
+		return IdPMetadata{}, fmt.Errorf("Unable to load SAML IdP metadata: %w", err)
+	}
+	md, err := parseMetadata(data)
+	if err != nil {
+		return IdPMetadata{}, fmt.Errorf("Unable to parse SAML IdP metadata: %w", err)
+	}
+	return md, nil
+}
+
+// entityID returns the IdP entity ID, which does not change on refresh.
+func (m *idpMetadata) entityID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.md.EntityID
+}
+
+// get returns the current metadata, fetching it again first if it is older
+// than metadataMaxAge.
+func (m *idpMetadata) get(now time.Time) IdPMetadata {
+	m.mu.RLock()
+	md, stale := m.md, now.Sub(m.fetchedAt) >= metadataMaxAge
+	m.mu.RUnlock()
+	if stale {
+		md, _ = m.refresh(now)
+	}
+	return md
+}
+
+// refresh fetches the metadata again, unless this was last tried less than
+// minMetadataRefreshInterval ago, and returns the current metadata and
+// whether it was updated. The previous metadata is kept if fetching fails
+// or if the IdP entity ID changed, which requires a reconfiguration.
+func (m *idpMetadata) refresh(now time.Time) (IdPMetadata, bool) {
+	m.mu.Lock()
+	if m.location == "" || now.Sub(m.triedAt) < minMetadataRefreshInterval {
+		md := m.md
+		m.mu.Unlock()
+		return md, false
+	}
+	m.triedAt = now
+	m.mu.Unlock()
+
+	md, err := m.fetch()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil || md.EntityID != m.md.EntityID {
+		return m.md, false
+	}
+	m.md, m.fetchedAt = md, now
+	return md, true
+}
@@ -0,0 +1,355 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	statusSuccess          = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	subjectConfirmBearer   = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	maxSAMLResponseSize    = 1 << 20
+	allowedClockSkew       = 3 * time.Minute
+	awsRoleAttribute       = "https://aws.amazon.com/SAML/Attributes/Role"
+	awsRoleSessionNameAttr = "https://aws.amazon.com/SAML/Attributes/RoleSessionName"
+	awsPrincipalTagAttr    = "https://aws.amazon.com/SAML/Attributes/PrincipalTag:"
+	awsTransitiveTagsAttr  = "https://aws.amazon.com/SAML/Attributes/TransitiveTagKeys"
+)
+
+// Errors returned when validating SAML responses.
+var (
+	ErrAssertionExpired = errors.New("SAML: assertion expired")
+	ErrInvalidAudience  = errors.New("SAML: assertion audience does not match this service provider")
+	ErrInvalidIssuer    = errors.New("SAML: assertion issuer does not match the identity provider")
+	ErrResponseTooLarge = errors.New("SAML: response too large")
+	ErrInvalidRecipient = errors.New("SAML: assertion recipient does not match the ACS URL")
+	ErrReplayed         = errors.New("SAML: assertion was already used")
+)
+
+// Assertion is the validated content of a SAML assertion.
+type Assertion struct {
+	Issuer  string
+	Subject string
+	// SessionNotOnOrAfter is the end of the IdP session, if any.
+	SessionNotOnOrAfter time.Time
+	Attributes          map[string][]string
+
+	// confirmedUntil is when the bearer confirmation expires.
+	confirmedUntil time.Time
+}
+
+// Policies returns the policies carried by the configured policy attribute.
+func (a Assertion) Policies(attr string) []string {
+	return splitValues(a.Attributes[attr])
+}
+
+// Groups returns the groups carried by the configured groups attribute.
+func (a Assertion) Groups(attr string) []string {
+	if attr == "" {
+		return nil
+	}
+	return splitValues(a.Attributes[attr])
+}
+
+// Roles returns the role ARNs listed in the AWS role attribute, whose
+// values are "roleArn,principalArn" pairs.
+func (a Assertion) Roles() []string {
+	var roles []string
+	for _, v := range a.Attributes[awsRoleAttribute] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); strings.Contains(part, ":role/") {
+				roles = append(roles, part)
+			}
+		}
+	}
+	return roles
+}
+
+// SessionName returns the AWS role session name attribute, if any.
+func (a Assertion) SessionName() string {
+	if v := a.Attributes[awsRoleSessionNameAttr]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// SessionTags returns the session tags passed in the AWS principal tag
+// attributes, e.g. ".../PrincipalTag:team", and the keys of the transitive
+// tags among them.
+func (a Assertion) SessionTags() (tags map[string]string, transitive []string) {
+	for name, v := range a.Attributes {
+		if !strings.HasPrefix(name, awsPrincipalTagAttr) || len(v) == 0 {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[strings.TrimPrefix(name, awsPrincipalTagAttr)] = v[0]
+	}
+	for _, k := range splitValues(a.Attributes[awsTransitiveTagsAttr]) {
+		if _, ok := tags[k]; ok {
+			transitive = append(transitive, k)
+		}
+	}
+	return tags, transitive
+}
+
+func splitValues(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
+// Validate parses the base64 encoded SAML response, verifies its signature
+// against the IdP signing certificates and validates the assertion
+// conditions. Either the response or the assertion must be signed. The
+// assertion must be addressed to the configured ACS URL and is accepted
+// only once across the cluster.
+func (c *Config) Validate(ctx context.Context, samlResponse string, now time.Time) (Assertion, error) {
+	if len(samlResponse) > maxSAMLResponseSize {
+		return Assertion{}, ErrResponseTooLarge
+	}
+	doc, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(samlResponse), ""))
+	if err != nil {
+		return Assertion{}, fmt.Errorf("SAML: invalid base64 response: %w", err)
+	}
+	return c.validateResponse(ctx, doc, now)
+}
+
+func (c *Config) validateResponse(ctx context.Context, doc []byte, now time.Time) (Assertion, error) {
+	root, err := parseXML(doc)
+	if err != nil {
+		return Assertion{}, err
+	}
+	if !root.is(nsProtocol, "Response") {
+		return Assertion{}, errors.New("SAML: expected a Response element")
+	}
+	if err = checkStatus(root); err != nil {
+		return Assertion{}, err
+	}
+	if _, err = singleAssertion(root); err != nil {
+		return Assertion{}, err
+	}
+
+	md := c.metadata.get(now)
+	assertion, err := verifyResponseSignature(root, md.Certificates, now)
+	if err == errSignatureInvalid {
+		// The IdP may have rotated its signing certificate.
+		var updated bool
+		if md, updated = c.metadata.refresh(now); updated {
+			assertion, err = verifyResponseSignature(root, md.Certificates, now)
+		}
+	}
+	if err != nil {
+		return Assertion{}, err
+	}
+	a, err := c.parseAssertion(assertion, md.EntityID, now)
+	if err != nil {
+		return Assertion{}, err
+	}
+	id := assertion.attr("ID")
+	if id == "" {
+		return Assertion{}, errors.New("SAML: assertion ID missing")
+	}
+	if c.Replays == nil {
+		return Assertion{}, errors.New("SAML: no replay cache configured")
+	}
+	ok, err := c.Replays.Add(ctx, c.IssuerID()+"/"+id, a.confirmedUntil)
+	if err != nil {
+		return Assertion{}, err
+	}
+	if !ok {
+		return Assertion{}, ErrReplayed
+	}
+	return a, nil
+}
+
+// singleAssertion returns the only assertion of the response.
+func singleAssertion(resp node) (node, error) {
+	if len(resp.childrenNamed(nsAssertion, "EncryptedAssertion")) > 0 {
+		return node{}, errors.New("SAML: encrypted assertions are not supported")
+	}
+	assertions := resp.childrenNamed(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return node{}, errors.New("SAML: expected exactly one assertion")
+	}
+	return assertions[0], nil
+}
+
+// verifyResponseSignature verifies the signature of the assertion or, if it
+// is not signed, of the whole response, and returns the signed assertion.
+// Only content covered by a verified signature is trusted.
+func verifyResponseSignature(resp node, certs []*x509.Certificate, now time.Time) (node, error) {
+	assertion, err := singleAssertion(resp)
+	if err != nil {
+		return node{}, err
+	}
+	signed, err := verifySignature(assertion, certs, now)
+	if err != errSignatureMissing {
+		return signed, err
+	}
+	if signed, err = verifySignature(resp, certs, now); err != nil {
+		return node{}, err
+	}
+	return singleAssertion(signed)
+}
+
+func checkStatus(resp node) error {
+	status := resp.child(nsProtocol, "Status")
+	if status.Element == nil {
+		return errors.New("SAML: response status missing")
+	}
+	code := status.child(nsProtocol, "StatusCode")
+	if code.Element == nil || code.attr("Value") != statusSuccess {
+		value := ""
+		if code.Element != nil {
+			value = code.attr("Value")
+		}
+		return fmt.Errorf("SAML: authentication failed with status %s", value)
+	}
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func (c *Config) parseAssertion(assertion node, issuerID string, now time.Time) (Assertion, error) {
+	a := Assertion{Attributes: make(map[string][]string)}
+
+	issuer := assertion.child(nsAssertion, "Issuer")
+	if issuer.Element == nil || issuer.text() != issuerID {
+		return a, ErrInvalidIssuer
+	}
+	a.Issuer = issuer.text()
+
+	conditions := assertion.child(nsAssertion, "Conditions")
+	if conditions.Element == nil {
+		return a, errors.New("SAML: assertion conditions missing")
+	}
+	if v := conditions.attr("NotBefore"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return a, err
+		}
+		if now.Add(allowedClockSkew).Before(t) {
+			return a, errors.New("SAML: assertion not yet valid")
+		}
+	}
+	if v := conditions.attr("NotOnOrAfter"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return a, err
+		}
+		if !now.Add(-allowedClockSkew).Before(t) {
+			return a, ErrAssertionExpired
+		}
+	}
+	restrictions := conditions.childrenNamed(nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return a, ErrInvalidAudience
+	}
+	// Every audience restriction must be satisfied.
+	for _, r := range restrictions {
+		found := false
+		for _, aud := range r.childrenNamed(nsAssertion, "Audience") {
+			if aud.text() == c.SPEntityID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return a, ErrInvalidAudience
+		}
+	}
+
+	subject := assertion.child(nsAssertion, "Subject")
+	if subject.Element == nil {
+		return a, errors.New("SAML: assertion subject missing")
+	}
+	nameID := subject.child(nsAssertion, "NameID")
+	if nameID.Element == nil || nameID.text() == "" {
+		return a, errors.New("SAML: assertion subject NameID missing")
+	}
+	a.Subject = nameID.text()
+	// Bearer confirmations must be addressed to us and expire, the
+	// assertion is remembered until then to refuse replays.
+	for _, sc := range subject.childrenNamed(nsAssertion, "SubjectConfirmation") {
+		if sc.attr("Method") != subjectConfirmBearer {
+			continue
+		}
+		data := sc.child(nsAssertion, "SubjectConfirmationData")
+		if data.Element == nil || data.attr("NotOnOrAfter") == "" || data.attr("Recipient") == "" {
+			return a, errors.New("SAML: bearer subject confirmation must have a recipient and an expiry")
+		}
+		t, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil {
+			return a, err
+		}
+		if !now.Add(-allowedClockSkew).Before(t) {
+			return a, ErrAssertionExpired
+		}
+		if strings.TrimSuffix(data.attr("Recipient"), "/") != strings.TrimSuffix(c.ACSURL, "/") {
+			return a, ErrInvalidRecipient
+		}
+		if t = t.Add(allowedClockSkew); t.After(a.confirmedUntil) {
+			a.confirmedUntil = t
+		}
+	}
+	if a.confirmedUntil.IsZero() {
+		return a, errors.New("SAML: bearer subject confirmation missing")
+	}
+
+	for _, stmt := range assertion.childrenNamed(nsAssertion, "AuthnStatement") {
+		if v := stmt.attr("SessionNotOnOrAfter"); v != "" {
+			t, err := parseTime(v)
+			if err != nil {
+				return a, err
+			}
+			if !now.Before(t) {
+				return a, ErrAssertionExpired
+			}
+			if a.SessionNotOnOrAfter.IsZero() || t.Before(a.SessionNotOnOrAfter) {
+				a.SessionNotOnOrAfter = t
+			}
+		}
+	}
+
+	for _, stmt := range assertion.childrenNamed(nsAssertion, "AttributeStatement") {
+		for _, attr := range stmt.childrenNamed(nsAssertion, "Attribute") {
+			name := attr.attr("Name")
+			for _, v := range attr.childrenNamed(nsAssertion, "AttributeValue") {
+				a.Attributes[name] = append(a.Attributes[name], v.text())
+			}
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,433 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package saml
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	testIssuer    = "https://idp.example.com"
+	testAudience  = "https://minio.example.com"
+	testRecipient = "https://minio.example.com/"
+)
+
+const testAssertionTemplate = `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:xs="http://www.w3.org/2001/XMLSchema" ID="{id}" Version="2.0" IssueInstant="{now}">` +
+	`<saml:Issuer>{issuer}</saml:Issuer>` +
+	`<saml:Subject><saml:NameID>alice@example.com</saml:NameID>` +
+	`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer"><saml:SubjectConfirmationData NotOnOrAfter="{notOnOrAfter}" Recipient="{recipient}"/></saml:SubjectConfirmation></saml:Subject>` +
+	`<saml:Conditions NotBefore="{notBefore}" NotOnOrAfter="{notOnOrAfter}"><saml:AudienceRestriction><saml:Audience>{audience}</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+	`<saml:AuthnStatement AuthnInstant="{now}" SessionNotOnOrAfter="{session}"/>` +
+	`<saml:AttributeStatement><saml:Attribute Name="policy"><saml:AttributeValue>readwrite</saml:AttributeValue></saml:Attribute>` +
+	`<saml:Attribute Name="groups"><saml:AttributeValue>dev, ops</saml:AttributeValue></saml:Attribute></saml:AttributeStatement>` +
+	`</saml:Assertion>`
+
+func testResponse(assertion string) string {
+	return `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_r1" Version="2.0">` +
+		`<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>` +
+		assertion + `</samlp:Response>`
+}
+
+// testReplayCache is an in-memory ReplayCache.
+type testReplayCache map[string]time.Time
+
+func (c testReplayCache) Add(_ context.Context, id string, expiry time.Time) (bool, error) {
+	if _, ok := c[id]; ok {
+		return false, nil
+	}
+	c[id] = expiry
+	return true, nil
+}
+
+func newTestKey(t *testing.T) (*dsig.SigningContext, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := dsig.NewSigningContext(key, [][]byte{der})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	return signer, cert
+}
+
+// signAssertion renders the assertion template with vars and signs it
+// with an enveloped signature. The assertion gets a unique ID unless vars
+// sets one.
+func signAssertion(t *testing.T, signer *dsig.SigningContext, vars map[string]string) string {
+	t.Helper()
+	if _, ok := vars["id"]; !ok {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			t.Fatal(err)
+		}
+		vars["id"] = "_" + base64.RawURLEncoding.EncodeToString(id)
+	}
+	if _, ok := vars["recipient"]; !ok {
+		vars["recipient"] = testRecipient
+	}
+	assertion := testAssertionTemplate
+	for k, v := range vars {
+		assertion = strings.ReplaceAll(assertion, "{"+k+"}", v)
+	}
+	return signXML(t, signer, assertion)
+}
+
+// signXML signs the root element of a document with an enveloped
+// signature.
+func signXML(t *testing.T, signer *dsig.SigningContext, s string) string {
+	t.Helper()
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(s); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.SignEnveloped(doc.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.SetRoot(signed)
+	res, err := doc.WriteToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	signer, cert := newTestKey(t)
+	otherSigner, _ := newTestKey(t)
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	vars := func(overrides ...string) map[string]string {
+		m := map[string]string{
+			"id":           "_a1",
+			"now":          now.Format(time.RFC3339),
+			"issuer":       testIssuer,
+			"audience":     testAudience,
+			"notBefore":    now.Add(-time.Minute).Format(time.RFC3339),
+			"notOnOrAfter": now.Add(5 * time.Minute).Format(time.RFC3339),
+			"session":      now.Add(30 * time.Minute).Format(time.RFC3339),
+		}
+		for i := 0; i+1 < len(overrides); i += 2 {
+			m[overrides[i]] = overrides[i+1]
+		}
+		return m
+	}
+
+	c := Config{
+		Enabled:         true,
+		SPEntityID:      testAudience,
+		ACSURL:          testRecipient,
+		ClaimName:       defaultClaimName,
+		GroupsAttribute: "groups",
+		metadata:        &idpMetadata{md: IdPMetadata{EntityID: testIssuer, Certificates: []*x509.Certificate{cert}}},
+		Replays:         testReplayCache{},
+	}
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	valid := signAssertion(t, signer, vars())
+	a, err := c.Validate(context.Background(), encode(testResponse(valid)), now)
+	if err != nil {
+		t.Fatalf("expected valid assertion, got %v", err)
+	}
+	if a.Subject != "alice@example.com" || a.Issuer != testIssuer {
+		t.Errorf("unexpected subject %s or issuer %s", a.Subject, a.Issuer)
+	}
+	if p := a.Policies(c.ClaimName); len(p) != 1 || p[0] != "readwrite" {
+		t.Errorf("unexpected policies %v", p)
+	}
+	if g := a.Groups(c.GroupsAttribute); len(g) != 2 || g[0] != "dev" || g[1] != "ops" {
+		t.Errorf("unexpected groups %v", g)
+	}
+	// The assertion may instead be covered by a signature of the response.
+	unsigned := testAssertionTemplate
+	for k, v := range vars("id", "_a5", "recipient", testRecipient) {
+		unsigned = strings.ReplaceAll(unsigned, "{"+k+"}", v)
+	}
+	if _, err = c.Validate(context.Background(), encode(signXML(t, signer, testResponse(unsigned))), now); err != nil {
+		t.Fatalf("expected valid signed response, got %v", err)
+	}
+
+	d, err := c.GetExpiryDuration("3600", a, now)
+	if err != nil || d != 30*time.Minute {
+		t.Errorf("expected the session end to limit the duration, got %v, %v", d, err)
+	}
+
+	testCases := []struct {
+		name     string
+		response string
+		now      time.Time
+		wantErr  error
+	}{
+		{
+			name:     "tampered attribute",
+			response: testResponse(strings.Replace(valid, "readwrite", "consoleAdmin", 1)),
+			now:      now,
+			wantErr:  errSignatureInvalid,
+		},
+		{
+			name:     "wrong audience",
+			response: testResponse(signAssertion(t, signer, vars("audience", "https://other.example.com"))),
+			now:      now,
+			wantErr:  ErrInvalidAudience,
+		},
+		{
+			name:     "wrong issuer",
+			response: testResponse(signAssertion(t, signer, vars("issuer", "https://evil.example.com"))),
+			now:      now,
+			wantErr:  ErrInvalidIssuer,
+		},
+		{
+			name:     "expired",
+			response: testResponse(valid),
+			now:      now.Add(time.Hour),
+			wantErr:  ErrAssertionExpired,
+		},
+		{
+			name:     "replayed",
+			response: testResponse(valid),
+			now:      now.Add(time.Minute),
+			wantErr:  ErrReplayed,
+		},
+		{
+			name:     "wrong recipient",
+			response: testResponse(signAssertion(t, signer, vars("id", "_a3", "recipient", "https://other.example.com/"))),
+			now:      now,
+			wantErr:  ErrInvalidRecipient,
+		},
+		{
+			name:     "missing recipient",
+			response: testResponse(signAssertion(t, signer, vars("id", "_a4", "recipient", ""))),
+			now:      now,
+		},
+		{
+			name:     "untrusted key",
+			response: testResponse(signAssertion(t, otherSigner, vars())),
+			now:      now,
+			wantErr:  errSignatureInvalid,
+		},
+		{
+			name:     "unsigned",
+			response: testResponse(valid[:strings.Index(valid, "<ds:Signature")] + valid[strings.Index(valid, "</ds:Signature>")+len("</ds:Signature>"):]),
+			now:      now,
+			wantErr:  errSignatureMissing,
+		},
+		{
+			// A second, unsigned assertion must not be accepted next to
+			// the signed one.
+			name:     "wrapped assertion",
+			response: testResponse(strings.Replace(valid, `ID="_a1"`, `ID="_a2"`, 1) + valid),
+			now:      now,
+		},
+		{
+			// A copy of the signed assertion hidden elsewhere must not
+			// let its signature vouch for a forged one.
+			name: "duplicate ID",
+			response: testResponse(`<samlp:Extensions xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol">` + valid + `</samlp:Extensions>` +
+				strings.Replace(valid, "readwrite", "consoleAdmin", 1)),
+			now: now,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.Validate(context.Background(), encode(tc.response), tc.now)
+			if err == nil {
+				t.Fatal("expected validation to fail")
+			}
+			if tc.wantErr != nil && err != tc.wantErr {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateACSURL(t *testing.T) {
+	signer, cert := newTestKey(t)
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := Config{
+		Enabled:    true,
+		SPEntityID: testAudience,
+		ACSURL:     "https://sts.example.com/saml",
+		ClaimName:  defaultClaimName,
+		metadata:   &idpMetadata{md: IdPMetadata{EntityID: testIssuer, Certificates: []*x509.Certificate{cert}}},
+		Replays:    testReplayCache{},
+	}
+	response := func(recipient string) string {
+		assertion := signAssertion(t, signer, map[string]string{
+			"now":          now.Format(time.RFC3339),
+			"issuer":       testIssuer,
+			"audience":     testAudience,
+			"recipient":    recipient,
+			"notBefore":    now.Add(-time.Minute).Format(time.RFC3339),
+			"notOnOrAfter": now.Add(5 * time.Minute).Format(time.RFC3339),
+			"session":      now.Add(30 * time.Minute).Format(time.RFC3339),
+		})
+		return base64.StdEncoding.EncodeToString([]byte(testResponse(assertion)))
+	}
+
+	// Assertions must be addressed to the configured ACS URL.
+	if _, err := c.Validate(context.Background(), response(testRecipient), now); err != ErrInvalidRecipient {
+		t.Fatalf("expected %v, got %v", ErrInvalidRecipient, err)
+	}
+	if _, err := c.Validate(context.Background(), response(c.ACSURL+"/"), now); err != nil {
+		t.Fatalf("expected valid assertion, got %v", err)
+	}
+}
+
+func testMetadata(cert *x509.Certificate) string {
+	return `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="` + testIssuer + `">` +
+		`<md:IDPSSODescriptor><md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data>` +
+		`<ds:X509Certificate>` + base64.StdEncoding.EncodeToString(cert.Raw) + `</ds:X509Certificate>` +
+		`</ds:X509Data></ds:KeyInfo></md:KeyDescriptor></md:IDPSSODescriptor></md:EntityDescriptor>`
+}
+
+func TestLookupConfigACSURL(t *testing.T) {
+	_, cert := newTestKey(t)
+	location := filepath.Join(t.TempDir(), "metadata.xml")
+	if err := os.WriteFile(location, []byte(testMetadata(cert)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	kvs := config.KVS{
+		config.KV{Key: IdPMetadataURL, Value: location},
+		config.KV{Key: SPEntityID, Value: testAudience},
+	}
+	if _, err := LookupConfig(kvs, nil, ""); err == nil {
+		t.Fatal("expected an ACS URL to be required")
+	}
+
+	kvs = append(kvs, config.KV{Key: ACSURL, Value: testRecipient})
+	c, err := LookupConfig(kvs, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Enabled || c.ACSURL != testRecipient {
+		t.Fatalf("unexpected config %+v", c)
+	}
+}
+
+func TestMetadataRefresh(t *testing.T) {
+	oldSigner, oldCert := newTestKey(t)
+	newSigner, newCert := newTestKey(t)
+
+	location := filepath.Join(t.TempDir(), "metadata.xml")
+	if err := os.WriteFile(location, []byte(testMetadata(oldCert)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	metadata, err := newIdPMetadata(location, nil, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Config{
+		Enabled:    true,
+		SPEntityID: testAudience,
+		ACSURL:     testRecipient,
+		ClaimName:  defaultClaimName,
+		metadata:   metadata,
+		Replays:    testReplayCache{},
+	}
+
+	response := func(signer *dsig.SigningContext, now time.Time) string {
+		assertion := signAssertion(t, signer, map[string]string{
+			"now":          now.Format(time.RFC3339),
+			"issuer":       testIssuer,
+			"audience":     testAudience,
+			"notBefore":    now.Add(-time.Minute).Format(time.RFC3339),
+			"notOnOrAfter": now.Add(5 * time.Minute).Format(time.RFC3339),
+			"session":      now.Add(30 * time.Minute).Format(time.RFC3339),
+		})
+		return base64.StdEncoding.EncodeToString([]byte(testResponse(assertion)))
+	}
+
+	if _, err = c.Validate(context.Background(), response(oldSigner, start), start); err != nil {
+		t.Fatalf("expected valid assertion, got %v", err)
+	}
+
+	// The IdP rotates its signing certificate.
+	if err = os.WriteFile(location, []byte(testMetadata(newCert)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refreshing right after the last fetch is not allowed.
+	now := start.Add(time.Second)
+	if _, err = c.Validate(context.Background(), response(newSigner, now), now); err != errSignatureInvalid {
+		t.Fatalf("expected %v, got %v", errSignatureInvalid, err)
+	}
+
+	now = start.Add(2 * minMetadataRefreshInterval)
+	if _, err = c.Validate(context.Background(), response(newSigner, now), now); err != nil {
+		t.Fatalf("expected the metadata to be refreshed, got %v", err)
+	}
+	if _, err = c.Validate(context.Background(), response(oldSigner, now), now); err != errSignatureInvalid {
+		t.Fatalf("expected the old certificate to be dropped, got %v", err)
+	}
+}
+
+func TestSessionTags(t *testing.T) {
+	a := Assertion{Attributes: map[string][]string{
+		awsPrincipalTagAttr + "team":    {"red"},
+		awsPrincipalTagAttr + "project": {"apollo", "gemini"},
+		awsTransitiveTagsAttr:           {"team,unknown"},
+		"team":                          {"blue"},
+	}}
+	tags, transitive := a.SessionTags()
+	if !reflect.DeepEqual(tags, map[string]string{"team": "red", "project": "apollo"}) {
+		t.Errorf("unexpected session tags %v", tags)
+	}
+	if !reflect.DeepEqual(transitive, []string{"team"}) {
+		t.Errorf("unexpected transitive tag keys %v", transitive)
+	}
+
+	if tags, transitive = (Assertion{}).SessionTags(); tags != nil || transitive != nil {
+		t.Errorf("expected no session tags, got %v, %v", tags, transitive)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package saml
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+// XML namespaces of the SAML documents.
+const (
+	nsDSig      = "http://www.w3.org/2000/09/xmldsig#"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	maxXMLDocumentDepth = 64
+)
+
+var (
+	errSignatureMissing = errors.New("SAML: signature missing")
+	errSignatureInvalid = errors.New("SAML: signature verification failed")
+	errXMLTooDeep       = errors.New("SAML: XML document nested too deep")
+)
+
+// node is an XML element with namespace aware accessors.
+type node struct {
+	*etree.Element
+}
+
+// parseXML parses doc, refusing documents nested deeper than
+// maxXMLDocumentDepth.
+func parseXML(doc []byte) (node, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	depth := 0
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return node{}, err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			if depth++; depth > maxXMLDocumentDepth {
+				return node{}, errXMLTooDeep
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	tree := etree.NewDocument()
+	if err := tree.ReadFromBytes(doc); err != nil {
+		return node{}, err
+	}
+	if tree.Root() == nil {
+		return node{}, errors.New("SAML: empty XML document")
+	}
+	return node{tree.Root()}, nil
+}
+
+func (n node) is(ns, local string) bool {
+	return n.Element != nil && n.Tag == local && n.NamespaceURI() == ns
+}
+
+func (n node) attr(name string) string {
+	return n.SelectAttrValue(name, "")
+}
+
+func (n node) child(ns, local string) node {
+	for _, c := range n.ChildElements() {
+		if c := (node{c}); c.is(ns, local) {
+			return c
+		}
+	}
+	return node{}
+}
+
+func (n node) childrenNamed(ns, local string) (res []node) {
+	for _, c := range n.ChildElements() {
+		if c := (node{c}); c.is(ns, local) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func (n node) text() string {
+	return strings.TrimSpace(n.Text())
+}
+
+// verifySignature verifies the enveloped signature of n against the IdP
+// signing certificates and returns the signed content, which is the only
+// part of the document that can be trusted.
+func verifySignature(n node, certs []*x509.Certificate, now time.Time) (node, error) {
+	// Keep the namespaces n inherits from its ancestors.
+	ctx, err := etreeutils.NSBuildParentContext(n.Element)
+	if err != nil {
+		return node{}, err
+	}
+	detached, err := etreeutils.NSDetatch(ctx, n.Element)
+	if err != nil {
+		return node{}, err
+	}
+
+	// Try the certificates one by one, signatures need not name the
+	// certificate they were made with.
+	for _, cert := range certs {
+		v := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+			Roots: []*x509.Certificate{cert},
+		})
+		v.Clock = dsig.NewFakeClockAt(now)
+		signed, err := v.Validate(detached)
+		if err == nil {
+			return node{signed}, nil
+		}
+		if err == dsig.ErrMissingSignature {
+			return node{}, errSignatureMissing
+		}
+	}
+	return node{}, errSignatureInvalid
+}
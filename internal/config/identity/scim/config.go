@@ -0,0 +1,71 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package scim
+
+import (
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/minio/pkg/env"
+)
+
+// SCIM provisioning config and env variables
+const (
+	Token = "token"
+
+	EnvIdentitySCIMToken = "MINIO_IDENTITY_SCIM_TOKEN"
+
+	// Minimum length of the bearer token.
+	minTokenLength = 32
+)
+
+// DefaultKVS - default config for SCIM config
+var DefaultKVS = config.KVS{
+	config.KV{
+		Key:   Token,
+		Value: "",
+	},
+}
+
+// Config - SCIM provisioning configuration.
+type Config struct {
+	Enabled bool
+
+	// Token is the bearer token authenticating SCIM clients.
+	Token string
+}
+
+// Enabled returns if SCIM provisioning is enabled.
+func Enabled(kvs config.KVS) bool {
+	return kvs.Get(Token) != ""
+}
+
+// LookupConfig - lookup SCIM config from config, override with any ENVs.
+func LookupConfig(kvs config.KVS) (c Config, err error) {
+	if err = config.CheckValidKeys(config.IdentitySCIMSubSys, kvs, DefaultKVS); err != nil {
+		return c, err
+	}
+
+	c.Token = env.Get(EnvIdentitySCIMToken, kvs.Get(Token))
+	if c.Token == "" {
+		return c, nil
+	}
+	if len(c.Token) < minTokenLength {
+		return Config{}, config.Errorf("SCIM bearer token must be at least %d characters long", minTokenLength)
+	}
+	c.Enabled = true
+	return c, nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ratelimit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/dustin/go-humanize"
+)
+
+// Rate limit config constants.
+const (
+	AccessKey = "access_key"
+	Group     = "group"
+	Bucket    = "bucket"
+	Requests  = "requests"
+	Bandwidth = "bandwidth"
+	Burst     = "burst"
+
+	EnvRateLimitAccessKey = "MINIO_RATE_LIMIT_ACCESS_KEY"
+	EnvRateLimitGroup     = "MINIO_RATE_LIMIT_GROUP"
+	EnvRateLimitBucket    = "MINIO_RATE_LIMIT_BUCKET"
+	EnvRateLimitRequests  = "MINIO_RATE_LIMIT_REQUESTS"
+	EnvRateLimitBandwidth = "MINIO_RATE_LIMIT_BANDWIDTH"
+	EnvRateLimitBurst     = "MINIO_RATE_LIMIT_BURST"
+
+	// Any is the subject of a rule applying to each access key, group or
+	// bucket separately.
+	Any = "*"
+)
+
+// DefaultKVS - default KV config for rate limit rules
+var DefaultKVS = config.KVS{
+	config.KV{
+		Key:   AccessKey,
+		Value: "",
+	},
+	config.KV{
+		Key:   Group,
+		Value: "",
+	},
+	config.KV{
+		Key:   Bucket,
+		Value: "",
+	},
+	config.KV{
+		Key:   Requests,
+		Value: "",
+	},
+	config.KV{
+		Key:   Bandwidth,
+		Value: "",
+	},
+	config.KV{
+		Key:   Burst,
+		Value: "1s",
+	},
+}
+
+// RuleType is the type of the subject of a rule.
+type RuleType string
+
+// Rule types.
+const (
+	RuleAccessKey RuleType = AccessKey
+	RuleGroup     RuleType = Group
+	RuleBucket    RuleType = Bucket
+)
+
+// Rule limits the rate of the requests of an access key, of the members of a
+// group or on a bucket.
+type Rule struct {
+	// Name is the name of the rate_limit target of the rule.
+	Name string `json:"name"`
+
+	Type RuleType `json:"type"`
+	// Subject is the access key, group or bucket, or Any.
+	Subject string `json:"subject"`
+
+	// Requests is the number of requests per second, 0 for no limit.
+	Requests float64 `json:"requests,omitempty"`
+	// Bandwidth is the number of bytes per second received and sent, 0
+	// for no limit.
+	Bandwidth uint64 `json:"bandwidth,omitempty"`
+	// Burst is the duration of requests and bytes at the limits which are
+	// allowed at once after being idle.
+	Burst time.Duration `json:"burst"`
+}
+
+// Config - the rate limit rules of all rate_limit targets.
+type Config struct {
+	// Rules sorted by name.
+	Rules []Rule `json:"rules"`
+}
+
+// Enabled - returns whether a rule is configured.
+func (c Config) Enabled() bool {
+	return len(c.Rules) > 0
+}
+
+// LookupConfig - lookup the rate limit rules of all targets and override
+// with valid environment settings if any. A target without limits is
+// ignored.
+func LookupConfig(s config.Config) (c Config, err error) {
+	targets, err := s.GetAvailableTargets(config.RateLimitSubSys)
+	if err != nil {
+		return c, err
+	}
+
+	for _, name := range targets {
+		if err = config.CheckValidKeys(config.RateLimitSubSys, s[config.RateLimitSubSys][name], DefaultKVS); err != nil {
+			return c, err
+		}
+
+		getCfgVal := func(key string) string {
+			val, _ := s.ResolveConfigParam(config.RateLimitSubSys, name, key)
+			return val
+		}
+		rule, err := lookupRule(name, getCfgVal)
+		if err != nil {
+			if name != config.Default {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+			return c, err
+		}
+		if rule.Requests == 0 && rule.Bandwidth == 0 {
+			continue
+		}
+		c.Rules = append(c.Rules, rule)
+	}
+
+	sort.Slice(c.Rules, func(i, j int) bool {
+		return c.Rules[i].Name < c.Rules[j].Name
+	})
+	return c, nil
+}
+
+func lookupRule(name string, getCfgVal func(key string) string) (r Rule, err error) {
+	r.Name = name
+
+	if v := getCfgVal(Requests); v != "" {
+		r.Requests, err = strconv.ParseFloat(v, 64)
+		if err != nil || r.Requests < 0 {
+			return r, config.Errorf("invalid 'requests' value %q, expected a positive number of requests per second", v)
+		}
+	}
+	if v := getCfgVal(Bandwidth); v != "" {
+		r.Bandwidth, err = humanize.ParseBytes(v)
+		if err != nil {
+			return r, config.Errorf("invalid 'bandwidth' value %q, expected a number of bytes per second such as 10MiB", v)
+		}
+	}
+	if r.Requests == 0 && r.Bandwidth == 0 {
+		return r, nil
+	}
+
+	r.Burst, err = time.ParseDuration(getCfgVal(Burst))
+	if err != nil || r.Burst <= 0 {
+		return r, config.Errorf("invalid 'burst' value %q, expected a positive duration", getCfgVal(Burst))
+	}
+
+	for _, t := range []RuleType{RuleAccessKey, RuleGroup, RuleBucket} {
+		v := getCfgVal(string(t))
+		if v == "" {
+			continue
+		}
+		if r.Type != "" {
+			return r, config.Errorf("only one of 'access_key', 'group' or 'bucket' may be set")
+		}
+		r.Type, r.Subject = t, v
+	}
+	if r.Type == "" {
+		return r, config.Errorf("one of 'access_key', 'group' or 'bucket' is required")
+	}
+	return r, nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookupRule(t *testing.T) {
+	testCases := []struct {
+		kvs     map[string]string
+		rule    Rule
+		success bool
+	}{
+		{
+			kvs:     map[string]string{Burst: "1s"},
+			rule:    Rule{Name: "r"},
+			success: true,
+		},
+		{
+			kvs:     map[string]string{AccessKey: "alice", Requests: "10.5", Burst: "1s"},
+			rule:    Rule{Name: "r", Type: RuleAccessKey, Subject: "alice", Requests: 10.5, Burst: time.Second},
+			success: true,
+		},
+		{
+			kvs:     map[string]string{Bucket: Any, Bandwidth: "1MiB", Burst: "10s"},
+			rule:    Rule{Name: "r", Type: RuleBucket, Subject: Any, Bandwidth: 1 << 20, Burst: 10 * time.Second},
+			success: true,
+		},
+		{
+			kvs:     map[string]string{Group: "finance", Requests: "1", Bandwidth: "1000", Burst: "1m"},
+			rule:    Rule{Name: "r", Type: RuleGroup, Subject: "finance", Requests: 1, Bandwidth: 1000, Burst: time.Minute},
+			success: true,
+		},
+		// No subject.
+		{kvs: map[string]string{Requests: "10", Burst: "1s"}},
+		// Several subjects.
+		{kvs: map[string]string{AccessKey: "alice", Bucket: "b", Requests: "10", Burst: "1s"}},
+		// Invalid limits.
+		{kvs: map[string]string{AccessKey: "alice", Requests: "-1", Burst: "1s"}},
+		{kvs: map[string]string{AccessKey: "alice", Requests: "many", Burst: "1s"}},
+		{kvs: map[string]string{AccessKey: "alice", Bandwidth: "fast", Burst: "1s"}},
+		{kvs: map[string]string{AccessKey: "alice", Requests: "10", Burst: "0s"}},
+	}
+	for i, tc := range testCases {
+		rule, err := lookupRule("r", func(key string) string { return tc.kvs[key] })
+		if tc.success != (err == nil) {
+			t.Errorf("case %d: expected success %t, got error %v", i+1, tc.success, err)
+			continue
+		}
+		if tc.success && rule != tc.rule {
+			t.Errorf("case %d: expected %+v, got %+v", i+1, tc.rule, rule)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ratelimit
+
+import "github.com/GuinsooLab/annastore/internal/config"
+
+// Help template for rate limit rules.
+var (
+	defaultHelpPostfix = func(key string) string {
+		return config.DefaultHelpPostfix(DefaultKVS, key)
+	}
+
+	// Help provides help for config values
+	Help = config.HelpKVS{
+		config.HelpKV{
+			Key:         AccessKey,
+			Description: `limit the requests of this access key, including its service accounts and temporary credentials, "*" limits each access key separately`,
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         Group,
+			Description: `limit the requests of all the members of this group together, "*" limits each group separately`,
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         Bucket,
+			Description: `limit the requests on this bucket, "*" limits each bucket separately`,
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         Requests,
+			Description: `maximum number of requests per second on each node, e.g. "100"`,
+			Optional:    true,
+			Type:        "number",
+		},
+		config.HelpKV{
+			Key:         Bandwidth,
+			Description: `maximum number of bytes per second received and sent on each node, e.g. "100MiB"`,
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         Burst,
+			Description: `duration of requests and bytes at the limits allowed at once after being idle` + defaultHelpPostfix(Burst),
+			Optional:    true,
+			Type:        "duration",
+		},
+	}
+)
@@ -44,5 +44,17 @@ var (
 			Optional:    true,
 			Type:        "duration",
 		},
+		config.HelpKV{
+			Key:         ExcessVersions,
+			Description: `alert when an object has this many versions, 0 to disable` + defaultHelpPostfix(ExcessVersions),
+			Optional:    true,
+			Type:        "int",
+		},
+		config.HelpKV{
+			Key:         ExcessVersionsSize,
+			Description: `alert when the versions of an object total this size, 0 to disable` + defaultHelpPostfix(ExcessVersionsSize),
+			Optional:    true,
+			Type:        "size",
+		},
 	}
 )
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/dustin/go-humanize"
 	"github.com/minio/pkg/env"
 )
 
@@ -31,11 +32,17 @@ const (
 	MaxWait = "max_wait"
 	Cycle   = "cycle"
 
+	ExcessVersions     = "alert_excess_versions"
+	ExcessVersionsSize = "alert_excess_versions_size"
+
 	EnvDelay         = "MINIO_SCANNER_DELAY"
 	EnvCycle         = "MINIO_SCANNER_CYCLE"
 	EnvDelayLegacy   = "MINIO_CRAWLER_DELAY"
 	EnvMaxWait       = "MINIO_SCANNER_MAX_WAIT"
 	EnvMaxWaitLegacy = "MINIO_CRAWLER_MAX_WAIT"
+
+	EnvExcessVersions     = "MINIO_SCANNER_ALERT_EXCESS_VERSIONS"
+	EnvExcessVersionsSize = "MINIO_SCANNER_ALERT_EXCESS_VERSIONS_SIZE"
 )
 
 // Config represents the heal settings.
@@ -46,6 +53,12 @@ type Config struct {
 	MaxWait time.Duration
 	// Cycle is the time.Duration between each scanner cycles
 	Cycle time.Duration
+	// ExcessVersions is the number of versions of an object
+	// from which an alert is raised, 0 to disable.
+	ExcessVersions uint64
+	// ExcessVersionsSize is the total size of the versions of an
+	// object from which an alert is raised, 0 to disable.
+	ExcessVersionsSize uint64
 }
 
 // DefaultKVS - default KV config for heal settings
@@ -62,6 +75,14 @@ var DefaultKVS = config.KVS{
 		Key:   Cycle,
 		Value: "1m",
 	},
+	config.KV{
+		Key:   ExcessVersions,
+		Value: "100",
+	},
+	config.KV{
+		Key:   ExcessVersionsSize,
+		Value: "1TiB",
+	},
 }
 
 // LookupConfig - lookup config and override with valid environment settings if any.
@@ -90,5 +111,14 @@ func LookupConfig(kvs config.KVS) (cfg Config, err error) {
 	if err != nil {
 		return cfg, err
 	}
+
+	cfg.ExcessVersions, err = strconv.ParseUint(env.Get(EnvExcessVersions, kvs.GetWithDefault(ExcessVersions, DefaultKVS)), 10, 64)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.ExcessVersionsSize, err = humanize.ParseBytes(env.Get(EnvExcessVersionsSize, kvs.GetWithDefault(ExcessVersionsSize, DefaultKVS)))
+	if err != nil {
+		return cfg, err
+	}
 	return cfg, nil
 }
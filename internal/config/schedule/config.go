@@ -0,0 +1,295 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+)
+
+// Schedule config constants.
+const (
+	Tasks = "tasks"
+	Days  = "days"
+	Start = "start"
+	End   = "end"
+	Speed = "speed"
+
+	EnvScheduleTasks = "MINIO_SCHEDULE_TASKS"
+	EnvScheduleDays  = "MINIO_SCHEDULE_DAYS"
+	EnvScheduleStart = "MINIO_SCHEDULE_START"
+	EnvScheduleEnd   = "MINIO_SCHEDULE_END"
+	EnvScheduleSpeed = "MINIO_SCHEDULE_SPEED"
+)
+
+// DefaultKVS - default KV config for scheduled windows
+var DefaultKVS = config.KVS{
+	config.KV{
+		Key:   Tasks,
+		Value: "",
+	},
+	config.KV{
+		Key:   Days,
+		Value: "*",
+	},
+	config.KV{
+		Key:   Start,
+		Value: "00:00",
+	},
+	config.KV{
+		Key:   End,
+		Value: "00:00",
+	},
+	config.KV{
+		Key:   Speed,
+		Value: string(SpeedDefault),
+	},
+}
+
+// Task is a background task which speed is scheduled.
+type Task string
+
+// Scheduled background tasks.
+const (
+	TaskScanner     Task = "scanner"
+	TaskHeal        Task = "heal"
+	TaskTransition  Task = "transition"
+	TaskReplication Task = "replication"
+)
+
+// AllTasks are the background tasks which speed can be scheduled.
+var AllTasks = []Task{TaskScanner, TaskHeal, TaskTransition, TaskReplication}
+
+// SpeedPreset is the speed of a background task during a window.
+type SpeedPreset string
+
+// Speed presets, from stopped to unthrottled.
+const (
+	SpeedPaused  SpeedPreset = "paused"
+	SpeedSlowest SpeedPreset = "slowest"
+	SpeedSlow    SpeedPreset = "slow"
+	// SpeedDefault runs the task with its configured throttles.
+	SpeedDefault SpeedPreset = "default"
+	SpeedFast    SpeedPreset = "fast"
+	SpeedFastest SpeedPreset = "fastest"
+)
+
+func parseSpeed(s string) (SpeedPreset, error) {
+	switch sp := SpeedPreset(s); sp {
+	case SpeedPaused, SpeedSlowest, SpeedSlow, SpeedDefault, SpeedFast, SpeedFastest:
+		return sp, nil
+	}
+	return "", config.Errorf("invalid 'speed' value %q, expected one of paused, slowest, slow, default, fast or fastest", s)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseDays parses "*" or a comma separated list of days and day ranges
+// such as "mon-fri,sun".
+func parseDays(s string) (days [7]bool, err error) {
+	if s == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return days, config.Errorf("invalid day %q in 'days', expected * or days such as mon-fri,sun", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return days, config.Errorf("invalid day %q in 'days', expected * or days such as mon-fri,sun", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseTimeOfDay parses HH:MM into the duration since midnight.
+func parseTimeOfDay(key, s string) (time.Duration, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	h, herr := strconv.Atoi(hh)
+	m, merr := strconv.Atoi(mm)
+	if !ok || herr != nil || merr != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, config.Errorf("invalid '%s' value %q, expected a time of day such as 22:00", key, s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Window runs background tasks at a speed during some hours of some days,
+// in the local time of the server.
+type Window struct {
+	// Name is the name of the schedule target of the window.
+	Name  string      `json:"name"`
+	Tasks []Task      `json:"tasks"`
+	Days  string      `json:"days"`
+	Start string      `json:"start"`
+	End   string      `json:"end"`
+	Speed SpeedPreset `json:"speed"`
+
+	days [7]bool
+	// start and end are durations since midnight, a window ending
+	// before it starts ends on the next day and a window ending when
+	// it starts lasts all day.
+	start, end time.Duration
+}
+
+// Has returns true if the window applies to task.
+func (w Window) Has(task Task) bool {
+	for _, t := range w.Tasks {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns true if t is within the window.
+func (w Window) Active(t time.Time) bool {
+	y, m, d := t.Date()
+	tod := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	wd := t.Weekday()
+	switch {
+	case w.start == w.end:
+		return w.days[wd]
+	case w.start < w.end:
+		return w.days[wd] && tod >= w.start && tod < w.end
+	default:
+		// The window started the day before.
+		return (w.days[wd] && tod >= w.start) || (w.days[(wd+6)%7] && tod < w.end)
+	}
+}
+
+// Config - the scheduled windows of all schedule targets.
+type Config struct {
+	// Windows sorted by name.
+	Windows []Window `json:"windows"`
+}
+
+// Active returns the first window by name applying to task at t.
+func (c Config) Active(task Task, t time.Time) (Window, bool) {
+	for _, w := range c.Windows {
+		if w.Has(task) && w.Active(t) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// LookupConfig - lookup the scheduled windows of all targets and override
+// with valid environment settings if any. A target without tasks is
+// ignored.
+func LookupConfig(s config.Config) (c Config, err error) {
+	targets, err := s.GetAvailableTargets(config.ScheduleSubSys)
+	if err != nil {
+		return c, err
+	}
+
+	for _, name := range targets {
+		if err = config.CheckValidKeys(config.ScheduleSubSys, s[config.ScheduleSubSys][name], DefaultKVS); err != nil {
+			return c, err
+		}
+
+		getCfgVal := func(key string) string {
+			val, _ := s.ResolveConfigParam(config.ScheduleSubSys, name, key)
+			return val
+		}
+		w, err := lookupWindow(name, getCfgVal)
+		if err != nil {
+			if name != config.Default {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+			return c, err
+		}
+		if len(w.Tasks) == 0 {
+			continue
+		}
+		c.Windows = append(c.Windows, w)
+	}
+
+	sort.Slice(c.Windows, func(i, j int) bool {
+		return c.Windows[i].Name < c.Windows[j].Name
+	})
+	return c, nil
+}
+
+func lookupWindow(name string, getCfgVal func(key string) string) (w Window, err error) {
+	w.Name = name
+
+	if v := getCfgVal(Tasks); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			task := Task(strings.TrimSpace(t))
+			switch task {
+			case TaskScanner, TaskHeal, TaskTransition, TaskReplication:
+			default:
+				return w, config.Errorf("invalid task %q in 'tasks', expected scanner, heal, transition or replication", t)
+			}
+			if !w.Has(task) {
+				w.Tasks = append(w.Tasks, task)
+			}
+		}
+	}
+	if len(w.Tasks) == 0 {
+		return w, nil
+	}
+
+	w.Days, w.Start, w.End = getCfgVal(Days), getCfgVal(Start), getCfgVal(End)
+	if w.days, err = parseDays(w.Days); err != nil {
+		return w, err
+	}
+	if w.start, err = parseTimeOfDay(Start, w.Start); err != nil {
+		return w, err
+	}
+	if w.end, err = parseTimeOfDay(End, w.End); err != nil {
+		return w, err
+	}
+	if w.start == 24*time.Hour {
+		w.start = 0
+	}
+	if w.end == 24*time.Hour {
+		w.end = 0
+	}
+	if w.Speed, err = parseSpeed(getCfgVal(Speed)); err != nil {
+		return w, err
+	}
+	return w, nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookupWindow(t *testing.T) {
+	testCases := []struct {
+		kvs     map[string]string
+		success bool
+	}{
+		{kvs: map[string]string{}, success: true},
+		{kvs: map[string]string{Tasks: "scanner,heal", Days: "*", Start: "22:00", End: "06:00", Speed: "fast"}, success: true},
+		{kvs: map[string]string{Tasks: "transition", Days: "mon-fri,sun", Start: "09:00", End: "18:00", Speed: "paused"}, success: true},
+		{kvs: map[string]string{Tasks: "replication", Days: "fri-mon", Start: "00:00", End: "24:00", Speed: "slowest"}, success: true},
+		// Invalid tasks.
+		{kvs: map[string]string{Tasks: "lifecycle", Days: "*", Start: "00:00", End: "00:00", Speed: "fast"}},
+		// Invalid days.
+		{kvs: map[string]string{Tasks: "heal", Days: "weekend", Start: "00:00", End: "00:00", Speed: "fast"}},
+		{kvs: map[string]string{Tasks: "heal", Days: "mon-", Start: "00:00", End: "00:00", Speed: "fast"}},
+		// Invalid times.
+		{kvs: map[string]string{Tasks: "heal", Days: "*", Start: "25:00", End: "00:00", Speed: "fast"}},
+		{kvs: map[string]string{Tasks: "heal", Days: "*", Start: "10", End: "00:00", Speed: "fast"}},
+		{kvs: map[string]string{Tasks: "heal", Days: "*", Start: "00:00", End: "12:60", Speed: "fast"}},
+		// Invalid speed.
+		{kvs: map[string]string{Tasks: "heal", Days: "*", Start: "00:00", End: "00:00", Speed: "turbo"}},
+	}
+	for i, tc := range testCases {
+		_, err := lookupWindow("w", func(key string) string { return tc.kvs[key] })
+		if tc.success != (err == nil) {
+			t.Errorf("case %d: expected success %t, got error %v", i+1, tc.success, err)
+		}
+	}
+}
+
+func TestWindowActive(t *testing.T) {
+	// 2022-10-17 is a Monday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2022, 10, 17+day, hour, min, 0, 0, time.UTC)
+	}
+	testCases := []struct {
+		days, start, end string
+		t                time.Time
+		active           bool
+	}{
+		{"*", "00:00", "00:00", at(0, 12, 0), true},
+		{"sat,sun", "00:00", "00:00", at(0, 12, 0), false},
+		{"sat,sun", "00:00", "00:00", at(6, 12, 0), true},
+		{"mon-fri", "09:00", "18:00", at(0, 9, 0), true},
+		{"mon-fri", "09:00", "18:00", at(0, 18, 0), false},
+		{"mon-fri", "09:00", "18:00", at(5, 12, 0), false},
+		{"fri-mon", "09:00", "18:00", at(6, 12, 0), true},
+		{"fri-mon", "09:00", "18:00", at(1, 12, 0), false},
+		// Windows over midnight started the day before.
+		{"mon", "22:00", "06:00", at(0, 23, 0), true},
+		{"mon", "22:00", "06:00", at(1, 5, 59), true},
+		{"mon", "22:00", "06:00", at(0, 5, 0), false},
+		{"mon", "22:00", "06:00", at(1, 23, 0), false},
+	}
+	for i, tc := range testCases {
+		kvs := map[string]string{Tasks: "scanner", Days: tc.days, Start: tc.start, End: tc.end, Speed: "fast"}
+		w, err := lookupWindow("w", func(key string) string { return kvs[key] })
+		if err != nil {
+			t.Fatalf("case %d: %v", i+1, err)
+		}
+		if active := w.Active(tc.t); active != tc.active {
+			t.Errorf("case %d: expected active %t, got %t", i+1, tc.active, active)
+		}
+	}
+}
+
+func TestConfigActive(t *testing.T) {
+	windows := []map[string]string{
+		{Tasks: "scanner,heal", Days: "*", Start: "22:00", End: "06:00", Speed: "fast"},
+		{Tasks: "scanner", Days: "*", Start: "00:00", End: "00:00", Speed: "slow"},
+	}
+	var c Config
+	for i, kvs := range windows {
+		kvs := kvs
+		w, err := lookupWindow(string(rune('a'+i)), func(key string) string { return kvs[key] })
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Windows = append(c.Windows, w)
+	}
+
+	night := time.Date(2022, 10, 17, 23, 0, 0, 0, time.UTC)
+	day := time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC)
+	if w, ok := c.Active(TaskScanner, night); !ok || w.Name != "a" || w.Speed != SpeedFast {
+		t.Errorf("expected window a, got %+v", w)
+	}
+	if w, ok := c.Active(TaskScanner, day); !ok || w.Name != "b" || w.Speed != SpeedSlow {
+		t.Errorf("expected window b, got %+v", w)
+	}
+	if w, ok := c.Active(TaskHeal, day); ok {
+		t.Errorf("expected no window, got %+v", w)
+	}
+	if _, ok := c.Active(TaskTransition, night); ok {
+		t.Error("expected no window for transitions")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package schedule
+
+import "github.com/GuinsooLab/annastore/internal/config"
+
+// Help template for scheduled windows.
+var (
+	defaultHelpPostfix = func(key string) string {
+		return config.DefaultHelpPostfix(DefaultKVS, key)
+	}
+
+	// Help provides help for config values
+	Help = config.HelpKVS{
+		config.HelpKV{
+			Key:         Tasks,
+			Description: `comma separated background tasks run at 'speed' during the window: scanner, heal, transition, replication`,
+			Type:        "csv",
+		},
+		config.HelpKV{
+			Key:         Days,
+			Description: `days of the window such as "mon-fri" or "sat,sun", "*" for every day` + defaultHelpPostfix(Days),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         Start,
+			Description: `local time the window starts at such as "22:00"` + defaultHelpPostfix(Start),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         End,
+			Description: `local time the window ends at, on the next day if before 'start', the window lasts all day if equal to 'start'` + defaultHelpPostfix(End),
+			Optional:    true,
+			Type:        "string",
+		},
+		config.HelpKV{
+			Key:         Speed,
+			Description: `speed of the tasks during the window: paused, slowest, slow, default, fast or fastest` + defaultHelpPostfix(Speed),
+			Optional:    true,
+			Type:        "paused|slowest|slow|default|fast|fastest",
+		},
+	}
+)
@@ -0,0 +1,168 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storageclass
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GuinsooLab/annastore/internal/config"
+	"github.com/dustin/go-humanize"
+)
+
+// Custom storage class config constants.
+const (
+	CustomParity          = "parity"
+	CustomPools           = "pools"
+	CustomInlineThreshold = "inline_threshold"
+
+	EnvCustomParity          = "MINIO_STORAGE_CLASS_CUSTOM_PARITY"
+	EnvCustomPools           = "MINIO_STORAGE_CLASS_CUSTOM_POOLS"
+	EnvCustomInlineThreshold = "MINIO_STORAGE_CLASS_CUSTOM_INLINE_THRESHOLD"
+
+	// MaxInlineThreshold is the largest inline threshold, inlined data
+	// is read along with xl.meta on every access of the object.
+	MaxInlineThreshold = humanize.MiByte
+)
+
+// CustomDefaultKVS - default KV config for custom storage classes
+var CustomDefaultKVS = config.KVS{
+	config.KV{
+		Key:   CustomParity,
+		Value: "",
+	},
+	config.KV{
+		Key:   CustomPools,
+		Value: "",
+	},
+	config.KV{
+		Key:   CustomInlineThreshold,
+		Value: "",
+	},
+}
+
+var customNameRegex = regexp.MustCompile("^[A-Z0-9_]+$")
+
+// CustomClass - a storage class defined by the administrator in addition to
+// STANDARD and REDUCED_REDUNDANCY.
+type CustomClass struct {
+	// Name is the upper cased name of the storage_class_custom target,
+	// as used in the x-amz-storage-class header.
+	Name string `json:"name"`
+
+	Parity int `json:"parity"`
+	// Pools are the indexes of the pools new objects are preferably
+	// placed on, all pools when empty.
+	Pools []int `json:"pools,omitempty"`
+	// InlineThreshold is the shard size below which object data is
+	// inlined in the metadata, the server default when negative.
+	InlineThreshold int64 `json:"inlineThreshold"`
+}
+
+// LookupCustomConfig - lookup the custom storage classes of all
+// storage_class_custom targets and override with valid environment settings
+// if any. The default target does not define a storage class.
+func LookupCustomConfig(s config.Config, setDriveCounts []int) (classes map[string]CustomClass, err error) {
+	targets, err := s.GetAvailableTargets(config.StorageClassCustomSubSys)
+	if err != nil {
+		return nil, err
+	}
+
+	classes = make(map[string]CustomClass, len(targets))
+	for _, target := range targets {
+		if err = config.CheckValidKeys(config.StorageClassCustomSubSys, s[config.StorageClassCustomSubSys][target], CustomDefaultKVS); err != nil {
+			return nil, err
+		}
+
+		getCfgVal := func(key string) string {
+			val, _ := s.ResolveConfigParam(config.StorageClassCustomSubSys, target, key)
+			return val
+		}
+		if target == config.Default {
+			if getCfgVal(CustomParity) != "" {
+				return nil, config.Errorf("a storage class name is required, use 'storage_class_custom:<name>'")
+			}
+			continue
+		}
+
+		class, err := lookupCustomClass(target, getCfgVal, setDriveCounts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", target, err)
+		}
+		if _, ok := classes[class.Name]; ok {
+			return nil, config.Errorf("storage class %s is defined more than once", class.Name)
+		}
+		classes[class.Name] = class
+	}
+	return classes, nil
+}
+
+func lookupCustomClass(target string, getCfgVal func(key string) string, setDriveCounts []int) (c CustomClass, err error) {
+	c.Name = strings.ToUpper(target)
+	if !customNameRegex.MatchString(c.Name) {
+		return c, config.Errorf("invalid storage class name %q, only letters, digits and '_' are allowed", target)
+	}
+	if c.Name == STANDARD || c.Name == RRS {
+		return c, config.Errorf("storage class name %s is reserved", c.Name)
+	}
+
+	v := getCfgVal(CustomParity)
+	if v == "" {
+		return c, config.Errorf("'parity' is required")
+	}
+	sc, err := parseStorageClass(v)
+	if err != nil {
+		return c, err
+	}
+	c.Parity = sc.Parity
+
+	if v = getCfgVal(CustomPools); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			idx, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || idx < 0 || idx >= len(setDriveCounts) {
+				return c, config.Errorf("invalid 'pools' value %q, expected a comma separated list of pool indexes from 0 to %d", v, len(setDriveCounts)-1)
+			}
+			c.Pools = append(c.Pools, idx)
+		}
+		sort.Ints(c.Pools)
+	}
+
+	// Objects of the class may end up on any pool once the preferred
+	// pools are full, so the parity has to fit all of them.
+	for _, setDriveCount := range setDriveCounts {
+		if c.Parity > setDriveCount/2 {
+			return c, config.Errorf("storage class %s parity %d should be less than or equal to %d", c.Name, c.Parity, setDriveCount/2)
+		}
+	}
+
+	c.InlineThreshold = -1
+	if v = getCfgVal(CustomInlineThreshold); v != "" {
+		threshold, err := humanize.ParseBytes(v)
+		if err != nil {
+			return c, config.Errorf("invalid 'inline_threshold' value %q, expected a size such as 64KiB", v)
+		}
+		if threshold > MaxInlineThreshold {
+			return c, config.Errorf("invalid 'inline_threshold' value %q, should be at most %s", v, humanize.IBytes(MaxInlineThreshold))
+		}
+		c.InlineThreshold = int64(threshold)
+	}
+	return c, nil
+}
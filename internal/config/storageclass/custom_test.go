@@ -0,0 +1,102 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storageclass
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupCustomClass(t *testing.T) {
+	setDriveCounts := []int{16, 8}
+	tests := []struct {
+		target  string
+		kvs     map[string]string
+		want    CustomClass
+		wantErr bool
+	}{
+		{
+			target: "logs",
+			kvs:    map[string]string{CustomParity: "EC:1"},
+			want:   CustomClass{Name: "LOGS", Parity: 1, InlineThreshold: -1},
+		},
+		{
+			target: "contracts",
+			kvs:    map[string]string{CustomParity: "EC:4", CustomPools: "1, 0", CustomInlineThreshold: "64KiB"},
+			want:   CustomClass{Name: "CONTRACTS", Parity: 4, Pools: []int{0, 1}, InlineThreshold: 64 << 10},
+		},
+		{
+			target: "cold",
+			kvs:    map[string]string{CustomParity: "EC:2", CustomInlineThreshold: "0"},
+			want:   CustomClass{Name: "COLD", Parity: 2, InlineThreshold: 0},
+		},
+		// parity is required
+		{target: "logs", kvs: map[string]string{}, wantErr: true},
+		// parity must fit the smallest erasure set
+		{target: "logs", kvs: map[string]string{CustomParity: "EC:5"}, wantErr: true},
+		// reserved names
+		{target: "standard", kvs: map[string]string{CustomParity: "EC:2"}, wantErr: true},
+		{target: "reduced_redundancy", kvs: map[string]string{CustomParity: "EC:2"}, wantErr: true},
+		{target: "logs-1", kvs: map[string]string{CustomParity: "EC:2"}, wantErr: true},
+		// unknown pool
+		{target: "logs", kvs: map[string]string{CustomParity: "EC:2", CustomPools: "2"}, wantErr: true},
+		{target: "logs", kvs: map[string]string{CustomParity: "EC:2", CustomInlineThreshold: "lots"}, wantErr: true},
+		{target: "logs", kvs: map[string]string{CustomParity: "EC:2", CustomInlineThreshold: "2MiB"}, wantErr: true},
+	}
+	for i, test := range tests {
+		got, err := lookupCustomClass(test.target, func(key string) string { return test.kvs[key] }, setDriveCounts)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Test %d: expected an error, got %+v", i+1, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Test %d: expected %+v, got %+v", i+1, test.want, got)
+		}
+	}
+}
+
+func TestConfigCustomClass(t *testing.T) {
+	cfg := Config{
+		Standard: StorageClass{Parity: 4},
+		RRS:      StorageClass{Parity: 2},
+	}
+	cfg.UpdateCustom(map[string]CustomClass{
+		"LOGS": {Name: "LOGS", Parity: 1, Pools: []int{1}, InlineThreshold: -1},
+	})
+	if !cfg.IsValid("LOGS") || !cfg.IsValid(STANDARD) || cfg.IsValid("GLACIER") {
+		t.Fatal("unexpected storage class validity")
+	}
+	if p := cfg.GetParityForSC("LOGS"); p != 1 {
+		t.Fatalf("expected parity 1, got %d", p)
+	}
+	if p := cfg.GetParityForSC("GLACIER"); p != 4 {
+		t.Fatalf("expected standard parity 4, got %d", p)
+	}
+	if pools := cfg.GetPoolsForSC("LOGS"); !reflect.DeepEqual(pools, []int{1}) {
+		t.Fatalf("expected pools [1], got %v", pools)
+	}
+	if pools := cfg.GetPoolsForSC(STANDARD); pools != nil {
+		t.Fatalf("expected no pools, got %v", pools)
+	}
+}
@@ -46,3 +46,30 @@ var (
 		},
 	}
 )
+
+// CustomHelp template for custom storage classes.
+var CustomHelp = config.HelpKVS{
+	config.HelpKV{
+		Key:         CustomParity,
+		Description: `set the parity count of the storage class e.g. "EC:2"`,
+		Type:        "string",
+	},
+	config.HelpKV{
+		Key:         CustomPools,
+		Description: `comma separated list of the indexes of the pools preferred for new objects of the storage class, starting from 0`,
+		Optional:    true,
+		Type:        "csv",
+	},
+	config.HelpKV{
+		Key:         CustomInlineThreshold,
+		Description: `shard size below which object data of the storage class is inlined with its metadata e.g. "64KiB", "0" to never inline`,
+		Optional:    true,
+		Type:        "string",
+	},
+	config.HelpKV{
+		Key:         config.Comment,
+		Description: config.DefaultComment,
+		Optional:    true,
+		Type:        "sentence",
+	},
+}
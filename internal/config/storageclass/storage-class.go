@@ -20,6 +20,7 @@ package storageclass
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -82,6 +83,9 @@ var ConfigLock sync.RWMutex
 type Config struct {
 	Standard StorageClass `json:"standard"`
 	RRS      StorageClass `json:"rrs"`
+
+	// Custom storage classes by name.
+	Custom map[string]CustomClass `json:"custom,omitempty"`
 }
 
 // UnmarshalJSON - Validate SS and RRS parity when unmarshalling JSON.
@@ -101,6 +105,54 @@ func IsValid(sc string) bool {
 	return sc == RRS || sc == STANDARD
 }
 
+// IsValid - returns true if input string is STANDARD, REDUCED_REDUNDANCY
+// or a configured custom storage class.
+func (sCfg *Config) IsValid(sc string) bool {
+	if IsValid(sc) {
+		return true
+	}
+	_, ok := sCfg.GetCustom(sc)
+	return ok
+}
+
+// GetCustom - returns the custom storage class named sc, if configured.
+func (sCfg *Config) GetCustom(sc string) (CustomClass, bool) {
+	ConfigLock.RLock()
+	defer ConfigLock.RUnlock()
+	c, ok := sCfg.Custom[strings.TrimSpace(sc)]
+	return c, ok
+}
+
+// GetCustomClasses - returns all custom storage classes sorted by name.
+func (sCfg *Config) GetCustomClasses() []CustomClass {
+	ConfigLock.RLock()
+	defer ConfigLock.RUnlock()
+	classes := make([]CustomClass, 0, len(sCfg.Custom))
+	for _, c := range sCfg.Custom {
+		classes = append(classes, c)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i].Name < classes[j].Name
+	})
+	return classes
+}
+
+// GetPoolsForSC - returns the preferred pools of storage class sc, nil if
+// objects of the class may be placed on any pool.
+func (sCfg *Config) GetPoolsForSC(sc string) []int {
+	c, _ := sCfg.GetCustom(sc)
+	return c.Pools
+}
+
+// GetInlineThresholdForSC - returns the shard size below which object data
+// of storage class sc is inlined, negative for the server default.
+func (sCfg *Config) GetInlineThresholdForSC(sc string) int64 {
+	if c, ok := sCfg.GetCustom(sc); ok {
+		return c.InlineThreshold
+	}
+	return -1
+}
+
 // UnmarshalText unmarshals storage class from its textual form into
 // storageClass structure.
 func (sc *StorageClass) UnmarshalText(b []byte) error {
@@ -220,13 +272,17 @@ func validateParity(ssParity, rrsParity, setDriveCount int) (err error) {
 // -- if input is STANDARD but STANDARD is not configured '0' parity
 //    is returned, the caller is expected to choose the right parity
 //    at that point.
+// -- if input is a custom storage class its configured parity is returned.
 func (sCfg Config) GetParityForSC(sc string) (parity int) {
 	ConfigLock.RLock()
 	defer ConfigLock.RUnlock()
-	switch strings.TrimSpace(sc) {
+	switch sc = strings.TrimSpace(sc); sc {
 	case RRS:
 		return sCfg.RRS.Parity
 	default:
+		if c, ok := sCfg.Custom[sc]; ok {
+			return c.Parity
+		}
 		return sCfg.Standard.Parity
 	}
 }
@@ -239,6 +295,13 @@ func (sCfg *Config) Update(newCfg Config) {
 	sCfg.Standard = newCfg.Standard
 }
 
+// UpdateCustom update the custom storage classes with new config
+func (sCfg *Config) UpdateCustom(classes map[string]CustomClass) {
+	ConfigLock.Lock()
+	defer ConfigLock.Unlock()
+	sCfg.Custom = classes
+}
+
 // Enabled returns if etcd is enabled.
 func Enabled(kvs config.KVS) bool {
 	ssc := kvs.Get(ClassStandard)
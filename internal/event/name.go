@@ -57,6 +57,7 @@ const (
 	ObjectRestorePostCompleted
 	ObjectTransitionFailed
 	ObjectTransitionComplete
+	ObjectManyVersions
 
 	objectSingleTypesEnd
 	// Start Compound types that require expansion:
@@ -67,6 +68,7 @@ const (
 	ObjectReplicationAll
 	ObjectRestorePostAll
 	ObjectTransitionAll
+	ObjectScannerAll
 )
 
 // The number of single names should not exceed 64.
@@ -112,6 +114,10 @@ func (name Name) Expand() []Name {
 			ObjectTransitionFailed,
 			ObjectTransitionComplete,
 		}
+	case ObjectScannerAll:
+		return []Name{
+			ObjectManyVersions,
+		}
 	default:
 		return []Name{name}
 	}
@@ -193,6 +199,10 @@ func (name Name) String() string {
 		return "s3:ObjectTransition:Failed"
 	case ObjectTransitionComplete:
 		return "s3:ObjectTransition:Complete"
+	case ObjectScannerAll:
+		return "s3:Scanner:*"
+	case ObjectManyVersions:
+		return "s3:Scanner:ManyVersions"
 	}
 
 	return ""
@@ -305,6 +315,10 @@ func ParseName(s string) (Name, error) {
 		return ObjectTransitionComplete, nil
 	case "s3:ObjectTransition:*":
 		return ObjectTransitionAll, nil
+	case "s3:Scanner:*":
+		return ObjectScannerAll, nil
+	case "s3:Scanner:ManyVersions":
+		return ObjectManyVersions, nil
 	default:
 		return 0, &ErrInvalidEventName{s}
 	}
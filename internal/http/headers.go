@@ -144,6 +144,9 @@ const (
 	// Server-Status
 	MinIOServerStatus = "x-minio-server-status"
 
+	// Flags an object version which lost more shards than its parity
+	MinIOUnrecoverable = "x-minio-unrecoverable"
+
 	// Delete special flag to force delete a bucket or a prefix
 	MinIOForceDelete = "x-minio-force-delete"
 
@@ -190,12 +193,19 @@ const (
 
 	// MinIOCompressed is returned when object is compressed
 	MinIOCompressed = "X-Minio-Compressed"
+
+	// MinIOAsOf requests a point in time view of a versioned bucket, GET,
+	// HEAD and ListObjectsV2 resolve objects to their version at that time.
+	MinIOAsOf = "X-Minio-As-Of"
 )
 
 // Common http query params S3 API
 const (
 	VersionID = "versionId"
 
+	// AsOf is the query parameter equivalent of MinIOAsOf.
+	AsOf = "as-of"
+
 	PartNumber = "partNumber"
 
 	UploadID = "uploadId"
@@ -0,0 +1,142 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package smart
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Health - health indicators of a drive, as reported by its ATA SMART
+// attributes or NVMe SMART log.
+type Health struct {
+	Device string `json:"device"`
+	// Temperature in degrees Celsius, 0 if not reported.
+	Temperature int `json:"temperature"`
+	// ReallocatedSectors is the number of sectors remapped to spare
+	// sectors, not reported by NVMe drives.
+	ReallocatedSectors uint64 `json:"reallocatedSectors"`
+	// MediaErrors is the number of unrecovered data integrity errors.
+	MediaErrors uint64 `json:"mediaErrors"`
+	// WearLevel is the percentage of the rated endurance of the drive
+	// which has been used, -1 if not reported.
+	WearLevel int `json:"wearLevel"`
+	// Failing is set when the drive itself predicts its failure.
+	Failing bool `json:"failing"`
+}
+
+// ATA SMART attributes of interest.
+const (
+	ataAttrReallocatedSectors   = 5
+	ataAttrAirflowTemperature   = 190
+	ataAttrTemperature          = 194
+	ataAttrReportedUncorrect    = 187
+	ataAttrOfflineUncorrectable = 198
+	ataAttrWearLevelingCount    = 177
+	ataAttrLifetimeRemaining    = 202
+	ataAttrSSDLifeLeft          = 231
+	ataAttrMediaWearout         = 233
+
+	// Attribute flag of the pre-failure attributes.
+	ataAttrFlagPrefailure = 0x1
+
+	ataSMARTPageLen = 512
+	ataAttrLen      = 12
+	ataAttrCount    = 30
+)
+
+var errInvalidSMARTPage = errors.New("invalid SMART page")
+
+// ataHealth decodes the 512 bytes SMART READ DATA and SMART READ
+// THRESHOLDS pages of an ATA drive.
+func ataHealth(data, thresholds []byte) (h Health, err error) {
+	if len(data) < ataSMARTPageLen || len(thresholds) < ataSMARTPageLen {
+		return h, errInvalidSMARTPage
+	}
+
+	threshold := make(map[uint8]uint8, ataAttrCount)
+	for i := 0; i < ataAttrCount; i++ {
+		t := thresholds[2+i*ataAttrLen:]
+		if t[0] != 0 {
+			threshold[t[0]] = t[1]
+		}
+	}
+
+	h.WearLevel = -1
+	for i := 0; i < ataAttrCount; i++ {
+		a := data[2+i*ataAttrLen:]
+		id, flags, value := a[0], binary.LittleEndian.Uint16(a[1:3]), a[3]
+		if id == 0 {
+			continue
+		}
+		raw := uint64(a[5]) | uint64(a[6])<<8 | uint64(a[7])<<16 | uint64(a[8])<<24 | uint64(a[9])<<32 | uint64(a[10])<<40
+
+		switch id {
+		case ataAttrReallocatedSectors:
+			h.ReallocatedSectors = raw
+		case ataAttrTemperature:
+			h.Temperature = int(a[5])
+		case ataAttrAirflowTemperature:
+			if h.Temperature == 0 {
+				h.Temperature = int(a[5])
+			}
+		case ataAttrReportedUncorrect, ataAttrOfflineUncorrectable:
+			if raw > h.MediaErrors {
+				h.MediaErrors = raw
+			}
+		case ataAttrWearLevelingCount, ataAttrLifetimeRemaining, ataAttrSSDLifeLeft, ataAttrMediaWearout:
+			// The normalized value is the remaining life in percent.
+			if value <= 100 {
+				h.WearLevel = 100 - int(value)
+			}
+		}
+
+		if t, ok := threshold[id]; ok && t != 0 && flags&ataAttrFlagPrefailure != 0 && value <= t {
+			h.Failing = true
+		}
+	}
+	return h, nil
+}
+
+// NVMe critical warning bits.
+const (
+	nvmeWarnSpare       = 1 << 0
+	nvmeWarnReliability = 1 << 2
+	nvmeWarnReadOnly    = 1 << 3
+)
+
+// nvmeHealth decodes the SMART / health information log of an NVMe drive.
+func nvmeHealth(sl nvmeSMARTLog) (h Health) {
+	if kelvin := int(sl.Temperature[1])<<8 | int(sl.Temperature[0]); kelvin > 0 {
+		h.Temperature = kelvin - 273
+	}
+	h.MediaErrors = le128ToUint64(sl.MediaErrors)
+	h.WearLevel = int(sl.PercentUsed)
+	h.Failing = sl.CritWarning&(nvmeWarnSpare|nvmeWarnReliability|nvmeWarnReadOnly) != 0
+	return h
+}
+
+// le128ToUint64 converts a little-endian 128 bits counter, saturating at
+// math.MaxUint64.
+func le128ToUint64(buf [16]byte) uint64 {
+	if binary.LittleEndian.Uint64(buf[8:]) != 0 {
+		return math.MaxUint64
+	}
+	return binary.LittleEndian.Uint64(buf[:8])
+}
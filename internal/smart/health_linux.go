@@ -0,0 +1,170 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+//go:build linux
+// +build linux
+
+package smart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/dswarbrick/smart/ioctl"
+	"github.com/dswarbrick/smart/utils"
+	"golang.org/x/sys/unix"
+)
+
+// SCSI generic and ATA pass-through constants.
+const (
+	sgIO           = 0x2285
+	sgDxferFromDev = -3
+	sgInfoOkMask   = 0x1
+	sgTimeout      = 20000 // milliseconds
+
+	scsiATAPassThru16 = 0x85
+	ataSMART          = 0xb0
+
+	ataSMARTReadData       = 0xd0
+	ataSMARTReadThresholds = 0xd1
+)
+
+// SCSI generic ioctl header, sg_io_hdr_t in <scsi/sg.h>
+type sgIoHdr struct {
+	interfaceID    int32
+	dxferDirection int32
+	cmdLen         uint8
+	mxSbLen        uint8
+	iovecCount     uint16
+	dxferLen       uint32
+	dxferp         uintptr
+	cmdp           uintptr
+	sbp            uintptr
+	timeout        uint32
+	flags          uint32
+	packID         int32
+	usrPtr         uintptr
+	status         uint8
+	maskedStatus   uint8
+	msgStatus      uint8
+	sbLenWr        uint8
+	hostStatus     uint16
+	driverStatus   uint16
+	resid          int32
+	duration       uint32
+	info           uint32
+}
+
+// GetHealth - reads the health indicators of a NVMe or ATA drive, e.g.
+// `/dev/nvme0n1` or `/dev/sda`.
+func GetHealth(device string) (Health, error) {
+	if strings.HasPrefix(filepath.Base(device), "nvme") {
+		d := NewNVMeDevice(device)
+		if err := d.Open(); err != nil {
+			return Health{Device: device}, err
+		}
+		defer d.Close()
+
+		buf := make([]byte, 512)
+		if err := d.readLogPage(0x02, &buf); err != nil {
+			return Health{Device: device}, err
+		}
+		var sl nvmeSMARTLog
+		binary.Read(bytes.NewReader(buf), utils.NativeEndian, &sl)
+		h := nvmeHealth(sl)
+		h.Device = device
+		return h, nil
+	}
+
+	fd, err := unix.Open(device, unix.O_RDWR|unix.O_NONBLOCK, 0o600)
+	if err != nil {
+		return Health{Device: device}, err
+	}
+	defer unix.Close(fd)
+
+	data, err := ataSMARTCommand(fd, ataSMARTReadData)
+	if err != nil {
+		return Health{Device: device}, err
+	}
+	thresholds, err := ataSMARTCommand(fd, ataSMARTReadThresholds)
+	if err != nil {
+		return Health{Device: device}, err
+	}
+	h, err := ataHealth(data, thresholds)
+	h.Device = device
+	return h, err
+}
+
+// ataSMARTCommand sends an ATA SMART command reading a 512 bytes page
+// through SCSI-ATA translation.
+func ataSMARTCommand(fd int, feature uint8) ([]byte, error) {
+	buf := make([]byte, ataSMARTPageLen)
+	sense := make([]byte, 32)
+
+	cdb := [16]byte{scsiATAPassThru16}
+	cdb[1] = 0x08    // ATA protocol (4 << 1, PIO data-in)
+	cdb[2] = 0x0e    // BYT_BLOK = 1, T_LENGTH = 2, T_DIR = 1
+	cdb[4] = feature // feature LSB
+	cdb[6] = 0x01    // sector count
+	cdb[10] = 0x4f   // low lba_mid
+	cdb[12] = 0xc2   // low lba_high
+	cdb[14] = ataSMART
+
+	hdr := sgIoHdr{
+		interfaceID:    'S',
+		dxferDirection: sgDxferFromDev,
+		timeout:        sgTimeout,
+		cmdLen:         uint8(len(cdb)),
+		mxSbLen:        uint8(len(sense)),
+		dxferLen:       uint32(len(buf)),
+		dxferp:         uintptr(unsafe.Pointer(&buf[0])),
+		cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
+		sbp:            uintptr(unsafe.Pointer(&sense[0])),
+	}
+	if err := ioctl.Ioctl(uintptr(fd), sgIO, uintptr(unsafe.Pointer(&hdr))); err != nil {
+		return nil, err
+	}
+	if hdr.info&sgInfoOkMask != 0 {
+		return nil, fmt.Errorf("SMART command %#x failed, SCSI status: %#02x, host status: %#02x, driver status: %#02x",
+			feature, hdr.status, hdr.hostStatus, hdr.driverStatus)
+	}
+	return buf, nil
+}
+
+// DeviceOf - returns the block device of the drive holding path, the whole
+// drive when path is on a partition.
+func DeviceOf(path string) (string, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return "", err
+	}
+	//nolint:unconvert
+	dev := uint64(st.Dev)
+	target, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(dev), unix.Minor(dev)))
+	if err != nil {
+		return "", err
+	}
+	if _, err = os.Stat(filepath.Join(target, "partition")); err == nil {
+		target = filepath.Dir(target)
+	}
+	return "/dev/" + filepath.Base(target), nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !linux
+// +build !linux
+
+package smart
+
+import "errors"
+
+var errUnsupported = errors.New("drive health is only supported on Linux")
+
+// GetHealth - reads the health indicators of a NVMe or ATA drive.
+func GetHealth(device string) (Health, error) {
+	return Health{Device: device}, errUnsupported
+}
+
+// DeviceOf - returns the block device of the drive holding path.
+func DeviceOf(path string) (string, error) {
+	return "", errUnsupported
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2022 GuinsooLab
+//
+// This file is part of GuinsooLab stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package smart
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestATAHealth(t *testing.T) {
+	data := make([]byte, ataSMARTPageLen)
+	thresholds := make([]byte, ataSMARTPageLen)
+	attr := func(i int, id uint8, flags uint16, value uint8, raw uint64, threshold uint8) {
+		a := data[2+i*ataAttrLen:]
+		a[0] = id
+		binary.LittleEndian.PutUint16(a[1:3], flags)
+		a[3], a[4] = value, value
+		for b := 0; b < 6; b++ {
+			a[5+b] = byte(raw >> (8 * b))
+		}
+		th := thresholds[2+i*ataAttrLen:]
+		th[0], th[1] = id, threshold
+	}
+	attr(0, ataAttrReallocatedSectors, ataAttrFlagPrefailure, 100, 12, 10)
+	attr(1, ataAttrTemperature, 0, 60, 40|55<<16, 0)
+	attr(2, ataAttrReportedUncorrect, 0, 100, 3, 0)
+	attr(3, ataAttrOfflineUncorrectable, 0, 100, 7, 0)
+	attr(4, ataAttrWearLevelingCount, ataAttrFlagPrefailure, 85, 400, 5)
+
+	h, err := ataHealth(data, thresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Health{Temperature: 40, ReallocatedSectors: 12, MediaErrors: 7, WearLevel: 15}
+	if h != want {
+		t.Fatalf("expected %+v, got %+v", want, h)
+	}
+
+	// A pre-failure attribute at its threshold predicts a failure.
+	attr(0, ataAttrReallocatedSectors, ataAttrFlagPrefailure, 10, 2000, 10)
+	if h, _ = ataHealth(data, thresholds); !h.Failing {
+		t.Fatalf("expected a failing drive, got %+v", h)
+	}
+
+	if _, err = ataHealth(data[:100], thresholds); err == nil {
+		t.Fatal("expected an error for a short page")
+	}
+}
+
+func TestNVMeHealth(t *testing.T) {
+	var sl nvmeSMARTLog
+	binary.LittleEndian.PutUint16(sl.Temperature[:], 273+45)
+	sl.PercentUsed = 12
+	sl.MediaErrors[0] = 2
+
+	want := Health{Temperature: 45, MediaErrors: 2, WearLevel: 12}
+	if h := nvmeHealth(sl); h != want {
+		t.Fatalf("expected %+v, got %+v", want, h)
+	}
+
+	sl.CritWarning = nvmeWarnReliability
+	if h := nvmeHealth(sl); !h.Failing {
+		t.Fatalf("expected a failing drive, got %+v", h)
+	}
+
+	sl.MediaErrors[15] = 1
+	if h := nvmeHealth(sl); h.MediaErrors != 1<<64-1 {
+		t.Fatalf("expected a saturated media errors count, got %d", h.MediaErrors)
+	}
+}